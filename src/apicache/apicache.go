@@ -0,0 +1,263 @@
+package apicache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// DefaultTTL is how long a cached response is used without asking the API again.
+const DefaultTTL = 10 * time.Minute
+
+// ------------------ Structs ------------------
+
+// Cache is an on-disk cache of API responses (Modrinth, CurseForge and other JSON APIs), so
+// resolving the mods of a pack does not send the same hundreds of requests every time. Fresh
+// entries (younger than TTL) are served without a request; older ones are revalidated with
+// If-None-Match / If-Modified-Since, so an unchanged response costs a 304 only. When the API
+// cannot be reached, a stale entry is served instead of failing.
+//
+// A Cache is safe for concurrent use. Share one per API between the packages using it.
+type Cache struct {
+	// Dir holds the cached responses.
+	Dir string
+	// TTL is how long a response is used without revalidation; 0 means DefaultTTL and a
+	// negative TTL revalidates every time.
+	TTL time.Duration
+	// Header is sent with every request, e.g. CurseForge's "x-api-key". It is not part of the
+	// cache key.
+	Header http.Header
+	// Client performs the requests; nil uses utils.HTTPClient.
+	Client *http.Client
+
+	mu sync.Mutex
+}
+
+// entry is the metadata of a cached response; the body is stored next to it.
+type entry struct {
+	URL          string    `json:"url"`
+	Fetched      time.Time `json:"fetched"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+}
+
+// ------------------ Helpers ------------------
+
+// New creates a cache in dir with the given TTL.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// DefaultDir returns the API cache directory of an installation root.
+func DefaultDir(mcDir string) string {
+	return filepath.Join(mcDir, "cache", "api")
+}
+
+// paths returns the metadata and body files of url.
+func (c *Cache) paths(url string) (string, string) {
+	sum := sha1.Sum([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	base := filepath.Join(c.Dir, key[:2], key)
+	return base + ".json", base + ".body"
+}
+
+// ttl returns the effective TTL.
+func (c *Cache) ttl() time.Duration {
+	if c.TTL == 0 {
+		return DefaultTTL
+	}
+	return c.TTL
+}
+
+// load reads the cached entry and body of url.
+func (c *Cache) load(url string) (*entry, []byte, bool) {
+	metaPath, bodyPath := c.paths(url)
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, nil, false
+	}
+	var e entry
+	if json.Unmarshal(data, &e) != nil || e.URL != url {
+		return nil, nil, false
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, nil, false
+	}
+	return &e, body, true
+}
+
+// store writes an entry and, when body is not nil, its body. Files are replaced atomically.
+func (c *Cache) store(e *entry, body []byte) error {
+	metaPath, bodyPath := c.paths(e.URL)
+	if err := os.MkdirAll(filepath.Dir(metaPath), utils.Modes.Dir); err != nil {
+		return err
+	}
+	if body != nil {
+		if err := writeAtomic(bodyPath, body); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(metaPath, data)
+}
+
+// writeAtomic writes data to a temporary file and renames it over path.
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, utils.Modes.File); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ------------------ Public API ------------------
+
+// Get returns the body of a GET request to url, from the cache when possible. Only 200
+// responses are cached; other statuses are returned as errors.
+func (c *Cache) Get(url string, E *events.EventEmitter) ([]byte, error) {
+	c.mu.Lock()
+	cached, body, ok := c.load(url)
+	c.mu.Unlock()
+	if ok && time.Since(cached.Fetched) < c.ttl() {
+		E.Emit("api_cache_hit", url)
+		return body, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	client := c.Client
+	if client == nil {
+		client = utils.HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ok {
+			E.Emit("api_cache_stale", map[string]string{"url": url, "error": err.Error()})
+			return body, nil
+		}
+		err = fmt.Errorf("request to %s failed: %w", url, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		cached.Fetched = time.Now()
+		c.mu.Lock()
+		_ = c.store(cached, nil)
+		c.mu.Unlock()
+		E.Emit("api_cache_revalidated", url)
+		return body, nil
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode >= 500 && ok:
+		E.Emit("api_cache_stale", map[string]string{"url": url, "error": resp.Status})
+		return body, nil
+	default:
+		err := fmt.Errorf("request to %s failed: %s", url, resp.Status)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s: %w", url, err)
+	}
+	fresh := &entry{
+		URL:          url,
+		Fetched:      time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	c.mu.Lock()
+	if err := c.store(fresh, data); err != nil {
+		E.Emit("api_cache_write_failed", map[string]string{"url": url, "error": err.Error()})
+	}
+	c.mu.Unlock()
+	E.Emit("api_cache_miss", url)
+	return data, nil
+}
+
+// GetJSON fetches url through Get and decodes the JSON response into out.
+func (c *Cache) GetJSON(url string, out any, E *events.EventEmitter) error {
+	data, err := c.Get(url, E)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %w", url, err)
+	}
+	return nil
+}
+
+// Invalidate drops the cached response of url, e.g. after publishing a new version.
+func (c *Cache) Invalidate(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metaPath, bodyPath := c.paths(url)
+	os.Remove(metaPath)
+	os.Remove(bodyPath)
+}
+
+// Prune removes the responses fetched more than maxAge ago and returns how many were removed.
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var e entry
+		if json.Unmarshal(data, &e) == nil && time.Since(e.Fetched) <= maxAge {
+			return nil
+		}
+		os.Remove(path)
+		os.Remove(strings.TrimSuffix(path, ".json") + ".body")
+		removed++
+		return nil
+	})
+	return removed, err
+}
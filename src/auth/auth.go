@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// User types passed to the game as ${user_type}.
+const (
+	UserTypeMSA    = "msa"
+	UserTypeMojang = "mojang"
+	UserTypeLegacy = "legacy"
+)
+
+// ErrNotLoggedIn is returned when an operation needs a session that is missing or was logged out.
+var ErrNotLoggedIn error = i18n.New("auth.not_logged_in", "not logged in")
+
+// ------------------ Structs ------------------
+
+// Session is the result of a successful login: everything needed to launch the game
+// as the account, plus what the provider needs to refresh it later.
+type Session struct {
+	// Provider is the Name of the provider that created the session.
+	Provider     string    `json:"provider"`
+	Username     string    `json:"username"`
+	UUID         string    `json:"uuid"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt,omitempty"`
+	// UserType is UserTypeMSA, UserTypeMojang or UserTypeLegacy.
+	UserType string `json:"userType"`
+	// XUID is the Xbox user ID of Microsoft accounts.
+	XUID string `json:"xuid,omitempty"`
+	// ClientID identifies the launcher application to the game (${clientid}).
+	ClientID string `json:"clientId,omitempty"`
+	// VerifiedAt is when the account and its game ownership were last confirmed online.
+	VerifiedAt time.Time `json:"verifiedAt,omitempty"`
+}
+
+// Expired reports whether the access token has expired, with a minute of margin.
+// Sessions without an expiry never expire.
+func (s *Session) Expired() bool {
+	return !s.ExpiresAt.IsZero() && time.Now().Add(time.Minute).After(s.ExpiresAt)
+}
+
+// Profile is the game profile of an account.
+type Profile struct {
+	ID      string
+	Name    string
+	SkinURL string
+	CapeURL string
+}
+
+// Provider authenticates accounts with one scheme (Microsoft, offline, a Yggdrasil server, ...).
+// Launch options accept any Provider, so new schemes need no launcher changes.
+type Provider interface {
+	// Name identifies the provider, e.g. "microsoft".
+	Name() string
+	// Login authenticates interactively or with stored credentials and returns a new session.
+	Login(E *events.EventEmitter) (*Session, error)
+	// Refresh renews an expired session without user interaction.
+	Refresh(session *Session, E *events.EventEmitter) (*Session, error)
+	// Logout invalidates the session where the scheme supports it.
+	Logout(session *Session) error
+	// Profile fetches the current game profile of the session's account.
+	Profile(session *Session) (*Profile, error)
+}
+
+// APIError is returned when an authentication endpoint answers with an error status.
+type APIError struct {
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.URL, e.Status, e.Body)
+}
+
+// ------------------ Helpers ------------------
+
+// Ensure returns a usable session: session itself if still valid, a refreshed one when it
+// expired, or a new login when there is none or refreshing fails.
+func Ensure(provider Provider, session *Session, E *events.EventEmitter) (*Session, error) {
+	return EnsureWithGrace(provider, session, 0, E)
+}
+
+// EnsureWithGrace works like Ensure, but when an expired session cannot be refreshed because
+// the network is unreachable, it keeps using the session if the account was verified online
+// within grace, emitting auth_offline_grace. This matches the official launcher, which lets
+// recently authenticated accounts play offline. Sessions should come from a Store so their
+// signature was checked.
+func EnsureWithGrace(provider Provider, session *Session, grace time.Duration, E *events.EventEmitter) (*Session, error) {
+	if session != nil && !session.Expired() {
+		return session, nil
+	}
+
+	if session != nil {
+		refreshed, err := provider.Refresh(session, E)
+		if err == nil {
+			E.Emit("auth_refreshed", refreshed.Username)
+			return refreshed, nil
+		}
+		E.Emit("auth_refresh_failed", err.Error())
+
+		var apiErr *APIError
+		offline := !errors.As(err, &apiErr) && !errors.Is(err, ErrNotLoggedIn)
+		if offline && grace > 0 && !session.VerifiedAt.IsZero() && time.Since(session.VerifiedAt) < grace {
+			E.Emit("auth_offline_grace", map[string]interface{}{
+				"username":   session.Username,
+				"verifiedAt": session.VerifiedAt,
+				"graceUntil": session.VerifiedAt.Add(grace),
+			})
+			return session, nil
+		}
+	}
+
+	session, err := provider.Login(E)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	E.Emit("auth_logged_in", session.Username)
+	return session, nil
+}
+
+// doRequest sends a request and decodes a JSON response into out, returning an *APIError
+// for non-2xx statuses.
+func doRequest(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{URL: req.URL.String(), Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+// postJSON sends body as JSON and decodes the response into out.
+func postJSON(endpoint string, body any, out any, headers map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return doRequest(req, out)
+}
+
+// postForm sends form values and decodes the response into out.
+func postForm(endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doRequest(req, out)
+}
+
+// getJSON fetches endpoint with a bearer token and decodes the response into out.
+func getJSON(endpoint, bearer string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return doRequest(req, out)
+}
@@ -0,0 +1,382 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// ------------------ Endpoints ------------------
+
+const (
+	msDeviceCodeURL   = "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode"
+	msTokenURL        = "https://login.microsoftonline.com/consumers/oauth2/v2.0/token"
+	xblAuthURL        = "https://user.auth.xboxlive.com/user/authenticate"
+	xstsAuthURL       = "https://xsts.auth.xboxlive.com/xsts/authorize"
+	mcLoginURL        = "https://api.minecraftservices.com/authentication/login_with_xbox"
+	mcProfileURL      = "https://api.minecraftservices.com/minecraft/profile"
+	defaultMSScope    = "XboxLive.signin offline_access"
+	deviceCodeGrant   = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshTokenGrant = "refresh_token"
+)
+
+// ErrNoGameOwnership is returned when the Microsoft account does not own Minecraft.
+var ErrNoGameOwnership error = i18n.New("auth.no_game_ownership", "this Microsoft account does not own Minecraft: Java Edition")
+
+// ErrDeviceCodeExpired is returned when the user did not complete the device-code login in time.
+var ErrDeviceCodeExpired error = i18n.New("auth.device_code_expired", "device code expired before the login was completed")
+
+// ErrAuthorizationPending is returned by DeviceCode.Poll while the user has not completed the login yet.
+var ErrAuthorizationPending = errors.New("waiting for the user to complete the login")
+
+// ErrLoginCancelled is returned by DeviceCode.Wait after Cancel.
+var ErrLoginCancelled error = i18n.New("auth.login_cancelled", "login cancelled")
+
+// Microsoft logs in Microsoft accounts with the OAuth device-code flow, then exchanges the
+// token through Xbox Live and XSTS for a Minecraft access token.
+type Microsoft struct {
+	// ClientID is the Azure application ID registered for the launcher.
+	ClientID string
+	// Scope defaults to "XboxLive.signin offline_access".
+	Scope string
+}
+
+// deviceCodeResponse is returned when starting a device-code login.
+type deviceCodeResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+	Message         string `json:"message"`
+}
+
+// msTokenResponse is returned by the Microsoft token endpoint.
+type msTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+}
+
+// xboxResponse is returned by the Xbox Live and XSTS endpoints.
+type xboxResponse struct {
+	Token         string `json:"Token"`
+	DisplayClaims struct {
+		XUI []struct {
+			UHS string `json:"uhs"`
+		} `json:"xui"`
+	} `json:"DisplayClaims"`
+}
+
+// Name returns "microsoft".
+func (m *Microsoft) Name() string {
+	return "microsoft"
+}
+
+// scope returns the OAuth scope to request.
+func (m *Microsoft) scope() string {
+	if m.Scope != "" {
+		return m.Scope
+	}
+	return defaultMSScope
+}
+
+// DeviceCode is a pending device-code login. Frontends show UserCode and VerificationURI
+// ("go to microsoft.com/link and enter CODE") and either call Wait, or Poll on their own schedule.
+type DeviceCode struct {
+	UserCode        string
+	VerificationURI string
+	// Message is Microsoft's ready-made instruction text.
+	Message   string
+	ExpiresAt time.Time
+	// Interval is the minimum delay between two polls.
+	Interval time.Duration
+
+	provider   *Microsoft
+	deviceCode string
+	cancel     chan struct{}
+	cancelOnce sync.Once
+}
+
+// StartDeviceCode begins a device-code login without waiting for the user and emits auth_device_code.
+func (m *Microsoft) StartDeviceCode(E *events.EventEmitter) (*DeviceCode, error) {
+	if m.ClientID == "" {
+		return nil, fmt.Errorf("microsoft login requires a client ID")
+	}
+
+	var resp deviceCodeResponse
+	err := postForm(msDeviceCodeURL, url.Values{"client_id": {m.ClientID}, "scope": {m.scope()}}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device code login: %w", err)
+	}
+
+	interval := time.Duration(resp.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	code := &DeviceCode{
+		UserCode:        resp.UserCode,
+		VerificationURI: resp.VerificationURI,
+		Message:         resp.Message,
+		ExpiresAt:       time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+		Interval:        interval,
+		provider:        m,
+		deviceCode:      resp.DeviceCode,
+		cancel:          make(chan struct{}),
+	}
+
+	E.Emit("auth_device_code", map[string]interface{}{
+		"userCode":        code.UserCode,
+		"verificationUri": code.VerificationURI,
+		"message":         code.Message,
+		"expiresIn":       resp.ExpiresIn,
+		"expiresAt":       code.ExpiresAt,
+	})
+	return code, nil
+}
+
+// Remaining returns how long the code stays valid.
+func (d *DeviceCode) Remaining() time.Duration {
+	if remaining := time.Until(d.ExpiresAt); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Cancel stops a running Wait.
+func (d *DeviceCode) Cancel() {
+	d.cancelOnce.Do(func() { close(d.cancel) })
+}
+
+// Poll checks once whether the user completed the login. It returns ErrAuthorizationPending
+// while they have not, and the session once they have.
+func (d *DeviceCode) Poll(E *events.EventEmitter) (*Session, error) {
+	if d.Remaining() == 0 {
+		return nil, ErrDeviceCodeExpired
+	}
+
+	var token msTokenResponse
+	err := postForm(msTokenURL, url.Values{
+		"client_id":   {d.provider.ClientID},
+		"grant_type":  {deviceCodeGrant},
+		"device_code": {d.deviceCode},
+	}, &token)
+	if err == nil {
+		E.Emit("auth_device_code_status", map[string]interface{}{"status": "authorized"})
+		return d.provider.minecraftSession(&token, E)
+	}
+
+	// Pending logins are reported as 400 errors with an error code in the body
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(apiErr.Body), &token)
+	switch token.Error {
+	case "authorization_pending":
+		return nil, ErrAuthorizationPending
+	case "slow_down":
+		d.Interval += 5 * time.Second
+		return nil, ErrAuthorizationPending
+	case "expired_token":
+		return nil, ErrDeviceCodeExpired
+	case "authorization_declined":
+		return nil, fmt.Errorf("the user declined the login")
+	}
+	return nil, fmt.Errorf("device code login failed: %w", err)
+}
+
+// Wait polls until the user completed the login, the code expired or Cancel was called.
+// Every poll emits auth_device_code_status with the remaining validity, so frontends can
+// render a countdown.
+func (d *DeviceCode) Wait(E *events.EventEmitter) (*Session, error) {
+	for {
+		select {
+		case <-d.cancel:
+			E.Emit("auth_device_code_status", map[string]interface{}{"status": "cancelled"})
+			return nil, ErrLoginCancelled
+		case <-time.After(d.Interval):
+		}
+
+		session, err := d.Poll(E)
+		switch {
+		case errors.Is(err, ErrAuthorizationPending):
+			E.Emit("auth_device_code_status", map[string]interface{}{
+				"status":    "pending",
+				"remaining": int(d.Remaining() / time.Second),
+			})
+		case errors.Is(err, ErrDeviceCodeExpired):
+			E.Emit("auth_device_code_status", map[string]interface{}{"status": "expired"})
+			return nil, err
+		default:
+			return session, err
+		}
+	}
+}
+
+// Login runs the device-code flow: an auth_device_code event carries the code the user enters
+// at the verification URL, and the login completes once they have done so.
+func (m *Microsoft) Login(E *events.EventEmitter) (*Session, error) {
+	code, err := m.StartDeviceCode(E)
+	if err != nil {
+		return nil, err
+	}
+	return code.Wait(E)
+}
+
+// minecraftSession exchanges a Microsoft token for a Minecraft session.
+func (m *Microsoft) minecraftSession(token *msTokenResponse, E *events.EventEmitter) (*Session, error) {
+	// Xbox Live user token
+	var xbl xboxResponse
+	err := postJSON(xblAuthURL, map[string]any{
+		"Properties": map[string]any{
+			"AuthMethod": "RPS",
+			"SiteName":   "user.auth.xboxlive.com",
+			"RpsTicket":  "d=" + token.AccessToken,
+		},
+		"RelyingParty": "http://auth.xboxlive.com",
+		"TokenType":    "JWT",
+	}, &xbl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("xbox live authentication failed: %w", err)
+	}
+
+	// XSTS token for the Minecraft services
+	var xsts xboxResponse
+	err = postJSON(xstsAuthURL, map[string]any{
+		"Properties": map[string]any{
+			"SandboxId":  "RETAIL",
+			"UserTokens": []string{xbl.Token},
+		},
+		"RelyingParty": "rp://api.minecraftservices.com/",
+		"TokenType":    "JWT",
+	}, &xsts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("xsts authorization failed: %w", err)
+	}
+	if len(xsts.DisplayClaims.XUI) == 0 {
+		return nil, fmt.Errorf("xsts authorization returned no user hash")
+	}
+	uhs := xsts.DisplayClaims.XUI[0].UHS
+
+	// Minecraft access token
+	var mc struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = postJSON(mcLoginURL, map[string]any{"identityToken": "XBL3.0 x=" + uhs + ";" + xsts.Token}, &mc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("minecraft login failed: %w", err)
+	}
+
+	session := &Session{
+		Provider:     m.Name(),
+		AccessToken:  mc.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(mc.ExpiresIn) * time.Second),
+		UserType:     UserTypeMSA,
+		XUID:         tokenClaim(mc.AccessToken, "xuid"),
+		ClientID:     m.ClientID,
+		VerifiedAt:   time.Now(),
+	}
+
+	profile, err := m.Profile(session)
+	if err != nil {
+		return nil, err
+	}
+	session.Username = profile.Name
+	session.UUID = profile.ID
+	return session, nil
+}
+
+// Refresh renews the session with its Microsoft refresh token.
+func (m *Microsoft) Refresh(session *Session, E *events.EventEmitter) (*Session, error) {
+	if session == nil || session.RefreshToken == "" {
+		return nil, ErrNotLoggedIn
+	}
+
+	var token msTokenResponse
+	err := postForm(msTokenURL, url.Values{
+		"client_id":     {m.ClientID},
+		"grant_type":    {refreshTokenGrant},
+		"refresh_token": {session.RefreshToken},
+		"scope":         {m.scope()},
+	}, &token)
+	if err != nil {
+		return nil, fmt.Errorf("microsoft token refresh failed: %w", err)
+	}
+	return m.minecraftSession(&token, E)
+}
+
+// Logout forgets the session. Microsoft tokens cannot be revoked by the launcher;
+// callers should delete the stored session.
+func (m *Microsoft) Logout(session *Session) error {
+	return nil
+}
+
+// Profile fetches the Minecraft profile of the account, failing with ErrNoGameOwnership
+// when the account has none.
+func (m *Microsoft) Profile(session *Session) (*Profile, error) {
+	if session == nil {
+		return nil, ErrNotLoggedIn
+	}
+
+	var resp struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Skins []struct {
+			State string `json:"state"`
+			URL   string `json:"url"`
+		} `json:"skins"`
+		Capes []struct {
+			State string `json:"state"`
+			URL   string `json:"url"`
+		} `json:"capes"`
+	}
+	if err := getJSON(mcProfileURL, session.AccessToken, &resp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Status == 404 {
+			return nil, ErrNoGameOwnership
+		}
+		return nil, fmt.Errorf("failed to fetch minecraft profile: %w", err)
+	}
+
+	profile := &Profile{ID: resp.ID, Name: resp.Name}
+	for _, skin := range resp.Skins {
+		if skin.State == "ACTIVE" {
+			profile.SkinURL = skin.URL
+		}
+	}
+	for _, cape := range resp.Capes {
+		if cape.State == "ACTIVE" {
+			profile.CapeURL = cape.URL
+		}
+	}
+	return profile, nil
+}
+
+// tokenClaim reads a string claim from a JWT payload without verifying the signature.
+func tokenClaim(token, claim string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims map[string]any
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	value, _ := claims[claim].(string)
+	return value
+}
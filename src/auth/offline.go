@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/md5"
+	"fmt"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Offline creates sessions without any server, for offline play and LAN worlds.
+type Offline struct {
+	Username string
+}
+
+// OfflineUUID returns the UUID vanilla servers in offline mode assign to username:
+// a version 3 UUID of "OfflinePlayer:<username>".
+func OfflineUUID(username string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + username))
+	sum[6] = sum[6]&0x0f | 0x30 // version 3
+	sum[8] = sum[8]&0x3f | 0x80 // IETF variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// Name returns "offline".
+func (o *Offline) Name() string {
+	return "offline"
+}
+
+// Login returns a session for the configured username with the placeholder token "0".
+func (o *Offline) Login(E *events.EventEmitter) (*Session, error) {
+	if o.Username == "" {
+		return nil, fmt.Errorf("offline login requires a username")
+	}
+	return &Session{
+		Provider:    o.Name(),
+		Username:    o.Username,
+		UUID:        OfflineUUID(o.Username),
+		AccessToken: "0",
+		UserType:    UserTypeLegacy,
+	}, nil
+}
+
+// Refresh returns the session unchanged; offline sessions never expire.
+func (o *Offline) Refresh(session *Session, E *events.EventEmitter) (*Session, error) {
+	if session == nil {
+		return nil, ErrNotLoggedIn
+	}
+	return session, nil
+}
+
+// Logout does nothing; there is no server to notify.
+func (o *Offline) Logout(session *Session) error {
+	return nil
+}
+
+// Profile returns the profile stored in the session.
+func (o *Offline) Profile(session *Session) (*Profile, error) {
+	if session == nil {
+		return nil, ErrNotLoggedIn
+	}
+	return &Profile{ID: session.UUID, Name: session.Username}, nil
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Names of the files kept by a Store.
+const (
+	storeKeyFile      = "session.key"
+	storeSessionsFile = "sessions.json"
+)
+
+// ErrSessionTampered is returned when a stored session does not match its signature.
+var ErrSessionTampered error = i18n.New("auth.session_tampered", "stored session signature does not match")
+
+// ErrSessionNotFound is returned when no session is stored for an account.
+var ErrSessionNotFound error = i18n.New("auth.session_not_found", "no stored session")
+
+// Store persists sessions in a directory, each signed with an HMAC key private to the
+// installation. Only sessions whose signature matches are returned, so a session edited
+// on disk (e.g. another username) is never used for the offline grace period.
+type Store struct {
+	dir string
+	key []byte
+	mu  sync.Mutex
+}
+
+// signedSession is the on-disk form of a session.
+type signedSession struct {
+	Session   Session `json:"session"`
+	Signature string  `json:"signature"`
+}
+
+// OpenStore opens the session store in dir, creating it and its signing key if needed.
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session store: %w", err)
+	}
+
+	keyPath := filepath.Join(dir, storeKeyFile)
+	key, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write session key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read session key: %w", err)
+	}
+
+	return &Store{dir: dir, key: key}, nil
+}
+
+// sign returns the HMAC of the JSON form of session.
+func (s *Store) sign(session *Session) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// storeKey identifies an account across providers.
+func storeKey(provider, uuid string) string {
+	return provider + ":" + uuid
+}
+
+// readAll loads every stored entry.
+func (s *Store) readAll() (map[string]signedSession, error) {
+	entries := map[string]signedSession{}
+	data, err := os.ReadFile(filepath.Join(s.dir, storeSessionsFile))
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse stored sessions: %w", err)
+	}
+	return entries, nil
+}
+
+// writeAll replaces the stored entries.
+func (s *Store) writeAll(entries map[string]signedSession) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, storeSessionsFile)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Save stores a signed copy of session, replacing any previous session of the same account.
+func (s *Store) Save(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	signature, err := s.sign(session)
+	if err != nil {
+		return err
+	}
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	entries[storeKey(session.Provider, session.UUID)] = signedSession{Session: *session, Signature: signature}
+	return s.writeAll(entries)
+}
+
+// Load returns the stored session of an account after verifying its signature.
+func (s *Store) Load(provider, uuid string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := entries[storeKey(provider, uuid)]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	expected, err := s.sign(&entry.Session)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(expected), []byte(entry.Signature)) {
+		return nil, ErrSessionTampered
+	}
+	session := entry.Session
+	return &session, nil
+}
+
+// Delete removes the stored session of an account, e.g. after Logout.
+func (s *Store) Delete(provider, uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	delete(entries, storeKey(provider, uuid))
+	return s.writeAll(entries)
+}
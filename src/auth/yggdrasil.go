@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Yggdrasil authenticates against a custom Yggdrasil-compatible server (authlib-injector
+// skin sites, private auth servers). ServerURL is the API root, e.g.
+// "https://example.com/api/yggdrasil"; the authserver endpoints live below it.
+type Yggdrasil struct {
+	ServerURL string
+	Username  string
+	Password  string
+	// ClientToken identifies this launcher installation; it is generated when empty.
+	ClientToken string
+}
+
+// yggdrasilResponse is the body returned by authenticate and refresh.
+type yggdrasilResponse struct {
+	AccessToken     string `json:"accessToken"`
+	ClientToken     string `json:"clientToken"`
+	SelectedProfile struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"selectedProfile"`
+}
+
+// Name returns "yggdrasil".
+func (y *Yggdrasil) Name() string {
+	return "yggdrasil"
+}
+
+// endpoint returns the URL of an authserver endpoint.
+func (y *Yggdrasil) endpoint(name string) string {
+	return strings.TrimSuffix(y.ServerURL, "/") + "/authserver/" + name
+}
+
+// clientToken returns the configured client token, generating one on first use.
+func (y *Yggdrasil) clientToken() string {
+	if y.ClientToken == "" {
+		buf := make([]byte, 16)
+		_, _ = rand.Read(buf)
+		y.ClientToken = hex.EncodeToString(buf)
+	}
+	return y.ClientToken
+}
+
+// session converts a server response into a Session.
+func (y *Yggdrasil) session(resp *yggdrasilResponse) (*Session, error) {
+	if resp.SelectedProfile.ID == "" {
+		return nil, fmt.Errorf("account on %s has no game profile", y.ServerURL)
+	}
+	return &Session{
+		Provider:     y.Name(),
+		Username:     resp.SelectedProfile.Name,
+		UUID:         resp.SelectedProfile.ID,
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.ClientToken, // Yggdrasil refreshes with the client token
+		UserType:     UserTypeMojang,
+		VerifiedAt:   time.Now(),
+	}, nil
+}
+
+// Login authenticates with the username and password.
+func (y *Yggdrasil) Login(E *events.EventEmitter) (*Session, error) {
+	body := map[string]any{
+		"agent":       map[string]any{"name": "Minecraft", "version": 1},
+		"username":    y.Username,
+		"password":    y.Password,
+		"clientToken": y.clientToken(),
+		"requestUser": true,
+	}
+
+	var resp yggdrasilResponse
+	if err := postJSON(y.endpoint("authenticate"), body, &resp, nil); err != nil {
+		return nil, fmt.Errorf("yggdrasil login failed: %w", err)
+	}
+	return y.session(&resp)
+}
+
+// Refresh exchanges the session's access token for a new one.
+func (y *Yggdrasil) Refresh(session *Session, E *events.EventEmitter) (*Session, error) {
+	if session == nil {
+		return nil, ErrNotLoggedIn
+	}
+	body := map[string]any{
+		"accessToken": session.AccessToken,
+		"clientToken": session.RefreshToken,
+		"requestUser": true,
+	}
+
+	var resp yggdrasilResponse
+	if err := postJSON(y.endpoint("refresh"), body, &resp, nil); err != nil {
+		return nil, fmt.Errorf("yggdrasil refresh failed: %w", err)
+	}
+	return y.session(&resp)
+}
+
+// Logout invalidates the session's access token.
+func (y *Yggdrasil) Logout(session *Session) error {
+	if session == nil {
+		return nil
+	}
+	body := map[string]any{
+		"accessToken": session.AccessToken,
+		"clientToken": session.RefreshToken,
+	}
+	return postJSON(y.endpoint("invalidate"), body, nil, nil)
+}
+
+// Profile returns the profile selected at login.
+func (y *Yggdrasil) Profile(session *Session) (*Profile, error) {
+	if session == nil {
+		return nil, ErrNotLoggedIn
+	}
+	return &Profile{ID: session.UUID, Name: session.Username}, nil
+}
@@ -0,0 +1,403 @@
+// Package bridge serves the events of the core and accepts commands over a localhost
+// WebSocket speaking JSON-RPC 2.0, so frontends written in other languages (Electron, Tauri,
+// web UIs) can drive the core running as a sidecar process.
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// DefaultAddr listens on a free loopback port.
+const DefaultAddr = "127.0.0.1:0"
+
+// clientQueue is how many messages may wait for a slow client before events to it are dropped.
+const clientQueue = 256
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeJobNotFound is returned by cancel for unknown or finished jobs.
+	CodeJobNotFound = -32001
+)
+
+// ------------------ Structs ------------------
+
+// Handler runs a command. params is the raw JSON-RPC params; the result is sent with
+// job_done. Handlers should stop when ctx is cancelled and then return ctx.Err().
+type Handler func(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error)
+
+// Error is a JSON-RPC error. Handlers may return one to choose the code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Job is a command in progress, as listed by the jobs method.
+type Job struct {
+	ID      string    `json:"id"`
+	Method  string    `json:"method"`
+	Started time.Time `json:"started"`
+
+	cancel context.CancelFunc
+}
+
+// Bridge forwards every event of E to the connected clients as "event" notifications
+// ({"event": name, "data": payload}) and runs their requests. Registered methods run as jobs:
+// the request is answered at once with {"job": id}, and the outcome is emitted as job_done,
+// job_failed or job_cancelled. The built-in cancel ({"job": id}) and jobs methods answer
+// directly. Clients authenticate with Token, sent as "Authorization: Bearer <token>" or, for
+// browsers, as the token query parameter.
+type Bridge struct {
+	// Token authenticates clients; New generates a random one.
+	Token string
+	E     *events.EventEmitter
+
+	mu      sync.Mutex
+	methods map[string]Handler
+	jobs    map[string]*Job
+	nextJob int
+	clients map[*client]struct{}
+	server  *http.Server
+}
+
+// client is a connected WebSocket with its outgoing queue.
+type client struct {
+	ws  *wsConn
+	out chan []byte
+}
+
+// request is a JSON-RPC request or notification (without ID).
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// NewToken returns a random token for a Bridge.
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// New returns a bridge for the events of E with a random Token and the built-in install,
+// launch, login, refresh and logout methods. Register more with Handle, then call Listen or mount it as an http.Handler.
+func New(E *events.EventEmitter) (*Bridge, error) {
+	token, err := NewToken()
+	if err != nil {
+		err = fmt.Errorf("failed to generate bridge token: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	b := &Bridge{
+		Token:   token,
+		E:       E,
+		methods: map[string]Handler{},
+		jobs:    map[string]*Job{},
+		clients: map[*client]struct{}{},
+	}
+	b.Handle("install", Install)
+	b.Handle("launch", Launch)
+	b.Handle("login", Login)
+	b.Handle("refresh", Refresh)
+	b.Handle("logout", Logout)
+	E.OnAny(b.broadcast)
+	return b, nil
+}
+
+// Handle registers a method run as a job, replacing any previous handler of that name.
+// "cancel" and "jobs" are reserved.
+func (b *Bridge) Handle(method string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.methods[method] = handler
+}
+
+// ------------------ Server ------------------
+
+// Listen serves the bridge on addr, which must be a loopback address (DefaultAddr when
+// empty), and returns the WebSocket URL clients connect to.
+func (b *Bridge) Listen(addr string) (string, error) {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		err := fmt.Errorf("bridge address %s is not a loopback address", addr)
+		b.E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		err = fmt.Errorf("failed to listen on %s: %w", addr, err)
+		b.E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	server := &http.Server{Handler: b, ReadHeaderTimeout: 10 * time.Second}
+	b.mu.Lock()
+	b.server = server
+	b.mu.Unlock()
+	go server.Serve(l)
+
+	url := "ws://" + l.Addr().String() + "/"
+	b.E.Emit("bridge_listening", url)
+	return url, nil
+}
+
+// Close stops the server started by Listen, disconnects every client and cancels running jobs.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	server := b.server
+	b.server = nil
+	for _, job := range b.jobs {
+		job.cancel()
+	}
+	for c := range b.clients {
+		c.ws.close()
+	}
+	b.mu.Unlock()
+
+	if server != nil {
+		return server.Close()
+	}
+	return nil
+}
+
+// authorized reports whether r carries the token.
+func (b *Bridge) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return b.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(b.Token)) == 1
+}
+
+// ServeHTTP upgrades an authenticated request to a WebSocket and serves it until it closes.
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !b.authorized(r) {
+		http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+		return
+	}
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	c := &client{ws: ws, out: make(chan []byte, clientQueue)}
+	b.mu.Lock()
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+	b.E.Emit("bridge_client_connected", r.RemoteAddr)
+
+	go func() {
+		for message := range c.out {
+			if ws.writeText(message) != nil {
+				ws.close()
+			}
+		}
+	}()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+		close(c.out)
+		ws.close()
+		b.E.Emit("bridge_client_disconnected", r.RemoteAddr)
+	}()
+
+	for {
+		message, err := ws.readMessage()
+		if err != nil {
+			return
+		}
+		if reply := b.call(message); reply != nil {
+			c.send(reply)
+		}
+	}
+}
+
+// send queues a message for the client, dropping it when the client does not keep up.
+func (c *client) send(message []byte) {
+	select {
+	case c.out <- message:
+	default:
+	}
+}
+
+// broadcast sends an event to every client. Payloads that cannot be encoded are sent as text.
+func (b *Bridge) broadcast(event string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.clients) == 0 {
+		return
+	}
+
+	if err, ok := data.(error); ok {
+		data = err.Error()
+	}
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "method": "event", "params": map[string]any{"event": event, "data": data},
+	})
+	if err != nil {
+		message, _ = json.Marshal(map[string]any{
+			"jsonrpc": "2.0", "method": "event", "params": map[string]any{"event": event, "data": fmt.Sprint(data)},
+		})
+	}
+	for c := range b.clients {
+		c.send(message)
+	}
+}
+
+// ------------------ Requests ------------------
+
+// reply encodes a response; notifications (without ID) get none.
+func reply(id json.RawMessage, result any, err error) []byte {
+	if len(id) == 0 {
+		return nil
+	}
+	response := map[string]any{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+	data, _ := json.Marshal(response)
+	return data
+}
+
+// call runs one request and returns its encoded response.
+func (b *Bridge) call(message []byte) []byte {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return reply(json.RawMessage("null"), nil, &Error{Code: CodeParseError, Message: err.Error()})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return reply(req.ID, nil, &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"})
+	}
+
+	switch req.Method {
+	case "cancel":
+		var params struct {
+			Job string `json:"job"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return reply(req.ID, nil, &Error{Code: CodeInvalidParams, Message: err.Error()})
+		}
+		return reply(req.ID, map[string]bool{"cancelled": true}, b.Cancel(params.Job))
+	case "jobs":
+		return reply(req.ID, b.Jobs(), nil)
+	}
+
+	id, err := b.Start(req.Method, req.Params)
+	return reply(req.ID, map[string]string{"job": id}, err)
+}
+
+// ------------------ Jobs ------------------
+
+// Start runs a registered method as a job, as if a client had called it, and returns the job ID.
+func (b *Bridge) Start(method string, params json.RawMessage) (string, error) {
+	b.mu.Lock()
+	handler, ok := b.methods[method]
+	if !ok {
+		b.mu.Unlock()
+		return "", &Error{Code: CodeMethodNotFound, Message: "unknown method " + method}
+	}
+	b.nextJob++
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{ID: strconv.Itoa(b.nextJob), Method: method, Started: time.Now(), cancel: cancel}
+	b.jobs[job.ID] = job
+	b.mu.Unlock()
+
+	b.E.Emit("job_started", map[string]string{"job": job.ID, "method": method})
+	go b.run(ctx, job, handler, params)
+	return job.ID, nil
+}
+
+// run executes a job and emits its outcome.
+func (b *Bridge) run(ctx context.Context, job *Job, handler Handler, params json.RawMessage) {
+	var result any
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", job.Method, r)
+		}
+		job.cancel()
+		b.mu.Lock()
+		delete(b.jobs, job.ID)
+		b.mu.Unlock()
+
+		payload := map[string]any{"job": job.ID, "method": job.Method}
+		switch {
+		case err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()):
+			b.E.Emit("job_cancelled", payload)
+		case err != nil:
+			payload["error"] = i18n.ErrorEvent(err)
+			b.E.Emit("job_failed", payload)
+		default:
+			payload["result"] = result
+			b.E.Emit("job_done", payload)
+		}
+	}()
+	result, err = handler(ctx, params, b.E)
+}
+
+// Cancel cancels a running job.
+func (b *Bridge) Cancel(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return &Error{Code: CodeJobNotFound, Message: "no running job " + id}
+	}
+	job.cancel()
+	return nil
+}
+
+// Jobs returns the running jobs, oldest first.
+func (b *Bridge) Jobs() []Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	jobs := make([]Job, 0, len(b.jobs))
+	for _, job := range b.jobs {
+		jobs = append(jobs, Job{ID: job.ID, Method: job.Method, Started: job.Started})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Started.Before(jobs[j].Started) })
+	return jobs
+}
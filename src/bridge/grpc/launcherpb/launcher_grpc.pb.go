@@ -0,0 +1,423 @@
+// Service definition for driving the launcher core remotely over gRPC.
+//
+// The service mirrors the JSON-RPC methods of the bridge package: commands start jobs, whose
+// progress and outcome arrive as events (job_started, job_done, job_failed, job_cancelled and
+// every event the core emits while running them). Event payloads are JSON, as on the bridge.
+//
+// The generated code lives in launcherpb and the server forwarding to a bridge.Bridge in this
+// directory, a module of its own so the core keeps depending on the standard library alone.
+// Regenerate with go generate after editing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: launcher.proto
+
+package launcherpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Launcher_Install_FullMethodName  = "/minecraftlauncher.v1.Launcher/Install"
+	Launcher_Launch_FullMethodName   = "/minecraftlauncher.v1.Launcher/Launch"
+	Launcher_Cancel_FullMethodName   = "/minecraftlauncher.v1.Launcher/Cancel"
+	Launcher_ListJobs_FullMethodName = "/minecraftlauncher.v1.Launcher/ListJobs"
+	Launcher_Events_FullMethodName   = "/minecraftlauncher.v1.Launcher/Events"
+	Launcher_Login_FullMethodName    = "/minecraftlauncher.v1.Launcher/Login"
+	Launcher_Refresh_FullMethodName  = "/minecraftlauncher.v1.Launcher/Refresh"
+	Launcher_Logout_FullMethodName   = "/minecraftlauncher.v1.Launcher/Logout"
+)
+
+// LauncherClient is the client API for Launcher service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Every call carries the token of the bridge as "authorization: Bearer <token>" metadata.
+type LauncherClient interface {
+	// Install installs a vanilla version (bridge method "install").
+	Install(ctx context.Context, in *InstallRequest, opts ...grpc.CallOption) (*JobReply, error)
+	// Launch starts the game and waits for it in a job (bridge method "launch").
+	Launch(ctx context.Context, in *LaunchRequest, opts ...grpc.CallOption) (*JobReply, error)
+	// Cancel cancels a running job; launches are killed, installs stop and roll back.
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelReply, error)
+	// ListJobs returns the running jobs, oldest first.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsReply, error)
+	// Events streams every emitted event until the client disconnects.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// Login signs in with an auth provider ("microsoft", "offline", "yggdrasil")
+	// (bridge method "login").
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Session, error)
+	// Refresh renews an expired session (bridge method "refresh").
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Session, error)
+	// Logout invalidates a session where the provider supports it (bridge method "logout").
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutReply, error)
+}
+
+type launcherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLauncherClient(cc grpc.ClientConnInterface) LauncherClient {
+	return &launcherClient{cc}
+}
+
+func (c *launcherClient) Install(ctx context.Context, in *InstallRequest, opts ...grpc.CallOption) (*JobReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobReply)
+	err := c.cc.Invoke(ctx, Launcher_Install_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) Launch(ctx context.Context, in *LaunchRequest, opts ...grpc.CallOption) (*JobReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobReply)
+	err := c.cc.Invoke(ctx, Launcher_Launch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelReply)
+	err := c.cc.Invoke(ctx, Launcher_Cancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsReply)
+	err := c.cc.Invoke(ctx, Launcher_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Launcher_ServiceDesc.Streams[0], Launcher_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Launcher_EventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *launcherClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, Launcher_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, Launcher_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *launcherClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogoutReply)
+	err := c.cc.Invoke(ctx, Launcher_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LauncherServer is the server API for Launcher service.
+// All implementations must embed UnimplementedLauncherServer
+// for forward compatibility.
+//
+// Every call carries the token of the bridge as "authorization: Bearer <token>" metadata.
+type LauncherServer interface {
+	// Install installs a vanilla version (bridge method "install").
+	Install(context.Context, *InstallRequest) (*JobReply, error)
+	// Launch starts the game and waits for it in a job (bridge method "launch").
+	Launch(context.Context, *LaunchRequest) (*JobReply, error)
+	// Cancel cancels a running job; launches are killed, installs stop and roll back.
+	Cancel(context.Context, *CancelRequest) (*CancelReply, error)
+	// ListJobs returns the running jobs, oldest first.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsReply, error)
+	// Events streams every emitted event until the client disconnects.
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
+	// Login signs in with an auth provider ("microsoft", "offline", "yggdrasil")
+	// (bridge method "login").
+	Login(context.Context, *LoginRequest) (*Session, error)
+	// Refresh renews an expired session (bridge method "refresh").
+	Refresh(context.Context, *RefreshRequest) (*Session, error)
+	// Logout invalidates a session where the provider supports it (bridge method "logout").
+	Logout(context.Context, *LogoutRequest) (*LogoutReply, error)
+	mustEmbedUnimplementedLauncherServer()
+}
+
+// UnimplementedLauncherServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLauncherServer struct{}
+
+func (UnimplementedLauncherServer) Install(context.Context, *InstallRequest) (*JobReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Install not implemented")
+}
+func (UnimplementedLauncherServer) Launch(context.Context, *LaunchRequest) (*JobReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Launch not implemented")
+}
+func (UnimplementedLauncherServer) Cancel(context.Context, *CancelRequest) (*CancelReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedLauncherServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedLauncherServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedLauncherServer) Login(context.Context, *LoginRequest) (*Session, error) {
+	return nil, status.Error(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedLauncherServer) Refresh(context.Context, *RefreshRequest) (*Session, error) {
+	return nil, status.Error(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedLauncherServer) Logout(context.Context, *LogoutRequest) (*LogoutReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedLauncherServer) mustEmbedUnimplementedLauncherServer() {}
+func (UnimplementedLauncherServer) testEmbeddedByValue()                  {}
+
+// UnsafeLauncherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LauncherServer will
+// result in compilation errors.
+type UnsafeLauncherServer interface {
+	mustEmbedUnimplementedLauncherServer()
+}
+
+func RegisterLauncherServer(s grpc.ServiceRegistrar, srv LauncherServer) {
+	// If the following call panics, it indicates UnimplementedLauncherServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Launcher_ServiceDesc, srv)
+}
+
+func _Launcher_Install_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Install(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Install_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Install(ctx, req.(*InstallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_Launch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LaunchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Launch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Launch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Launch(ctx, req.(*LaunchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LauncherServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Launcher_EventsServer = grpc.ServerStreamingServer[Event]
+
+func _Launcher_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Launcher_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LauncherServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Launcher_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LauncherServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Launcher_ServiceDesc is the grpc.ServiceDesc for Launcher service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Launcher_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "minecraftlauncher.v1.Launcher",
+	HandlerType: (*LauncherServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Install",
+			Handler:    _Launcher_Install_Handler,
+		},
+		{
+			MethodName: "Launch",
+			Handler:    _Launcher_Launch_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Launcher_Cancel_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _Launcher_ListJobs_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Launcher_Login_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Launcher_Refresh_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Launcher_Logout_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Launcher_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "launcher.proto",
+}
@@ -0,0 +1,353 @@
+// Package grpc serves the Launcher service of launcher.proto by forwarding to a bridge.Bridge,
+// for remote management daemons and clients in any language with gRPC support. It is a module
+// of its own, so the core keeps depending on the standard library alone.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/urixen-org/minecraft-launcher-core/src/bridge/grpc --go-grpc_out=. --go-grpc_opt=module=github.com/urixen-org/minecraft-launcher-core/src/bridge/grpc launcher.proto
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+	"github.com/urixen-org/minecraft-launcher-core/src/bridge"
+	"github.com/urixen-org/minecraft-launcher-core/src/bridge/grpc/launcherpb"
+)
+
+// streamQueue is how many events may wait for a slow Events stream before events to it are dropped.
+const streamQueue = 256
+
+// ------------------ Structs ------------------
+
+// Server implements launcherpb.LauncherServer on top of a bridge: Install and Launch start
+// bridge jobs, Cancel and ListJobs manage them, Login, Refresh and Logout run the bridge
+// methods of the same names and answer with their result, and Events streams every event of
+// the bridge's emitter.
+type Server struct {
+	launcherpb.UnimplementedLauncherServer
+
+	b *bridge.Bridge
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+// subscriber is an Events stream with its outgoing queue.
+type subscriber struct {
+	// names filters the streamed events; nil streams all of them.
+	names map[string]bool
+	out   chan *launcherpb.Event
+}
+
+// Token sends the bridge token with every call of a Go client, as
+// grpc.WithPerRPCCredentials(Token(b.Token)). Plaintext connections are only safe on loopback;
+// serve other addresses with TLS credentials.
+type Token string
+
+// GetRequestMetadata returns the authorization metadata of the token.
+func (t Token) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity reports false, so the token can be sent to a loopback server.
+func (t Token) RequireTransportSecurity() bool {
+	return false
+}
+
+// New returns a Server forwarding to b. Most callers want NewServer, which also checks the
+// bridge token.
+func New(b *bridge.Bridge) *Server {
+	s := &Server{b: b, subscribers: map[*subscriber]struct{}{}}
+	b.E.OnAny(s.forward)
+	return s
+}
+
+// NewServer returns a gRPC server with the Launcher service of b registered, accepting only
+// calls that carry b.Token as "authorization: Bearer <token>" metadata. opts are passed to
+// grpc.NewServer, e.g. TLS credentials.
+func NewServer(b *bridge.Bridge, opts ...grpc.ServerOption) *grpc.Server {
+	s := New(b)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	gs := grpc.NewServer(opts...)
+	launcherpb.RegisterLauncherServer(gs, s)
+	return gs
+}
+
+// authorize checks the token in the metadata of a call.
+func (s *Server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && s.b.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.b.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bridge token")
+}
+
+// ------------------ Jobs ------------------
+
+// Install starts the install method of the bridge.
+func (s *Server) Install(ctx context.Context, req *launcherpb.InstallRequest) (*launcherpb.JobReply, error) {
+	return s.start("install", bridge.InstallParams{Version: req.GetVersion(), GameDir: req.GetGameDir()})
+}
+
+// Launch starts the launch method of the bridge, with the session when set and offline as
+// the username otherwise.
+func (s *Server) Launch(ctx context.Context, req *launcherpb.LaunchRequest) (*launcherpb.JobReply, error) {
+	params := bridge.LaunchParams{
+		Username: req.GetUsername(),
+		GameDir:  req.GetGameDir(),
+		Version:  req.GetVersion(),
+		JavaPath: req.GetJavaPath(),
+		MaxRam:   req.GetMaxRam(),
+		MinRam:   req.GetMinRam(),
+	}
+	if session := req.GetSession(); session != nil {
+		params.Username = session.GetUsername()
+		params.AccessToken = session.GetAccessToken()
+		params.UUID = session.GetUuid()
+	}
+	return s.start("launch", params)
+}
+
+// start runs a bridge method as a job.
+func (s *Server) start(method string, params any) (*launcherpb.JobReply, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	id, err := s.b.Start(method, data)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &launcherpb.JobReply{Job: id}, nil
+}
+
+// Cancel cancels a running job of the bridge.
+func (s *Server) Cancel(ctx context.Context, req *launcherpb.CancelRequest) (*launcherpb.CancelReply, error) {
+	err := s.b.Cancel(req.GetJob())
+	var rpcErr *bridge.Error
+	if errors.As(err, &rpcErr) && rpcErr.Code == bridge.CodeJobNotFound {
+		return &launcherpb.CancelReply{}, nil
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &launcherpb.CancelReply{Cancelled: true}, nil
+}
+
+// ListJobs returns the running jobs of the bridge, oldest first.
+func (s *Server) ListJobs(ctx context.Context, req *launcherpb.ListJobsRequest) (*launcherpb.ListJobsReply, error) {
+	jobs := s.b.Jobs()
+	reply := &launcherpb.ListJobsReply{Jobs: make([]*launcherpb.Job, 0, len(jobs))}
+	for _, job := range jobs {
+		reply.Jobs = append(reply.Jobs, &launcherpb.Job{Id: job.ID, Method: job.Method, Started: job.Started.UnixMilli()})
+	}
+	return reply, nil
+}
+
+// ------------------ Events ------------------
+
+// Events streams the events of the bridge until the client disconnects. Events a slow client
+// does not receive in time are dropped, as on the bridge.
+func (s *Server) Events(req *launcherpb.EventsRequest, stream launcherpb.Launcher_EventsServer) error {
+	sub := &subscriber{out: make(chan *launcherpb.Event, streamQueue)}
+	if names := req.GetNames(); len(names) > 0 {
+		sub.names = map[string]bool{}
+		for _, name := range names {
+			sub.names[name] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-sub.out:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forward queues an event for every Events stream that wants it. Payloads that cannot be
+// encoded are sent as text.
+func (s *Server) forward(name string, data any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	if err, ok := data.(error); ok {
+		data = err.Error()
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		payload, _ = json.Marshal(fmt.Sprint(data))
+	}
+	for sub := range s.subscribers {
+		if sub.names != nil && !sub.names[name] {
+			continue
+		}
+		select {
+		case sub.out <- &launcherpb.Event{Name: name, Data: payload}:
+		default:
+		}
+	}
+}
+
+// ------------------ Accounts ------------------
+
+// Login signs in with the login method of the bridge and returns the session.
+func (s *Server) Login(ctx context.Context, req *launcherpb.LoginRequest) (*launcherpb.Session, error) {
+	return s.session(ctx, bridge.Login, bridge.LoginParams{
+		Provider:  req.GetProvider(),
+		Username:  req.GetUsername(),
+		Password:  req.GetPassword(),
+		ServerURL: req.GetServerUrl(),
+		ClientID:  req.GetClientId(),
+	})
+}
+
+// Refresh renews a session with the refresh method of the bridge.
+func (s *Server) Refresh(ctx context.Context, req *launcherpb.RefreshRequest) (*launcherpb.Session, error) {
+	return s.session(ctx, bridge.Refresh, bridge.SessionParams{
+		Session:   sessionFromPB(req.GetSession()),
+		ServerURL: req.GetServerUrl(),
+		ClientID:  req.GetClientId(),
+	})
+}
+
+// Logout invalidates a session with the logout method of the bridge.
+func (s *Server) Logout(ctx context.Context, req *launcherpb.LogoutRequest) (*launcherpb.LogoutReply, error) {
+	if _, err := s.call(ctx, bridge.Logout, bridge.SessionParams{
+		Session:   sessionFromPB(req.GetSession()),
+		ServerURL: req.GetServerUrl(),
+		ClientID:  req.GetClientId(),
+	}); err != nil {
+		return nil, err
+	}
+	return &launcherpb.LogoutReply{}, nil
+}
+
+// session runs a bridge method answering with a session.
+func (s *Server) session(ctx context.Context, handler bridge.Handler, params any) (*launcherpb.Session, error) {
+	result, err := s.call(ctx, handler, params)
+	if err != nil {
+		return nil, err
+	}
+	session, ok := result.(*auth.Session)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected result %T", result)
+	}
+	return sessionToPB(session), nil
+}
+
+// call runs a bridge method for the duration of the call, with the events of the bridge.
+func (s *Server) call(ctx context.Context, handler bridge.Handler, params any) (any, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result, err := handler(ctx, data, s.b.E)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return result, nil
+}
+
+// ------------------ Conversions ------------------
+
+// statusError converts an error of the bridge to a gRPC status.
+func statusError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	var rpcErr *bridge.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case bridge.CodeInvalidParams:
+			return status.Error(codes.InvalidArgument, rpcErr.Message)
+		case bridge.CodeMethodNotFound:
+			return status.Error(codes.Unimplemented, rpcErr.Message)
+		case bridge.CodeJobNotFound:
+			return status.Error(codes.NotFound, rpcErr.Message)
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// sessionFromPB converts a session of the service to an auth.Session; nil stays nil.
+func sessionFromPB(s *launcherpb.Session) *auth.Session {
+	if s == nil {
+		return nil
+	}
+	session := &auth.Session{
+		Provider:     s.GetProvider(),
+		Username:     s.GetUsername(),
+		UUID:         s.GetUuid(),
+		AccessToken:  s.GetAccessToken(),
+		RefreshToken: s.GetRefreshToken(),
+		UserType:     s.GetUserType(),
+		XUID:         s.GetXuid(),
+		ClientID:     s.GetClientId(),
+	}
+	if s.GetExpiresAt() != 0 {
+		session.ExpiresAt = time.Unix(s.GetExpiresAt(), 0)
+	}
+	return session
+}
+
+// sessionToPB converts an auth.Session to a session of the service.
+func sessionToPB(s *auth.Session) *launcherpb.Session {
+	session := &launcherpb.Session{
+		Provider:     s.Provider,
+		Username:     s.Username,
+		Uuid:         s.UUID,
+		AccessToken:  s.AccessToken,
+		RefreshToken: s.RefreshToken,
+		UserType:     s.UserType,
+		Xuid:         s.XUID,
+		ClientId:     s.ClientID,
+	}
+	if !s.ExpiresAt.IsZero() {
+		session.ExpiresAt = s.ExpiresAt.Unix()
+	}
+	return session
+}
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/bridge"
+	"github.com/urixen-org/minecraft-launcher-core/src/bridge/grpc/launcherpb"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// serve starts a server for a new bridge and returns the bridge and a client dialing it
+// with token.
+func serve(t *testing.T, token func(b *bridge.Bridge) string) (*bridge.Bridge, launcherpb.LauncherClient) {
+	t.Helper()
+	b, err := bridge.New(events.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	gs := NewServer(b)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(Token(token(b))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return b, launcherpb.NewLauncherClient(conn)
+}
+
+func TestServerRejectsWrongToken(t *testing.T) {
+	_, client := serve(t, func(*bridge.Bridge) string { return "wrong" })
+	_, err := client.ListJobs(context.Background(), &launcherpb.ListJobsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("ListJobs with a wrong token = %v, want Unauthenticated", err)
+	}
+}
+
+func TestServerForwardsToBridge(t *testing.T) {
+	b, client := serve(t, func(b *bridge.Bridge) string { return b.Token })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := client.Login(ctx, &launcherpb.LoginRequest{Provider: "offline", Username: "Steve"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if session.GetProvider() != "offline" || session.GetUsername() != "Steve" || session.GetUuid() == "" {
+		t.Errorf("Login = %+v, want an offline session of Steve", session)
+	}
+
+	_, err = client.Login(ctx, &launcherpb.LoginRequest{Provider: "nope"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Login with an unknown provider = %v, want InvalidArgument", err)
+	}
+	// Params are checked by the job, which fails on its own
+	job, err := client.Install(ctx, &launcherpb.InstallRequest{})
+	if err != nil || job.GetJob() == "" {
+		t.Errorf("Install = %+v, %v, want a job", job, err)
+	}
+
+	reply, err := client.Cancel(ctx, &launcherpb.CancelRequest{Job: "missing"})
+	if err != nil || reply.GetCancelled() {
+		t.Errorf("Cancel of a missing job = %+v, %v, want not cancelled", reply, err)
+	}
+
+	stream, err := client.Events(ctx, &launcherpb.EventsRequest{Names: []string{"ping"}})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	// The stream is registered once the server handles the call; emit until it arrives
+	received := make(chan *launcherpb.Event, 1)
+	go func() {
+		if event, err := stream.Recv(); err == nil {
+			received <- event
+		}
+	}()
+	for {
+		b.E.Emit("ignored", 1)
+		b.E.Emit("ping", map[string]int{"n": 1})
+		select {
+		case event := <-received:
+			if event.GetName() != "ping" || string(event.GetData()) != `{"n":1}` {
+				t.Errorf("Events = %s %s, want ping {\"n\":1}", event.GetName(), event.GetData())
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("no event streamed")
+		}
+	}
+}
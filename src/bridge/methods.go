@@ -0,0 +1,227 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/launcher"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Parameters ------------------
+
+// InstallParams are the params of the install method.
+type InstallParams struct {
+	Version string `json:"version"`
+	// GameDir defaults to utils.GetMCDir().
+	GameDir string `json:"gameDir,omitempty"`
+}
+
+// LaunchParams are the params of the launch method, the subset of launcher.LaunchOptions
+// that can be sent as JSON.
+type LaunchParams struct {
+	Username    string `json:"username"`
+	AccessToken string `json:"accessToken,omitempty"`
+	UUID        string `json:"uuid,omitempty"`
+	// GameDir defaults to utils.GetMCDir().
+	GameDir  string `json:"gameDir,omitempty"`
+	Version  string `json:"version"`
+	JavaPath string `json:"javaPath,omitempty"`
+	MaxRam   string `json:"maxRam,omitempty"`
+	MinRam   string `json:"minRam,omitempty"`
+}
+
+// LoginParams are the params of the login method.
+type LoginParams struct {
+	// Provider is "microsoft", "offline" or "yggdrasil".
+	Provider string `json:"provider"`
+	// Username is the offline username, or the account name for Yggdrasil servers.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// ServerURL is the API root of Yggdrasil providers.
+	ServerURL string `json:"serverUrl,omitempty"`
+	// ClientID is the Azure application ID of Microsoft logins.
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// SessionParams are the params of the refresh and logout methods. The provider is taken from
+// the session; ServerURL and ClientID configure it as in LoginParams.
+type SessionParams struct {
+	Session   *auth.Session `json:"session"`
+	ServerURL string        `json:"serverUrl,omitempty"`
+	ClientID  string        `json:"clientId,omitempty"`
+}
+
+// decode unmarshals params, reporting failures as invalid params.
+func decode(params json.RawMessage, out any) error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, out); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// provider returns the auth provider called name, configured from p.
+func provider(name string, p LoginParams) (auth.Provider, error) {
+	switch name {
+	case "microsoft":
+		return &auth.Microsoft{ClientID: p.ClientID}, nil
+	case "offline":
+		return &auth.Offline{Username: p.Username}, nil
+	case "yggdrasil":
+		if p.ServerURL == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "missing serverUrl"}
+		}
+		return &auth.Yggdrasil{ServerURL: p.ServerURL, Username: p.Username, Password: p.Password}, nil
+	}
+	return nil, &Error{Code: CodeInvalidParams, Message: "unknown provider " + name}
+}
+
+// decodeSession decodes SessionParams and returns the session with its provider.
+func decodeSession(params json.RawMessage) (*auth.Session, auth.Provider, error) {
+	var p SessionParams
+	if err := decode(params, &p); err != nil {
+		return nil, nil, err
+	}
+	if p.Session == nil {
+		return nil, nil, &Error{Code: CodeInvalidParams, Message: "missing session"}
+	}
+	clientID := p.ClientID
+	if clientID == "" {
+		clientID = p.Session.ClientID
+	}
+	prov, err := provider(p.Session.Provider, LoginParams{
+		Username:  p.Session.Username,
+		ServerURL: p.ServerURL,
+		ClientID:  clientID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.Session, prov, nil
+}
+
+// ------------------ Methods ------------------
+
+// Install is the install method: it installs a vanilla version like downloader.InstallVersion.
+// Cancelling stops the download: files of the version are rolled back by its transaction, and
+// asset objects already downloaded are kept for the next install.
+func Install(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error) {
+	var p InstallParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Version == "" {
+		return nil, &Error{Code: CodeInvalidParams, Message: "missing version"}
+	}
+	if p.GameDir == "" {
+		p.GameDir = utils.GetMCDir()
+	}
+
+	install, err := downloader.InstallVersionWithOptions(p.Version, p.GameDir, downloader.VersionOptions{Context: ctx}, E)
+	if err != nil {
+		return nil, err
+	}
+	// Like InstallVersion, missing assets do not fail the install; they are retried next time
+	install.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return map[string]string{"version": p.Version}, nil
+}
+
+// Launch is the launch method: it starts the game with launcher.LaunchWithOptions and waits
+// for it to exit, returning the exit code. Cancelling kills the game.
+func Launch(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error) {
+	var p LaunchParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	if p.GameDir == "" {
+		p.GameDir = utils.GetMCDir()
+	}
+
+	cmd, err := launcher.LaunchWithOptions(launcher.LaunchOptions{
+		Username:    p.Username,
+		AccessToken: p.AccessToken,
+		UUID:        p.UUID,
+		GameDir:     p.GameDir,
+		Version:     p.Version,
+		JavaPath:    p.JavaPath,
+		MaxRam:      p.MaxRam,
+		MinRam:      p.MinRam,
+	}, E)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err = <-exited:
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		<-exited
+		return nil, ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	return map[string]int{"exitCode": cmd.ProcessState.ExitCode()}, nil
+}
+
+// Login is the login method: it signs in with the provider of the params and returns the
+// session, tokens included, as the job_done result. Microsoft logins emit auth_device_code and
+// wait for the user to enter the code; cancelling does not interrupt the wait.
+func Login(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error) {
+	var p LoginParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	prov, err := provider(p.Provider, p)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := prov.Login(E)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Refresh is the refresh method: it renews a session with its provider and returns the new one.
+func Refresh(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error) {
+	session, prov, err := decodeSession(params)
+	if err != nil {
+		return nil, err
+	}
+	return prov.Refresh(session, E)
+}
+
+// Logout is the logout method: it invalidates a session where its provider supports it.
+func Logout(ctx context.Context, params json.RawMessage, E *events.EventEmitter) (any, error) {
+	session, prov, err := decodeSession(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := prov.Logout(session); err != nil {
+		return nil, err
+	}
+	return map[string]bool{"ok": true}, nil
+}
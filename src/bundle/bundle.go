@@ -0,0 +1,252 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/instance"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ManifestFile is the name of the manifest at the root of a bundle archive.
+const ManifestFile = "bundle.json"
+
+// FormatVersion is the bundle format written by Export.
+const FormatVersion = 1
+
+// Asset selections of a bundle.
+const (
+	// AssetsAll includes every object of the asset index.
+	AssetsAll = "all"
+	// AssetsEssential includes only the objects matching downloader.EssentialAssets; the rest is
+	// downloaded once the machine is online.
+	AssetsEssential = "essential"
+	// AssetsNone includes the asset index only.
+	AssetsNone = "none"
+)
+
+// InstanceExcludes are paths (slash-separated, relative to the instance directory) left out when
+// an instance is exported: logs, backups and files rewritten by every launch.
+var InstanceExcludes = []string{
+	"logs", "crash-reports", "screenshots", instance.BackupsDir, "session.lock", "usercache.json",
+}
+
+// ------------------ Structs ------------------
+
+// Entry is one file of a bundle.
+type Entry struct {
+	// Path is slash-separated and relative to the game directory the bundle installs into.
+	Path string `json:"path"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+	// Link is the target of a symbolic link (found in macOS runtimes); links have no SHA1.
+	Link string `json:"link,omitempty"`
+}
+
+// Manifest describes the content of a bundle, so it can be verified and installed offline.
+type Manifest struct {
+	Format  int       `json:"format"`
+	Created time.Time `json:"created"`
+	// Versions are the exported versions; the versions they inherit from are included too.
+	Versions []string `json:"versions"`
+	// Instance is the ID of the exported instance, if any.
+	Instance     string   `json:"instance,omitempty"`
+	AssetIndexes []string `json:"assetIndexes,omitempty"`
+	// Assets is the asset selection: AssetsAll, AssetsEssential or AssetsNone.
+	Assets string `json:"assets"`
+	// Runtimes are the included Java runtimes as "<component>/<platform>".
+	Runtimes []string `json:"runtimes,omitempty"`
+	Files    []Entry  `json:"files"`
+}
+
+// versionFile is the part of a version JSON needed to collect its files.
+type versionFile struct {
+	downloader.VersionMetadata
+	InheritsFrom string `json:"inheritsFrom"`
+	Assets       string `json:"assets"`
+}
+
+// ------------------ Helpers ------------------
+
+// readVersion reads versions/<id>/<id>.json of a game directory.
+func readVersion(mcDir, id string) (*versionFile, error) {
+	data, err := os.ReadFile(utils.NewLayout(mcDir).VersionJSON(id))
+	if err != nil {
+		return nil, fmt.Errorf("version %s is not installed: %w", id, err)
+	}
+	var v versionFile
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse version %s: %w", id, err)
+	}
+	return &v, nil
+}
+
+// exists reports whether rel (slash-separated) exists below mcDir.
+func exists(mcDir, rel string) bool {
+	_, err := os.Lstat(filepath.Join(mcDir, filepath.FromSlash(rel)))
+	return err == nil
+}
+
+// excluded reports whether rel (slash-separated) is or lies under an excluded path.
+func excluded(rel string, excludes []string) bool {
+	for _, ex := range excludes {
+		if rel == ex || strings.HasPrefix(rel, ex+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// essentialAsset reports whether an asset name matches downloader.EssentialAssets.
+func essentialAsset(name string) bool {
+	for _, prefix := range downloader.EssentialAssets {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileList collects the files of a bundle without duplicates, in the order they were added.
+type fileList struct {
+	mcDir string
+	paths []string
+	seen  map[string]bool
+}
+
+// add records rel (slash-separated, relative to the game directory).
+func (l *fileList) add(rel string) {
+	if !l.seen[rel] {
+		l.seen[rel] = true
+		l.paths = append(l.paths, rel)
+	}
+}
+
+// require records rel and fails when it does not exist.
+func (l *fileList) require(rel string) error {
+	if !exists(l.mcDir, rel) {
+		return fmt.Errorf("%s is missing; install the version completely before exporting it", rel)
+	}
+	l.add(rel)
+	return nil
+}
+
+// addDir records every regular file and symbolic link below rel, skipping excluded paths
+// (relative to rel).
+func (l *fileList) addDir(rel string, excludes []string) error {
+	root := filepath.Join(l.mcDir, filepath.FromSlash(rel))
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		sub, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if sub != "." && excluded(filepath.ToSlash(sub), excludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() || info.Mode()&os.ModeSymlink != 0 {
+			l.add(rel + "/" + filepath.ToSlash(sub))
+		}
+		return nil
+	})
+}
+
+// addVersion records a version and the versions it inherits from: their JSONs, client JARs and
+// the libraries allowed on platform. It returns the asset index of the chain ("" if none).
+func (l *fileList) addVersion(id string, platform rules.Platform) (string, error) {
+	assetIndex := ""
+	for depth := 0; id != ""; depth++ {
+		if depth >= 16 {
+			return "", fmt.Errorf("inheritance chain of %s is too deep", id)
+		}
+		v, err := readVersion(l.mcDir, id)
+		if err != nil {
+			return "", err
+		}
+		if err := l.require("versions/" + id + "/" + id + ".json"); err != nil {
+			return "", err
+		}
+		// Only the root of a chain must have a JAR; children fall back to their parent's
+		jar := "versions/" + id + "/" + id + ".jar"
+		if v.InheritsFrom == "" {
+			if err := l.require(jar); err != nil {
+				return "", err
+			}
+		} else if exists(l.mcDir, jar) {
+			l.add(jar)
+		}
+
+		for _, lib := range v.Libraries {
+			if !rules.EvaluateRules(lib.Rules, platform, nil) {
+				continue
+			}
+			artifact := lib.Downloads.Artifact.Path
+			if artifact == "" && len(lib.Downloads.Classifiers) == 0 {
+				artifact = downloader.MavenPath(lib.Name)
+			}
+			if artifact != "" {
+				if err := l.require("libraries/" + artifact); err != nil {
+					return "", err
+				}
+			}
+			// Natives of other platforms are only present when they were installed for them
+			for _, classifier := range lib.Downloads.Classifiers {
+				if rel := "libraries/" + classifier.Path; classifier.Path != "" && exists(l.mcDir, rel) {
+					l.add(rel)
+				}
+			}
+		}
+
+		if assetIndex == "" {
+			assetIndex = v.AssetIndex.Id
+			if assetIndex == "" {
+				assetIndex = v.Assets
+			}
+		}
+		id = v.InheritsFrom
+	}
+	return assetIndex, nil
+}
+
+// addAssets records an asset index and the objects selected by mode.
+func (l *fileList) addAssets(indexID, mode string) error {
+	rel := "assets/indexes/" + indexID + ".json"
+	if err := l.require(rel); err != nil {
+		return err
+	}
+	if mode == AssetsNone {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(l.mcDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	var index downloader.AssetIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("failed to parse asset index %s: %w", indexID, err)
+	}
+	for name, obj := range index.Objects {
+		if mode == AssetsEssential && !essentialAsset(name) {
+			continue
+		}
+		if len(obj.Hash) < 2 {
+			continue
+		}
+		if err := l.require("assets/objects/" + obj.Hash[:2] + "/" + obj.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/instance"
+	"github.com/urixen-org/minecraft-launcher-core/src/javaruntime"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// storedExtensions are already compressed, so they are stored instead of deflated again.
+var storedExtensions = map[string]bool{".jar": true, ".zip": true, ".png": true, ".ogg": true}
+
+// ExportOptions selects what Export puts into a bundle.
+type ExportOptions struct {
+	// Versions are the versions to include, with every version they inherit from.
+	Versions []string
+	// Instance is the ID of an instance under <mcDir>/instances to include, without the
+	// InstanceExcludes. Its version is included as well.
+	Instance string
+	// Assets is AssetsAll (the default), AssetsEssential or AssetsNone.
+	Assets string
+	// Runtimes includes the Java runtime of every version, installed under mcDir with
+	// javaruntime.Install.
+	Runtimes bool
+	// Platform selects the libraries and runtimes of another platform than the host, for
+	// bundles prepared for other machines. Nil means rules.Host().
+	Platform *rules.Platform
+	// RuntimePlatform is the javaruntime platform of the included runtimes, e.g. "windows-x64".
+	// Empty means javaruntime.Platform().
+	RuntimePlatform string
+}
+
+// ------------------ Export ------------------
+
+// collect lists the files selected by opts and fills in the manifest fields describing them.
+func collect(mcDir string, opts ExportOptions, manifest *Manifest) ([]string, error) {
+	files := &fileList{mcDir: mcDir, seen: map[string]bool{}}
+	platform := rules.Host()
+	if opts.Platform != nil {
+		platform = *opts.Platform
+	}
+
+	versions := append([]string(nil), opts.Versions...)
+	if opts.Instance != "" {
+		inst, err := instance.Load(filepath.Join(instance.InstancesDir(mcDir), opts.Instance))
+		if err != nil {
+			return nil, err
+		}
+		if err := files.addDir("instances/"+opts.Instance, InstanceExcludes); err != nil {
+			return nil, fmt.Errorf("failed to read instance %s: %w", opts.Instance, err)
+		}
+		if inst.Version != "" {
+			versions = append(versions, inst.Version)
+		}
+		manifest.Instance = inst.ID
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("nothing to export")
+	}
+
+	indexes := map[string]bool{}
+	runtimes := map[string]bool{}
+	for _, version := range versions {
+		index, err := files.addVersion(version, platform)
+		if err != nil {
+			return nil, err
+		}
+		if index != "" && !indexes[index] {
+			indexes[index] = true
+			if err := files.addAssets(index, manifest.Assets); err != nil {
+				return nil, err
+			}
+			manifest.AssetIndexes = append(manifest.AssetIndexes, index)
+		}
+
+		if opts.Runtimes {
+			component := javaruntime.VersionComponent(mcDir, version)
+			rt, err := javaruntime.LoadPlatform(mcDir, component, opts.RuntimePlatform)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(mcDir, rt.Dir)
+			if err != nil {
+				return nil, err
+			}
+			if id := rt.Component + "/" + rt.Platform; !runtimes[id] {
+				runtimes[id] = true
+				if err := files.addDir(filepath.ToSlash(rel), nil); err != nil {
+					return nil, fmt.Errorf("failed to read runtime %s: %w", id, err)
+				}
+				manifest.Runtimes = append(manifest.Runtimes, id)
+			}
+		}
+		if !containsString(manifest.Versions, version) {
+			manifest.Versions = append(manifest.Versions, version)
+		}
+	}
+	return files.paths, nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// addEntry copies one file of mcDir into the archive and returns its manifest entry.
+func addEntry(zw *zip.Writer, mcDir, rel string) (Entry, error) {
+	path := filepath.Join(mcDir, filepath.FromSlash(rel))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return Entry{}, err
+	}
+	header.Name = rel
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return Entry{}, err
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return Entry{}, err
+		}
+		_, err = io.WriteString(w, target)
+		return Entry{Path: rel, Link: filepath.ToSlash(target)}, err
+	}
+
+	header.Method = zip.Deflate
+	if storedExtensions[strings.ToLower(filepath.Ext(rel))] {
+		header.Method = zip.Store
+	}
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return Entry{}, err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer in.Close()
+
+	h := sha1.New()
+	size, err := io.Copy(io.MultiWriter(w, h), in)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Path: rel, SHA1: hex.EncodeToString(h.Sum(nil)), Size: size}, nil
+}
+
+// Export packages installed versions, an instance, their assets and optionally their Java
+// runtimes from mcDir into a single ZIP at path, with a ManifestFile listing every file and its
+// SHA1, so the installation can be reproduced offline with Import (LAN parties, schools,
+// air-gapped machines). Every version must be installed completely. The archive is written to a
+// temporary file first, so an interrupted export never looks complete.
+func Export(mcDir, path string, opts ExportOptions, E *events.EventEmitter) (*Manifest, error) {
+	manifest := &Manifest{Format: FormatVersion, Created: time.Now(), Assets: opts.Assets}
+	if manifest.Assets == "" {
+		manifest.Assets = AssetsAll
+	}
+	fail := func(err error) (*Manifest, error) {
+		err = fmt.Errorf("failed to export bundle: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	if manifest.Assets != AssetsAll && manifest.Assets != AssetsEssential && manifest.Assets != AssetsNone {
+		return fail(fmt.Errorf("unknown asset selection %q", manifest.Assets))
+	}
+
+	files, err := collect(mcDir, opts, manifest)
+	if err != nil {
+		return fail(err)
+	}
+	sort.Strings(files)
+	E.Emit("bundle_export_start", map[string]any{"path": path, "files": len(files)})
+
+	if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+		return fail(err)
+	}
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fail(err)
+	}
+	zw := zip.NewWriter(out)
+
+	for i, rel := range files {
+		var entry Entry
+		if entry, err = addEntry(zw, mcDir, rel); err != nil {
+			err = fmt.Errorf("%s: %w", rel, err)
+			break
+		}
+		manifest.Files = append(manifest.Files, entry)
+		E.Emit("bundle_export_progress", map[string]any{"done": i + 1, "total": len(files), "path": rel})
+	}
+	if err == nil {
+		var w io.Writer
+		if w, err = zw.Create(ManifestFile); err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(manifest)
+		}
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fail(err)
+	}
+
+	E.Emit("bundle_exported", map[string]any{"path": path, "files": len(manifest.Files)})
+	return manifest, nil
+}
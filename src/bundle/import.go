@@ -0,0 +1,236 @@
+package bundle
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/instance"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Helpers ------------------
+
+// fileSHA1 returns the hex SHA1 of a file.
+func fileSHA1(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readManifest reads the ManifestFile of an opened bundle.
+func readManifest(zr *zip.Reader) (*Manifest, error) {
+	f, err := zr.Open(ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	defer f.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	if manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("bundle format %d is newer than the supported format %d", manifest.Format, FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// validEntryPath reports whether rel is a clean relative path that stays inside the game directory.
+func validEntryPath(rel string) bool {
+	return rel != "" && rel != ManifestFile && !path.IsAbs(rel) && !strings.Contains(rel, `\`) &&
+		path.Clean(rel) == rel && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// validate checks that every manifest entry is present in the archive with a safe path, and
+// returns the archive files by path.
+func validate(zr *zip.Reader, manifest *Manifest) (map[string]*zip.File, error) {
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	for _, entry := range manifest.Files {
+		if !validEntryPath(entry.Path) {
+			return nil, fmt.Errorf("bundle entry %q escapes the game directory", entry.Path)
+		}
+		if entry.Link != "" && !validEntryPath(path.Join(path.Dir(entry.Path), entry.Link)) {
+			return nil, fmt.Errorf("bundle link %q points outside the game directory", entry.Path)
+		}
+		if files[entry.Path] == nil {
+			return nil, fmt.Errorf("bundle entry %s is missing from the archive", entry.Path)
+		}
+	}
+	return files, nil
+}
+
+// extractEntry writes one archive file to dst through a temporary file, verifying its SHA1
+// before it replaces dst. Files already present with the expected SHA1 are left untouched;
+// it reports whether dst was written. Writes are journaled in the transaction of ctx.
+func extractEntry(ctx context.Context, f *zip.File, entry Entry, dst string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return false, err
+	}
+
+	// Links are recreated by Import once every file is in place
+	if entry.Link != "" {
+		if target, err := os.Readlink(dst); err == nil && filepath.ToSlash(target) == entry.Link {
+			return false, nil
+		}
+		downloader.Track(ctx, dst)
+		return true, nil
+	}
+
+	if sum, err := fileSHA1(dst); err == nil && sum == entry.SHA1 {
+		return false, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+
+	mode := utils.Modes.File
+	if f.Mode()&0111 != 0 {
+		mode = utils.Modes.Executable
+	}
+	tmp := dst + ".import"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return false, err
+	}
+	h := sha1.New()
+	size, err := io.Copy(io.MultiWriter(out, h), rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != entry.SHA1 || size != entry.Size {
+			err = fmt.Errorf("checksum mismatch (expected %s, got %s)", entry.SHA1, sum)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+
+	downloader.Track(ctx, dst)
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, nil
+}
+
+// ------------------ Import ------------------
+
+// Import installs a bundle written by Export into mcDir without network access. Every file is
+// verified against the SHA1 in the bundle's manifest before it is put in place, files already
+// present with the right content are kept, and the whole import runs in a downloader
+// transaction under the install lock, so a damaged archive leaves mcDir as it was. The
+// bundle's versions become available by their IDs; its instance is registered under
+// <mcDir>/instances and must not exist yet.
+func Import(bundlePath, mcDir string, E *events.EventEmitter) (*Manifest, error) {
+	fail := func(err error) (*Manifest, error) {
+		err = fmt.Errorf("failed to import bundle %s: %w", bundlePath, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return fail(err)
+	}
+	defer zr.Close()
+
+	manifest, err := readManifest(&zr.Reader)
+	if err != nil {
+		return fail(err)
+	}
+	files, err := validate(&zr.Reader, manifest)
+	if err != nil {
+		return fail(err)
+	}
+	instanceDir := ""
+	if manifest.Instance != "" {
+		instanceDir = filepath.Join(instance.InstancesDir(mcDir), manifest.Instance)
+		if _, err := os.Stat(filepath.Join(instanceDir, instance.MetadataFile)); err == nil {
+			return fail(fmt.Errorf("instance %s already exists", manifest.Instance))
+		}
+	}
+
+	var totalBytes int64
+	for _, entry := range manifest.Files {
+		totalBytes += entry.Size
+	}
+	E.Emit("bundle_import_start", map[string]any{"path": bundlePath, "files": len(manifest.Files), "bytes": totalBytes})
+
+	err = downloader.RunTransaction(mcDir, "bundle-"+filepath.Base(bundlePath), func(ctx context.Context) error {
+		var done int64
+		var links []utils.ArchiveEntry
+		for i, entry := range manifest.Files {
+			dst := filepath.Join(mcDir, filepath.FromSlash(entry.Path))
+			written, err := extractEntry(ctx, files[entry.Path], entry, dst)
+			if err != nil {
+				return fmt.Errorf("%s: %w", entry.Path, err)
+			}
+			if entry.Link != "" && written {
+				links = append(links, utils.ArchiveEntry{Path: entry.Path, Link: entry.Link})
+			}
+			done += entry.Size
+			E.Emit("bundle_import_progress", map[string]any{
+				"done": i + 1, "total": len(manifest.Files), "bytes": done, "totalBytes": totalBytes,
+				"path": entry.Path, "skipped": !written,
+			})
+		}
+
+		if err := utils.RestoreArchiveEntries(mcDir, links); err != nil {
+			return err
+		}
+		// Bundles written on Windows carry no executable bits
+		for _, rt := range manifest.Runtimes {
+			if _, err := utils.FixJavaExecutables(filepath.Join(utils.NewLayout(mcDir).RuntimesDir(), filepath.FromSlash(rt))); err != nil {
+				return err
+			}
+		}
+
+		if instanceDir != "" {
+			if _, err := instance.Load(instanceDir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, E)
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, version := range manifest.Versions {
+		E.Emit("version_registered", version)
+	}
+	if manifest.Instance != "" {
+		E.Emit("instance_registered", manifest.Instance)
+	}
+	E.Emit("bundle_imported", map[string]any{"path": bundlePath, "versions": manifest.Versions, "instance": manifest.Instance})
+	return manifest, nil
+}
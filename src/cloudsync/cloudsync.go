@@ -0,0 +1,395 @@
+package cloudsync
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ManifestKey is the backend key of the manifest describing every synced file.
+const ManifestKey = "manifest.json"
+
+// stateFile stores, inside the synced directory, the hashes of the last synchronization.
+const stateFile = ".sync-state.json"
+
+// DefaultPaths are synced when a Syncer has no Paths: worlds, mod configs and game options.
+var DefaultPaths = []string{"saves", "config", "options.txt"}
+
+// ErrNotExist is returned by Backend.Get for keys that do not exist.
+var ErrNotExist = errors.New("remote file does not exist")
+
+// ------------------ Structs ------------------
+
+// Backend stores synced files under slash-separated keys. Implementations only need plain
+// object storage; change detection is done by the Syncer through the manifest.
+type Backend interface {
+	// Get opens the file stored under key, or returns ErrNotExist.
+	Get(key string) (io.ReadCloser, error)
+	// Put stores the contents of r under key, replacing any previous file.
+	Put(key string, r io.Reader) error
+	// Delete removes the file stored under key. Missing keys are not an error.
+	Delete(key string) error
+}
+
+// FileInfo describes a synced file in the manifest.
+type FileInfo struct {
+	SHA1    string    `json:"sha1"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Manifest maps slash-separated paths, relative to the synced directory, to their files.
+type Manifest map[string]FileInfo
+
+// Conflict is a file changed both locally and remotely since the last synchronization.
+type Conflict struct {
+	Path   string
+	Local  FileInfo
+	Remote FileInfo
+}
+
+// Resolution is how a Conflict is settled.
+type Resolution int
+
+const (
+	// KeepBoth keeps the remote file and saves the local one next to it as
+	// <name>.conflict-<timestamp><ext>. It never loses data and is the default.
+	KeepBoth Resolution = iota
+	// KeepLocal overwrites the remote file with the local one.
+	KeepLocal
+	// KeepRemote overwrites the local file with the remote one.
+	KeepRemote
+)
+
+// Resolver decides how a conflict is settled.
+type Resolver func(c Conflict) Resolution
+
+// PreferNewer resolves conflicts in favor of the most recently modified file.
+func PreferNewer(c Conflict) Resolution {
+	if c.Local.ModTime.After(c.Remote.ModTime) {
+		return KeepLocal
+	}
+	return KeepRemote
+}
+
+// Syncer synchronizes selected files of a directory (usually an instance) with a Backend.
+// Files deleted on one side are not deleted on the other.
+type Syncer struct {
+	Backend Backend
+	// Dir is the local directory being synced.
+	Dir string
+	// Paths are the files and directories of Dir to sync; empty means DefaultPaths.
+	Paths []string
+	// Resolve settles files changed on both sides; nil keeps both copies.
+	Resolve Resolver
+}
+
+// ------------------ Helpers ------------------
+
+// hashFile returns the SHA1, size and modification time of a file.
+func hashFile(path string) (FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return FileInfo{}, err
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{SHA1: hex.EncodeToString(h.Sum(nil)), Size: info.Size(), ModTime: info.ModTime().UTC()}, nil
+}
+
+// localManifest hashes every synced file of the directory.
+func (s *Syncer) localManifest() (Manifest, error) {
+	paths := s.Paths
+	if len(paths) == 0 {
+		paths = DefaultPaths
+	}
+
+	manifest := Manifest{}
+	for _, root := range paths {
+		err := filepath.Walk(filepath.Join(s.Dir, filepath.FromSlash(root)), func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			// session.lock is held open by a running game and never needs syncing
+			if !info.Mode().IsRegular() || info.Name() == "session.lock" {
+				return nil
+			}
+			rel, err := filepath.Rel(s.Dir, path)
+			if err != nil {
+				return err
+			}
+			file, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			manifest[filepath.ToSlash(rel)] = file
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// remoteManifest fetches the manifest of the backend; a missing manifest is empty.
+func (s *Syncer) remoteManifest() (Manifest, error) {
+	rc, err := s.Backend.Get(ManifestKey)
+	if errors.Is(err, ErrNotExist) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	manifest := Manifest{}
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse remote manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// loadState returns the hashes recorded at the last synchronization.
+func (s *Syncer) loadState() map[string]string {
+	state := map[string]string{}
+	if data, err := os.ReadFile(filepath.Join(s.Dir, stateFile)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveState records the hashes of the last synchronization.
+func (s *Syncer) saveState(state map[string]string) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, stateFile), data, utils.Modes.File)
+}
+
+// resolve settles a conflict with the configured resolver.
+func (s *Syncer) resolve(c Conflict, E *events.EventEmitter) Resolution {
+	resolution := KeepBoth
+	if s.Resolve != nil {
+		resolution = s.Resolve(c)
+	}
+	E.Emit("sync_conflict", map[string]interface{}{"path": c.Path, "resolution": resolution})
+	return resolution
+}
+
+// localPath returns the local file of a manifest path, refusing paths outside Dir.
+func (s *Syncer) localPath(rel string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.FromSlash(rel))
+	if !strings.HasPrefix(path, filepath.Clean(s.Dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("remote path %q escapes the synced directory", rel)
+	}
+	return path, nil
+}
+
+// upload stores a local file on the backend.
+func (s *Syncer) upload(rel string, E *events.EventEmitter) error {
+	path, err := s.localPath(rel)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.Backend.Put(rel, f); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", rel, err)
+	}
+	E.Emit("sync_file_uploaded", rel)
+	return nil
+}
+
+// download replaces a local file with the one on the backend.
+func (s *Syncer) download(rel string, E *events.EventEmitter) error {
+	path, err := s.localPath(rel)
+	if err != nil {
+		return err
+	}
+	rc, err := s.Backend.Get(rel)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", rel, err)
+	}
+	defer rc.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+		return err
+	}
+	tmp := path + ".sync-tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to download %s: %w", rel, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	E.Emit("sync_file_downloaded", rel)
+	return nil
+}
+
+// keepConflictCopy renames a local file out of the way of the remote version.
+func (s *Syncer) keepConflictCopy(rel string, E *events.EventEmitter) error {
+	path, err := s.localPath(rel)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	copyPath := strings.TrimSuffix(path, ext) + ".conflict-" + time.Now().Format("20060102-150405") + ext
+	if err := os.Rename(path, copyPath); err != nil {
+		return err
+	}
+	E.Emit("sync_conflict_copy", copyPath)
+	return nil
+}
+
+// ------------------ Public API ------------------
+
+// Push uploads local files changed since the last synchronization. Files that also changed
+// remotely are settled by Resolve; when the remote side wins, the remote file is downloaded.
+func (s *Syncer) Push(E *events.EventEmitter) error {
+	return s.sync(true, E)
+}
+
+// Pull downloads remote files changed since the last synchronization. Files that also changed
+// locally are settled by Resolve; when the local side wins, the local file is uploaded.
+func (s *Syncer) Pull(E *events.EventEmitter) error {
+	return s.sync(false, E)
+}
+
+// sync runs a synchronization in one direction, settling conflicts either way.
+func (s *Syncer) sync(push bool, E *events.EventEmitter) error {
+	direction := "pull"
+	if push {
+		direction = "push"
+	}
+	E.Emit("sync_start", direction)
+
+	local, err := s.localManifest()
+	if err != nil {
+		err = fmt.Errorf("failed to scan %s: %w", s.Dir, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	remote, err := s.remoteManifest()
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	state := s.loadState()
+
+	var paths []string
+	for path := range local {
+		paths = append(paths, path)
+	}
+	for path := range remote {
+		if _, ok := local[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	uploaded, downloaded := 0, 0
+	for _, path := range paths {
+		l, hasLocal := local[path]
+		r, hasRemote := remote[path]
+		if hasLocal && hasRemote && l.SHA1 == r.SHA1 {
+			state[path] = l.SHA1
+			continue
+		}
+		localChanged := hasLocal && l.SHA1 != state[path]
+		remoteChanged := hasRemote && r.SHA1 != state[path]
+
+		upload := push && localChanged && !remoteChanged
+		download := !push && remoteChanged && !localChanged
+		if localChanged && remoteChanged {
+			switch s.resolve(Conflict{Path: path, Local: l, Remote: r}, E) {
+			case KeepLocal:
+				upload = true
+			case KeepRemote:
+				download = true
+			default:
+				if err := s.keepConflictCopy(path, E); err != nil {
+					E.Emit("error", i18n.ErrorEvent(err))
+					return err
+				}
+				download = true
+			}
+		}
+
+		switch {
+		case upload:
+			if err := s.upload(path, E); err != nil {
+				E.Emit("error", i18n.ErrorEvent(err))
+				return err
+			}
+			remote[path] = l
+			state[path] = l.SHA1
+			uploaded++
+		case download:
+			if err := s.download(path, E); err != nil {
+				E.Emit("error", i18n.ErrorEvent(err))
+				return err
+			}
+			state[path] = r.SHA1
+			downloaded++
+		}
+	}
+
+	if uploaded > 0 {
+		data, err := json.MarshalIndent(remote, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := s.Backend.Put(ManifestKey, bytes.NewReader(data)); err != nil {
+			err = fmt.Errorf("failed to upload manifest: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+	}
+	if err := s.saveState(state); err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to save sync state: %w", err)))
+		return err
+	}
+
+	E.Emit("sync_done", map[string]interface{}{"direction": direction, "uploaded": uploaded, "downloaded": downloaded})
+	return nil
+}
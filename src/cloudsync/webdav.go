@@ -0,0 +1,113 @@
+package cloudsync
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// WebDAV is a Backend storing files on a WebDAV server (Nextcloud, ownCloud, Apache
+// mod_dav, ...). Keys are appended to BaseURL; missing collections are created on upload.
+type WebDAV struct {
+	// BaseURL is the collection holding the synced files, e.g.
+	// "https://cloud.example.com/remote.php/dav/files/alex/minecraft/survival".
+	BaseURL  string
+	Username string
+	Password string
+	// Client sends the requests; nil uses utils.HTTPClient.
+	Client *http.Client
+}
+
+// keyURL returns the URL of a key, escaping each path segment.
+func (w *WebDAV) keyURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(w.BaseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+// do sends an authenticated request.
+func (w *WebDAV) do(method, target string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" || w.Password != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	client := w.Client
+	if client == nil {
+		client = utils.HTTPClient
+	}
+	return client.Do(req)
+}
+
+// mkcol creates the collections leading to key, ignoring those that already exist.
+func (w *WebDAV) mkcol(key string) error {
+	segments := strings.Split(key, "/")
+	for i := 1; i < len(segments); i++ {
+		resp, err := w.do("MKCOL", w.keyURL(strings.Join(segments[:i], "/"))+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405 Method Not Allowed means the collection already exists
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("MKCOL %s: %s", strings.Join(segments[:i], "/"), resp.Status)
+		}
+	}
+	return nil
+}
+
+// Get opens the file stored under key.
+func (w *WebDAV) Get(key string) (io.ReadCloser, error) {
+	resp, err := w.do(http.MethodGet, w.keyURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotExist
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", key, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Put stores the contents of r under key, creating missing collections.
+func (w *WebDAV) Put(key string, r io.Reader) error {
+	if strings.Contains(key, "/") {
+		if err := w.mkcol(key); err != nil {
+			return err
+		}
+	}
+	resp, err := w.do(http.MethodPut, w.keyURL(key), r)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// Delete removes the file stored under key.
+func (w *WebDAV) Delete(key string) error {
+	resp, err := w.do(http.MethodDelete, w.keyURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return nil
+	}
+	return fmt.Errorf("DELETE %s: %s", key, resp.Status)
+}
@@ -0,0 +1,76 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// VersionManifestURL lists every Minecraft version, newest first, and the latest release and snapshot.
+const VersionManifestURL = "https://launchermeta.mojang.com/mc/game/version_manifest.json"
+
+// Version aliases accepted wherever a version id is, resolved through the manifest when used.
+const (
+	AliasLatestRelease  = "latest-release"
+	AliasLatestSnapshot = "latest-snapshot"
+)
+
+// ------------------ Manifest ------------------
+
+// FetchManifest downloads the version manifest.
+func FetchManifest() (*Manifest, error) {
+	resp, err := utils.HTTPClient.Get(VersionManifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrManifestUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read body: %w", ErrManifestUnavailable, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse it: %w", ErrManifestUnavailable, err)
+	}
+	return &manifest, nil
+}
+
+// ------------------ Aliases ------------------
+
+// IsVersionAlias reports whether version is an alias rather than a version id.
+func IsVersionAlias(version string) bool {
+	return version == AliasLatestRelease || version == AliasLatestSnapshot
+}
+
+// ResolveVersionAlias returns the version id an alias stands for right now and emits
+// version_alias_resolved with the alias and the id. Version ids are returned unchanged
+// without fetching anything. "latest-snapshot" is the newest version of any type, so it is
+// the latest release while no snapshot is newer.
+func ResolveVersionAlias(version string, E *events.EventEmitter) (string, error) {
+	if !IsVersionAlias(version) {
+		return version, nil
+	}
+
+	manifest, err := FetchManifest()
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	id := manifest.Latest.Release
+	if version == AliasLatestSnapshot {
+		id = manifest.Latest.Snapshot
+	}
+	if id == "" {
+		err := fmt.Errorf("version manifest names no %s", version)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	E.Emit("version_alias_resolved", map[string]string{"alias": version, "version": id})
+	return id, nil
+}
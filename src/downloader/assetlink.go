@@ -0,0 +1,106 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// LinkAssets materializes the assets of an index in destDir (a per-instance "assets" directory)
+// from the shared object store of mcDir. Objects are hard-linked, so every instance shares a
+// single copy on disk; copies are only made when linking is impossible, e.g. across filesystems.
+// The index must have been downloaded with DownloadAssets. It returns the number of objects linked.
+func LinkAssets(mcDir, destDir, indexID string, E *events.EventEmitter) (int, error) {
+	indexPath := utils.NewLayout(mcDir).AssetIndex(indexID)
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		err = fmt.Errorf("failed to read asset index %s: %w", indexID, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return 0, err
+	}
+
+	var index AssetIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		err = fmt.Errorf("failed to parse asset index %s: %w", indexID, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return 0, err
+	}
+
+	if err := linkOrCopy(indexPath, filepath.Join(destDir, "indexes", indexID+".json")); err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to link asset index: %w", err)))
+		return 0, err
+	}
+
+	linked, copied := 0, 0
+	for _, asset := range index.Objects {
+		if len(asset.Hash) < 2 {
+			continue
+		}
+		rel := filepath.Join(asset.Hash[:2], asset.Hash)
+		src := filepath.Join(utils.NewLayout(mcDir).AssetObjectsDir(), rel)
+		dst := filepath.Join(destDir, "objects", rel)
+
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if _, err := os.Stat(src); err != nil {
+			E.Emit("asset_missing", asset.Hash)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return linked, err
+		}
+		if os.Link(src, dst) == nil {
+			linked++
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to copy asset: %w", err)))
+			return linked, err
+		}
+		copied++
+	}
+
+	E.Emit("assets_linked", map[string]int{"linked": linked, "copied": copied})
+	return linked, nil
+}
+
+// linkOrCopy hard-links src to dst, copying it when linking fails. An existing dst is replaced.
+func linkOrCopy(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return err
+	}
+	_ = os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+// copyFile copies a single file.
+func copyFile(src, dst string) error {
+	in, err := os.Open(utils.LongPath(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(utils.LongPath(dst))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,86 @@
+package downloader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// assetStateSaveInterval is how many finished assets are processed between two saves of the state file.
+const assetStateSaveInterval = 256
+
+// assetState records the progress of DownloadAssets for one asset index, so an interrupted
+// download resumes without statting and re-checking every object again.
+type assetState struct {
+	Index string `json:"index"`
+	// Complete is set once every object of the index was present.
+	Complete bool `json:"complete"`
+	// Done lists the hashes of the objects known to be present.
+	Done []string `json:"done"`
+
+	done map[string]bool
+	path string
+}
+
+// assetStatePath returns the state file of an asset index.
+func assetStatePath(mcDir, indexID string) string {
+	return filepath.Join(utils.NewLayout(mcDir).AssetsDir(), ".state", indexID+".json")
+}
+
+// loadAssetState reads the state of an asset index; a missing or unreadable file starts over.
+func loadAssetState(mcDir, indexID string) *assetState {
+	state := &assetState{Index: indexID, done: map[string]bool{}, path: assetStatePath(mcDir, indexID)}
+
+	data, err := os.ReadFile(state.path)
+	if err != nil {
+		return state
+	}
+	var saved assetState
+	if json.Unmarshal(data, &saved) != nil || saved.Index != indexID {
+		return state
+	}
+
+	state.Complete = saved.Complete
+	for _, hash := range saved.Done {
+		state.done[hash] = true
+	}
+	return state
+}
+
+// markDone records that the object with the given hash is present.
+func (s *assetState) markDone(hash string) {
+	s.done[hash] = true
+}
+
+// save writes the state atomically so an interruption never leaves a truncated file.
+func (s *assetState) save() error {
+	s.Done = s.Done[:0]
+	for hash := range s.done {
+		s.Done = append(s.Done, hash)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), utils.Modes.Dir); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, utils.Modes.File); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ResetAssetState forgets the recorded progress of an asset index, so the next DownloadAssets
+// checks every object again (e.g. after asset files were deleted by hand).
+func ResetAssetState(mcDir, indexID string) error {
+	err := os.Remove(assetStatePath(mcDir, indexID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -1,21 +1,42 @@
 package downloader
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/metrics"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Keyed errors of the install steps users see fail.
+var (
+	ErrManifestUnavailable error = i18n.New("downloader.manifest_unavailable", "failed to fetch the version manifest")
+	ErrVersionNotFound     error = i18n.New("downloader.version_not_found", "version {version} not found in manifest")
+	ErrMetadataUnavailable error = i18n.New("downloader.metadata_unavailable", "failed to fetch the metadata of {version}")
+	ErrLibrariesFailed     error = i18n.New("downloader.libraries_failed", "{failed} libraries of {version} failed to download")
+	ErrAssetIndexFailed    error = i18n.New("downloader.asset_index_failed", "failed to fetch the asset index of {version}")
+	ErrAssetsFailed        error = i18n.New("downloader.assets_failed", "{missing} assets of {version} failed to download")
 )
 
 // ------------------ Structs ------------------
 
 // Manifest represents the structure of the Minecraft version manifest file.
 type Manifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
 	Versions []Version `json:"versions"`
 }
 
@@ -32,13 +53,19 @@ type Version struct {
 type VersionMetadata struct {
 	Downloads struct {
 		Client struct {
-			Url string `json:"url"`
+			Url  string `json:"url"`
+			Sha1 string `json:"sha1"`
+			Size int64  `json:"size"`
 		} `json:"client"`
 	} `json:"downloads"`
 
 	AssetIndex struct {
-		Id  string `json:"id"`
-		Url string `json:"url"`
+		Id   string `json:"id"`
+		Url  string `json:"url"`
+		Sha1 string `json:"sha1"`
+		Size int64  `json:"size"`
+		// TotalSize is the size of every object the index lists.
+		TotalSize int64 `json:"totalSize"`
 	} `json:"assetIndex"`
 
 	Libraries []struct {
@@ -48,26 +75,27 @@ type VersionMetadata struct {
 				Url  string `json:"url"`
 				Sha1 string `json:"sha1"`
 				Path string `json:"path"`
+				Size int64  `json:"size"`
 			} `json:"artifact"`
 			Classifiers map[string]struct {
 				Url  string `json:"url"`
 				Sha1 string `json:"sha1"`
 				Path string `json:"path"`
+				Size int64  `json:"size"`
 			} `json:"classifiers"`
 		} `json:"downloads"`
-		Rules []struct {
-			Action string `json:"action"`
-			OS     struct {
-				Name string `json:"name"`
-			} `json:"os"`
-		} `json:"rules"`
+		Rules   []rules.Rule      `json:"rules"`
 		Natives map[string]string `json:"natives"`
 	} `json:"libraries"`
 }
 
 // AssetIndex represents the structure of the Minecraft asset index file, mapping asset names to object hashes.
 type AssetIndex struct {
-	Objects map[string]struct {
+	// Virtual indexes (1.6) are read by name from assets/virtual/<index>, and MapToResources
+	// indexes (before 1.6) from <game dir>/resources, instead of from the object store.
+	Virtual        bool `json:"virtual"`
+	MapToResources bool `json:"map_to_resources"`
+	Objects        map[string]struct {
 		Hash string `json:"hash"`
 		Size int64  `json:"size"`
 	} `json:"objects"`
@@ -84,98 +112,117 @@ var E *events.EventEmitter
 // It checks if the file already exists before downloading and emits events for status.
 // It creates the parent directories for the file if they don't exist.
 func DownloadFile(file string, url string, E *events.EventEmitter) error {
+	return DownloadFileContext(context.Background(), file, url, E)
+}
+
+// DownloadFileContext works like DownloadFile for an install running with ctx: the file is
+// journaled in the transaction of ctx, if any, and the download stops when ctx is done.
+func DownloadFileContext(ctx context.Context, file string, url string, E *events.EventEmitter) error {
 	// Check if file already exists
-	if _, err := os.Stat(file); err == nil {
+	if _, err := os.Stat(utils.LongPath(file)); err == nil {
 		E.Emit("file_exists", file)
 		return nil
 	}
 
+	if err := fetchFile(ctx, file, url); err != nil {
+		E.Emit("download_failed", FailureDetails(file, err))
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	E.Emit("file_downloaded", file)
+	return nil
+}
+
+// fetchFile downloads url into file without emitting events. Non-2xx responses are errors,
+// and a partially written file is removed so the next run downloads it again. Failures are
+// returned as *DownloadError. The file is journaled in the transaction of ctx, and the request
+// is cancelled with ctx.
+func fetchFile(ctx context.Context, file string, url string) (err error) {
+	start := time.Now()
+	defer func() {
+		metrics.Inc(metrics.DownloadsTotal, metrics.Result(err))
+		metrics.Since(metrics.DownloadDuration, start, metrics.Result(err))
+	}()
+
 	// Start download
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		E.Emit("error", "Failed to download "+file+": "+err.Error())
-		return err
+		return requestError(file, url, err)
+	}
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return requestError(file, url, err)
 	}
 	defer resp.Body.Close()
 
-	// Create parent directories
-	os.MkdirAll(filepath.Dir(file), 0755)
+	// Never save error pages as if they were the requested file
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError(file, url, resp.StatusCode, resp.Status)
+	}
+
+	// Create parent directories; library trees of modded versions can exceed MAX_PATH
+	long := utils.LongPath(file)
+	os.MkdirAll(filepath.Dir(long), utils.Modes.Dir)
+	Track(ctx, file)
 
 	// Create output file
-	out, err := os.Create(file)
+	out, err := os.OpenFile(long, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, utils.Modes.File)
 	if err != nil {
-		E.Emit("error", "Failed to create file "+file+": "+err.Error())
-		return err
+		return &DownloadError{File: file, URL: url, Kind: ErrorKindIO, Err: err}
 	}
-	defer out.Close()
 
 	// Copy data from response body to file
-	_, err = io.Copy(out, resp.Body)
+	written, err := io.Copy(out, resp.Body)
+	out.Close()
+	metrics.Add(metrics.DownloadBytesTotal, float64(written), nil)
 	if err != nil {
-		E.Emit("error", "Failed to write file "+file+": "+err.Error())
-	} else {
-		E.Emit("file_downloaded", file)
+		os.Remove(long)
+		return &DownloadError{File: file, URL: url, Kind: ErrorKindIO, Err: err}
 	}
-	return err
+	return nil
 }
 
-// getOSName returns the Minecraft-specific operating system name based on runtime.GOOS.
-func getOSName() string {
-	switch runtime.GOOS {
-	case "windows":
-		return "windows"
-	case "darwin":
-		return "osx"
-	case "linux":
-		return "linux"
-	default:
-		return runtime.GOOS
-	}
+// ------------------ Libraries ------------------
+
+// DownloadLibraries iterates through the version metadata and downloads all necessary libraries,
+// including main artifacts and OS-specific natives, applying OS rules.
+func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEmitter) {
+	downloadLibraries(context.Background(), metadata, mcDir, rules.Host(), E)
 }
 
-// shouldIncludeLibrary determines if a library should be downloaded based on its OS rules.
-func shouldIncludeLibrary(rules []struct {
-	Action string `json:"action"`
-	OS     struct {
-		Name string `json:"name"`
-	} `json:"os"`
-}) bool {
-	// If no rules, the library is always included.
-	if len(rules) == 0 {
-		return true
-	}
-
-	allowed := false
-	osName := getOSName()
-
-	for _, rule := range rules {
-		if rule.Action == "allow" {
-			// If rule applies to current OS (or no specific OS is defined)
-			if rule.OS.Name == "" || rule.OS.Name == osName {
-				allowed = true
-			}
-		} else if rule.Action == "disallow" {
-			// If rule disallows for current OS (or no specific OS is defined), immediately exclude.
-			if rule.OS.Name == "" || rule.OS.Name == osName {
-				return false
-			}
+// nativeClassifier returns the classifier of the native libraries for a platform.
+func nativeClassifier(platform rules.Platform) string {
+	switch platform.OS {
+	case rules.OSWindows:
+		switch platform.Arch {
+		case "x86_64", "":
+			return "natives-windows"
+		case "arm64":
+			return "natives-windows-arm64"
 		}
+		return "natives-windows-32" // Assuming x86 is 32-bit if not x86_64
+	case rules.OSMac:
+		return "natives-osx"
+	case rules.OSLinux:
+		return "natives-linux"
 	}
-	// If there were rules, but none resulted in an explicit disallow, return if at least one allow rule matched.
-	return allowed
+	return ""
 }
 
-// ------------------ Libraries ------------------
-
-// DownloadLibraries iterates through the version metadata and downloads all necessary libraries,
-// including main artifacts and OS-specific natives, applying OS rules.
-func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEmitter) {
-	libDir := filepath.Join(mcDir, "libraries")
-	osName := getOSName()
+// downloadLibraries performs DownloadLibraries for a platform and returns the number of files that failed.
+func downloadLibraries(ctx context.Context, metadata VersionMetadata, mcDir string, platform rules.Platform, E *events.EventEmitter) int {
+	failed := 0
+	libDir := utils.NewLayout(mcDir).LibrariesDir()
 
 	for _, lib := range metadata.Libraries {
+		// A cancelled install reports ctx.Err() itself, not the libraries it left out
+		if ctx.Err() != nil {
+			break
+		}
+
 		// Check if library should be included based on rules
-		if !shouldIncludeLibrary(lib.Rules) {
+		if !rules.EvaluateRules(lib.Rules, platform, nil) {
 			E.Emit("library_skipped", lib.Name+" (OS rules)")
 			continue
 		}
@@ -187,8 +234,9 @@ func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEm
 			path := filepath.Join(libDir, filepath.FromSlash(lib.Downloads.Artifact.Path))
 
 			E.Emit("library_download_start", lib.Name)
-			if err := DownloadFile(path, url, E); err != nil {
-				E.Emit("library_failed", lib.Name)
+			if err := fetchLibrary(ctx, path, url, lib.Downloads.Artifact.Path, lib.Downloads.Artifact.Sha1, E); err != nil {
+				E.Emit("library_failed", FailureDetails(lib.Name, err))
+				failed++
 			} else {
 				E.Emit("library_done", lib.Name)
 			}
@@ -197,18 +245,7 @@ func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEm
 		// Download natives (classifiers are typically native platform-specific libraries)
 		if lib.Downloads.Classifiers != nil && len(lib.Downloads.Classifiers) > 0 {
 			// Determine the native key string for this OS and architecture
-			var nativeKey string
-			if osName == "windows" {
-				if runtime.GOARCH == "amd64" {
-					nativeKey = "natives-windows"
-				} else {
-					nativeKey = "natives-windows-32" // Assuming x86 is 32-bit if not amd64
-				}
-			} else if osName == "osx" {
-				nativeKey = "natives-osx"
-			} else if osName == "linux" {
-				nativeKey = "natives-linux"
-			}
+			nativeKey := nativeClassifier(platform)
 
 			// Download the matching native classifier
 			for classifierName, classifier := range lib.Downloads.Classifiers {
@@ -217,8 +254,9 @@ func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEm
 						// Convert forward slashes in path to OS-specific path separators
 						path := filepath.Join(libDir, filepath.FromSlash(classifier.Path))
 						E.Emit("library_download_start", lib.Name+" ("+classifierName+")")
-						if err := DownloadFile(path, classifier.Url, E); err != nil {
-							E.Emit("library_failed", lib.Name+" (native)")
+						if err := fetchLibrary(ctx, path, classifier.Url, classifier.Path, classifier.Sha1, E); err != nil {
+							E.Emit("library_failed", FailureDetails(lib.Name+" (native)", err))
+							failed++
 						} else {
 							E.Emit("library_done", lib.Name+" (native)")
 						}
@@ -230,31 +268,101 @@ func DownloadLibraries(metadata VersionMetadata, mcDir string, E *events.EventEm
 			E.Emit("library_skipped", lib.Name+" (no artifact URL)")
 		}
 	}
+	return failed
 }
 
 // ------------------ Assets ------------------
 
 // DownloadAssets fetches the asset index and then downloads all required assets
 // (textures, sounds, etc.) into the 'assets/objects' directory.
+//
+// Progress is recorded in assets/.state/<index>.json: objects already present are skipped
+// without touching the disk, and once an index is complete later calls return immediately.
+// Use ResetAssetState to check every object again.
 func DownloadAssets(metadata VersionMetadata, mcDir string, E *events.EventEmitter) {
-	// Download asset index
-	resp, err := http.Get(metadata.AssetIndex.Url)
+	ctx := context.Background()
+	index, err := downloadAssetIndex(ctx, metadata, mcDir)
 	if err != nil {
-		E.Emit("error", "Failed to fetch asset index: "+err.Error())
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to fetch asset index: %w", err)))
 		return
 	}
+	downloadAssetObjects(ctx, index, metadata.AssetIndex.Id, mcDir, nil, E)
+}
+
+// downloadAssetIndex fetches the asset index of a version and stores it in assets/indexes.
+func downloadAssetIndex(ctx context.Context, metadata VersionMetadata, mcDir string) (*AssetIndex, error) {
+	indexPath := utils.NewLayout(mcDir).AssetIndex(metadata.AssetIndex.Id)
+	data, index, err := fetchAssetIndex(ctx, metadata, indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the index next to the objects; the game and LinkAssets read it from there
+	if err := os.MkdirAll(filepath.Dir(indexPath), utils.Modes.Dir); err == nil {
+		Track(ctx, indexPath)
+		_ = os.WriteFile(indexPath, data, utils.Modes.File)
+	}
+	return index, nil
+}
+
+// fetchAssetIndex downloads the asset index of a version and returns it as served and decoded.
+// indexPath is the file errors are reported for.
+func fetchAssetIndex(ctx context.Context, metadata VersionMetadata, indexPath string) ([]byte, *AssetIndex, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.AssetIndex.Url, nil)
+	if err != nil {
+		return nil, nil, requestError(indexPath, metadata.AssetIndex.Url, err)
+	}
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, requestError(indexPath, metadata.AssetIndex.Url, err)
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, statusError(indexPath, metadata.AssetIndex.Url, resp.StatusCode, resp.Status)
+	}
 
-	data, _ := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var index AssetIndex
-	json.Unmarshal(data, &index)
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, nil, err
+	}
+	return data, &index, nil
+}
 
-	objectsDir := filepath.Join(mcDir, "assets", "objects")
+// downloadAssetObjects downloads the objects of an index and returns how many are still missing.
+// A filter restricts the download to the assets it accepts by name; the index is then not
+// recorded as complete. Objects are content-addressed, so a failed install never needs them
+// rolled back: they are not journaled, whatever transaction ctx carries. When ctx is done the
+// download stops, keeping the progress made so far.
+func downloadAssetObjects(ctx context.Context, index *AssetIndex, indexID, mcDir string, filter func(string) bool, E *events.EventEmitter) int {
+	objectsDir := utils.NewLayout(mcDir).AssetObjectsDir()
+	ctx = WithTransaction(ctx, nil)
+
+	state := loadAssetState(mcDir, indexID)
+	if state.Complete {
+		E.Emit("assets_done", nil)
+		return 0
+	}
+	if len(state.done) > 0 {
+		E.Emit("assets_resumed", map[string]int{"done": len(state.done), "total": len(index.Objects)})
+	}
 
 	// Iterate through all objects defined in the asset index
-	for _, asset := range index.Objects {
+	missing := 0
+	processed := 0
+	for name, asset := range index.Objects {
+		if ctx.Err() != nil {
+			break
+		}
 		hash := asset.Hash
+		if state.done[hash] || len(hash) < 2 || (filter != nil && !filter(name)) {
+			continue
+		}
+
 		// The path for assets is determined by the first two characters of the SHA1 hash
 		sub := hash[:2]
 
@@ -263,35 +371,240 @@ func DownloadAssets(metadata VersionMetadata, mcDir string, E *events.EventEmitt
 		path := filepath.Join(objectsDir, sub, hash)
 
 		E.Emit("asset_download_start", hash)
-		_ = DownloadFile(path, url, E) // Ignore error to continue with next assets
+		if seedFile(ctx, path, "assets/objects/"+sub+"/"+hash, hash, E) {
+			state.markDone(hash)
+		} else if err := DownloadFileContext(ctx, path, url, E); err != nil {
+			missing++ // Continue with next assets
+		} else {
+			state.markDone(hash)
+		}
+
+		processed++
+		if processed%assetStateSaveInterval == 0 {
+			_ = state.save()
+		}
+	}
+
+	if filter != nil || ctx.Err() != nil {
+		_ = state.save()
+		return missing
 	}
+	state.Complete = missing == 0
+	_ = state.save()
 
 	E.Emit("assets_done", nil)
+	return missing
+}
+
+// EssentialAssets are the asset name prefixes MinimalAssets downloads before launching: the
+// window icons, fonts, language files and sound definitions the game needs to show its menus.
+var EssentialAssets = []string{
+	"icons/",
+	"pack.mcmeta",
+	"minecraft/font/",
+	"minecraft/textures/font/",
+	"minecraft/lang/",
+	"minecraft/sounds.json",
+	"lang/",
+}
+
+// isEssentialAsset reports whether an asset matches EssentialAssets.
+func isEssentialAsset(name string) bool {
+	for _, prefix := range EssentialAssets {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // ------------------ Version Download ------------------
 
+// VersionOptions controls the order in which InstallVersionWithOptions fetches files.
+type VersionOptions struct {
+	// BackgroundAssets returns as soon as the game is playable (client JAR, libraries and the
+	// asset index present) and downloads the asset objects in the background, so a "play now"
+	// flow can start the game while sounds and textures stream in. Wait on the returned
+	// *Install for the assets to finish.
+	BackgroundAssets bool
+	// MinimalAssets downloads only the EssentialAssets before the game is playable and backfills
+	// the rest in the background (it implies BackgroundAssets), cutting the time to first play on
+	// slow connections. Sounds and textures missing at startup are silent or shown as
+	// placeholders until the game reloads its resources.
+	MinimalAssets bool
+	// Platform selects libraries and natives for another platform than the host, e.g. to
+	// pre-build a Windows instance on a Linux CI machine. Nil means rules.Host().
+	Platform *rules.Platform
+	// Lock is the install lock of the game directory when the caller already holds it, e.g. a
+	// loader installing its vanilla base inside its own transaction; nil acquires it. Background
+	// asset downloads take the lock of their own once the caller releases it, so callers must not
+	// Wait for them while holding Lock.
+	Lock *Lock
+	// Context cancels the install when it is done: launch-critical files stop downloading and
+	// are rolled back, and asset objects stop downloading, keeping those already saved. It also
+	// carries the transaction of a caller installing the version as part of its own install,
+	// which the version's own transaction is nested in (see RunLockedTransaction). Nil means
+	// context.Background().
+	Context context.Context
+}
+
+// context returns the context the install runs with.
+func (o VersionOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// platform returns the platform libraries are selected for.
+func (o VersionOptions) platform() rules.Platform {
+	if o.Platform == nil {
+		return rules.Host()
+	}
+	platform := *o.Platform
+	if platform.OS == "" {
+		platform.OS = rules.Host().OS
+	}
+	if platform.Arch == "" {
+		platform.Arch = rules.Host().Arch
+	}
+	return platform
+}
+
+// Install is an installation whose assets may still be downloading.
+type Install struct {
+	Version string
+	done    chan struct{}
+	missing int
+	// err is why the asset objects were not all downloaded, e.g. the install lock was not
+	// acquired or the install was cancelled.
+	err error
+}
+
+// Wait blocks until the asset objects are downloaded and returns an error when some could not be.
+func (i *Install) Wait() error {
+	<-i.done
+	if i.err != nil {
+		return i.err
+	}
+	if i.missing > 0 {
+		return i18n.WithArgs(ErrAssetsFailed, map[string]any{"missing": i.missing, "version": i.Version})
+	}
+	return nil
+}
+
 // DownloadVersion orchestrates the entire download process for a vanilla Minecraft version,
-// including fetching manifest, metadata, the client JAR, libraries, and assets.
+// including fetching manifest, metadata, the client JAR, libraries, and assets. version may
+// be an alias such as AliasLatestRelease.
 func DownloadVersion(version string, mcDir string, E *events.EventEmitter) {
-	E.Emit("version_download_start", version)
+	_ = InstallVersion(version, mcDir, E)
+}
 
-	// Fetch version manifest from Mojang
-	resp, err := http.Get("https://launchermeta.mojang.com/mc/game/version_manifest.json")
+// InstallVersion works like DownloadVersion and returns the error that stopped it. The install
+// runs in a Transaction: when the client JAR or a library cannot be downloaded, every file it
+// wrote is removed or restored again. Missing assets do not fail the install; they are
+// retried by the next DownloadAssets.
+func InstallVersion(version string, mcDir string, E *events.EventEmitter) error {
+	install, err := InstallVersionWithOptions(version, mcDir, VersionOptions{}, E)
 	if err != nil {
-		E.Emit("error", "Failed to fetch version manifest: "+err.Error())
-		return
+		return err
 	}
-	defer resp.Body.Close()
+	install.Wait()
+	return nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// InstallVersionWithOptions installs a version like InstallVersion, launch-critical files first:
+// metadata, client JAR, libraries and the asset index, then the asset objects. A playable event
+// is emitted once the game can be launched. The returned error only covers the launch-critical
+// files; with opts.BackgroundAssets the objects are still downloading when it returns.
+func InstallVersionWithOptions(version string, mcDir string, opts VersionOptions, E *events.EventEmitter) (*Install, error) {
+	start := time.Now()
+	version, err := ResolveVersionAlias(version, E)
 	if err != nil {
-		E.Emit("error", "Failed to read manifest body: "+err.Error())
-		return
+		return nil, err
+	}
+	ctx := opts.context()
+	var metadata *VersionMetadata
+	var index *AssetIndex
+	err = RunLockedTransaction(ctx, opts.Lock, mcDir, "version-"+version, func(ctx context.Context, _ *Lock) (err error) {
+		metadata, index, err = downloadVersion(ctx, version, mcDir, opts.platform(), E)
+		return err
+	}, E)
+	if err != nil {
+		metrics.Since(metrics.InstallDuration, start, metrics.Result(err))
+		return nil, err
+	}
+	if opts.MinimalAssets {
+		opts.BackgroundAssets = true
+		if _, release, err := lockFor(opts.Lock, mcDir, "assets-"+version, InstallLockOptions, E); err == nil {
+			missing := downloadAssetObjects(ctx, index, metadata.AssetIndex.Id, mcDir, isEssentialAsset, E)
+			release()
+			E.Emit("essential_assets_done", map[string]int{"missing": missing})
+		}
+	}
+	E.Emit("playable", version)
+
+	install := &Install{Version: version, done: make(chan struct{})}
+	held := opts.Lock
+	if opts.BackgroundAssets {
+		// The caller may release its lock before the background download finishes
+		held = nil
+	}
+	assets := func() {
+		defer close(install.done)
+		// Objects are content-addressed, so a failed object never needs rolling back; the lock
+		// still keeps other installs from writing the same files meanwhile
+		_, release, err := lockFor(held, mcDir, "assets-"+version, InstallLockOptions, E)
+		if err != nil {
+			install.err = err
+			metrics.Since(metrics.InstallDuration, start, metrics.Result(err))
+			return
+		}
+		install.missing = downloadAssetObjects(ctx, index, metadata.AssetIndex.Id, mcDir, nil, E)
+		release()
+		if err := ctx.Err(); err != nil {
+			install.err = err
+			metrics.Since(metrics.InstallDuration, start, metrics.Result(err))
+			return
+		}
+		// Missing objects do not fail the install, see InstallVersion
+		metrics.Since(metrics.InstallDuration, start, metrics.Result(nil))
+		E.Emit("version_downloaded", version)
 	}
+	if opts.BackgroundAssets {
+		go assets()
+	} else {
+		assets()
+	}
+	return install, nil
+}
 
-	var manifest Manifest
-	json.Unmarshal(body, &manifest)
+// downloadClientJar installs the client JAR of a version: from a seed directory, patched from
+// an installed base JAR, or downloaded in full.
+func downloadClientJar(ctx context.Context, version, mcDir string, metadata VersionMetadata, E *events.EventEmitter) error {
+	jarPath := utils.NewLayout(mcDir).VersionJar(version)
+	E.Emit("client_download_start", jarPath)
+	// Reuse a verified copy from a seed directory, or try rebuilding the client JAR from an
+	// installed base JAR before downloading it in full
+	patched := seedFile(ctx, jarPath, "versions/"+version+"/"+version+".jar", metadata.Downloads.Client.Sha1, E)
+	if _, err := os.Stat(jarPath); err != nil && !patched {
+		patched = patchClientJar(ctx, version, mcDir, jarPath, metadata.Downloads.Client.Sha1, metadata.Downloads.Client.Size, E)
+	}
+	if patched {
+		return nil
+	}
+	return DownloadFileContext(ctx, jarPath, metadata.Downloads.Client.Url, E)
+}
+
+// fetchVersionMetadata looks a version up in the manifest and downloads its metadata. It
+// returns the metadata as served and decoded.
+func fetchVersionMetadata(ctx context.Context, version string, E *events.EventEmitter) ([]byte, *VersionMetadata, error) {
+	// Fetch version manifest from Mojang
+	manifest, err := FetchManifest()
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, nil, err
+	}
 
 	// Find the specific version entry
 	var selected *Version
@@ -304,36 +617,93 @@ func DownloadVersion(version string, mcDir string, E *events.EventEmitter) {
 
 	if selected == nil {
 		E.Emit("version_not_found", version)
-		return
+		return nil, nil, i18n.WithArgs(ErrVersionNotFound, map[string]any{"version": version})
 	}
 
 	// Download detailed version metadata
-	metaResp, err := http.Get(selected.Url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, selected.Url, nil)
 	if err != nil {
-		E.Emit("error", "Failed to fetch version metadata: "+err.Error())
-		return
+		return nil, nil, err
+	}
+	metaResp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", i18n.WithArgs(ErrMetadataUnavailable, map[string]any{"version": version}), err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, nil, err
 	}
 	defer metaResp.Body.Close()
 
-	metaBody, _ := io.ReadAll(metaResp.Body)
+	metaBody, err := io.ReadAll(metaResp.Body)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", i18n.WithArgs(ErrMetadataUnavailable, map[string]any{"version": version}), err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, nil, err
+	}
 	var metadata VersionMetadata
 	json.Unmarshal(metaBody, &metadata)
+	return metaBody, &metadata, nil
+}
 
-	// Download client jar and save metadata locally
-	jarPath := filepath.Join(mcDir, "versions", version, version+".jar")
-	metadataPath := filepath.Join(mcDir, "versions", version, version+".json")
-	E.Emit("client_download_start", jarPath)
-	_ = DownloadFile(jarPath, metadata.Downloads.Client.Url, E)
+// downloadVersion installs the launch-critical files of a version: its metadata, client JAR,
+// libraries and asset index; the last three are fetched concurrently. It returns the metadata and the index.
+func downloadVersion(ctx context.Context, version string, mcDir string, platform rules.Platform, E *events.EventEmitter) (*VersionMetadata, *AssetIndex, error) {
+	E.Emit("version_download_start", version)
+
+	metaBody, meta, err := fetchVersionMetadata(ctx, version, E)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, nil, ctxErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := *meta
 
 	// Save the metadata JSON file to the local version directory
-	_ = os.WriteFile(metadataPath, metaBody, 0644)
+	metadataPath := utils.NewLayout(mcDir).VersionJSON(version)
+	Track(ctx, metadataPath)
+	if err := os.MkdirAll(filepath.Dir(metadataPath), utils.Modes.Dir); err == nil {
+		_ = os.WriteFile(metadataPath, metaBody, utils.Modes.File)
+	}
 	E.Emit("metadata_saved", metadataPath)
 
-	// Download libraries (includes natives now!)
-	DownloadLibraries(metadata, mcDir, E)
-
-	// Download assets
-	DownloadAssets(metadata, mcDir, E)
+	// The client JAR, the libraries and the asset index only depend on the metadata, so they
+	// are fetched concurrently
+	var (
+		wg                                sync.WaitGroup
+		clientErr, librariesErr, indexErr error
+		index                             *AssetIndex
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		clientErr = downloadClientJar(ctx, version, mcDir, metadata, E)
+	}()
+	go func() {
+		defer wg.Done()
+		// Download libraries (includes natives now!)
+		if failed := downloadLibraries(ctx, metadata, mcDir, platform, E); failed > 0 {
+			librariesErr = i18n.WithArgs(ErrLibrariesFailed, map[string]any{"failed": failed, "version": version})
+			E.Emit("error", i18n.ErrorEvent(librariesErr))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// The game reads the index at startup, so it is needed before launching
+		if index, indexErr = downloadAssetIndex(ctx, metadata, mcDir); indexErr != nil {
+			indexErr = fmt.Errorf("%w: %w", i18n.WithArgs(ErrAssetIndexFailed, map[string]any{"version": version}), indexErr)
+			E.Emit("error", i18n.ErrorEvent(indexErr))
+		}
+	}()
+	wg.Wait()
 
-	E.Emit("version_downloaded", version)
+	// Failures caused by the cancellation are reported as it, so callers can tell them apart
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	for _, err := range []error{clientErr, librariesErr, indexErr} {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &metadata, index, nil
 }
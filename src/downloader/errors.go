@@ -0,0 +1,117 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Kinds of download failures, reported as "kind" in failure events.
+const (
+	// ErrorKindNetwork is a connection, DNS, TLS or proxy failure before a response arrived.
+	ErrorKindNetwork = "network"
+	// ErrorKindTimeout is a request or transfer that timed out.
+	ErrorKindTimeout = "timeout"
+	// ErrorKindHTTP is a response with a non-2xx status.
+	ErrorKindHTTP = "http"
+	// ErrorKindIO is a failure to write the downloaded file or to read the response body.
+	ErrorKindIO = "io"
+	// ErrorKindNoSource means no URL or mirror was available for the file.
+	ErrorKindNoSource = "no_source"
+	// ErrorKindChecksum is a downloaded file whose hash does not match the expected one.
+	ErrorKindChecksum = "checksum"
+)
+
+// Keyed errors of the download failure kinds. A *DownloadError matches the one of its Kind with
+// errors.Is, and i18n.ErrorEvent reports its key with the file, URL and status as args.
+var (
+	ErrDownloadNetwork  error = i18n.New("downloader.download_network", "could not connect to download {file}")
+	ErrDownloadTimeout  error = i18n.New("downloader.download_timeout", "the download of {file} timed out")
+	ErrDownloadHTTP     error = i18n.New("downloader.download_http", "the server answered {status} for {file}")
+	ErrDownloadIO       error = i18n.New("downloader.download_io", "failed to write file {file}")
+	ErrDownloadNoSource error = i18n.New("downloader.download_no_source", "no download source for {file}")
+	ErrDownloadChecksum error = i18n.New("downloader.download_checksum", "the download of {file} is corrupt")
+)
+
+// kindErrors maps failure kinds to their keyed errors.
+var kindErrors = map[string]error{
+	ErrorKindNetwork:  ErrDownloadNetwork,
+	ErrorKindTimeout:  ErrDownloadTimeout,
+	ErrorKindHTTP:     ErrDownloadHTTP,
+	ErrorKindIO:       ErrDownloadIO,
+	ErrorKindNoSource: ErrDownloadNoSource,
+	ErrorKindChecksum: ErrDownloadChecksum,
+}
+
+// DownloadError describes a failed download, so mirror and proxy issues can be told apart.
+type DownloadError struct {
+	// File is the destination path.
+	File string
+	URL  string
+	// StatusCode is the HTTP status for ErrorKindHTTP, 0 otherwise.
+	StatusCode int
+	Kind       string
+	Err        error
+}
+
+// Error implements error.
+func (e *DownloadError) Error() string {
+	switch e.Kind {
+	case ErrorKindHTTP:
+		return fmt.Sprintf("failed to download %s from %s: %v", e.File, e.URL, e.Err)
+	case ErrorKindIO:
+		return fmt.Sprintf("failed to write file %s: %v", e.File, e.Err)
+	case ErrorKindNoSource:
+		return fmt.Sprintf("no download source for %s", e.File)
+	case ErrorKindChecksum:
+		if e.URL == "" {
+			return fmt.Sprintf("checksum mismatch for %s: %v", e.File, e.Err)
+		}
+		return fmt.Sprintf("checksum mismatch for %s from %s: %v", e.File, e.URL, e.Err)
+	}
+	return fmt.Sprintf("failed to download %s from %s (%s): %v", e.File, e.URL, e.Kind, e.Err)
+}
+
+// Unwrap returns the underlying error and the keyed error of the failure kind.
+func (e *DownloadError) Unwrap() []error {
+	var errs []error
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	if keyed, ok := kindErrors[e.Kind].(*i18n.Error); ok {
+		errs = append(errs, keyed.With(map[string]any{"file": e.File, "url": e.URL, "status": e.StatusCode}))
+	}
+	return errs
+}
+
+// requestError wraps the error of an HTTP request that got no response.
+func requestError(file, url string, err error) *DownloadError {
+	kind := ErrorKindNetwork
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		kind = ErrorKindTimeout
+	}
+	return &DownloadError{File: file, URL: url, Kind: kind, Err: err}
+}
+
+// statusError reports a non-2xx response.
+func statusError(file, url string, code int, status string) *DownloadError {
+	return &DownloadError{File: file, URL: url, StatusCode: code, Kind: ErrorKindHTTP, Err: errors.New(status)}
+}
+
+// FailureDetails returns the payload of a failure event for name: the error message and, when
+// err is a *DownloadError, its URL, HTTP status and kind.
+func FailureDetails(name string, err error) map[string]interface{} {
+	details := map[string]interface{}{"name": name, "error": err.Error()}
+	var dlErr *DownloadError
+	if errors.As(err, &dlErr) {
+		details["url"] = dlErr.URL
+		details["kind"] = dlErr.Kind
+		if dlErr.StatusCode != 0 {
+			details["status"] = dlErr.StatusCode
+		}
+	}
+	return details
+}
@@ -0,0 +1,159 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// DownloadEstimate counts files and their size in bytes.
+type DownloadEstimate struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// add counts one file of size bytes.
+func (d *DownloadEstimate) add(size int64) {
+	d.Files++
+	d.Bytes += size
+}
+
+// InstallEstimate is what installing a version still has to download, per category. Files
+// already present with their expected size are counted in Present instead.
+type InstallEstimate struct {
+	Version   string           `json:"version"`
+	Client    DownloadEstimate `json:"client"`
+	Libraries DownloadEstimate `json:"libraries"`
+	// Assets includes the asset index.
+	Assets  DownloadEstimate `json:"assets"`
+	Present DownloadEstimate `json:"present"`
+}
+
+// Total returns the sum of the categories still to download.
+func (e *InstallEstimate) Total() DownloadEstimate {
+	return DownloadEstimate{
+		Files: e.Client.Files + e.Libraries.Files + e.Assets.Files,
+		Bytes: e.Client.Bytes + e.Libraries.Bytes + e.Assets.Bytes,
+	}
+}
+
+// libraryDownload is a library file a version needs on a platform.
+type libraryDownload struct {
+	path string
+	sha1 string
+	size int64
+}
+
+// ------------------ Helpers ------------------
+
+// libraryDownloads lists the library files of metadata for a platform, selected like
+// downloadLibraries selects them. Paths are slash-separated and relative to the libraries folder.
+func libraryDownloads(metadata VersionMetadata, platform rules.Platform) []libraryDownload {
+	var files []libraryDownload
+	nativeKey := nativeClassifier(platform)
+	for _, lib := range metadata.Libraries {
+		if !rules.EvaluateRules(lib.Rules, platform, nil) {
+			continue
+		}
+		artifact := lib.Downloads.Artifact
+		if artifact.Url != "" && artifact.Path != "" {
+			files = append(files, libraryDownload{artifact.Path, artifact.Sha1, artifact.Size})
+		}
+		for name, classifier := range lib.Downloads.Classifiers {
+			if strings.Contains(name, nativeKey) && classifier.Url != "" && classifier.Path != "" {
+				files = append(files, libraryDownload{classifier.Path, classifier.Sha1, classifier.Size})
+			}
+		}
+	}
+	return files
+}
+
+// present reports whether path exists with the expected size; a size of 0 is not checked.
+func present(path string, size int64) bool {
+	info, err := os.Stat(utils.LongPath(path))
+	return err == nil && !info.IsDir() && (size == 0 || info.Size() == size)
+}
+
+// ------------------ Public API ------------------
+
+// EstimateInstall reports how much installing version into mcDir for the host downloads, for
+// confirmation dialogs before large downloads. It fetches the version metadata and, unless
+// it is already installed, the asset index, but writes nothing. version may be an alias such
+// as AliasLatestRelease. Presence is judged by file size only; hashes are checked on install.
+func EstimateInstall(version, mcDir string, E *events.EventEmitter) (*InstallEstimate, error) {
+	version, err := ResolveVersionAlias(version, E)
+	if err != nil {
+		return nil, err
+	}
+	_, metadata, err := fetchVersionMetadata(context.Background(), version, E)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := utils.NewLayout(mcDir)
+	estimate := &InstallEstimate{Version: version}
+	count := func(category *DownloadEstimate, path string, size int64) {
+		if present(path, size) {
+			estimate.Present.add(size)
+		} else {
+			category.add(size)
+		}
+	}
+
+	count(&estimate.Client, layout.VersionJar(version), metadata.Downloads.Client.Size)
+
+	libDir := layout.LibrariesDir()
+	seen := map[string]bool{}
+	for _, lib := range libraryDownloads(*metadata, rules.Host()) {
+		if seen[lib.path] {
+			continue
+		}
+		seen[lib.path] = true
+		count(&estimate.Libraries, filepath.Join(libDir, filepath.FromSlash(lib.path)), lib.size)
+	}
+
+	if metadata.AssetIndex.Id == "" {
+		E.Emit("install_estimated", estimate)
+		return estimate, nil
+	}
+	indexPath := layout.AssetIndex(metadata.AssetIndex.Id)
+	count(&estimate.Assets, indexPath, metadata.AssetIndex.Size)
+
+	// Prefer the installed index, which lists the same objects
+	var index *AssetIndex
+	if data, err := os.ReadFile(indexPath); err == nil {
+		var installed AssetIndex
+		if json.Unmarshal(data, &installed) == nil {
+			index = &installed
+		}
+	}
+	if index == nil {
+		if _, index, err = fetchAssetIndex(context.Background(), *metadata, indexPath); err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to fetch asset index: %w", err)))
+			return nil, err
+		}
+	}
+
+	objectsDir := layout.AssetObjectsDir()
+	seen = map[string]bool{}
+	for _, object := range index.Objects {
+		if len(object.Hash) < 2 || seen[object.Hash] {
+			continue
+		}
+		seen[object.Hash] = true
+		count(&estimate.Assets, filepath.Join(objectsDir, object.Hash[:2], object.Hash), object.Size)
+	}
+
+	E.Emit("install_estimated", estimate)
+	return estimate, nil
+}
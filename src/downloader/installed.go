@@ -0,0 +1,64 @@
+package downloader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// spotCheckLibraries is how many library files IsVersionInstalled verifies by hash.
+const spotCheckLibraries = 3
+
+// ------------------ Install Detection ------------------
+
+// IsVersionInstalled reports quickly whether a vanilla version is installed in mcDir for the
+// host: its version JSON and asset index are present, the client JAR matches the SHA1 of the
+// metadata, every library of the host exists, and a few libraries spread over the list match
+// their SHA1. Asset objects are not checked; DownloadAssets resumes missing ones.
+func IsVersionInstalled(version, mcDir string) bool {
+	return isVersionInstalled(version, mcDir, rules.Host())
+}
+
+// isVersionInstalled performs IsVersionInstalled for a platform.
+func isVersionInstalled(version, mcDir string, platform rules.Platform) bool {
+	layout := utils.NewLayout(mcDir)
+	data, err := os.ReadFile(layout.VersionJSON(version))
+	if err != nil {
+		return false
+	}
+	var metadata VersionMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata.Downloads.Client.Sha1 == "" {
+		return false
+	}
+
+	if metadata.AssetIndex.Id != "" {
+		if _, err := os.Stat(layout.AssetIndex(metadata.AssetIndex.Id)); err != nil {
+			return false
+		}
+	}
+	// The client JAR and the spot-checked libraries are hashed in parallel
+	paths := []string{utils.LongPath(layout.VersionJar(version))}
+	sums := []string{metadata.Downloads.Client.Sha1}
+	files := libraryDownloads(metadata, platform)
+	libDir := layout.LibrariesDir()
+	stride := len(files)/spotCheckLibraries + 1
+	for i, file := range files {
+		path := utils.LongPath(filepath.Join(libDir, filepath.FromSlash(file.path)))
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+		if i%stride == 0 && file.sha1 != "" {
+			paths = append(paths, path)
+			sums = append(sums, file.sha1)
+		}
+	}
+	for i, hash := range utils.HashFiles(paths, nil) {
+		if hash.Err != nil || hash.SHA1 != sums[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// LockFile is the advisory lock of a game directory, held while installing into it.
+const LockFile = ".install.lock"
+
+// lockPollInterval is how often a waiting AcquireLock checks the lock again.
+const lockPollInterval = 500 * time.Millisecond
+
+// ErrLocked is returned when another process holds the install lock and waiting is disabled
+// or timed out.
+var ErrLocked error = i18n.New("downloader.locked", "game directory is locked by another install")
+
+// LockOptions configures AcquireLock.
+type LockOptions struct {
+	// Wait blocks until the lock is free instead of failing fast with ErrLocked.
+	Wait bool
+	// Timeout bounds the wait; zero waits as long as it takes.
+	Timeout time.Duration
+	// StaleAfter treats locks of other hosts (e.g. on a network share) older than this as
+	// abandoned. Zero never breaks them; locks of dead processes on this host are always broken.
+	StaleAfter time.Duration
+}
+
+// InstallLockOptions are used by RunTransaction, and therefore by InstallVersion and the
+// loader installers.
+var InstallLockOptions = LockOptions{Wait: true}
+
+// LockInfo is the content of a lock file.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Operation string    `json:"operation"`
+	Acquired  time.Time `json:"acquired"`
+}
+
+// Lock is a held install lock. It is not reentrant: a nested install (a loader installing its
+// vanilla base) runs under the Lock of its caller, passed in explicitly, while every other
+// AcquireLock of the same directory waits or fails with ErrLocked, even in this process.
+type Lock struct {
+	Info LockInfo
+	path string
+}
+
+// ------------------ Helpers ------------------
+
+// readLock reads a lock file.
+func readLock(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// stale reports whether a lock was abandoned by its holder.
+func (i *LockInfo) stale(opts LockOptions) bool {
+	host, _ := os.Hostname()
+	if i.Host == host {
+		return !utils.ProcessAlive(i.PID)
+	}
+	return opts.StaleAfter > 0 && time.Since(i.Acquired) > opts.StaleAfter
+}
+
+// tryLock creates the lock file exclusively.
+func tryLock(path string, info LockInfo) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, utils.Modes.File)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(info)
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// ------------------ Locking ------------------
+
+// AcquireLock takes the install lock of mcDir for operation (e.g. "install 1.20.1"). Locks left
+// behind by processes that died are detected and broken, emitting install_lock_stale. While
+// another holder, in this process or another one, has the lock it waits (emitting
+// install_lock_waiting once) or fails with ErrLocked, depending on opts. Installs nested in one
+// that holds the lock must be given that Lock instead of acquiring it again, which would wait
+// for themselves.
+func AcquireLock(mcDir, operation string, opts LockOptions, E *events.EventEmitter) (*Lock, error) {
+	root, err := filepath.Abs(mcDir)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, LockFile)
+
+	if err := os.MkdirAll(root, utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Host: host, Operation: operation}
+
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+	waiting := false
+	for {
+		info.Acquired = time.Now().UTC()
+		err := tryLock(path, info)
+		if err == nil {
+			E.Emit("install_lock_acquired", info)
+			return &Lock{Info: info, path: path}, nil
+		}
+		if !os.IsExist(err) {
+			err = fmt.Errorf("failed to create install lock: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+
+		holder, err := readLock(path)
+		if err != nil {
+			// Being written right now, or left corrupt; check again after a moment
+			holder = &LockInfo{}
+			if stat, statErr := os.Stat(path); statErr == nil && time.Since(stat.ModTime()) > time.Minute {
+				holder = nil
+			}
+		}
+		if holder == nil || (holder.PID != 0 && holder.stale(opts)) {
+			E.Emit("install_lock_stale", holder)
+			os.Remove(path)
+			continue
+		}
+
+		if !opts.Wait || (!deadline.IsZero() && time.Now().After(deadline)) {
+			err := fmt.Errorf("%w: %s (pid %d on %s, since %s)", ErrLocked, holder.Operation, holder.PID, holder.Host, holder.Acquired.Format(time.RFC3339))
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		if !waiting {
+			waiting = true
+			E.Emit("install_lock_waiting", holder)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Release gives the lock up. Releasing it again does nothing.
+func (l *Lock) Release() error {
+	if l.path == "" {
+		return nil
+	}
+	path := l.path
+	l.path = ""
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// covers reports whether l is the held lock of mcDir.
+func (l *Lock) covers(mcDir string) bool {
+	if l == nil || l.path == "" {
+		return false
+	}
+	root, err := filepath.Abs(mcDir)
+	return err == nil && filepath.Join(root, LockFile) == l.path
+}
+
+// lockFor returns held when it is the lock of mcDir, with a release that leaves it to its
+// owner, and otherwise acquires the lock of mcDir for operation.
+func lockFor(held *Lock, mcDir, operation string, opts LockOptions, E *events.EventEmitter) (*Lock, func(), error) {
+	if held.covers(mcDir) {
+		return held, func() {}, nil
+	}
+	lock, err := AcquireLock(mcDir, operation, opts, E)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lock, func() { lock.Release() }, nil
+}
@@ -0,0 +1,135 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// LibraryMirrors are the Maven repositories tried, in order, when a library cannot be downloaded
+// from its own URL. Relocated Forge libraries in particular often 404 at their original location.
+var LibraryMirrors = []string{
+	"https://libraries.minecraft.net/",
+	"https://maven.minecraftforge.net/",
+	"https://maven.neoforged.net/releases/",
+	"https://maven.fabricmc.net/",
+	"https://repo1.maven.org/maven2/",
+}
+
+// MavenPath converts a Maven coordinate "group:artifact:version[:classifier][@extension]"
+// into its repository-relative path, e.g. "net/fabricmc/fabric-loader/0.15.0/fabric-loader-0.15.0.jar".
+// It returns "" for malformed coordinates.
+func MavenPath(coordinate string) string {
+	extension := "jar"
+	if at := strings.LastIndex(coordinate, "@"); at >= 0 {
+		extension = coordinate[at+1:]
+		coordinate = coordinate[:at]
+	}
+
+	parts := strings.Split(coordinate, ":")
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return ""
+	}
+
+	group, artifact, version := parts[0], parts[1], parts[2]
+	file := artifact + "-" + version
+	if len(parts) > 3 && parts[3] != "" {
+		file += "-" + parts[3]
+	}
+
+	return strings.ReplaceAll(group, ".", "/") + "/" + artifact + "/" + version + "/" + file + "." + extension
+}
+
+// verifyLibrary checks a library fetched from url against expectedSHA1, deleting it on a
+// mismatch so the next source can be tried. An empty expectedSHA1 accepts any file.
+func verifyLibrary(file, url, expectedSHA1 string) error {
+	if expectedSHA1 == "" {
+		return nil
+	}
+	sum, err := fileSHA1(utils.LongPath(file))
+	if err == nil && strings.EqualFold(sum, expectedSHA1) {
+		return nil
+	}
+	os.Remove(utils.LongPath(file))
+	if err == nil {
+		err = fmt.Errorf("expected %s, got %s", expectedSHA1, sum)
+	}
+	return &DownloadError{File: file, URL: url, Kind: ErrorKindChecksum, Err: err}
+}
+
+// fetchLibraryFrom downloads a library from url and verifies it against expectedSHA1.
+func fetchLibraryFrom(ctx context.Context, file, url, expectedSHA1 string) error {
+	if err := fetchFile(ctx, file, url); err != nil {
+		return err
+	}
+	return verifyLibrary(file, url, expectedSHA1)
+}
+
+// DownloadLibraryFile downloads a library to file from url, falling back to LibraryMirrors when
+// the primary source fails. artifactPath is the repository-relative path of the artifact (the
+// "path" of a version JSON download, or MavenPath of its coordinate). When expectedSHA1 is set,
+// every download is checked against it and a mismatching file is deleted and the next source
+// tried, so a mirror serving a different artifact is never accepted. The mirror that finally
+// served the file is reported with a library_mirror_used event; an error event is only emitted
+// when every source failed.
+func DownloadLibraryFile(file, url, artifactPath, expectedSHA1 string, E *events.EventEmitter) error {
+	return DownloadLibraryFileContext(context.Background(), file, url, artifactPath, expectedSHA1, E)
+}
+
+// DownloadLibraryFileContext works like DownloadLibraryFile for an install running with ctx: the
+// library is journaled in the transaction of ctx, if any, and no further source is tried once
+// ctx is done.
+func DownloadLibraryFileContext(ctx context.Context, file, url, artifactPath, expectedSHA1 string, E *events.EventEmitter) error {
+	// Check if file already exists
+	if _, err := os.Stat(utils.LongPath(file)); err == nil {
+		E.Emit("file_exists", file)
+		return nil
+	}
+
+	var err error
+	if url != "" {
+		if err = fetchLibraryFrom(ctx, file, url, expectedSHA1); err == nil {
+			E.Emit("file_downloaded", file)
+			return nil
+		}
+	}
+
+	if artifactPath != "" {
+		for _, mirror := range LibraryMirrors {
+			if ctx.Err() != nil {
+				break
+			}
+			mirrorURL := mirror + strings.TrimPrefix(artifactPath, "/")
+			if mirrorURL == url {
+				continue
+			}
+			if mirrorErr := fetchLibraryFrom(ctx, file, mirrorURL, expectedSHA1); mirrorErr != nil {
+				// A mirror that had the file but served other content is worth reporting over a 404
+				var dlErr *DownloadError
+				if err == nil || errors.As(mirrorErr, &dlErr) && dlErr.Kind == ErrorKindChecksum {
+					err = mirrorErr
+				}
+				continue
+			}
+			E.Emit("library_mirror_used", map[string]string{
+				"path":   artifactPath,
+				"mirror": mirror,
+				"url":    mirrorURL,
+			})
+			E.Emit("file_downloaded", file)
+			return nil
+		}
+	}
+
+	if err == nil {
+		err = &DownloadError{File: file, Kind: ErrorKindNoSource}
+	}
+	E.Emit("error", i18n.ErrorEvent(err))
+	return err
+}
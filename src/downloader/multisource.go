@@ -0,0 +1,231 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/metrics"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// MultiSourceChunkSize is the size of the ranges fetched by DownloadMultiSource.
+var MultiSourceChunkSize int64 = 4 << 20
+
+// maxChunkAttempts bounds how often one chunk is retried across sources.
+const maxChunkAttempts = 5
+
+// chunk is a byte range of a multi-source download.
+type chunk struct {
+	index      int
+	start, end int64 // inclusive
+	attempts   int
+}
+
+// probeRanges returns the size of url's resource if the server supports range requests.
+func probeRanges(ctx context.Context, url string) (int64, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, false
+	}
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return 0, false
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.Header.Get("Accept-Ranges") != "bytes" {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// fetchChunk downloads one range of url into out. Request failures are returned as *DownloadError.
+func fetchChunk(ctx context.Context, out *os.File, url string, c chunk) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return requestError(out.Name(), url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return statusError(out.Name(), url, resp.StatusCode, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.end-c.start+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != c.end-c.start+1 {
+		return fmt.Errorf("short range from %s: %d of %d bytes", url, len(data), c.end-c.start+1)
+	}
+	_, err = out.WriteAt(data, c.start)
+	metrics.Add(metrics.DownloadBytesTotal, float64(len(data)), nil)
+	return err
+}
+
+// DownloadMultiSource downloads a large file (e.g. a 1 GB+ modpack) from several mirrors of
+// the same content at once. The file is split into MultiSourceChunkSize ranges that every
+// source fetches in parallel, so faster mirrors complete more chunks; a chunk that fails is
+// retried on another source and a source that fails is dropped (multi_source_source_failed).
+// Sources that do not support range requests are tried one after another for the whole file.
+// When expectedSHA1 is set the result is verified and removed on mismatch.
+//
+// Torrent sources are not supported; list their HTTP web seeds instead.
+func DownloadMultiSource(file string, urls []string, expectedSHA1 string, E *events.EventEmitter) error {
+	if _, err := os.Stat(utils.LongPath(file)); err == nil {
+		E.Emit("file_exists", file)
+		return nil
+	}
+	if len(urls) == 0 {
+		err := fmt.Errorf("no sources for %s", file)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	start := time.Now()
+	err := downloadMultiSource(context.Background(), file, urls, E)
+	if err == nil && expectedSHA1 != "" {
+		if sum, hashErr := fileSHA1(file); hashErr != nil || sum != expectedSHA1 {
+			os.Remove(file)
+			err = &DownloadError{File: file, Kind: ErrorKindChecksum, Err: fmt.Errorf("expected %s, got %s", expectedSHA1, sum)}
+		}
+	}
+	metrics.Inc(metrics.DownloadsTotal, metrics.Result(err))
+	metrics.Since(metrics.DownloadDuration, start, metrics.Result(err))
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	E.Emit("file_downloaded", file)
+	return nil
+}
+
+// downloadMultiSource fetches file from urls without verification, journaling it in the
+// transaction of ctx. Its requests are cancelled with ctx.
+func downloadMultiSource(ctx context.Context, file string, urls []string, E *events.EventEmitter) error {
+	// Keep only the sources serving ranges of the same size
+	var sources []string
+	var size int64
+	for _, url := range urls {
+		if n, ok := probeRanges(ctx, url); ok && (size == 0 || n == size) {
+			size = n
+			sources = append(sources, url)
+		}
+	}
+
+	// Without range support, fall back to whole-file downloads source by source
+	if len(sources) == 0 {
+		var err error
+		for _, url := range urls {
+			if err = fetchFile(ctx, file, url); err == nil {
+				return nil
+			}
+			E.Emit("multi_source_source_failed", FailureDetails(file, err))
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(utils.LongPath(file)), utils.Modes.Dir); err != nil {
+		return err
+	}
+	part := utils.LongPath(file + ".part")
+	out, err := os.Create(part)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", part, err)
+	}
+	if err := out.Truncate(size); err != nil {
+		out.Close()
+		os.Remove(part)
+		return err
+	}
+
+	chunks := make(chan chunk, int(size/MultiSourceChunkSize)+1)
+	total := 0
+	for offset := int64(0); offset < size; offset += MultiSourceChunkSize {
+		chunks <- chunk{index: total, start: offset, end: min(offset+MultiSourceChunkSize, size) - 1}
+		total++
+	}
+	E.Emit("multi_source_start", map[string]interface{}{"file": file, "sources": len(sources), "chunks": total, "size": size})
+
+	var (
+		mu       sync.Mutex
+		done     int
+		active   = len(sources)
+		finalErr error
+		wg       sync.WaitGroup
+		finished = make(chan struct{})
+	)
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source string) {
+			defer wg.Done()
+			for {
+				var c chunk
+				select {
+				case c = <-chunks:
+				case <-finished:
+					return
+				}
+
+				if err := fetchChunk(ctx, out, source, c); err != nil {
+					mu.Lock()
+					c.attempts++
+					active--
+					details := FailureDetails(file, err)
+					details["url"] = source
+					E.Emit("multi_source_source_failed", details)
+					if c.attempts >= maxChunkAttempts || active == 0 {
+						if finalErr == nil {
+							finalErr = fmt.Errorf("chunk %d of %s failed on every source: %w", c.index, file, err)
+							close(finished)
+						}
+					} else {
+						chunks <- c
+					}
+					mu.Unlock()
+					return
+				}
+
+				mu.Lock()
+				done++
+				E.Emit("multi_source_chunk", map[string]interface{}{"file": file, "chunk": c.index, "done": done, "total": total, "source": source})
+				if done == total {
+					close(finished)
+				}
+				mu.Unlock()
+			}
+		}(source)
+	}
+	wg.Wait()
+
+	closeErr := out.Close()
+	if finalErr == nil && done < total {
+		finalErr = fmt.Errorf("download of %s stopped with %d of %d chunks", file, done, total)
+	}
+	if finalErr == nil {
+		finalErr = closeErr
+	}
+	if finalErr != nil {
+		os.Remove(part)
+		return finalErr
+	}
+	Track(ctx, file)
+	return os.Rename(part, utils.LongPath(file))
+}
@@ -0,0 +1,94 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Modes of placing the parent client JAR into a modded version directory.
+const (
+	// ParentJarNone leaves the modded version without a JAR; the launcher falls back to the parent's.
+	ParentJarNone = ""
+	// ParentJarCopy copies the parent JAR.
+	ParentJarCopy = "copy"
+	// ParentJarLink hard-links the parent JAR, copying only when linking is impossible.
+	ParentJarLink = "link"
+)
+
+// findParentJar returns the client JAR of id or of the nearest version it inherits from.
+func findParentJar(mcDir, parentID string) (string, error) {
+	id := parentID
+	for depth := 0; id != "" && depth < 16; depth++ {
+		jar := utils.NewLayout(mcDir).VersionJar(id)
+		if _, err := os.Stat(jar); err == nil {
+			return jar, nil
+		}
+
+		data, err := os.ReadFile(utils.NewLayout(mcDir).VersionJSON(id))
+		if err != nil {
+			break
+		}
+		var v struct {
+			InheritsFrom string `json:"inheritsFrom"`
+		}
+		if json.Unmarshal(data, &v) != nil {
+			break
+		}
+		id = v.InheritsFrom
+	}
+	return "", fmt.Errorf("no client jar found for %s or its parents", parentID)
+}
+
+// PlaceParentJar puts the client JAR of parentID at versions/<versionID>/<versionID>.jar, for mod
+// setups that expect every version directory to contain its own JAR. mode is ParentJarCopy or
+// ParentJarLink; ParentJarNone does nothing. An existing JAR is left untouched.
+func PlaceParentJar(mcDir, versionID, parentID, mode string, E *events.EventEmitter) error {
+	return PlaceParentJarContext(context.Background(), mcDir, versionID, parentID, mode, E)
+}
+
+// PlaceParentJarContext works like PlaceParentJar for an install running with ctx: the JAR is
+// journaled in the transaction of ctx, if any.
+func PlaceParentJarContext(ctx context.Context, mcDir, versionID, parentID, mode string, E *events.EventEmitter) error {
+	if mode == ParentJarNone {
+		return nil
+	}
+	if mode != ParentJarCopy && mode != ParentJarLink {
+		return fmt.Errorf("unknown parent jar mode %q", mode)
+	}
+
+	dst := utils.NewLayout(mcDir).VersionJar(versionID)
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+
+	src, err := findParentJar(mcDir, parentID)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	Track(ctx, dst)
+	if mode == ParentJarLink {
+		err = linkOrCopy(src, dst)
+	} else {
+		err = os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir)
+		if err == nil {
+			err = copyFile(src, dst)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to place parent jar for %s: %w", versionID, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	E.Emit("parent_jar_placed", map[string]string{"version": versionID, "from": src, "mode": mode})
+	return nil
+}
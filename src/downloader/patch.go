@@ -0,0 +1,185 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ClientPatch describes a binary patch that turns the client JAR of an older version into a newer one.
+type ClientPatch struct {
+	// From is the version ID whose client JAR the patch applies to.
+	From string
+	// URL serves the patch in bsdiff (BSDIFF40) format.
+	URL string
+}
+
+// ClientPatchSource returns the patches available for producing the client JAR of a version,
+// typically between consecutive snapshots. Zstd dictionary patches are not supported.
+type ClientPatchSource func(version string) []ClientPatch
+
+// ClientPatches, when set, is consulted by DownloadVersion before downloading a client JAR in full.
+// A patch is only used if its base JAR is installed, and the result must match the SHA1 from the
+// version metadata; otherwise the full JAR is downloaded as usual.
+var ClientPatches ClientPatchSource
+
+// MaxPatchedSize caps the output of ApplyBSDiff when the caller knows no tighter bound, so a
+// malformed header cannot make it allocate arbitrary amounts of memory.
+var MaxPatchedSize int64 = 256 << 20
+
+// ErrCorruptPatch is returned when a bsdiff patch cannot be applied.
+var ErrCorruptPatch = errors.New("corrupt bsdiff patch")
+
+// offtin decodes the sign-magnitude 64-bit integers used by bsdiff.
+func offtin(buf []byte) int64 {
+	y := int64(buf[7] & 0x7f)
+	for i := 6; i >= 0; i-- {
+		y = y*256 + int64(buf[i])
+	}
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
+
+// ApplyBSDiff applies a BSDIFF40 patch to old and returns the new file contents. maxSize is the
+// largest result accepted, e.g. the size of the file from its metadata; a patch declaring a
+// larger one is rejected before anything is allocated. Zero or less uses MaxPatchedSize.
+func ApplyBSDiff(old, patch []byte, maxSize int64) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != "BSDIFF40" {
+		return nil, fmt.Errorf("%w: bad header", ErrCorruptPatch)
+	}
+
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || 32+ctrlLen+diffLen > int64(len(patch)) {
+		return nil, fmt.Errorf("%w: bad block lengths", ErrCorruptPatch)
+	}
+	if maxSize <= 0 || maxSize > MaxPatchedSize {
+		maxSize = MaxPatchedSize
+	}
+	if newSize > maxSize {
+		return nil, fmt.Errorf("%w: new size %d exceeds %d", ErrCorruptPatch, newSize, maxSize)
+	}
+
+	ctrl := bzip2.NewReader(bytes.NewReader(patch[32 : 32+ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen : 32+ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen+diffLen:]))
+
+	newData := make([]byte, newSize)
+	var oldPos, newPos int64
+	var triple [24]byte
+
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, triple[:]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptPatch, err)
+		}
+		addLen, copyLen, seek := offtin(triple[0:8]), offtin(triple[8:16]), offtin(triple[16:24])
+
+		// Add the diff block to the old data
+		if addLen < 0 || newPos+addLen > newSize {
+			return nil, fmt.Errorf("%w: add length out of range", ErrCorruptPatch)
+		}
+		if _, err := io.ReadFull(diff, newData[newPos:newPos+addLen]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptPatch, err)
+		}
+		for i := int64(0); i < addLen; i++ {
+			if oldPos+i >= 0 && oldPos+i < int64(len(old)) {
+				newData[newPos+i] += old[oldPos+i]
+			}
+		}
+		newPos += addLen
+		oldPos += addLen
+
+		// Copy the extra block verbatim
+		if copyLen < 0 || newPos+copyLen > newSize {
+			return nil, fmt.Errorf("%w: copy length out of range", ErrCorruptPatch)
+		}
+		if _, err := io.ReadFull(extra, newData[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptPatch, err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+
+	return newData, nil
+}
+
+// patchClientJar tries to build the client JAR of version from an installed base JAR using
+// ClientPatches. expectedSize bounds the patched JAR (zero for unknown). It reports whether
+// jarPath was written with a verified result.
+func patchClientJar(ctx context.Context, version, mcDir, jarPath, expectedSHA1 string, expectedSize int64, E *events.EventEmitter) bool {
+	if ClientPatches == nil || expectedSHA1 == "" {
+		return false
+	}
+
+	for _, patch := range ClientPatches(version) {
+		basePath := utils.NewLayout(mcDir).VersionJar(patch.From)
+		old, err := os.ReadFile(basePath)
+		if err != nil {
+			continue
+		}
+
+		E.Emit("client_patch_start", map[string]string{"from": patch.From, "to": version})
+		newData, err := downloadAndApplyPatch(ctx, old, patch.URL, expectedSize)
+		if err != nil {
+			E.Emit("client_patch_failed", map[string]string{"from": patch.From, "to": version, "reason": err.Error()})
+			continue
+		}
+
+		sum := sha1.Sum(newData)
+		if actual := hex.EncodeToString(sum[:]); actual != expectedSHA1 {
+			E.Emit("client_patch_failed", map[string]string{"from": patch.From, "to": version, "reason": "sha1 mismatch: " + actual})
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(jarPath), utils.Modes.Dir); err != nil {
+			return false
+		}
+		Track(ctx, jarPath)
+		if err := os.WriteFile(jarPath, newData, utils.Modes.File); err != nil {
+			E.Emit("client_patch_failed", map[string]string{"from": patch.From, "to": version, "reason": err.Error()})
+			return false
+		}
+
+		E.Emit("client_patched", map[string]string{"from": patch.From, "to": version})
+		return true
+	}
+	return false
+}
+
+// downloadAndApplyPatch fetches a bsdiff patch into memory and applies it to old, accepting a
+// result of at most maxSize bytes.
+func downloadAndApplyPatch(ctx context.Context, old []byte, url string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch patch %s: %s", url, resp.Status)
+	}
+
+	patch, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyBSDiff(old, patch, maxSize)
+}
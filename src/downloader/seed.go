@@ -0,0 +1,104 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// SeedSource is a read-only game directory, typically the official launcher's .minecraft,
+// whose libraries, asset objects and client JARs are reused instead of downloaded again.
+type SeedSource struct {
+	Dir string
+	// Link hard-links matching files instead of copying them. Linked files share their data
+	// with the source, so only enable it when nothing modifies either copy in place.
+	Link bool
+}
+
+// Seeds are searched, in order, before a library, asset object or client JAR is downloaded.
+// A file is only taken from a seed when its SHA1 matches the expected one; seeds are never
+// written to.
+var Seeds []SeedSource
+
+// UseOfficialInstall adds the official launcher's .minecraft as a seed for mcDir, so a first
+// install reuses what the official launcher already downloaded. It reports whether a
+// directory was found; nothing is added when it is mcDir itself.
+func UseOfficialInstall(mcDir string, link bool) bool {
+	dir := utils.DefaultMCDir()
+	if info, err := os.Stat(utils.NewLayout(dir).VersionsDir()); err != nil || !info.IsDir() {
+		return false
+	}
+	if abs, err := filepath.Abs(mcDir); err == nil {
+		if official, err := filepath.Abs(dir); err == nil && abs == official {
+			return false
+		}
+	}
+	for _, seed := range Seeds {
+		if seed.Dir == dir {
+			return true
+		}
+	}
+	Seeds = append(Seeds, SeedSource{Dir: dir, Link: link})
+	return true
+}
+
+// fileSHA1 returns the hex SHA1 of a file.
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// seedFile places file from the first seed holding rel (a slash-separated path relative to a
+// game directory) with the expected SHA1. It reports whether the file was seeded; it does
+// nothing when file already exists or no SHA1 is known to verify the source against. The file
+// is journaled in the transaction of ctx.
+func seedFile(ctx context.Context, file, rel, expectedSHA1 string, E *events.EventEmitter) bool {
+	if len(Seeds) == 0 || expectedSHA1 == "" {
+		return false
+	}
+	if _, err := os.Stat(utils.LongPath(file)); err == nil {
+		return false
+	}
+
+	for _, seed := range Seeds {
+		src := filepath.Join(seed.Dir, filepath.FromSlash(rel))
+		if sum, err := fileSHA1(src); err != nil || sum != expectedSHA1 {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(utils.LongPath(file)), utils.Modes.Dir); err != nil {
+			return false
+		}
+		Track(ctx, file)
+		if !seed.Link || os.Link(src, utils.LongPath(file)) != nil {
+			if err := copyFile(src, file); err != nil {
+				continue
+			}
+		}
+		E.Emit("file_seeded", map[string]string{"path": file, "source": src})
+		return true
+	}
+	return false
+}
+
+// fetchLibrary seeds a library or, failing that, downloads it with DownloadLibraryFile.
+func fetchLibrary(ctx context.Context, file, url, artifactPath, expectedSHA1 string, E *events.EventEmitter) error {
+	if seedFile(ctx, file, "libraries/"+artifactPath, expectedSHA1, E) {
+		return nil
+	}
+	return DownloadLibraryFileContext(ctx, file, url, artifactPath, expectedSHA1, E)
+}
@@ -0,0 +1,333 @@
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// JournalDir is the directory of a game directory holding the journals of unfinished installs.
+const JournalDir = ".install-journal"
+
+// journalFile lists, one JSON entry per line, the files an install created or overwrote.
+const journalFile = "journal.jsonl"
+
+var unsafeJournalChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ------------------ Structs ------------------
+
+// JournalEntry is a file written during a transaction.
+type JournalEntry struct {
+	// Path is relative to the transaction root.
+	Path string `json:"path"`
+	// Backup names the saved previous content of an overwritten file; empty means the
+	// transaction created the file.
+	Backup string `json:"backup,omitempty"`
+}
+
+// Transaction journals the files its install writes below Root while it is open, so a failed
+// install can be undone with Rollback. Writes are tied to the transaction through the context
+// the install runs with (see WithTransaction and Track), so files other code writes meanwhile
+// are never rolled back with it. The journal lives on disk, so installs interrupted by a crash
+// are undone by RecoverInstalls on the next start.
+type Transaction struct {
+	Name string
+	Root string
+
+	dir     string
+	mu      sync.Mutex
+	seen    map[string]bool
+	entries int
+	journal *os.File
+	// parent is the transaction of the install this one is nested in, which journals the
+	// writes of this one too.
+	parent *Transaction
+}
+
+// transactions are the open transactions of this process, whose journals RecoverInstalls
+// leaves alone.
+var (
+	transactionsMu sync.Mutex
+	transactions   []*Transaction
+)
+
+// transactionKey is the context key of the Transaction of an install.
+type transactionKey struct{}
+
+// ------------------ Journal ------------------
+
+// Begin opens a transaction for the installation described by name below mcDir.
+func Begin(mcDir, name string, E *events.EventEmitter) (*Transaction, error) {
+	root, err := filepath.Abs(mcDir)
+	if err != nil {
+		return nil, err
+	}
+	id := time.Now().UTC().Format("20060102-150405.000000000") + "-" + unsafeJournalChars.ReplaceAllString(name, "_")
+	dir := filepath.Join(root, JournalDir, id)
+	if err := os.MkdirAll(dir, utils.Modes.Dir); err != nil {
+		err = fmt.Errorf("failed to create install journal: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, utils.Modes.File)
+	if err != nil {
+		os.RemoveAll(dir)
+		err = fmt.Errorf("failed to create install journal: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	t := &Transaction{Name: name, Root: root, dir: dir, seen: map[string]bool{}, journal: journal}
+	transactionsMu.Lock()
+	transactions = append(transactions, t)
+	transactionsMu.Unlock()
+
+	E.Emit("transaction_begin", name)
+	return t, nil
+}
+
+// WithTransaction returns a context whose writes Track journals in t. Installs passing the
+// context on nest their own transactions in t.
+func WithTransaction(ctx context.Context, t *Transaction) context.Context {
+	return context.WithValue(ctx, transactionKey{}, t)
+}
+
+// TransactionFrom returns the transaction of ctx, or nil when it carries none.
+func TransactionFrom(ctx context.Context) *Transaction {
+	t, _ := ctx.Value(transactionKey{}).(*Transaction)
+	return t
+}
+
+// Track records that path is about to be created or overwritten in the transaction of ctx and
+// the transactions it is nested in, when their roots contain path. Installers writing files
+// themselves call it before each write with the context of their install; writes made with a
+// context without a transaction are not journaled.
+func Track(ctx context.Context, path string) {
+	t := TransactionFrom(ctx)
+	if t == nil {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	for ; t != nil; t = t.parent {
+		if rel, err := filepath.Rel(t.Root, abs); err == nil && !strings.HasPrefix(rel, "..") && !strings.HasPrefix(rel, JournalDir) {
+			t.record(abs, rel)
+		}
+	}
+}
+
+// record journals the first write of a file, saving its content if it exists.
+func (t *Transaction) record(abs, rel string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.journal == nil || t.seen[rel] {
+		return
+	}
+	t.seen[rel] = true
+
+	entry := JournalEntry{Path: filepath.ToSlash(rel)}
+	if info, err := os.Lstat(abs); err == nil {
+		if !info.Mode().IsRegular() {
+			return
+		}
+		entry.Backup = strconv.Itoa(t.entries)
+		backup := filepath.Join(t.dir, "backup", entry.Backup)
+		if err := os.MkdirAll(filepath.Dir(backup), utils.Modes.Dir); err != nil || copyFile(abs, backup) != nil {
+			// Without a backup the file can only be kept as is on rollback
+			return
+		}
+	}
+	t.entries++
+
+	data, _ := json.Marshal(entry)
+	t.journal.Write(append(data, '\n'))
+}
+
+// close stops journaling and unregisters the transaction.
+func (t *Transaction) close() {
+	transactionsMu.Lock()
+	for i, open := range transactions {
+		if open == t {
+			transactions = append(transactions[:i], transactions[i+1:]...)
+			break
+		}
+	}
+	transactionsMu.Unlock()
+
+	t.mu.Lock()
+	if t.journal != nil {
+		t.journal.Close()
+		t.journal = nil
+	}
+	t.mu.Unlock()
+}
+
+// Commit keeps every file written during the transaction and deletes its journal.
+func (t *Transaction) Commit(E *events.EventEmitter) error {
+	t.close()
+	if err := os.RemoveAll(t.dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to remove install journal: %w", err)))
+		return err
+	}
+	E.Emit("transaction_committed", t.Name)
+	return nil
+}
+
+// Rollback deletes the files the transaction created and restores the ones it overwrote.
+func (t *Transaction) Rollback(E *events.EventEmitter) error {
+	t.close()
+	files, err := rollbackJournal(t.Root, t.dir)
+	if err != nil {
+		err = fmt.Errorf("failed to roll back %s: %w", t.Name, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	E.Emit("transaction_rolled_back", map[string]interface{}{"name": t.Name, "files": files})
+	return nil
+}
+
+// rollbackJournal undoes the journal in dir, newest entry first, then deletes it. It returns
+// the number of files removed or restored.
+func rollbackJournal(root, dir string) (int, error) {
+	f, err := os.Open(filepath.Join(dir, journalFile))
+	if err != nil {
+		return 0, err
+	}
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry JournalEntry
+		// A line cut short by a crash is the last one and was never followed by a write
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Path != "" {
+			entries = append(entries, entry)
+		}
+	}
+	f.Close()
+
+	files := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		path := filepath.Join(root, filepath.FromSlash(entry.Path))
+		if entry.Backup == "" {
+			if err := os.Remove(path); err == nil {
+				files++
+				removeEmptyParents(root, filepath.Dir(path))
+			} else if !os.IsNotExist(err) {
+				return files, err
+			}
+			continue
+		}
+		backup := filepath.Join(dir, "backup", entry.Backup)
+		_ = os.Remove(path)
+		if err := os.Rename(backup, path); err != nil {
+			if err := copyFile(backup, path); err != nil {
+				return files, err
+			}
+		}
+		files++
+	}
+	return files, os.RemoveAll(dir)
+}
+
+// removeEmptyParents removes dir and its parents up to root while they are empty.
+func removeEmptyParents(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// RecoverInstalls rolls back the installs below mcDir that were interrupted (e.g. by a crash)
+// before they committed. Call it at startup, before installing anything. It takes the install
+// lock, so journals of installs still running in other processes are left alone. It returns
+// the number of installs rolled back and emits install_recovered for each.
+func RecoverInstalls(mcDir string, E *events.EventEmitter) (int, error) {
+	root, err := filepath.Abs(mcDir)
+	if err != nil {
+		return 0, err
+	}
+	lock, err := AcquireLock(root, "recover", InstallLockOptions, E)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release()
+	entries, err := os.ReadDir(filepath.Join(root, JournalDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	open := map[string]bool{}
+	transactionsMu.Lock()
+	for _, t := range transactions {
+		open[t.dir] = true
+	}
+	transactionsMu.Unlock()
+
+	recovered := 0
+	for _, entry := range entries {
+		dir := filepath.Join(root, JournalDir, entry.Name())
+		if !entry.IsDir() || open[dir] {
+			continue
+		}
+		files, err := rollbackJournal(root, dir)
+		if err != nil {
+			err = fmt.Errorf("failed to recover install %s: %w", entry.Name(), err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return recovered, err
+		}
+		recovered++
+		E.Emit("install_recovered", map[string]interface{}{"install": entry.Name(), "files": files})
+	}
+	return recovered, nil
+}
+
+// RunTransaction runs install inside a transaction named name, committing it when install
+// succeeds and rolling it back when it fails. The install lock of mcDir is held throughout,
+// as configured by InstallLockOptions. install must pass its context on to every write it
+// makes (Track, DownloadFileContext, ...) for the write to be journaled.
+func RunTransaction(mcDir, name string, install func(ctx context.Context) error, E *events.EventEmitter) error {
+	return RunLockedTransaction(context.Background(), nil, mcDir, name, func(ctx context.Context, _ *Lock) error { return install(ctx) }, E)
+}
+
+// RunLockedTransaction works like RunTransaction and passes the install lock of mcDir to
+// install, which hands it on to the installs it nests (e.g. as VersionOptions.Lock). held is
+// the lock of a caller that already holds it, or nil to acquire it. When ctx carries the
+// transaction of a caller, the new transaction is nested in it: its writes are also undone
+// when the caller's install fails.
+func RunLockedTransaction(ctx context.Context, held *Lock, mcDir, name string, install func(ctx context.Context, lock *Lock) error, E *events.EventEmitter) error {
+	lock, release, err := lockFor(held, mcDir, name, InstallLockOptions, E)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	t, err := Begin(mcDir, name, E)
+	if err != nil {
+		return err
+	}
+	t.parent = TransactionFrom(ctx)
+	if err := install(WithTransaction(ctx, t), lock); err != nil {
+		t.Rollback(E)
+		return err
+	}
+	return t.Commit(E)
+}
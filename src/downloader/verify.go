@@ -0,0 +1,186 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// VerifyReport is the result of VerifyVersion. Paths are those of the files on disk.
+type VerifyReport struct {
+	Version string `json:"version"`
+	// Files counts the files checked and Bytes the bytes hashed.
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// Missing files do not exist; Corrupt ones exist with another size or SHA1.
+	Missing []string `json:"missing,omitempty"`
+	Corrupt []string `json:"corrupt,omitempty"`
+	// assets reports whether an asset index or object is missing or corrupt.
+	assets bool
+}
+
+// OK reports whether every file was found intact.
+func (r *VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Corrupt) == 0
+}
+
+// verifyFile is a file of an installation with its expected SHA1 and size (empty and zero when
+// unknown).
+type verifyFile struct {
+	path, sha1 string
+	size       int64
+	asset      bool
+}
+
+// ------------------ Helpers ------------------
+
+// versionFiles lists the files of an installed vanilla version for a platform: its client
+// JAR, libraries, asset index and the objects the index lists. An unreadable asset index is
+// listed alone, so it is reported missing or corrupt.
+func versionFiles(version, mcDir string, platform rules.Platform) ([]verifyFile, string, error) {
+	layout := utils.NewLayout(mcDir)
+	data, err := os.ReadFile(layout.VersionJSON(version))
+	if err != nil {
+		return nil, "", err
+	}
+	var metadata VersionMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, "", fmt.Errorf("invalid version JSON: %w", err)
+	}
+
+	files := []verifyFile{{path: layout.VersionJar(version), sha1: metadata.Downloads.Client.Sha1, size: metadata.Downloads.Client.Size}}
+	for _, lib := range libraryDownloads(metadata, platform) {
+		files = append(files, verifyFile{path: filepath.Join(layout.LibrariesDir(), filepath.FromSlash(lib.path)), sha1: lib.sha1, size: lib.size})
+	}
+
+	indexID := metadata.AssetIndex.Id
+	if indexID == "" {
+		return files, "", nil
+	}
+	indexPath := layout.AssetIndex(indexID)
+	files = append(files, verifyFile{path: indexPath, sha1: metadata.AssetIndex.Sha1, size: metadata.AssetIndex.Size, asset: true})
+	data, err = os.ReadFile(indexPath)
+	if err != nil {
+		return files, indexID, nil
+	}
+	var index AssetIndex
+	if json.Unmarshal(data, &index) != nil {
+		return files, indexID, nil
+	}
+	objectsDir := layout.AssetObjectsDir()
+	for _, object := range index.Objects {
+		if len(object.Hash) < 2 {
+			continue
+		}
+		files = append(files, verifyFile{path: filepath.Join(objectsDir, object.Hash[:2], object.Hash), sha1: object.Hash, size: object.Size, asset: true})
+	}
+	return files, indexID, nil
+}
+
+// ------------------ Public API ------------------
+
+// VerifyVersion checks every file of a vanilla version installed in mcDir for the host against
+// its metadata: the client JAR, the libraries (natives included), the asset index and every
+// asset object. Files with a wrong size are reported without hashing them; the others are
+// hashed with one worker per usable CPU (GOMAXPROCS), and verify_progress reports each file,
+// so a full verify of a multi-gigabyte install takes seconds once it is in the page cache. It
+// works offline from the version JSON and asset index on disk and emits verify_done with the
+// report.
+func VerifyVersion(version, mcDir string, E *events.EventEmitter) (*VerifyReport, error) {
+	report, _, err := verifyVersion(version, mcDir, E)
+	return report, err
+}
+
+// verifyVersion performs VerifyVersion and also returns the ID of the asset index.
+func verifyVersion(version, mcDir string, E *events.EventEmitter) (*VerifyReport, string, error) {
+	files, indexID, err := versionFiles(version, mcDir, rules.Host())
+	if err != nil {
+		err = fmt.Errorf("failed to read version %s: %w", version, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, "", err
+	}
+	report := &VerifyReport{Version: version, Files: len(files)}
+	E.Emit("verify_start", map[string]interface{}{"version": version, "files": len(files)})
+
+	bad := func(file verifyFile, missing bool) {
+		if missing {
+			report.Missing = append(report.Missing, file.path)
+		} else {
+			report.Corrupt = append(report.Corrupt, file.path)
+		}
+		report.assets = report.assets || file.asset
+	}
+
+	// Sizes are checked up front; only files that could match are hashed
+	var hashed []verifyFile
+	var paths []string
+	for _, file := range files {
+		info, err := os.Stat(utils.LongPath(file.path))
+		switch {
+		case err != nil:
+			bad(file, true)
+		case !info.Mode().IsRegular() || file.size > 0 && info.Size() != file.size:
+			bad(file, false)
+		case file.sha1 != "":
+			hashed = append(hashed, file)
+			paths = append(paths, utils.LongPath(file.path))
+		}
+	}
+
+	hashes := utils.HashFiles(paths, func(done, i int) {
+		E.Emit("verify_progress", map[string]interface{}{
+			"version": version, "done": done, "total": len(paths), "path": hashed[i].path,
+		})
+	})
+	for i, hash := range hashes {
+		report.Bytes += hash.Size
+		switch {
+		case os.IsNotExist(hash.Err):
+			bad(hashed[i], true)
+		case hash.Err != nil || hash.SHA1 != hashed[i].sha1:
+			bad(hashed[i], false)
+		}
+	}
+
+	E.Emit("verify_done", report)
+	return report, indexID, nil
+}
+
+// RepairVersion verifies a vanilla version with VerifyVersion, deletes its corrupt files and
+// installs it again with InstallVersion, which downloads the deleted and missing files. It
+// returns the report of the verify; an intact version is left as is.
+func RepairVersion(version, mcDir string, E *events.EventEmitter) (*VerifyReport, error) {
+	report, indexID, err := verifyVersion(version, mcDir, E)
+	if err != nil || report.OK() {
+		return report, err
+	}
+
+	for _, path := range report.Corrupt {
+		if err := os.Remove(utils.LongPath(path)); err != nil && !os.IsNotExist(err) {
+			err = fmt.Errorf("failed to remove corrupt file: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return report, err
+		}
+	}
+	// Complete asset indexes are not checked again by DownloadAssets
+	if report.assets && indexID != "" {
+		if err := ResetAssetState(mcDir, indexID); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return report, err
+		}
+	}
+	E.Emit("repair_start", map[string]interface{}{"version": version, "missing": len(report.Missing), "corrupt": len(report.Corrupt)})
+	if err := InstallVersion(version, mcDir, E); err != nil {
+		return report, err
+	}
+	E.Emit("repair_done", version)
+	return report, nil
+}
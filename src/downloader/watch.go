@@ -0,0 +1,161 @@
+package downloader
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// DefaultUpdateInterval is the time between manifest checks of an UpdateWatcher.
+const DefaultUpdateInterval = time.Hour
+
+// Channels an UpdateWatcher can follow.
+const (
+	// ChannelRelease follows the latest release.
+	ChannelRelease = "release"
+	// ChannelSnapshot follows the newest version of any type, snapshots and releases alike.
+	ChannelSnapshot = "snapshot"
+)
+
+// ------------------ Structs ------------------
+
+// NewVersion is the payload of new_version_available.
+type NewVersion struct {
+	Channel     string `json:"channel"`
+	Version     string `json:"version"`
+	Type        string `json:"type"`
+	ReleaseTime string `json:"releaseTime"`
+	// Previous is the version known before, "" on the first check.
+	Previous string `json:"previous"`
+}
+
+// UpdateWatcher checks the version manifest for a new release or snapshot, once with Check or
+// periodically with Start, and emits new_version_available when the latest version of its
+// channel changes, for launchers offering to keep an installation up to date.
+type UpdateWatcher struct {
+	// Channel is ChannelRelease or ChannelSnapshot.
+	Channel string
+	// Interval is the time between checks once Start was called; zero uses DefaultUpdateInterval.
+	Interval time.Duration
+	// Known is the latest version already seen, e.g. persisted from a previous run. When empty,
+	// the first check only records the current latest version.
+	Known string
+	// AutoInstall installs the latest version into MCDir whenever it is not installed yet,
+	// emitting update_installed once done.
+	AutoInstall bool
+	MCDir       string
+
+	E       *events.EventEmitter
+	mu      sync.Mutex
+	stop    chan struct{}
+	running sync.Mutex
+}
+
+// NewUpdateWatcher returns a watcher of channel installing into mcDir. Configure its fields,
+// then call Check or Start.
+func NewUpdateWatcher(channel, mcDir string, E *events.EventEmitter) *UpdateWatcher {
+	return &UpdateWatcher{Channel: channel, MCDir: mcDir, E: E}
+}
+
+// ------------------ Checking ------------------
+
+// latest returns the manifest entry of the latest version of the channel.
+func (w *UpdateWatcher) latest(manifest *Manifest) Version {
+	id := manifest.Latest.Release
+	if w.Channel == ChannelSnapshot {
+		id = manifest.Latest.Snapshot
+	}
+	for _, v := range manifest.Versions {
+		if v.Id == id {
+			return v
+		}
+	}
+	return Version{Id: id}
+}
+
+// Check fetches the manifest and returns the latest version of the channel and whether it is
+// new, i.e. differs from Known. A new version is emitted as new_version_available and becomes
+// Known. With AutoInstall, the latest version is then installed unless it already is.
+func (w *UpdateWatcher) Check() (string, bool, error) {
+	w.running.Lock()
+	defer w.running.Unlock()
+
+	manifest, err := FetchManifest()
+	if err != nil {
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return "", false, err
+	}
+	latest := w.latest(manifest)
+
+	w.mu.Lock()
+	previous := w.Known
+	w.Known = latest.Id
+	w.mu.Unlock()
+
+	isNew := previous != "" && latest.Id != "" && latest.Id != previous
+	if isNew {
+		w.E.Emit("new_version_available", NewVersion{
+			Channel:     w.Channel,
+			Version:     latest.Id,
+			Type:        latest.Type,
+			ReleaseTime: latest.ReleaseTime,
+			Previous:    previous,
+		})
+	}
+
+	if w.AutoInstall && latest.Id != "" {
+		if _, err := os.Stat(utils.NewLayout(w.MCDir).VersionJSON(latest.Id)); err != nil {
+			if err := InstallVersion(latest.Id, w.MCDir, w.E); err != nil {
+				return latest.Id, isNew, err
+			}
+			w.E.Emit("update_installed", latest.Id)
+		}
+	}
+	return latest.Id, isNew, nil
+}
+
+// ------------------ Scheduling ------------------
+
+// Start checks immediately and then every Interval until Stop. Failed checks are reported as
+// error events and retried on the next tick.
+func (w *UpdateWatcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultUpdateInterval
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	go func() {
+		_, _, _ = w.Check()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _, _ = w.Check()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends periodic checks. A check in progress finishes.
+func (w *UpdateWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
@@ -1,20 +1,67 @@
 package events
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // EventEmitter provides a mechanism for event handling: registering listeners and emitting events.
 // It is thread-safe using a sync.RWMutex.
 type EventEmitter struct {
 	// listeners maps event names (string) to a slice of handler functions.
 	listeners map[string][]func(data any)
-	// mu protects the listeners map from concurrent access.
+	// anyListeners are called for every event, with its name.
+	anyListeners []func(event string, data any)
+	// mu protects the listeners map and anyListeners from concurrent access.
 	mu sync.RWMutex
+
+	// throttles maps event names to their rate limiting state.
+	throttles map[string]*throttle
+	// tmu protects the throttles map and the state of every throttle.
+	tmu sync.Mutex
+}
+
+// ThrottleOptions configures how often a high-frequency event is delivered to handlers.
+type ThrottleOptions struct {
+	// Interval is the minimum time between two deliveries of the event.
+	Interval time.Duration
+	// Batch delivers every payload emitted since the last delivery as a []any.
+	// Without it, only the most recent payload is delivered and the others are dropped.
+	Batch bool
+}
+
+// throttle holds the rate limiting state of one event name.
+type throttle struct {
+	opts    ThrottleOptions
+	last    time.Time
+	pending []any
+	timer   *time.Timer
+}
+
+// add queues a payload for the next delivery.
+func (t *throttle) add(data any) {
+	if t.opts.Batch {
+		t.pending = append(t.pending, data)
+	} else {
+		t.pending = []any{data}
+	}
+}
+
+// take removes the queued payloads and returns what should be delivered.
+func (t *throttle) take() any {
+	pending := t.pending
+	t.pending = nil
+	if t.opts.Batch {
+		return pending
+	}
+	return pending[0]
 }
 
 // New creates and returns a new initialized EventEmitter.
 func New() *EventEmitter {
 	return &EventEmitter{
 		listeners: make(map[string][]func(data any)),
+		throttles: make(map[string]*throttle),
 	}
 }
 
@@ -26,17 +73,114 @@ func (e *EventEmitter) On(event string, handler func(data any)) {
 	e.listeners[event] = append(e.listeners[event], handler)
 }
 
+// OnAny registers a handler function to be called for every emitted event, with the event name,
+// e.g. to forward all events to another process.
+func (e *EventEmitter) OnAny(handler func(event string, data any)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.anyListeners = append(e.anyListeners, handler)
+}
+
+// Throttle limits how often the specified event reaches its handlers, so UIs subscribing to
+// per-file progress are not flooded. The first emission is delivered immediately; emissions
+// within the interval are coalesced (or batched) and delivered when the interval ends, from a
+// timer goroutine. A zero Interval removes the throttle after flushing pending payloads.
+func (e *EventEmitter) Throttle(event string, opts ThrottleOptions) {
+	if opts.Interval <= 0 {
+		e.flushEvent(event)
+		e.tmu.Lock()
+		delete(e.throttles, event)
+		e.tmu.Unlock()
+		return
+	}
+
+	e.tmu.Lock()
+	defer e.tmu.Unlock()
+	if t, ok := e.throttles[event]; ok {
+		t.opts = opts
+		return
+	}
+	e.throttles[event] = &throttle{opts: opts}
+}
+
 // Emit executes all registered handlers for the specified event, passing the provided data.
-// Handlers are called synchronously (in the same goroutine).
+// Handlers are called synchronously (in the same goroutine), unless the event is throttled
+// and its delivery is deferred to the end of the throttle interval.
 func (e *EventEmitter) Emit(event string, data any) {
+	e.tmu.Lock()
+	if t, ok := e.throttles[event]; ok {
+		t.add(data)
+		elapsed := time.Since(t.last)
+		if elapsed < t.opts.Interval {
+			// Deliver whatever is pending once the interval is over
+			if t.timer == nil {
+				t.timer = time.AfterFunc(t.opts.Interval-elapsed, func() { e.flushEvent(event) })
+			}
+			e.tmu.Unlock()
+			return
+		}
+		t.last = time.Now()
+		data = t.take()
+	}
+	e.tmu.Unlock()
+
+	e.dispatch(event, data)
+}
+
+// Flush immediately delivers every payload held back by throttles, e.g. before reporting
+// that a download phase has finished.
+func (e *EventEmitter) Flush() {
+	e.tmu.Lock()
+	var names []string
+	for name, t := range e.throttles {
+		if len(t.pending) > 0 {
+			names = append(names, name)
+		}
+	}
+	e.tmu.Unlock()
+
+	for _, name := range names {
+		e.flushEvent(name)
+	}
+}
+
+// flushEvent delivers the pending payloads of a throttled event, if any.
+func (e *EventEmitter) flushEvent(event string) {
+	e.tmu.Lock()
+	t, ok := e.throttles[event]
+	if !ok {
+		e.tmu.Unlock()
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if len(t.pending) == 0 {
+		e.tmu.Unlock()
+		return
+	}
+	t.last = time.Now()
+	data := t.take()
+	e.tmu.Unlock()
+
+	e.dispatch(event, data)
+}
+
+// dispatch calls every handler registered for the event.
+func (e *EventEmitter) dispatch(event string, data any) {
 	e.mu.RLock() // Acquire read lock to safely read the list of handlers
 	// Note: The handlers slice is copied by value, allowing us to release the lock
 	// before calling the handlers.
 	handlers := e.listeners[event]
+	anyHandlers := e.anyListeners
 	e.mu.RUnlock()
 
 	// Call each handler synchronously
 	for _, handler := range handlers {
 		handler(data)
 	}
+	for _, handler := range anyHandlers {
+		handler(event, data)
+	}
 }
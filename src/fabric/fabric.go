@@ -1,14 +1,21 @@
 package fabric
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
 	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
 )
 
 // ------------------ Metadata Structs ------------------
@@ -20,20 +27,27 @@ type FabricLoaderMetadata struct {
 	MainClass string `json:"mainClass"`
 	Libraries []struct {
 		Name      string `json:"name"`
-		Url       string `json:"url"` // Base URL for the library (often not used for Fabric libraries)
+		Url       string `json:"url"`  // Base URL for the library (often not used for Fabric libraries)
+		Sha1      string `json:"sha1"` // SHA1 of the library fetched by coordinate from Url
 		Downloads struct {
 			Artifact struct {
 				Path string `json:"path"` // Relative path in the 'libraries' folder
 				Url  string `json:"url"`  // Direct download URL for the artifact
+				Sha1 string `json:"sha1"`
 			} `json:"artifact"`
 			Classifiers map[string]struct {
 				Path string `json:"path"`
 				Url  string `json:"url"`
+				Sha1 string `json:"sha1"`
 			} `json:"classifiers"`
 		} `json:"downloads"`
 	} `json:"libraries"`
 	InheritsFrom string `json:"inheritsFrom"` // The base Minecraft version ID (e.g., "1.19.2")
 	Id           string `json:"id"`           // The resulting version ID (e.g., "fabric-loader-0.14.9-1.19.2")
+
+	// raw is the profile JSON as served, written out by buildFabricVersionJSON so fields this
+	// struct does not model (arguments, type, releaseTime, ...) are kept.
+	raw []byte
 }
 
 // ------------------ Download Loader Metadata ------------------
@@ -43,7 +57,7 @@ type FabricLoaderMetadata struct {
 func fetchLoaderMeta(mcVersion, loaderVersion string) (*FabricLoaderMetadata, error) {
 	url := fmt.Sprintf("https://meta.fabricmc.net/v2/versions/loader/%s/%s/profile/json", mcVersion, loaderVersion)
 
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -53,10 +67,15 @@ func fetchLoaderMeta(mcVersion, loaderVersion string) (*FabricLoaderMetadata, er
 		return nil, fmt.Errorf("failed to fetch Fabric metadata, status: %s", resp.Status)
 	}
 
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
 	var meta FabricLoaderMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+	if err := json.Unmarshal(data, &meta); err != nil {
 		return nil, err
 	}
+	meta.raw = data
 
 	return &meta, nil
 }
@@ -64,17 +83,33 @@ func fetchLoaderMeta(mcVersion, loaderVersion string) (*FabricLoaderMetadata, er
 // ------------------ Library Download ------------------
 
 // downloadFabricLibraries iterates through the required libraries in the Fabric metadata
-// and downloads them into the Minecraft 'libraries' folder.
-func downloadFabricLibraries(meta *FabricLoaderMetadata, mcDir string, E *events.EventEmitter) {
-	libDir := filepath.Join(mcDir, "libraries")
+// and downloads them into the Minecraft 'libraries' folder. It returns the number of files that failed.
+func downloadFabricLibraries(ctx context.Context, meta *FabricLoaderMetadata, mcDir string, E *events.EventEmitter) int {
+	libDir := utils.NewLayout(mcDir).LibrariesDir()
+	failed := 0
+	fetch := func(name, path, url, artifactPath, sha1 string) {
+		if err := downloader.DownloadLibraryFileContext(ctx, path, url, artifactPath, sha1, E); err != nil {
+			E.Emit("fabric_library_failed", downloader.FailureDetails(name, err))
+			failed++
+		}
+	}
 
 	for _, lib := range meta.Libraries {
 		// Download main artifact (the primary JAR)
 		if lib.Downloads.Artifact.Url != "" && lib.Downloads.Artifact.Path != "" {
 			path := filepath.Join(libDir, filepath.FromSlash(lib.Downloads.Artifact.Path))
 			E.Emit("fabric_library_download_start", lib.Name)
-			// downloader.DownloadFile handles creation of directories and checks for existence
-			_ = downloader.DownloadFile(path, lib.Downloads.Artifact.Url, E)
+			// downloader.DownloadLibraryFile handles creation of directories, existence checks and mirrors
+			fetch(lib.Name, path, lib.Downloads.Artifact.Url, lib.Downloads.Artifact.Path, lib.Downloads.Artifact.Sha1)
+		} else if mavenPath := downloader.MavenPath(lib.Name); mavenPath != "" {
+			// Fabric profiles usually only give a coordinate and the repository it lives in
+			path := filepath.Join(libDir, filepath.FromSlash(mavenPath))
+			url := ""
+			if lib.Url != "" {
+				url = strings.TrimSuffix(lib.Url, "/") + "/" + mavenPath
+			}
+			E.Emit("fabric_library_download_start", lib.Name)
+			fetch(lib.Name, path, url, mavenPath, lib.Sha1)
 		}
 
 		// Download classifiers (e.g., natives or sources, though natives are less common for Fabric)
@@ -82,54 +117,192 @@ func downloadFabricLibraries(meta *FabricLoaderMetadata, mcDir string, E *events
 			if classifier.Url != "" && classifier.Path != "" {
 				path := filepath.Join(libDir, filepath.FromSlash(classifier.Path))
 				E.Emit("fabric_classifier_download_start", lib.Name)
-				_ = downloader.DownloadFile(path, classifier.Url, E)
+				fetch(lib.Name, path, classifier.Url, classifier.Path, classifier.Sha1)
 			}
 		}
 	}
+	return failed
 }
 
 // ------------------ Version JSON Builder ------------------
 
 // buildFabricVersionJSON creates the final version JSON file required by the launcher
-// in the appropriate 'versions' subdirectory.
-func buildFabricVersionJSON(meta *FabricLoaderMetadata, mcDir, mcVersion string, E *events.EventEmitter) {
+// in the appropriate 'versions' subdirectory. The profile JSON is written as the meta-server
+// served it, so other launchers reading the directory see a complete version JSON; only the
+// fields a launcher requires are filled in when the profile lacks them.
+func buildFabricVersionJSON(ctx context.Context, meta *FabricLoaderMetadata, mcDir, mcVersion string, E *events.EventEmitter) error {
 	// The new version ID includes the fabric loader version, e.g., "fabric-loader-0.14.9-1.19.2"
-	versionDir := filepath.Join(mcDir, "versions", meta.Id)
-	os.MkdirAll(versionDir, 0755)
+	versionDir := utils.NewLayout(mcDir).VersionDir(meta.Id)
+	if err := os.MkdirAll(versionDir, utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
 
 	versionJsonPath := filepath.Join(versionDir, meta.Id+".json")
 
-	// Write the downloaded and processed Fabric metadata as the new version file
-	data, _ := json.MarshalIndent(meta, "", "  ")
-	_ = os.WriteFile(versionJsonPath, data, 0644)
+	profile := map[string]interface{}{}
+	if len(meta.raw) > 0 {
+		if err := json.Unmarshal(meta.raw, &profile); err != nil {
+			err = fmt.Errorf("invalid Fabric profile JSON: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+	} else {
+		// Metadata not fetched from the meta-server; start from the modelled fields
+		data, _ := json.Marshal(meta)
+		json.Unmarshal(data, &profile)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	defaults := map[string]interface{}{
+		"id":           meta.Id,
+		"inheritsFrom": mcVersion,
+		"type":         "release",
+		"mainClass":    meta.MainClass,
+		"releaseTime":  now,
+		"time":         now,
+	}
+	for key, value := range defaults {
+		if current, ok := profile[key]; !ok || current == "" {
+			profile[key] = value
+		}
+	}
+
+	// Write the Fabric profile as the new version file
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	downloader.Track(ctx, versionJsonPath)
+	if err := os.WriteFile(versionJsonPath, data, utils.Modes.File); err != nil {
+		err = fmt.Errorf("failed to write Fabric version JSON: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
 
 	E.Emit("fabric_version_json_written", versionJsonPath)
+	return nil
+}
+
+// ------------------ Install Detection ------------------
+
+// FabricVersionID returns the version ID the Fabric meta-server gives a loader version for a
+// Minecraft version, e.g. "fabric-loader-0.14.9-1.19.2".
+func FabricVersionID(mcVersion, loaderVersion string) string {
+	return "fabric-loader-" + loaderVersion + "-" + mcVersion
+}
+
+// IsFabricInstalled reports whether exactly this Fabric loader version is installed for
+// mcVersion in mcDir: its version JSON names the loader and the base version, every library
+// it lists is present and downloader.IsVersionInstalled finds the base version installed.
+func IsFabricInstalled(mcVersion, loaderVersion, mcDir string) bool {
+	id := FabricVersionID(mcVersion, loaderVersion)
+	data, err := os.ReadFile(utils.NewLayout(mcDir).VersionJSON(id))
+	if err != nil {
+		return false
+	}
+	var meta FabricLoaderMetadata
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Id != id || meta.InheritsFrom != mcVersion {
+		return false
+	}
+
+	libDir := utils.NewLayout(mcDir).LibrariesDir()
+	hasLoader := false
+	for _, lib := range meta.Libraries {
+		if lib.Name == "net.fabricmc:fabric-loader:"+loaderVersion {
+			hasLoader = true
+		}
+		artifactPath := lib.Downloads.Artifact.Path
+		if artifactPath == "" {
+			artifactPath = downloader.MavenPath(lib.Name)
+		}
+		if artifactPath == "" {
+			continue
+		}
+		if _, err := os.Stat(utils.LongPath(filepath.Join(libDir, filepath.FromSlash(artifactPath)))); err != nil {
+			return false
+		}
+	}
+	return hasLoader && downloader.IsVersionInstalled(mcVersion, mcDir)
 }
 
 // ------------------ Public API ------------------
 
+// InstallOptions tunes how InstallFabricWithOptions sets up the loader.
+type InstallOptions struct {
+	// ParentJar places the vanilla client JAR into the Fabric version directory:
+	// downloader.ParentJarCopy or downloader.ParentJarLink. Empty relies on the launcher
+	// falling back to the parent's JAR at launch.
+	ParentJar string
+	// Platform installs the vanilla base version for another platform than the host, see
+	// downloader.VersionOptions.Platform. Nil means the host.
+	Platform *rules.Platform
+}
+
 // InstallFabric orchestrates the download and setup of Fabric Loader for a given
 // Minecraft version and Fabric loader version.
 // It ensures the base vanilla version is present, downloads Fabric libraries, and creates the launch JSON.
+// A base version that downloader.IsVersionInstalled finds installed is not downloaded again;
+// base_version_present is emitted instead. When IsFabricInstalled finds the loader version
+// installed, nothing is fetched and fabric_already_installed is emitted, so automation can
+// safely run the install again.
 func InstallFabric(mcVersion, loaderVersion, mcDir string, E *events.EventEmitter) {
+	InstallFabricWithOptions(mcVersion, loaderVersion, mcDir, InstallOptions{}, E)
+}
+
+// InstallFabricWithOptions works like InstallFabric with the given options.
+func InstallFabricWithOptions(mcVersion, loaderVersion, mcDir string, opts InstallOptions, E *events.EventEmitter) {
 	E.Emit("fabric_install_start", mcVersion+" + loader "+loaderVersion)
 
+	if id := FabricVersionID(mcVersion, loaderVersion); opts.Platform == nil && IsFabricInstalled(mcVersion, loaderVersion, mcDir) {
+		_, jarErr := os.Stat(utils.NewLayout(mcDir).VersionJar(id))
+		if opts.ParentJar == "" || jarErr == nil {
+			E.Emit("fabric_already_installed", id)
+			return
+		}
+	}
+
 	// 1. Get fabric metadata
 	meta, err := fetchLoaderMeta(mcVersion, loaderVersion)
 	if err != nil {
-		E.Emit("error", "Failed to fetch Fabric metadata: "+err.Error())
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to fetch Fabric metadata: %w", err)))
 		return
 	}
 
-	// 2. Ensure vanilla base version is installed first.
-	// This makes sure the client JAR and assets are available before proceeding.
-	downloader.DownloadVersion(mcVersion, mcDir, E)
+	// Everything below is journaled, so a failed install leaves no half-installed version behind
+	err = downloader.RunLockedTransaction(context.Background(), nil, mcDir, "fabric-"+meta.Id, func(ctx context.Context, lock *downloader.Lock) error {
+		// 2. Ensure vanilla base version is installed first.
+		// This makes sure the client JAR and assets are available before proceeding.
+		if opts.Platform == nil && downloader.IsVersionInstalled(mcVersion, mcDir) {
+			E.Emit("base_version_present", mcVersion)
+		} else {
+			install, err := downloader.InstallVersionWithOptions(mcVersion, mcDir, downloader.VersionOptions{Platform: opts.Platform, Lock: lock, Context: ctx}, E)
+			if err != nil {
+				return err
+			}
+			install.Wait()
+		}
+
+		// 3. Download Fabric-specific libraries (including the loader JAR itself)
+		if failed := downloadFabricLibraries(ctx, meta, mcDir, E); failed > 0 {
+			err := fmt.Errorf("%d Fabric libraries failed to download", failed)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
 
-	// 3. Download Fabric-specific libraries (including the loader JAR itself)
-	downloadFabricLibraries(meta, mcDir, E)
+		// 4. Write the merged version JSON for the launcher to read
+		if err := buildFabricVersionJSON(ctx, meta, mcDir, mcVersion, E); err != nil {
+			return err
+		}
 
-	// 4. Write the merged version JSON for the launcher to read
-	buildFabricVersionJSON(meta, mcDir, mcVersion, E)
+		// 5. Optionally give the Fabric version its own copy of the client JAR
+		return downloader.PlaceParentJarContext(ctx, mcDir, meta.Id, mcVersion, opts.ParentJar, E)
+	}, E)
+	if err != nil {
+		E.Emit("fabric_install_failed", meta.Id)
+		return
+	}
 
 	E.Emit("fabric_install_done", meta.Id)
 }
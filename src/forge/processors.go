@@ -0,0 +1,355 @@
+package forge
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// processorOutputTail is how many output lines of a failed processor are kept in its error.
+const processorOutputTail = 20
+
+// ProcessorOptions configures RunProcessors.
+type ProcessorOptions struct {
+	MCDir        string
+	InstallerJar string
+	// JavaPath runs the processors; empty uses "java".
+	JavaPath string
+	// Side is "client" or "server"; empty means "client".
+	Side string
+	// MinecraftJar is the vanilla JAR being patched; empty uses versions/<minecraft>/<minecraft>.jar.
+	MinecraftJar string
+}
+
+// processorRun holds the state shared by the processors of one installation.
+type processorRun struct {
+	opts    ProcessorOptions
+	vars    map[string]string
+	libDir  string
+	workDir string
+	archive *zip.ReadCloser
+}
+
+// ------------------ Variables ------------------
+
+// libraryPath returns the path of a library given as "group:artifact:version[:classifier][@ext]".
+func (r *processorRun) libraryPath(coordinate string) (string, error) {
+	path := downloader.MavenPath(coordinate)
+	if path == "" {
+		return "", fmt.Errorf("invalid library coordinate %q", coordinate)
+	}
+	return filepath.Join(r.libDir, filepath.FromSlash(path)), nil
+}
+
+// dataValue resolves a value of the profile's data section: [coordinates] are library paths,
+// 'quoted' values are literals and /paths are extracted from the installer.
+func (r *processorRun) dataValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		return r.libraryPath(value[1 : len(value)-1])
+	case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2:
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, "/"):
+		data, err := readZipEntry(r.archive, value)
+		if err != nil {
+			return "", err
+		}
+		target := filepath.Join(r.workDir, filepath.FromSlash(strings.TrimPrefix(value, "/")))
+		if err := os.MkdirAll(filepath.Dir(target), utils.Modes.Dir); err != nil {
+			return "", err
+		}
+		return target, os.WriteFile(target, data, utils.Modes.File)
+	}
+	return value, nil
+}
+
+// resolve expands a processor argument: a whole [coordinate] becomes a library path and
+// {NAME} tokens are replaced by variables.
+func (r *processorRun) resolve(arg string) (string, error) {
+	if strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]") {
+		return r.libraryPath(arg[1 : len(arg)-1])
+	}
+
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(arg, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(arg[open:], '}')
+		if end < 0 {
+			break
+		}
+		name := arg[open+1 : open+end]
+		value, ok := r.vars[name]
+		if !ok {
+			return "", fmt.Errorf("unknown installer variable {%s}", name)
+		}
+		b.WriteString(arg[:open])
+		b.WriteString(value)
+		arg = arg[open+end+1:]
+	}
+	b.WriteString(arg)
+	return strings.Trim(b.String(), "'"), nil
+}
+
+// ------------------ Helpers ------------------
+
+// sha1OfFile returns the hex SHA1 of a file, or "" if it cannot be read.
+func sha1OfFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// mainClass reads the Main-Class of a JAR manifest.
+func mainClass(jarPath string) (string, error) {
+	archive, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	data, err := readZipEntry(archive, "META-INF/MANIFEST.MF")
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Main-Class:"); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no Main-Class", filepath.Base(jarPath))
+}
+
+// appliesTo reports whether the processor runs on side.
+func (p *Processor) appliesTo(side string) bool {
+	if len(p.Sides) == 0 {
+		return true
+	}
+	for _, s := range p.Sides {
+		if s == side {
+			return true
+		}
+	}
+	return false
+}
+
+// outputLines emits every output line of a processor and keeps the last ones for error reports.
+type outputLines struct {
+	index   int
+	E       *events.EventEmitter
+	partial []byte
+	tail    []string
+}
+
+func (o *outputLines) Write(p []byte) (int, error) {
+	o.partial = append(o.partial, p...)
+	for {
+		newline := bytes.IndexByte(o.partial, '\n')
+		if newline < 0 {
+			break
+		}
+		o.line(strings.TrimRight(string(o.partial[:newline]), "\r"))
+		o.partial = o.partial[newline+1:]
+	}
+	return len(p), nil
+}
+
+// line records one complete output line.
+func (o *outputLines) line(text string) {
+	o.E.Emit("forge_processor_output", map[string]interface{}{"index": o.index, "line": text})
+	o.tail = append(o.tail, text)
+	if len(o.tail) > processorOutputTail {
+		o.tail = o.tail[1:]
+	}
+}
+
+// ------------------ Public API ------------------
+
+// RunProcessors runs the installer processors of profile (jarsplitter, binarypatcher,
+// SpecialSource, ...) for one side. Each processor runs as a child Java process in a scratch
+// directory with its output captured and emitted as forge_processor_output; progress is
+// reported with forge_processor_start and forge_processor_done. A processor whose declared
+// outputs already exist with the expected SHA1 is skipped (forge_processor_skipped), so an
+// interrupted installation resumes where it stopped. Outputs are verified after every run.
+//
+// The processors' libraries must be present; see DownloadProfileLibraries.
+func RunProcessors(profile *InstallProfile, opts ProcessorOptions, E *events.EventEmitter) error {
+	if opts.JavaPath == "" {
+		opts.JavaPath = "java"
+	}
+	if opts.Side == "" {
+		opts.Side = "client"
+	}
+	if opts.MinecraftJar == "" {
+		opts.MinecraftJar = utils.NewLayout(opts.MCDir).VersionJar(profile.Minecraft)
+	}
+
+	archive, err := zip.OpenReader(opts.InstallerJar)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to open installer: %w", err)))
+		return err
+	}
+	defer archive.Close()
+
+	workDir, err := os.MkdirTemp("", "forge-processors-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	run := &processorRun{
+		opts:    opts,
+		libDir:  utils.NewLayout(opts.MCDir).LibrariesDir(),
+		workDir: workDir,
+		archive: archive,
+		vars: map[string]string{
+			"SIDE":              opts.Side,
+			"MINECRAFT_JAR":     opts.MinecraftJar,
+			"MINECRAFT_VERSION": profile.Minecraft,
+			"ROOT":              opts.MCDir,
+			"INSTALLER":         opts.InstallerJar,
+			"LIBRARY_DIR":       utils.NewLayout(opts.MCDir).LibrariesDir(),
+		},
+	}
+
+	// Resolve the profile variables for this side
+	for name, entry := range profile.Data {
+		value := entry.Client
+		if opts.Side == "server" {
+			value = entry.Server
+		}
+		resolved, err := run.dataValue(value)
+		if err != nil {
+			err = fmt.Errorf("failed to resolve installer variable %s: %w", name, err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+		run.vars[name] = resolved
+	}
+
+	var processors []Processor
+	for _, p := range profile.Processors {
+		if p.appliesTo(opts.Side) {
+			processors = append(processors, p)
+		}
+	}
+
+	for i, p := range processors {
+		if err := run.runProcessor(i, len(processors), &p, E); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+	}
+
+	E.Emit("forge_processors_done", len(processors))
+	return nil
+}
+
+// runProcessor runs a single processor unless its outputs are already valid.
+func (r *processorRun) runProcessor(index, total int, p *Processor, E *events.EventEmitter) error {
+	progress := map[string]interface{}{"index": index, "total": total, "jar": p.Jar}
+
+	// Resolve the declared outputs and skip the step if they are already in place
+	outputs := map[string]string{}
+	for file, sum := range p.Outputs {
+		path, err := r.resolve(file)
+		if err != nil {
+			return err
+		}
+		expected, err := r.resolve(sum)
+		if err != nil {
+			return err
+		}
+		outputs[path] = expected
+	}
+	if len(outputs) > 0 && outputsValid(outputs) {
+		E.Emit("forge_processor_skipped", progress)
+		return nil
+	}
+
+	jar, err := r.libraryPath(p.Jar)
+	if err != nil {
+		return err
+	}
+	main, err := mainClass(jar)
+	if err != nil {
+		return fmt.Errorf("processor %s: %w", p.Jar, err)
+	}
+
+	classpath := []string{jar}
+	for _, lib := range p.Classpath {
+		path, err := r.libraryPath(lib)
+		if err != nil {
+			return err
+		}
+		classpath = append(classpath, path)
+	}
+
+	args := []string{"-cp", strings.Join(classpath, string(os.PathListSeparator)), main}
+	for _, arg := range p.Args {
+		resolved, err := r.resolve(arg)
+		if err != nil {
+			return fmt.Errorf("processor %s: %w", p.Jar, err)
+		}
+		args = append(args, resolved)
+	}
+
+	E.Emit("forge_processor_start", progress)
+
+	output := &outputLines{index: index, E: E}
+	cmd := exec.Command(r.opts.JavaPath, args...)
+	cmd.Dir = r.workDir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		if len(output.partial) > 0 {
+			output.line(string(output.partial))
+		}
+		return fmt.Errorf("processor %s failed: %w\n%s", p.Jar, err, strings.Join(output.tail, "\n"))
+	}
+
+	// Make sure the step produced what it declared
+	for path, expected := range outputs {
+		if actual := sha1OfFile(path); actual != expected {
+			os.Remove(path)
+			return fmt.Errorf("processor %s produced %s with SHA1 %q, expected %s", p.Jar, path, actual, expected)
+		}
+	}
+
+	E.Emit("forge_processor_done", progress)
+	return nil
+}
+
+// outputsValid reports whether every output exists with its expected SHA1.
+func outputsValid(outputs map[string]string) bool {
+	for path, expected := range outputs {
+		if sha1OfFile(path) != expected {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,329 @@
+package forge
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// InstallProfile is the install_profile.json of a Forge or NeoForge installer (spec 1+).
+type InstallProfile struct {
+	Spec      int    `json:"spec"`
+	Profile   string `json:"profile"`
+	Version   string `json:"version"`
+	Minecraft string `json:"minecraft"`
+	// JSON is the path of the version JSON inside the installer, e.g. "/version.json".
+	JSON string `json:"json"`
+	// ServerJarPath is where server installs keep the vanilla server JAR; it may use variables.
+	// Empty means <root>/minecraft_server.<minecraft>.jar.
+	ServerJarPath string `json:"serverJarPath"`
+	// Data holds the variables processors refer to as {NAME}, per side.
+	Data       map[string]DataEntry `json:"data"`
+	Processors []Processor          `json:"processors"`
+	Libraries  []Library            `json:"libraries"`
+}
+
+// DataEntry is the client and server value of an install profile variable.
+type DataEntry struct {
+	Client string `json:"client"`
+	Server string `json:"server"`
+}
+
+// Processor is one installer step: a Java program run with the given classpath and arguments.
+type Processor struct {
+	// Jar is the Maven coordinate of the processor, whose manifest names the main class.
+	Jar       string   `json:"jar"`
+	Classpath []string `json:"classpath"`
+	Args      []string `json:"args"`
+	// Outputs maps produced files to their expected SHA1; both may use variables.
+	Outputs map[string]string `json:"outputs"`
+	// Sides restricts the processor to "client" and/or "server"; empty runs on both.
+	Sides []string `json:"sides"`
+}
+
+// Library is a library needed by the processors.
+type Library struct {
+	Name      string `json:"name"`
+	Downloads struct {
+		Artifact struct {
+			Path string `json:"path"`
+			URL  string `json:"url"`
+			SHA1 string `json:"sha1"`
+		} `json:"artifact"`
+	} `json:"downloads"`
+}
+
+// ------------------ Profile ------------------
+
+// readZipEntry returns the contents of a file inside a zip archive.
+func readZipEntry(archive *zip.ReadCloser, name string) ([]byte, error) {
+	name = strings.TrimPrefix(name, "/")
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// LoadInstallProfile reads install_profile.json from a Forge or NeoForge installer JAR.
+func LoadInstallProfile(installerJar string) (*InstallProfile, error) {
+	archive, err := zip.OpenReader(installerJar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open installer: %w", err)
+	}
+	defer archive.Close()
+
+	data, err := readZipEntry(archive, "install_profile.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install profile: %w", err)
+	}
+
+	var profile InstallProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse install profile: %w", err)
+	}
+	if profile.Spec < 1 {
+		return nil, fmt.Errorf("legacy installer profiles (spec 0) have no processors")
+	}
+	return &profile, nil
+}
+
+// fetchLibraries downloads libs into libDir, extracting those without a URL from the maven/
+// directory of the installer archive. With optional, libraries that are neither downloadable
+// nor bundled are skipped instead of failing, as processors produce them. Files are journaled in
+// the transaction of ctx.
+func fetchLibraries(ctx context.Context, libs []Library, archive *zip.ReadCloser, libDir string, optional bool, E *events.EventEmitter) error {
+	for _, lib := range libs {
+		artifactPath := lib.Downloads.Artifact.Path
+		if artifactPath == "" {
+			artifactPath = downloader.MavenPath(lib.Name)
+		}
+		if artifactPath == "" {
+			continue
+		}
+		path := filepath.Join(libDir, filepath.FromSlash(artifactPath))
+
+		if lib.Downloads.Artifact.URL != "" {
+			if err := downloader.DownloadLibraryFileContext(ctx, path, lib.Downloads.Artifact.URL, artifactPath, lib.Downloads.Artifact.SHA1, E); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Bundled in the installer under maven/
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		data, err := readZipEntry(archive, "maven/"+artifactPath)
+		if err != nil && optional {
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("library %s is neither downloadable nor bundled: %w", lib.Name, err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+			return err
+		}
+		downloader.Track(ctx, path)
+		if err := os.WriteFile(path, data, utils.Modes.File); err != nil {
+			return err
+		}
+		E.Emit("file_extracted", path)
+	}
+	return nil
+}
+
+// DownloadProfileLibraries downloads the libraries the processors need into <mcDir>/libraries.
+// Libraries shipped inside the installer (empty URL) are extracted from installerJar.
+func DownloadProfileLibraries(profile *InstallProfile, installerJar, mcDir string, E *events.EventEmitter) error {
+	archive, err := zip.OpenReader(installerJar)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to open installer: %w", err)))
+		return err
+	}
+	defer archive.Close()
+
+	return fetchLibraries(context.Background(), profile.Libraries, archive, utils.NewLayout(mcDir).LibrariesDir(), false, E)
+}
+
+// versionJSON reads the version JSON of profile from the installer archive.
+func versionJSON(profile *InstallProfile, archive *zip.ReadCloser) ([]byte, error) {
+	name := strings.TrimPrefix(profile.JSON, "/")
+	if name == "" {
+		name = "version.json"
+	}
+	return readZipEntry(archive, name)
+}
+
+// librariesPresent reports whether every library of libs exists below libDir.
+func librariesPresent(libs []Library, libDir string) bool {
+	for _, lib := range libs {
+		artifactPath := lib.Downloads.Artifact.Path
+		if artifactPath == "" {
+			artifactPath = downloader.MavenPath(lib.Name)
+		}
+		if artifactPath == "" {
+			continue
+		}
+		if _, err := os.Stat(utils.LongPath(filepath.Join(libDir, filepath.FromSlash(artifactPath)))); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// IsProfileInstalled reports whether the loader version of profile is installed for opts.Side
+// in opts.MCDir, so callers can skip the installer when it returns true:
+//
+//   - client: its version JSON exists in versions/, the libraries of the profile and of the
+//     version JSON are present and downloader.IsVersionInstalled finds the Minecraft version
+//     installed;
+//   - server: the libraries of the profile and of the version JSON in opts.InstallerJar are
+//     present and so is the vanilla server JAR.
+//
+// Processor outputs are not checked here; RunProcessors itself skips processors whose outputs
+// are in place.
+func IsProfileInstalled(profile *InstallProfile, opts ProcessorOptions) bool {
+	if profile.Version == "" {
+		return false
+	}
+	layout := utils.NewLayout(opts.MCDir)
+
+	var data []byte
+	var err error
+	if opts.Side == "server" {
+		archive, openErr := zip.OpenReader(opts.InstallerJar)
+		if openErr != nil {
+			return false
+		}
+		data, err = versionJSON(profile, archive)
+		archive.Close()
+	} else {
+		data, err = os.ReadFile(layout.VersionJSON(profile.Version))
+	}
+	if err != nil {
+		return false
+	}
+	var version struct {
+		Libraries []Library `json:"libraries"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return false
+	}
+	if !librariesPresent(append(append([]Library{}, profile.Libraries...), version.Libraries...), layout.LibrariesDir()) {
+		return false
+	}
+
+	if opts.Side != "server" {
+		return downloader.IsVersionInstalled(profile.Minecraft, opts.MCDir)
+	}
+	serverJar := filepath.Join(opts.MCDir, "minecraft_server."+profile.Minecraft+".jar")
+	if profile.ServerJarPath != "" {
+		serverJar = strings.NewReplacer(
+			"{LIBRARY_DIR}", layout.LibrariesDir(),
+			"{MINECRAFT_VERSION}", profile.Minecraft,
+			"{ROOT}", opts.MCDir,
+		).Replace(profile.ServerJarPath)
+	}
+	_, err = os.Stat(serverJar)
+	return err == nil
+}
+
+// Install installs the client of a Forge or NeoForge installer into opts.MCDir without running
+// the installer's UI: it installs the vanilla base version when missing, writes the loader's
+// version JSON, downloads the libraries of the profile and of the version JSON, and runs the
+// client processors. Everything is journaled in a transaction, so a failed install is rolled
+// back. When IsProfileInstalled finds the loader version installed, nothing is done and
+// forge_already_installed is emitted, so automation can safely run the install again.
+func Install(opts ProcessorOptions, E *events.EventEmitter) error {
+	opts.Side = "client"
+	profile, err := LoadInstallProfile(opts.InstallerJar)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	if IsProfileInstalled(profile, opts) {
+		E.Emit("forge_already_installed", profile.Version)
+		return nil
+	}
+	E.Emit("forge_install_start", profile.Version)
+	fail := func(err error) error {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	err = downloader.RunLockedTransaction(context.Background(), nil, opts.MCDir, "forge-"+profile.Version, func(ctx context.Context, lock *downloader.Lock) error {
+		if downloader.IsVersionInstalled(profile.Minecraft, opts.MCDir) {
+			E.Emit("base_version_present", profile.Minecraft)
+		} else {
+			install, err := downloader.InstallVersionWithOptions(profile.Minecraft, opts.MCDir, downloader.VersionOptions{Lock: lock, Context: ctx}, E)
+			if err != nil {
+				return err
+			}
+			install.Wait()
+		}
+
+		archive, err := zip.OpenReader(opts.InstallerJar)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open installer: %w", err))
+		}
+		defer archive.Close()
+
+		data, err := versionJSON(profile, archive)
+		if err != nil {
+			return fail(fmt.Errorf("failed to read version JSON from installer: %w", err))
+		}
+		var version struct {
+			Libraries []Library `json:"libraries"`
+		}
+		if err := json.Unmarshal(data, &version); err != nil {
+			return fail(fmt.Errorf("invalid version JSON in installer: %w", err))
+		}
+		path := utils.NewLayout(opts.MCDir).VersionJSON(profile.Version)
+		if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+			return fail(err)
+		}
+		downloader.Track(ctx, path)
+		if err := os.WriteFile(path, data, utils.Modes.File); err != nil {
+			return fail(err)
+		}
+
+		libDir := utils.NewLayout(opts.MCDir).LibrariesDir()
+		if err := fetchLibraries(ctx, profile.Libraries, archive, libDir, false, E); err != nil {
+			return err
+		}
+		// The patched client libraries of the version JSON are produced by the processors
+		if err := fetchLibraries(ctx, version.Libraries, archive, libDir, true, E); err != nil {
+			return err
+		}
+		return RunProcessors(profile, opts, E)
+	}, E)
+	if err != nil {
+		E.Emit("forge_install_failed", profile.Version)
+		return err
+	}
+
+	E.Emit("forge_install_done", profile.Version)
+	return nil
+}
@@ -0,0 +1,327 @@
+// Package history records install, launch and repair jobs with their timing and outcome in a
+// small append-only store, so launchers can show past activity and spot recurring failures.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// File is the name of the store in a launcher's data directory.
+const File = "job-history.jsonl"
+
+// DefaultMaxRecords is how many records a store keeps before dropping the oldest.
+const DefaultMaxRecords = 1000
+
+// maxErrorLength caps the error summary stored with a record.
+const maxErrorLength = 300
+
+// Job kinds recorded by this core. Any other kind may be recorded as well.
+const (
+	KindInstall = "install"
+	KindLaunch  = "launch"
+	KindRepair  = "repair"
+)
+
+// Outcomes of a job.
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailure   = "failure"
+	OutcomeCancelled = "cancelled"
+)
+
+// ------------------ Structs ------------------
+
+// Record is one finished job.
+type Record struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Target is what the job worked on, e.g. a version ID or instance ID.
+	Target   string        `json:"target"`
+	Started  time.Time     `json:"started"`
+	Ended    time.Time     `json:"ended"`
+	Duration time.Duration `json:"duration"`
+	Outcome  string        `json:"outcome"`
+	// Error is the first line of the error of failed jobs, shortened.
+	Error string `json:"error,omitempty"`
+	// Details holds job-specific values, e.g. an exit code.
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Filter selects records in Query. Zero fields match everything.
+type Filter struct {
+	Kind    string
+	Target  string
+	Outcome string
+	Since   time.Time
+	Until   time.Time
+	// Limit returns only the newest records; zero returns all.
+	Limit int
+}
+
+// Failure is a group of failed jobs with the same kind, target and error.
+type Failure struct {
+	Kind   string    `json:"kind"`
+	Target string    `json:"target"`
+	Error  string    `json:"error"`
+	Count  int       `json:"count"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+}
+
+// Store is a job history kept in one JSON-lines file.
+type Store struct {
+	Path string
+	// MaxRecords is how many records are kept; older ones are dropped when the store grows
+	// past it. Zero means DefaultMaxRecords.
+	MaxRecords int
+
+	mu      sync.Mutex
+	records []Record
+	// lines counts the lines of the file, which holds dropped records until it is compacted.
+	lines int
+}
+
+// Job is a job being recorded; finish it with Finish.
+type Job struct {
+	Record
+	store *Store
+}
+
+// ------------------ Store ------------------
+
+// Open loads the store at path, creating its directory. A missing file is an empty history;
+// unreadable lines are skipped.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	s := &Store{Path: path}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.lines++
+		var record Record
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			s.records = append(s.records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job history: %w", err)
+	}
+	s.trim()
+	return s, nil
+}
+
+// maxRecords returns MaxRecords or its default.
+func (s *Store) maxRecords() int {
+	if s.MaxRecords > 0 {
+		return s.MaxRecords
+	}
+	return DefaultMaxRecords
+}
+
+// trim drops the oldest records in memory beyond MaxRecords.
+func (s *Store) trim() {
+	if extra := len(s.records) - s.maxRecords(); extra > 0 {
+		s.records = s.records[extra:]
+	}
+}
+
+// Add appends a record. Records beyond MaxRecords are dropped from memory at once, but the
+// file is only compacted once it holds twice as many lines, so it is not rewritten on every Add.
+func (s *Store) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record.ID == "" {
+		record.ID = strconv.FormatInt(record.Started.UnixNano(), 36)
+	}
+	if record.Duration == 0 && !record.Ended.IsZero() {
+		record.Duration = record.Ended.Sub(record.Started)
+	}
+	s.records = append(s.records, record)
+	s.trim()
+
+	if s.lines+1 > 2*s.maxRecords() {
+		return s.rewrite()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, utils.Modes.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.lines++
+	return nil
+}
+
+// rewrite replaces the file with the records in memory.
+func (s *Store) rewrite() error {
+	var buf strings.Builder
+	for _, record := range s.records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(s.Path+".tmp", []byte(buf.String()), utils.Modes.File); err != nil {
+		return err
+	}
+	if err := os.Rename(s.Path+".tmp", s.Path); err != nil {
+		return err
+	}
+	s.lines = len(s.records)
+	return nil
+}
+
+// matches reports whether a record passes the filter, ignoring Limit.
+func (f Filter) matches(r Record) bool {
+	return (f.Kind == "" || r.Kind == f.Kind) &&
+		(f.Target == "" || r.Target == f.Target) &&
+		(f.Outcome == "" || r.Outcome == f.Outcome) &&
+		(f.Since.IsZero() || !r.Started.Before(f.Since)) &&
+		(f.Until.IsZero() || r.Started.Before(f.Until))
+}
+
+// Query returns the records matching the filter, newest first.
+func (s *Store) Query(filter Filter) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Record
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if filter.matches(s.records[i]) {
+			out = append(out, s.records[i])
+			if filter.Limit > 0 && len(out) == filter.Limit {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// RecurringFailures groups the failed jobs matching filter by kind, target and error, and
+// returns the groups with at least min failures, most frequent first.
+func (s *Store) RecurringFailures(filter Filter, min int) []Failure {
+	filter.Outcome, filter.Limit = OutcomeFailure, 0
+	groups := map[string]*Failure{}
+	for _, r := range s.Query(filter) {
+		key := r.Kind + "\x00" + r.Target + "\x00" + r.Error
+		g, ok := groups[key]
+		if !ok {
+			g = &Failure{Kind: r.Kind, Target: r.Target, Error: r.Error, First: r.Started, Last: r.Started}
+			groups[key] = g
+		}
+		g.Count++
+		if r.Started.Before(g.First) {
+			g.First = r.Started
+		}
+		if r.Started.After(g.Last) {
+			g.Last = r.Started
+		}
+	}
+
+	var failures []Failure
+	for _, g := range groups {
+		if g.Count >= min {
+			failures = append(failures, *g)
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Count != failures[j].Count {
+			return failures[i].Count > failures[j].Count
+		}
+		return failures[i].Last.After(failures[j].Last)
+	})
+	return failures
+}
+
+// ------------------ Jobs ------------------
+
+// summarize returns the first line of an error, shortened for storage without splitting a
+// UTF-8 character.
+func summarize(err error) string {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	if len(msg) > maxErrorLength {
+		cut := maxErrorLength
+		for cut > 0 && !utf8.RuneStart(msg[cut]) {
+			cut--
+		}
+		msg = msg[:cut] + "…"
+	}
+	return msg
+}
+
+// Begin starts recording a job of the given kind on target.
+func (s *Store) Begin(kind, target string) *Job {
+	return &Job{Record: Record{Kind: kind, Target: target, Started: time.Now()}, store: s}
+}
+
+// Finish records the job with the outcome of err: success for nil, failure otherwise.
+func (j *Job) Finish(err error, E *events.EventEmitter) error {
+	j.Outcome = OutcomeSuccess
+	if err != nil {
+		j.Outcome, j.Error = OutcomeFailure, summarize(err)
+	}
+	return j.finish(E)
+}
+
+// Cancel records the job as cancelled.
+func (j *Job) Cancel(E *events.EventEmitter) error {
+	j.Outcome = OutcomeCancelled
+	return j.finish(E)
+}
+
+// finish stores the job and reports it with job_recorded.
+func (j *Job) finish(E *events.EventEmitter) error {
+	j.Ended = time.Now()
+	j.Duration = j.Ended.Sub(j.Started)
+	if err := j.store.Add(j.Record); err != nil {
+		err = fmt.Errorf("failed to record job: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	E.Emit("job_recorded", j.Record)
+	return nil
+}
+
+// Run records fn as a job of the given kind on target and returns its error.
+func (s *Store) Run(kind, target string, fn func() error, E *events.EventEmitter) error {
+	job := s.Begin(kind, target)
+	err := fn()
+	_ = job.Finish(err, E)
+	return err
+}
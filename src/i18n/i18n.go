@@ -0,0 +1,220 @@
+// Package i18n gives user-facing messages of the core stable keys and lets frontends translate
+// them with a pluggable Translator. Messages default to English.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ------------------ Interface ------------------
+
+// Translator localizes messages by key. args fill "{name}" placeholders. It returns false
+// for keys it has no translation for, which then fall back to English.
+type Translator interface {
+	Translate(key string, args map[string]any) (string, bool)
+}
+
+// Catalog is a Translator backed by a map from key to message, e.g. loaded from a locale file.
+type Catalog map[string]string
+
+// Translate looks up key and fills its placeholders.
+func (c Catalog) Translate(key string, args map[string]any) (string, bool) {
+	message, ok := c[key]
+	if !ok {
+		return "", false
+	}
+	return format(message, args), true
+}
+
+// LoadCatalog reads a catalog from a JSON object of key to message.
+func LoadCatalog(r io.Reader) (Catalog, error) {
+	var catalog Catalog
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse message catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// ------------------ Global Translator ------------------
+
+var (
+	current Translator
+	english = Catalog{}
+	mu      sync.RWMutex
+)
+
+// Set installs the Translator used by Translate and Localize. Passing nil restores English.
+func Set(t Translator) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = t
+}
+
+// English returns a copy of the English messages of every key registered so far, as a
+// template for translators.
+func English() Catalog {
+	mu.RLock()
+	defer mu.RUnlock()
+	catalog := make(Catalog, len(english))
+	for key, message := range english {
+		catalog[key] = message
+	}
+	return catalog
+}
+
+// Register adds the English message of a key. Packages register their keys when they define
+// them; New does it for errors.
+func Register(key, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+	english[key] = message
+}
+
+// format replaces the "{name}" placeholders of message.
+func format(message string, args map[string]any) string {
+	for name, value := range args {
+		message = strings.ReplaceAll(message, "{"+name+"}", fmt.Sprint(value))
+	}
+	return message
+}
+
+// Translate returns the message of key in the installed Translator's language, in English when
+// it has none, or the key itself when the key is unknown.
+func Translate(key string, args map[string]any) string {
+	mu.RLock()
+	t, message, ok := current, english[key], false
+	mu.RUnlock()
+	if t != nil {
+		var translated string
+		if translated, ok = t.Translate(key, args); ok {
+			return translated
+		}
+	}
+	if message == "" {
+		return key
+	}
+	return format(message, args)
+}
+
+// ------------------ Errors ------------------
+
+// Error is an error with a message key. Its Error method returns English; Localize returns
+// the translation.
+type Error struct {
+	Key     string
+	English string
+	Args    map[string]any
+}
+
+// New returns a keyed error and registers its English message, which may contain "{name}"
+// placeholders filled by With.
+func New(key, english string) *Error {
+	Register(key, english)
+	return &Error{Key: key, English: english}
+}
+
+// Error returns the English message.
+func (e *Error) Error() string {
+	return format(e.English, e.Args)
+}
+
+// With returns a copy of the error with placeholder values. The copy still matches the
+// original with errors.Is.
+func (e *Error) With(args map[string]any) *Error {
+	return &Error{Key: e.Key, English: e.English, Args: args}
+}
+
+// Is reports whether target is a keyed error with the same key.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Key == e.Key
+}
+
+// WithArgs returns err with placeholder values when it is a keyed error, e.g. a sentinel declared
+// as an error, and err itself otherwise.
+func WithArgs(err error, args map[string]any) error {
+	if keyed, ok := err.(*Error); ok {
+		return keyed.With(args)
+	}
+	return err
+}
+
+// Localize returns the translated message of the keyed error in err's chain, or err.Error()
+// when the chain has none. Context added by wrapping is dropped from translated messages, as
+// it is English.
+func Localize(err error) string {
+	if err == nil {
+		return ""
+	}
+	var keyed *Error
+	if !errors.As(err, &keyed) {
+		return err.Error()
+	}
+	return Translate(keyed.Key, keyed.Args)
+}
+
+// ErrorPayload is the payload of "error" events. Message is the English text of the whole
+// error; Key and Args are those of the keyed error in its chain, empty when it has none, so
+// frontends can show Translate(Key, Args) instead.
+type ErrorPayload struct {
+	Message string         `json:"message"`
+	Key     string         `json:"key,omitempty"`
+	Args    map[string]any `json:"args,omitempty"`
+}
+
+// ErrorEvent returns the payload of the "error" event reporting err.
+func ErrorEvent(err error) ErrorPayload {
+	payload := ErrorPayload{Message: err.Error()}
+	var keyed *Error
+	if errors.As(err, &keyed) {
+		payload.Key, payload.Args = keyed.Key, keyed.Args
+	}
+	return payload
+}
+
+// String returns the English message, so handlers printing the payload show the text.
+func (p ErrorPayload) String() string {
+	return p.Message
+}
+
+// ------------------ Events ------------------
+
+// EventKey returns the message key of an event name, e.g. "event.playable", whose message is
+// a short description of the event for progress displays.
+func EventKey(event string) string {
+	return "event." + event
+}
+
+// DescribeEvent returns the translated description of an event, or the event name when none
+// is registered.
+func DescribeEvent(event string) string {
+	key := EventKey(event)
+	if message := Translate(key, nil); message != key {
+		return message
+	}
+	return event
+}
+
+// init registers the descriptions of the main progress events.
+func init() {
+	for event, message := range map[string]string{
+		"version_download_start":   "Fetching version metadata",
+		"client_download_start":    "Downloading the game",
+		"library_download_start":   "Downloading libraries",
+		"asset_download_start":     "Downloading assets",
+		"playable":                 "Ready to play",
+		"version_downloaded":       "Download complete",
+		"launch_preparation_start": "Preparing launch",
+		"extracting_natives_start": "Extracting native libraries",
+		"launching_game":           "Launching the game",
+		"transaction_rolled_back":  "Install failed and was rolled back",
+		"game_oom":                 "The game ran out of memory",
+	} {
+		Register(EventKey(event), message)
+	}
+}
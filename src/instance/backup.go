@@ -0,0 +1,334 @@
+package instance
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// BackupsDir is the directory inside an instance holding its backups.
+const BackupsDir = "backups"
+
+// DefaultContentBackupKeep is how many content backups SetVersion keeps.
+const DefaultContentBackupKeep = 5
+
+// contentDirs are the instance directories archived by BackupContent.
+var contentDirs = []string{"mods", "config"}
+
+// contentBackupPrefix starts the file name of every content backup.
+const contentBackupPrefix = "content-"
+
+// backupTimeFormat is the timestamp in backup file names; it sorts chronologically.
+const backupTimeFormat = "20060102-150405.000"
+
+// Backup is an archive in the backups directory of an instance.
+type Backup struct {
+	// Name is the file name of the archive.
+	Name string
+	Path string
+	// Reason is the label given when the backup was made, e.g. "modpack-update".
+	Reason  string
+	Created time.Time
+	Size    int64
+}
+
+// ------------------ Archive Helpers ------------------
+
+// zipDirs writes the given directories of root into a new zip archive at dest. Missing
+// directories are skipped. The archive is written to a temporary file first so an
+// interrupted backup never looks complete.
+func zipDirs(dest, root string, dirs []string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), utils.Modes.Dir); err != nil {
+		return 0, err
+	}
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	zw := zip.NewWriter(out)
+	for _, dir := range dirs {
+		err = filepath.Walk(filepath.Join(root, dir), func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() && !info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+			if info.IsDir() {
+				header.Name += "/"
+				_, err = zw.CreateHeader(header)
+				return err
+			}
+			header.Method = zip.Deflate
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			in, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			_, err = io.Copy(w, in)
+			return err
+		})
+		if err != nil {
+			break
+		}
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp, dest)
+}
+
+// unzipTo extracts an archive into dest, rejecting entries that would escape it.
+func unzipTo(archive, dest string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target := filepath.Join(dest, filepath.FromSlash(f.Name))
+		if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %q escapes the destination", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, utils.Modes.Dir); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes a single archive entry to target.
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), utils.Modes.Dir); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, utils.Modes.File)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// listBackups returns the backups whose name starts with prefix, newest first.
+func listBackups(dir, prefix string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".zip")
+		reason := ""
+		if len(stamp) > len(backupTimeFormat) {
+			stamp, reason = stamp[:len(backupTimeFormat)], strings.TrimPrefix(stamp[len(backupTimeFormat):], "-")
+		}
+		created, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Name:    name,
+			Path:    filepath.Join(dir, name),
+			Reason:  reason,
+			Created: created,
+			Size:    info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// backupName returns the file name of a new backup.
+func backupName(prefix, reason string, now time.Time) string {
+	name := prefix + now.Format(backupTimeFormat)
+	if reason = sanitizeReason(reason); reason != "" {
+		name += "-" + reason
+	}
+	return name + ".zip"
+}
+
+// sanitizeReason keeps a backup reason usable in a file name.
+func sanitizeReason(reason string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ' || r == '.':
+			return '-'
+		}
+		return -1
+	}, reason)
+}
+
+// ------------------ Content Backups ------------------
+
+// BackupsPath returns the backups directory of the instance.
+func (i *Instance) BackupsPath() string {
+	return filepath.Join(i.dir, BackupsDir)
+}
+
+// BackupContent archives the mods and config directories of the instance into
+// backups/content-<timestamp>-<reason>.zip and then deletes all but the newest keep
+// content backups (keep <= 0 keeps every backup). It is meant to run before a modpack
+// update or a loader change; see SetVersion.
+func (i *Instance) BackupContent(reason string, keep int, E *events.EventEmitter) (*Backup, error) {
+	name := backupName(contentBackupPrefix, reason, time.Now())
+	path := filepath.Join(i.BackupsPath(), name)
+
+	E.Emit("backup_started", map[string]string{"instance": i.ID, "kind": "content", "path": path})
+	size, err := zipDirs(path, i.dir, contentDirs)
+	if err != nil {
+		err = fmt.Errorf("failed to back up instance content: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	backups, _ := listBackups(i.BackupsPath(), contentBackupPrefix)
+	backup := &Backup{Name: name, Path: path, Reason: sanitizeReason(reason), Created: time.Now(), Size: size}
+	for _, b := range backups {
+		if b.Name == name {
+			backup = &b
+			break
+		}
+	}
+	E.Emit("backup_completed", backup)
+
+	if keep > 0 {
+		for _, old := range backups[min(keep, len(backups)):] {
+			if err := os.Remove(old.Path); err == nil {
+				E.Emit("backup_pruned", old.Path)
+			}
+		}
+	}
+	return backup, nil
+}
+
+// ContentBackups lists the content backups of the instance, newest first.
+func (i *Instance) ContentBackups() ([]Backup, error) {
+	return listBackups(i.BackupsPath(), contentBackupPrefix)
+}
+
+// RestoreContent replaces the mods and config directories of the instance with those of a
+// content backup, given by its Name. The backup is extracted completely before the current
+// directories are replaced, so a damaged archive leaves the instance untouched.
+func (i *Instance) RestoreContent(name string, E *events.EventEmitter) error {
+	if filepath.Base(name) != name || !strings.HasPrefix(name, contentBackupPrefix) {
+		return fmt.Errorf("invalid content backup %q", name)
+	}
+	archive := filepath.Join(i.BackupsPath(), name)
+
+	staging, err := os.MkdirTemp(i.dir, ".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := unzipTo(archive, staging); err != nil {
+		err = fmt.Errorf("failed to extract backup %s: %w", name, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	for _, dir := range contentDirs {
+		target := filepath.Join(i.dir, dir)
+		if err := os.RemoveAll(target); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(staging, dir)); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(staging, dir), target); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+	}
+
+	E.Emit("backup_restored", map[string]string{"instance": i.ID, "backup": name})
+	return nil
+}
+
+// SetVersion changes the version launched by the instance, e.g. when switching loaders, and
+// saves it. When the version actually changes, mods and config are backed up first with
+// BackupContent, keeping DefaultContentBackupKeep backups.
+func (i *Instance) SetVersion(version string, E *events.EventEmitter) error {
+	if version == i.Version {
+		return nil
+	}
+	if i.Version != "" {
+		if _, err := i.BackupContent("version-change", DefaultContentBackupKeep, E); err != nil {
+			return err
+		}
+	}
+	i.Version = version
+	return i.Save()
+}
@@ -0,0 +1,176 @@
+package instance
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Bulk operations, as reported in bulk events.
+const (
+	BulkSetVersion = "set_version"
+	BulkVerify     = "verify"
+	BulkBackup     = "backup"
+	BulkDelete     = "delete"
+)
+
+// ------------------ Structs ------------------
+
+// BulkResult is the outcome of a bulk operation on one instance.
+type BulkResult struct {
+	Instance string `json:"instance"`
+	// Err is nil on success.
+	Err error `json:"-"`
+	// Error is Err as text, for serialising reports.
+	Error string `json:"error,omitempty"`
+}
+
+// BulkReport is the outcome of a bulk operation on a group.
+type BulkReport struct {
+	Operation string       `json:"operation"`
+	Group     string       `json:"group"`
+	Results   []BulkResult `json:"results"`
+}
+
+// Failed returns the results of the instances the operation failed on.
+func (r *BulkReport) Failed() []BulkResult {
+	var failed []BulkResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// ------------------ Groups ------------------
+
+// SetGroup moves the instance into a group ("" for none) and saves it.
+func (i *Instance) SetGroup(group string) error {
+	i.Group = strings.TrimSpace(group)
+	return i.Save()
+}
+
+// Groups lists the instances under root by group, with the sorted group names; ungrouped
+// instances are under "". Group names compare case-insensitively and are keyed by their first
+// spelling. Within a group, instances keep the order of List.
+func Groups(root string) (map[string][]*Instance, []string, error) {
+	instances, err := List(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups := map[string][]*Instance{}
+	spelling := map[string]string{}
+	var names []string
+	for _, inst := range instances {
+		name, ok := spelling[strings.ToLower(inst.Group)]
+		if !ok {
+			name = inst.Group
+			spelling[strings.ToLower(name)] = name
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], inst)
+	}
+	sort.Strings(names)
+	return groups, names, nil
+}
+
+// InGroup returns the instances of one group, in the order of List. Group names compare
+// case-insensitively, so "Servers" and "servers" are the same group.
+func InGroup(root, group string) ([]*Instance, error) {
+	instances, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	var members []*Instance
+	for _, inst := range instances {
+		if strings.EqualFold(inst.Group, group) {
+			members = append(members, inst)
+		}
+	}
+	return members, nil
+}
+
+// ------------------ Bulk Operations ------------------
+
+// Bulk runs fn on every instance of a group, one after another, and collects the results. A
+// failure does not stop the remaining instances. Progress is reported with bulk_start,
+// bulk_progress (after each instance) and bulk_done, all carrying the operation name.
+func Bulk(root, group, operation string, fn func(inst *Instance) error, E *events.EventEmitter) (*BulkReport, error) {
+	members, err := InGroup(root, group)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	report := &BulkReport{Operation: operation, Group: group}
+	E.Emit("bulk_start", map[string]any{"operation": operation, "group": group, "total": len(members)})
+	for n, inst := range members {
+		result := BulkResult{Instance: inst.ID}
+		if err := fn(inst); err != nil {
+			result.Err, result.Error = err, err.Error()
+		}
+		report.Results = append(report.Results, result)
+		E.Emit("bulk_progress", map[string]any{
+			"operation": operation, "instance": inst.ID, "done": n + 1, "total": len(members), "error": result.Error,
+		})
+	}
+
+	failed := len(report.Failed())
+	E.Emit("bulk_done", map[string]any{
+		"operation": operation, "group": group, "succeeded": len(members) - failed, "failed": failed,
+	})
+	return report, nil
+}
+
+// SetGroupVersion changes the version of every instance of a group, e.g. to move a group of
+// packs to a new loader release. version maps the current version ID of an instance to the
+// new one, which must already be installed; returning the current one leaves it unchanged.
+// Content is backed up first as by SetVersion.
+func SetGroupVersion(root, group string, version func(inst *Instance) (string, error), E *events.EventEmitter) (*BulkReport, error) {
+	return Bulk(root, group, BulkSetVersion, func(inst *Instance) error {
+		next, err := version(inst)
+		if err != nil {
+			return err
+		}
+		return inst.SetVersion(next, E)
+	}, E)
+}
+
+// VerifyGroup verifies every instance of a group against the ChecksumManifestFile in its
+// directory. Instances without a manifest or with differences count as failed.
+func VerifyGroup(root, group string, E *events.EventEmitter) (*BulkReport, error) {
+	return Bulk(root, group, BulkVerify, func(inst *Instance) error {
+		manifest, err := ReadChecksumManifest(filepath.Join(inst.Dir(), ChecksumManifestFile))
+		if err != nil {
+			return err
+		}
+		report, err := inst.Verify(manifest, E)
+		if err != nil {
+			return err
+		}
+		if !report.OK() {
+			return fmt.Errorf("%d missing, %d modified and %d extra files", len(report.Missing), len(report.Modified), len(report.Extra))
+		}
+		return nil
+	}, E)
+}
+
+// BackupGroup backs up the content of every instance of a group (see BackupContent).
+func BackupGroup(root, group, reason string, keep int, E *events.EventEmitter) (*BulkReport, error) {
+	return Bulk(root, group, BulkBackup, func(inst *Instance) error {
+		_, err := inst.BackupContent(reason, keep, E)
+		return err
+	}, E)
+}
+
+// DeleteGroup deletes every instance of a group with its saves and backups.
+func DeleteGroup(root, group string, E *events.EventEmitter) (*BulkReport, error) {
+	return Bulk(root, group, BulkDelete, func(inst *Instance) error {
+		return inst.Delete(E)
+	}, E)
+}
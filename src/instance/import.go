@@ -0,0 +1,393 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// OfficialInstall lists what an official-launcher .minecraft directory contains.
+type OfficialInstall struct {
+	Dir           string
+	Versions      []string
+	Saves         []string
+	ResourcePacks []string
+}
+
+// ImportOptions selects what to migrate from an official .minecraft into an instance.
+// Nil selections import nothing of that kind; use the names from ScanOfficial.
+type ImportOptions struct {
+	// Source is the .minecraft to import from; empty uses the platform default.
+	Source string
+	// Versions are copied into the shared installation root, together with the versions they inherit from.
+	Versions []string
+	// Saves and ResourcePacks are imported into the instance directory.
+	Saves         []string
+	ResourcePacks []string
+	// Options also imports options.txt (key bindings, video settings).
+	Options bool
+	// Link creates symbolic links instead of copies, keeping a single copy shared with the official launcher.
+	Link bool
+}
+
+// ImportReport describes what was migrated.
+type ImportReport struct {
+	Versions []string
+	// Libraries counts the library files of the imported versions copied from the source.
+	Libraries     int
+	Saves         []string
+	ResourcePacks []string
+	Options       bool
+	// Skipped lists items that already existed in the destination or could not be found.
+	Skipped []string
+	Files   int
+	Bytes   int64
+}
+
+// ------------------ Detection ------------------
+
+// DetectOfficial returns the official launcher's .minecraft directory if one exists.
+func DetectOfficial() (string, bool) {
+	dir := utils.DefaultMCDir()
+	for _, marker := range []string{"launcher_profiles.json", "versions"} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// listDirs returns the names of the subdirectories of dir.
+func listDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// ScanOfficial lists the versions, saves and resource packs of a .minecraft directory,
+// so a frontend can let the user pick what to import.
+func ScanOfficial(source string) (*OfficialInstall, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", source, err)
+	}
+
+	install := &OfficialInstall{Dir: source, Saves: listDirs(filepath.Join(source, "saves"))}
+	for _, id := range listDirs(utils.NewLayout(source).VersionsDir()) {
+		if _, err := os.Stat(utils.NewLayout(source).VersionJSON(id)); err == nil {
+			install.Versions = append(install.Versions, id)
+		}
+	}
+
+	// Resource packs may be folders or zip files
+	entries, _ := os.ReadDir(filepath.Join(source, "resourcepacks"))
+	for _, entry := range entries {
+		install.ResourcePacks = append(install.ResourcePacks, entry.Name())
+	}
+	return install, nil
+}
+
+// ------------------ Copy Helpers ------------------
+
+// copyTree copies a file or directory tree from src to dst and returns the number of files and bytes copied.
+// Symbolic links inside the tree are skipped.
+func copyTree(src, dst string) (int, int64, error) {
+	files := 0
+	var size int64
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, utils.Modes.Dir)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+	return files, size, err
+}
+
+// copyFile copies a single regular file, creating parent directories.
+func copyFile(src, dst string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// ------------------ Import ------------------
+
+// validateItemName rejects names of versions, saves and resource packs that are not a single
+// clean path element, so an import never reads or writes outside their directories.
+func validateItemName(kind, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+// validLibraryPath reports whether rel is a clean relative path that stays inside the libraries directory.
+func validLibraryPath(rel string) bool {
+	return rel != "" && !path.IsAbs(rel) && !strings.ContainsAny(rel, `\:`) &&
+		path.Clean(rel) == rel && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// versionLibraries returns the library paths, relative to the libraries directory, that the
+// version JSON of id in source declares: artifacts, classifiers, and Maven coordinates of
+// libraries without downloads.
+func versionLibraries(source, id string) []string {
+	data, err := os.ReadFile(utils.NewLayout(source).VersionJSON(id))
+	if err != nil {
+		return nil
+	}
+	var v struct {
+		Libraries []struct {
+			Name      string `json:"name"`
+			Downloads struct {
+				Artifact struct {
+					Path string `json:"path"`
+				} `json:"artifact"`
+				Classifiers map[string]struct {
+					Path string `json:"path"`
+				} `json:"classifiers"`
+			} `json:"downloads"`
+		} `json:"libraries"`
+	}
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, lib := range v.Libraries {
+		if lib.Downloads.Artifact.Path != "" {
+			paths = append(paths, lib.Downloads.Artifact.Path)
+		} else if len(lib.Downloads.Classifiers) == 0 {
+			paths = append(paths, downloader.MavenPath(lib.Name))
+		}
+		for _, classifier := range lib.Downloads.Classifiers {
+			paths = append(paths, classifier.Path)
+		}
+	}
+	return paths
+}
+
+// importItem copies or links src to dst unless dst already exists. It reports whether anything was imported.
+func importItem(src, dst string, link bool, report *ImportReport) (bool, error) {
+	if _, err := os.Stat(src); err != nil {
+		report.Skipped = append(report.Skipped, src)
+		return false, nil
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		report.Skipped = append(report.Skipped, dst)
+		return false, nil
+	}
+
+	if link {
+		if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+			return false, err
+		}
+		absSrc, err := filepath.Abs(src)
+		if err != nil {
+			return false, err
+		}
+		return true, os.Symlink(absSrc, dst)
+	}
+
+	files, size, err := copyTree(src, dst)
+	report.Files += files
+	report.Bytes += size
+	return err == nil, err
+}
+
+// versionWithParents returns the version followed by every version it inherits from.
+func versionWithParents(source, id string) []string {
+	var chain []string
+	for id != "" && len(chain) < 16 {
+		chain = append(chain, id)
+		data, err := os.ReadFile(utils.NewLayout(source).VersionJSON(id))
+		if err != nil {
+			break
+		}
+		var v struct {
+			InheritsFrom string `json:"inheritsFrom"`
+		}
+		if json.Unmarshal(data, &v) != nil {
+			break
+		}
+		id = v.InheritsFrom
+	}
+	return chain
+}
+
+// ImportOfficial migrates the selected parts of an official .minecraft: versions go into the shared
+// installation root so any instance can launch them, together with the library files they declare
+// that the source has; saves, resource packs and options go into the instance. Libraries missing
+// from the source too are not installed: launch with LaunchOptions.DownloadMissingLibraries (or
+// reinstall the version) to download them. Items already present in the destination are left
+// untouched and reported as skipped. Names that are not a single path element are rejected.
+func ImportOfficial(root string, inst *Instance, opts ImportOptions, E *events.EventEmitter) (*ImportReport, error) {
+	source := opts.Source
+	if source == "" {
+		detected, ok := DetectOfficial()
+		if !ok {
+			err := fmt.Errorf("no official .minecraft installation found")
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		source = detected
+	}
+
+	E.Emit("import_start", source)
+	report := &ImportReport{}
+
+	fail := func(err error) (*ImportReport, error) {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return report, err
+	}
+	for kind, names := range map[string][]string{"version": opts.Versions, "save": opts.Saves, "resource pack": opts.ResourcePacks} {
+		for _, name := range names {
+			if err := validateItemName(kind, name); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	// Versions, including their parents so inherited versions stay launchable
+	seen := map[string]bool{}
+	libraries := map[string]bool{}
+	for _, selected := range opts.Versions {
+		for _, id := range versionWithParents(source, selected) {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if err := validateItemName("version", id); err != nil {
+				return fail(err)
+			}
+			imported, err := importItem(utils.NewLayout(source).VersionDir(id), utils.NewLayout(root).VersionDir(id), opts.Link, report)
+			if err != nil {
+				err = fmt.Errorf("failed to import version %s: %w", id, err)
+				E.Emit("error", i18n.ErrorEvent(err))
+				return report, err
+			}
+			if imported {
+				report.Versions = append(report.Versions, id)
+				E.Emit("import_item", map[string]string{"kind": "version", "name": id})
+			}
+			for _, rel := range versionLibraries(source, id) {
+				if validLibraryPath(rel) {
+					libraries[rel] = true
+				}
+			}
+		}
+	}
+
+	// Libraries are shared by every version, so they are copied file by file; ones the source
+	// lacks stay missing until a launch with DownloadMissingLibraries downloads them
+	sourceLibs, rootLibs := utils.NewLayout(source).LibrariesDir(), utils.NewLayout(root).LibrariesDir()
+	// Libraries already installed are expected, not worth reporting as skipped
+	copied := &ImportReport{}
+	for rel := range libraries {
+		src, dst := filepath.Join(sourceLibs, filepath.FromSlash(rel)), filepath.Join(rootLibs, filepath.FromSlash(rel))
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		imported, err := importItem(src, dst, opts.Link, copied)
+		if err != nil {
+			return fail(fmt.Errorf("failed to import library %s: %w", rel, err))
+		}
+		if imported {
+			report.Libraries++
+		}
+	}
+	report.Files += copied.Files
+	report.Bytes += copied.Bytes
+
+	// Worlds and resource packs belong to the instance
+	for _, world := range opts.Saves {
+		imported, err := importItem(filepath.Join(source, "saves", world), filepath.Join(inst.Dir(), "saves", world), opts.Link, report)
+		if err != nil {
+			err = fmt.Errorf("failed to import world %s: %w", world, err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return report, err
+		}
+		if imported {
+			report.Saves = append(report.Saves, world)
+			E.Emit("import_item", map[string]string{"kind": "save", "name": world})
+		}
+	}
+
+	for _, pack := range opts.ResourcePacks {
+		imported, err := importItem(filepath.Join(source, "resourcepacks", pack), filepath.Join(inst.Dir(), "resourcepacks", pack), opts.Link, report)
+		if err != nil {
+			err = fmt.Errorf("failed to import resource pack %s: %w", pack, err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return report, err
+		}
+		if imported {
+			report.ResourcePacks = append(report.ResourcePacks, pack)
+			E.Emit("import_item", map[string]string{"kind": "resourcepack", "name": pack})
+		}
+	}
+
+	// options.txt is always copied: a linked file would be rewritten by both launchers
+	if opts.Options {
+		imported, err := importItem(filepath.Join(source, "options.txt"), filepath.Join(inst.Dir(), "options.txt"), false, report)
+		if err != nil {
+			err = fmt.Errorf("failed to import options: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return report, err
+		}
+		report.Options = imported
+	}
+
+	E.Emit("import_done", report)
+	return report, nil
+}
@@ -0,0 +1,244 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/cloudsync"
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// MetadataFile is the name of the file describing an instance inside its directory.
+const MetadataFile = "instance.json"
+
+// Instance is a separate game directory (saves, mods, config, resource packs) that launches
+// a version from the shared installation root. Instances live in <root>/instances/<id>.
+//
+// Besides the version to launch, an instance carries display metadata so every frontend
+// built on this core renders the same instance list.
+type Instance struct {
+	// ID is the directory name of the instance and never changes.
+	ID string `json:"id"`
+	// Version is the version ID launched by the instance, e.g. "fabric-loader-0.15.0-1.20.1".
+	Version string `json:"version"`
+
+	// Name is the display name; DisplayName falls back to ID when it is empty.
+	Name string `json:"name,omitempty"`
+	// Icon is the icon image, relative to the instance directory or absolute.
+	Icon string `json:"icon,omitempty"`
+	// Group is a free-form group label used to organise instance lists.
+	Group string `json:"group,omitempty"`
+	// Notes are user notes shown alongside the instance.
+	Notes string `json:"notes,omitempty"`
+	// LastPlayed is when the last session started; zero if never played.
+	LastPlayed time.Time `json:"lastPlayed,omitempty"`
+	// PlaytimeSeconds is the accumulated playtime across all sessions.
+	PlaytimeSeconds int64 `json:"playtimeSeconds,omitempty"`
+
+	// dir is the directory the instance was loaded from or created in.
+	dir string
+}
+
+// ------------------ Helpers ------------------
+
+// InstancesDir returns the directory holding all instances of an installation root.
+func InstancesDir(root string) string {
+	return filepath.Join(root, "instances")
+}
+
+// validateID rejects IDs that cannot safely be used as a single directory name.
+func validateID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\:`) {
+		return fmt.Errorf("invalid instance id %q", id)
+	}
+	return nil
+}
+
+// ------------------ Public API ------------------
+
+// Create makes a new instance directory under root and writes its metadata.
+// It fails if an instance with the same ID already exists.
+func Create(root, id, version string) (*Instance, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(InstancesDir(root), id)
+	if _, err := os.Stat(filepath.Join(dir, MetadataFile)); err == nil {
+		return nil, fmt.Errorf("instance %s already exists", id)
+	}
+	if err := os.MkdirAll(dir, utils.Modes.Dir); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+
+	inst := &Instance{ID: id, Version: version, Name: id, dir: dir}
+	if err := inst.Save(); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// Load reads the instance stored in dir.
+func Load(dir string) (*Instance, error) {
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance metadata: %w", err)
+	}
+
+	var inst Instance
+	if err := json.Unmarshal(data, &inst); err != nil {
+		return nil, fmt.Errorf("failed to parse instance metadata: %w", err)
+	}
+	if inst.ID == "" {
+		inst.ID = filepath.Base(dir)
+	}
+	inst.dir = dir
+	return &inst, nil
+}
+
+// List loads every instance under root, most recently played first and then by display name.
+// Directories without instance metadata are ignored.
+func List(root string) ([]*Instance, error) {
+	entries, err := os.ReadDir(InstancesDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instances directory: %w", err)
+	}
+
+	var instances []*Instance
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		inst, err := Load(filepath.Join(InstancesDir(root), entry.Name()))
+		if err != nil {
+			continue
+		}
+		instances = append(instances, inst)
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		if !instances[i].LastPlayed.Equal(instances[j].LastPlayed) {
+			return instances[i].LastPlayed.After(instances[j].LastPlayed)
+		}
+		return strings.ToLower(instances[i].DisplayName()) < strings.ToLower(instances[j].DisplayName())
+	})
+	return instances, nil
+}
+
+// Save writes the instance metadata to its directory.
+func (i *Instance) Save() error {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(i.dir, MetadataFile), data, utils.Modes.File); err != nil {
+		return fmt.Errorf("failed to write instance metadata: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the instance directory with everything in it, saves and backups included.
+func (i *Instance) Delete(E *events.EventEmitter) error {
+	if i.dir == "" {
+		return fmt.Errorf("instance %s has no directory", i.ID)
+	}
+	if err := os.RemoveAll(i.dir); err != nil {
+		err = fmt.Errorf("failed to delete instance %s: %w", i.ID, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	E.Emit("instance_deleted", i.ID)
+	return nil
+}
+
+// Dir returns the instance directory, which is also the game directory of the instance.
+func (i *Instance) Dir() string {
+	return i.dir
+}
+
+// DisplayName returns the name to show for the instance.
+func (i *Instance) DisplayName() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.ID
+}
+
+// IconPath returns the absolute path of the instance icon, or "" when none is set.
+func (i *Instance) IconPath() string {
+	if i.Icon == "" {
+		return ""
+	}
+	if filepath.IsAbs(i.Icon) {
+		return i.Icon
+	}
+	return filepath.Join(i.dir, i.Icon)
+}
+
+// SetIcon copies an image into the instance directory and makes it the instance icon,
+// so the instance stays self-contained when moved or shared.
+func (i *Instance) SetIcon(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open icon: %w", err)
+	}
+	defer in.Close()
+
+	name := "icon" + strings.ToLower(filepath.Ext(src))
+	out, err := os.Create(filepath.Join(i.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to create icon: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy icon: %w", err)
+	}
+
+	i.Icon = name
+	return i.Save()
+}
+
+// AssetsDir returns the per-instance assets directory populated by LinkAssets.
+func (i *Instance) AssetsDir() string {
+	return filepath.Join(i.dir, "assets")
+}
+
+// LinkAssets gives the instance its own assets directory for the given asset index, hard-linked
+// from the shared object store of root so the objects are not duplicated on disk.
+func (i *Instance) LinkAssets(root, indexID string, E *events.EventEmitter) error {
+	_, err := downloader.LinkAssets(root, i.AssetsDir(), indexID, E)
+	return err
+}
+
+// Syncer returns a cloudsync.Syncer for the worlds, configs and options of the instance.
+// Pair it with BackupWorld to keep local copies before pulling remote saves.
+func (i *Instance) Syncer(backend cloudsync.Backend) *cloudsync.Syncer {
+	return &cloudsync.Syncer{Backend: backend, Dir: i.dir}
+}
+
+// TotalPlaytime returns the accumulated playtime of the instance.
+func (i *Instance) TotalPlaytime() time.Duration {
+	return time.Duration(i.PlaytimeSeconds) * time.Second
+}
+
+// RecordSession marks the instance as played at start for the given duration and saves it.
+func (i *Instance) RecordSession(start time.Time, played time.Duration) error {
+	i.LastPlayed = start
+	i.PlaytimeSeconds += int64(played / time.Second)
+	return i.Save()
+}
@@ -0,0 +1,257 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ChecksumManifestFile is the conventional name of a checksum manifest next to a deployment.
+const ChecksumManifestFile = "checksums.json"
+
+// ManifestExcludes are paths (slash-separated, relative to the directory) left out of checksum
+// manifests by default: player data, logs, backups and files rewritten by every launch.
+var ManifestExcludes = []string{
+	"saves", "logs", "crash-reports", "screenshots", BackupsDir,
+	MetadataFile, ChecksumManifestFile, ".sync-state.json", "usercache.json", "session.lock",
+}
+
+// ------------------ Structs ------------------
+
+// ManifestEntry is one file of a checksum manifest.
+type ManifestEntry struct {
+	// Path is slash-separated and relative to the manifest's directory.
+	Path string `json:"path"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+}
+
+// ChecksumManifest lists every file of a built instance or server directory with its hash
+// and size, like a lockfile, so the same deployment can be reproduced and verified elsewhere.
+type ChecksumManifest struct {
+	Created time.Time `json:"created"`
+	// Version is the version the directory launches, if known.
+	Version string          `json:"version,omitempty"`
+	Files   []ManifestEntry `json:"files"`
+}
+
+// VerifyReport lists the differences between a directory and a checksum manifest.
+type VerifyReport struct {
+	// Missing files are in the manifest but not on disk.
+	Missing []string
+	// Modified files exist with another hash or size.
+	Modified []string
+	// Extra files are on disk but not in the manifest (excluded paths are ignored).
+	Extra []string
+}
+
+// OK reports whether the directory matches the manifest exactly.
+func (r *VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0 && len(r.Extra) == 0
+}
+
+// ------------------ Helpers ------------------
+
+// excluded reports whether rel (slash-separated) is or lies under an excluded path.
+func excluded(rel string, excludes []string) bool {
+	for _, ex := range excludes {
+		if rel == ex || strings.HasPrefix(rel, ex+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hashJob is a file to hash: its path on disk and its slash-separated manifest path.
+type hashJob struct {
+	path, rel string
+}
+
+// hashFiles hashes files with utils.HashFiles, one worker per usable CPU. Entries are returned
+// in the order of files, and checksum_progress reports every hashed file.
+func hashFiles(dir string, files []hashJob, E *events.EventEmitter) ([]ManifestEntry, error) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+	hashes := utils.HashFiles(paths, func(done, i int) {
+		E.Emit("checksum_progress", map[string]interface{}{
+			"dir": dir, "done": done, "total": len(files), "path": files[i].rel,
+		})
+	})
+
+	entries := make([]ManifestEntry, len(files))
+	for i, hash := range hashes {
+		if hash.Err != nil {
+			return nil, hash.Err
+		}
+		entries[i] = ManifestEntry{Path: files[i].rel, SHA1: hash.SHA1, Size: hash.Size}
+	}
+	return entries, nil
+}
+
+// walkFiles calls fn for every regular file of dir not excluded, with its slash-separated path.
+func walkFiles(dir string, excludes []string, fn func(path, rel string) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if excluded(rel, excludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return fn(path, rel)
+	})
+}
+
+// ------------------ Public API ------------------
+
+// BuildChecksumManifest hashes every file of dir except the excluded paths; nil excludes
+// means ManifestExcludes. Files are listed in path order so manifests diff cleanly.
+func BuildChecksumManifest(dir string, excludes []string, E *events.EventEmitter) (*ChecksumManifest, error) {
+	if excludes == nil {
+		excludes = ManifestExcludes
+	}
+
+	manifest := &ChecksumManifest{Created: time.Now().UTC()}
+	var files []hashJob
+	err := walkFiles(dir, excludes, func(path, rel string) error {
+		files = append(files, hashJob{path, rel})
+		return nil
+	})
+	if err == nil {
+		manifest.Files, err = hashFiles(dir, files, E)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to build checksum manifest of %s: %w", dir, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	E.Emit("checksum_manifest_built", map[string]interface{}{"dir": dir, "files": len(manifest.Files)})
+	return manifest, nil
+}
+
+// VerifyChecksumManifest compares dir with a manifest built by BuildChecksumManifest using
+// the same excludes (nil means ManifestExcludes).
+func VerifyChecksumManifest(dir string, manifest *ChecksumManifest, excludes []string, E *events.EventEmitter) (*VerifyReport, error) {
+	if excludes == nil {
+		excludes = ManifestExcludes
+	}
+
+	expected := map[string]ManifestEntry{}
+	for _, entry := range manifest.Files {
+		expected[entry.Path] = entry
+	}
+
+	report := &VerifyReport{}
+	seen := map[string]bool{}
+	var files []hashJob
+	err := walkFiles(dir, excludes, func(path, rel string) error {
+		want, ok := expected[rel]
+		if !ok {
+			report.Extra = append(report.Extra, rel)
+			return nil
+		}
+		seen[rel] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() != want.Size {
+			report.Modified = append(report.Modified, rel)
+			return nil
+		}
+		files = append(files, hashJob{path, rel})
+		return nil
+	})
+	// Only files whose size matches need hashing
+	if err == nil {
+		var hashed []ManifestEntry
+		hashed, err = hashFiles(dir, files, E)
+		for _, got := range hashed {
+			if got.SHA1 != expected[got.Path].SHA1 {
+				report.Modified = append(report.Modified, got.Path)
+			}
+		}
+		sort.Strings(report.Modified)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to verify %s: %w", dir, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	for _, entry := range manifest.Files {
+		if !seen[entry.Path] {
+			report.Missing = append(report.Missing, entry.Path)
+		}
+	}
+
+	E.Emit("checksum_manifest_verified", map[string]interface{}{
+		"dir":      dir,
+		"missing":  len(report.Missing),
+		"modified": len(report.Modified),
+		"extra":    len(report.Extra),
+	})
+	return report, nil
+}
+
+// WriteChecksumManifest saves a manifest as indented JSON.
+func WriteChecksumManifest(path string, manifest *ChecksumManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, utils.Modes.File)
+}
+
+// ReadChecksumManifest loads a manifest saved by WriteChecksumManifest.
+func ReadChecksumManifest(path string) (*ChecksumManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum manifest: %w", err)
+	}
+	var manifest ChecksumManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse checksum manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ChecksumManifest builds the checksum manifest of the instance directory.
+func (i *Instance) ChecksumManifest(E *events.EventEmitter) (*ChecksumManifest, error) {
+	manifest, err := BuildChecksumManifest(i.dir, nil, E)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Version = i.Version
+	return manifest, nil
+}
+
+// Verify compares the instance directory with a checksum manifest.
+func (i *Instance) Verify(manifest *ChecksumManifest, E *events.EventEmitter) (*VerifyReport, error) {
+	return VerifyChecksumManifest(i.dir, manifest, nil, E)
+}
@@ -0,0 +1,234 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// worldBackupPrefix starts the file name of every world backup.
+const worldBackupPrefix = "world-"
+
+// WorldRetention decides which world backups survive pruning. A backup is kept when it
+// satisfies any of the rules; all zero keeps every backup.
+type WorldRetention struct {
+	// Keep is the number of most recent backups always kept.
+	Keep int
+	// Daily keeps the newest backup of each of the last Daily days that have one.
+	Daily int
+	// Weekly keeps the newest backup of each of the last Weekly ISO weeks that have one.
+	Weekly int
+}
+
+// ------------------ World Backups ------------------
+
+// SavesPath returns the directory holding the worlds of the instance.
+func (i *Instance) SavesPath() string {
+	return filepath.Join(i.dir, "saves")
+}
+
+// Worlds returns the directory names of the worlds of the instance.
+func (i *Instance) Worlds() ([]string, error) {
+	entries, err := os.ReadDir(i.SavesPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var worlds []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			worlds = append(worlds, entry.Name())
+		}
+	}
+	return worlds, nil
+}
+
+// worldBackupsPath returns the directory holding the backups of one world.
+func (i *Instance) worldBackupsPath(world string) string {
+	return filepath.Join(i.BackupsPath(), "worlds", world)
+}
+
+// BackupWorld archives a world of the instance into backups/worlds/<world>/world-<timestamp>.zip.
+func (i *Instance) BackupWorld(world string, E *events.EventEmitter) (*Backup, error) {
+	if err := validateID(world); err != nil {
+		return nil, fmt.Errorf("invalid world name %q", world)
+	}
+	if _, err := os.Stat(filepath.Join(i.SavesPath(), world)); err != nil {
+		err = fmt.Errorf("world %s not found: %w", world, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	name := backupName(worldBackupPrefix, "", now)
+	path := filepath.Join(i.worldBackupsPath(world), name)
+
+	E.Emit("backup_started", map[string]string{"instance": i.ID, "kind": "world", "world": world, "path": path})
+	size, err := zipDirs(path, i.SavesPath(), []string{world})
+	if err != nil {
+		err = fmt.Errorf("failed to back up world %s: %w", world, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	backup := &Backup{Name: name, Path: path, Created: now, Size: size}
+	E.Emit("backup_completed", backup)
+	return backup, nil
+}
+
+// WorldBackups lists the backups of a world, newest first.
+func (i *Instance) WorldBackups(world string) ([]Backup, error) {
+	return listBackups(i.worldBackupsPath(world), worldBackupPrefix)
+}
+
+// retained returns the backups (newest first) that the retention keeps.
+func (r WorldRetention) retained(backups []Backup) map[string]bool {
+	keep := map[string]bool{}
+	if r.Keep <= 0 && r.Daily <= 0 && r.Weekly <= 0 {
+		for _, b := range backups {
+			keep[b.Name] = true
+		}
+		return keep
+	}
+
+	days, weeks := map[string]bool{}, map[string]bool{}
+	for n, b := range backups {
+		if n < r.Keep {
+			keep[b.Name] = true
+		}
+		day := b.Created.Format("2006-01-02")
+		if !days[day] && len(days) < r.Daily {
+			days[day] = true
+			keep[b.Name] = true
+		}
+		year, week := b.Created.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		if !weeks[weekKey] && len(weeks) < r.Weekly {
+			weeks[weekKey] = true
+			keep[b.Name] = true
+		}
+	}
+	return keep
+}
+
+// PruneWorldBackups deletes the backups of a world not kept by the retention and returns them.
+func (i *Instance) PruneWorldBackups(world string, retention WorldRetention, E *events.EventEmitter) ([]Backup, error) {
+	backups, err := i.WorldBackups(world)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := retention.retained(backups)
+	var pruned []Backup
+	for _, b := range backups {
+		if keep[b.Name] {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to prune backup: %w", err)))
+			continue
+		}
+		E.Emit("backup_pruned", b.Path)
+		pruned = append(pruned, b)
+	}
+	return pruned, nil
+}
+
+// ------------------ Scheduler ------------------
+
+// BackupScheduler backs up worlds of an instance periodically and/or when the game exits,
+// pruning old backups after every run.
+type BackupScheduler struct {
+	// Worlds are the worlds to back up; empty backs up every world of the instance.
+	Worlds []string
+	// Interval runs a backup periodically once Start was called; zero disables it.
+	Interval time.Duration
+	// OnExit runs a backup when GameExited is called.
+	OnExit    bool
+	Retention WorldRetention
+
+	inst *Instance
+	E    *events.EventEmitter
+	mu   sync.Mutex
+	stop chan struct{}
+	// running serializes backups started by the timer and by GameExited.
+	running sync.Mutex
+}
+
+// NewBackupScheduler returns a scheduler for the worlds of inst. Configure its fields, then
+// call Start for interval backups and GameExited after each session for on-exit backups.
+func NewBackupScheduler(inst *Instance, E *events.EventEmitter) *BackupScheduler {
+	return &BackupScheduler{inst: inst, E: E}
+}
+
+// Start begins interval backups. It does nothing when Interval is zero or it already runs.
+func (s *BackupScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Interval <= 0 || s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.RunNow()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends interval backups.
+func (s *BackupScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// GameExited runs a backup if OnExit is set. Call it once the game process has exited.
+func (s *BackupScheduler) GameExited() {
+	if s.OnExit {
+		s.RunNow()
+	}
+}
+
+// RunNow backs up the configured worlds and prunes their old backups. Failures are reported
+// as error events and do not stop the other worlds.
+func (s *BackupScheduler) RunNow() []Backup {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	worlds := s.Worlds
+	if len(worlds) == 0 {
+		worlds, _ = s.inst.Worlds()
+	}
+
+	var backups []Backup
+	for _, world := range worlds {
+		backup, err := s.inst.BackupWorld(world, s.E)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, *backup)
+		_, _ = s.inst.PruneWorldBackups(world, s.Retention, s.E)
+	}
+	return backups
+}
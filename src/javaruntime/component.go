@@ -0,0 +1,61 @@
+package javaruntime
+
+import (
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Runtime components published by Mojang.
+const (
+	// ComponentLegacy is Java 8, used by every version without a javaVersion (up to 1.16.5).
+	ComponentLegacy = "jre-legacy"
+	// ComponentAlpha is Java 16, used by 1.17.
+	ComponentAlpha = "java-runtime-alpha"
+	// ComponentBeta is the Java 17 build used by the first 1.18 snapshots.
+	ComponentBeta = "java-runtime-beta"
+	// ComponentGamma is Java 17, used by 1.18 to 1.20.4.
+	ComponentGamma = "java-runtime-gamma"
+	// ComponentDelta is Java 21, used from 1.20.5.
+	ComponentDelta = "java-runtime-delta"
+)
+
+// majorComponents maps Java major versions to the component the official launcher installs
+// for them when a version names only a major version.
+var majorComponents = map[int]string{
+	8:  ComponentLegacy,
+	16: ComponentAlpha,
+	17: ComponentGamma,
+	21: ComponentDelta,
+}
+
+// ComponentFor returns the runtime component the official launcher installs for a version
+// whose javaVersion has the given component and majorVersion. The component field wins;
+// otherwise the major version is mapped, and versions without javaVersion get ComponentLegacy.
+func ComponentFor(component string, majorVersion int) string {
+	if component != "" {
+		return component
+	}
+	if c, ok := majorComponents[majorVersion]; ok {
+		return c
+	}
+	if majorVersion > 21 {
+		return ComponentDelta
+	}
+	return ComponentLegacy
+}
+
+// VersionComponent returns the runtime component of a version installed under root,
+// following inheritsFrom like the launcher does.
+func VersionComponent(root, version string) string {
+	component, major := versionJavaVersion(root, version)
+	return ComponentFor(component, major)
+}
+
+// Ensure returns the installed component for platform (empty means Platform()), installing
+// it first when missing. Unlike InstallPlatform it does not check for updates, so launching
+// works offline once the runtime is installed.
+func Ensure(root, component, platform string, E *events.EventEmitter) (*Installed, error) {
+	if rt, err := LoadPlatform(root, component, platform); err == nil {
+		return rt, nil
+	}
+	return InstallPlatform(root, component, platform, E)
+}
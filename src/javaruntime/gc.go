@@ -0,0 +1,154 @@
+package javaruntime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/instance"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ References ------------------
+
+// versionJavaVersion returns the javaVersion a version asks for, following inheritsFrom.
+func versionJavaVersion(root, version string) (string, int) {
+	for depth := 0; version != "" && depth < 16; depth++ {
+		data, err := os.ReadFile(utils.NewLayout(root).VersionJSON(version))
+		if err != nil {
+			return "", 0
+		}
+		var v struct {
+			InheritsFrom string `json:"inheritsFrom"`
+			JavaVersion  struct {
+				Component    string `json:"component"`
+				MajorVersion int    `json:"majorVersion"`
+			} `json:"javaVersion"`
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return "", 0
+		}
+		if v.JavaVersion.Component != "" || v.JavaVersion.MajorVersion != 0 {
+			return v.JavaVersion.Component, v.JavaVersion.MajorVersion
+		}
+		version = v.InheritsFrom
+	}
+	return "", 0
+}
+
+// References maps every runtime component to the IDs of the instances of root whose version
+// needs it.
+func References(root string) (map[string][]string, error) {
+	instances, err := instance.List(root)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string][]string{}
+	for _, inst := range instances {
+		if _, err := os.Stat(utils.NewLayout(root).VersionDir(inst.Version)); err != nil {
+			continue
+		}
+		component := VersionComponent(root, inst.Version)
+		refs[component] = append(refs[component], inst.ID)
+	}
+	return refs, nil
+}
+
+// ------------------ Updates ------------------
+
+// CheckUpdates returns the published releases that are newer than the installed runtimes of
+// every platform, each compared with the releases of its own platform.
+func CheckUpdates(root string) ([]Release, error) {
+	installed, err := ListAll(root)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := map[string]map[string]Release{}
+	var updates []Release
+	for _, rt := range installed {
+		if _, ok := releases[rt.Platform]; !ok {
+			if releases[rt.Platform], err = Releases(rt.Platform); err != nil {
+				return nil, err
+			}
+		}
+		if release, ok := releases[rt.Platform][rt.Component]; ok && release.ManifestSHA1 != rt.ManifestSHA1 {
+			updates = append(updates, release)
+		}
+	}
+	return updates, nil
+}
+
+// Update installs every available update of the installed runtimes, emitting
+// runtime_update_available before each one. Unchanged files are reused from the old
+// installation. It returns the updated runtimes.
+func Update(root string, E *events.EventEmitter) ([]*Installed, error) {
+	updates, err := CheckUpdates(root)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	var updated []*Installed
+	for _, release := range updates {
+		E.Emit("runtime_update_available", release)
+		rt, err := installRelease(root, release, E)
+		if err != nil {
+			return updated, err
+		}
+		updated = append(updated, rt)
+	}
+	return updated, nil
+}
+
+// ------------------ Garbage Collection ------------------
+
+// Unused returns the installed runtimes of every platform no instance of root references,
+// except the components in keep.
+func Unused(root string, keep []string) ([]*Installed, error) {
+	installed, err := ListAll(root)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := References(root)
+	if err != nil {
+		return nil, err
+	}
+	kept := map[string]bool{}
+	for _, component := range keep {
+		kept[component] = true
+	}
+
+	var unused []*Installed
+	for _, rt := range installed {
+		if len(refs[rt.Component]) == 0 && !kept[rt.Component] {
+			unused = append(unused, rt)
+		}
+	}
+	return unused, nil
+}
+
+// RemoveUnused deletes the runtimes returned by Unused, emitting runtime_removed for each,
+// and returns them. Runtimes used by versions launched outside of instances should be
+// listed in keep.
+func RemoveUnused(root string, keep []string, E *events.EventEmitter) ([]*Installed, error) {
+	unused, err := Unused(root, keep)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	var removed []*Installed
+	for _, rt := range unused {
+		if err := os.RemoveAll(rt.Dir); err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to remove runtime: %w", err)))
+			continue
+		}
+		E.Emit("runtime_removed", rt)
+		removed = append(removed, rt)
+	}
+	return removed, nil
+}
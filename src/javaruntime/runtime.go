@@ -0,0 +1,394 @@
+package javaruntime
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// AllRuntimesURL lists every Java runtime component Mojang publishes, per platform.
+const AllRuntimesURL = "https://launchermeta.mojang.com/v1/products/java-runtime/2ec0cc96c44e5a76b9c8b7c39df7210883d12871/all.json"
+
+// versionFile records, inside an installed runtime, which release it contains.
+const versionFile = ".version.json"
+
+// ------------------ Structs ------------------
+
+// Release is a published version of a runtime component.
+type Release struct {
+	Component string
+	Platform  string
+	// Version is the Java version, e.g. "17.0.8".
+	Version  string
+	Released time.Time
+	// ManifestURL and ManifestSHA1 locate the file list of the release.
+	ManifestURL  string
+	ManifestSHA1 string
+}
+
+// Installed is a runtime installed under a root directory.
+type Installed struct {
+	Component string `json:"component"`
+	Platform  string `json:"platform"`
+	Version   string `json:"version"`
+	// ManifestSHA1 identifies the installed release; a different published SHA1 means an update.
+	ManifestSHA1 string    `json:"manifestSha1"`
+	InstalledAt  time.Time `json:"installedAt"`
+	// Dir is the runtime's home directory.
+	Dir string `json:"-"`
+}
+
+// manifestFile is one entry of a component manifest.
+type manifestFile struct {
+	Type       string `json:"type"`
+	Executable bool   `json:"executable"`
+	Target     string `json:"target"`
+	Downloads  struct {
+		Raw struct {
+			URL  string `json:"url"`
+			SHA1 string `json:"sha1"`
+			Size int64  `json:"size"`
+		} `json:"raw"`
+	} `json:"downloads"`
+}
+
+// ------------------ Helpers ------------------
+
+// Platform returns Mojang's runtime platform name for this system.
+func Platform() string {
+	switch runtime.GOOS {
+	case "windows":
+		switch runtime.GOARCH {
+		case "386":
+			return "windows-x86"
+		case "arm64":
+			return "windows-arm64"
+		}
+		return "windows-x64"
+	case "darwin":
+		if runtime.GOARCH == "arm64" {
+			return "mac-os-arm64"
+		}
+		return "mac-os"
+	}
+	if runtime.GOARCH == "386" {
+		return "linux-i386"
+	}
+	return "linux"
+}
+
+// RuntimesDir returns the directory holding the runtimes of an installation root.
+func RuntimesDir(root string) string {
+	return utils.NewLayout(root).RuntimesDir()
+}
+
+// componentDir returns where a component is installed for a platform.
+func componentDir(root, component, platform string) string {
+	return filepath.Join(RuntimesDir(root), component, platform)
+}
+
+// JavaPath returns the java executable of the runtime.
+func (i *Installed) JavaPath() string {
+	switch {
+	case strings.HasPrefix(i.Platform, "windows"):
+		return filepath.Join(i.Dir, "bin", "java.exe")
+	case strings.HasPrefix(i.Platform, "mac-os"):
+		return filepath.Join(i.Dir, "jre.bundle", "Contents", "Home", "bin", "java")
+	}
+	return filepath.Join(i.Dir, "bin", "java")
+}
+
+// getJSON decodes the JSON document at url into out.
+func getJSON(url string, out any) error {
+	resp, err := utils.HTTPClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// fileSHA1 returns the hex SHA1 of a file, or "" if it cannot be read.
+func fileSHA1(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ------------------ Releases ------------------
+
+// Releases returns the current release of every component published for platform
+// (empty means Platform()).
+func Releases(platform string) (map[string]Release, error) {
+	if platform == "" {
+		platform = Platform()
+	}
+
+	var all map[string]map[string][]struct {
+		Manifest struct {
+			SHA1 string `json:"sha1"`
+			URL  string `json:"url"`
+		} `json:"manifest"`
+		Version struct {
+			Name     string    `json:"name"`
+			Released time.Time `json:"released"`
+		} `json:"version"`
+	}
+	if err := getJSON(AllRuntimesURL, &all); err != nil {
+		return nil, err
+	}
+
+	releases := map[string]Release{}
+	for component, entries := range all[platform] {
+		if len(entries) == 0 {
+			continue
+		}
+		e := entries[0]
+		releases[component] = Release{
+			Component:    component,
+			Platform:     platform,
+			Version:      e.Version.Name,
+			Released:     e.Version.Released,
+			ManifestURL:  e.Manifest.URL,
+			ManifestSHA1: e.Manifest.SHA1,
+		}
+	}
+	return releases, nil
+}
+
+// ------------------ Installation ------------------
+
+// Install installs the current release of a component for this platform under root and
+// returns it. An installed runtime that is already current is returned as is.
+func Install(root, component string, E *events.EventEmitter) (*Installed, error) {
+	return InstallPlatform(root, component, "", E)
+}
+
+// InstallPlatform works like Install for another platform (empty means Platform()), e.g.
+// "mac-os" to run an x86_64 runtime under Rosetta.
+func InstallPlatform(root, component, platform string, E *events.EventEmitter) (*Installed, error) {
+	if platform == "" {
+		platform = Platform()
+	}
+	releases, err := Releases(platform)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	release, ok := releases[component]
+	if !ok {
+		err := fmt.Errorf("runtime %s is not available for %s", component, platform)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	if current, err := LoadPlatform(root, component, platform); err == nil && current.ManifestSHA1 == release.ManifestSHA1 {
+		E.Emit("runtime_up_to_date", current)
+		return current, nil
+	}
+	return installRelease(root, release, E)
+}
+
+// installRelease downloads a release into a staging directory and swaps it in, reusing
+// unchanged files of a previous installation.
+func installRelease(root string, release Release, E *events.EventEmitter) (*Installed, error) {
+	var manifest struct {
+		Files map[string]manifestFile `json:"files"`
+	}
+	if err := getJSON(release.ManifestURL, &manifest); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	dir := componentDir(root, release.Component, release.Platform)
+	staging := dir + ".new"
+	_ = os.RemoveAll(staging)
+	E.Emit("runtime_install_start", map[string]interface{}{"component": release.Component, "version": release.Version, "files": len(manifest.Files)})
+
+	// Directories first, so files and links can be placed in any order
+	paths := make([]string, 0, len(manifest.Files))
+	for path := range manifest.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fail := func(err error) (*Installed, error) {
+		os.RemoveAll(staging)
+		err = fmt.Errorf("failed to install runtime %s: %w", release.Component, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	var restore []utils.ArchiveEntry
+	for _, path := range paths {
+		file := manifest.Files[path]
+		target := filepath.Join(staging, filepath.FromSlash(path))
+		if !strings.HasPrefix(target, staging+string(os.PathSeparator)) {
+			return fail(fmt.Errorf("unsafe path %q in runtime manifest", path))
+		}
+
+		switch file.Type {
+		case "directory":
+			if err := os.MkdirAll(target, utils.Modes.Dir); err != nil {
+				return fail(err)
+			}
+		case "link":
+			// Created once every file is in place, so Windows can copy their targets
+			restore = append(restore, utils.ArchiveEntry{Path: path, Link: file.Target})
+		case "file":
+			raw := file.Downloads.Raw
+			previous := filepath.Join(dir, filepath.FromSlash(path))
+			if fileSHA1(previous) == raw.SHA1 {
+				if err := os.MkdirAll(filepath.Dir(target), utils.Modes.Dir); err != nil {
+					return fail(err)
+				}
+				if err := os.Link(previous, target); err != nil {
+					if err := downloader.DownloadFile(target, raw.URL, E); err != nil {
+						return fail(err)
+					}
+				}
+			} else if err := downloader.DownloadFile(target, raw.URL, E); err != nil {
+				return fail(err)
+			}
+			if sum := fileSHA1(target); sum != raw.SHA1 {
+				return fail(fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path, raw.SHA1, sum))
+			}
+			if err := utils.Chmod(target, utils.Modes.File); err != nil {
+				return fail(err)
+			}
+			if file.Executable {
+				restore = append(restore, utils.ArchiveEntry{Path: path, Executable: true})
+			}
+		}
+	}
+	if err := utils.RestoreArchiveEntries(staging, restore); err != nil {
+		return fail(err)
+	}
+	// Some manifests do not flag every program, the java binary included, as executable
+	if _, err := utils.FixJavaExecutables(staging); err != nil {
+		return fail(err)
+	}
+
+	installed := &Installed{
+		Component:    release.Component,
+		Platform:     release.Platform,
+		Version:      release.Version,
+		ManifestSHA1: release.ManifestSHA1,
+		InstalledAt:  time.Now().UTC(),
+		Dir:          dir,
+	}
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, versionFile), data, utils.Modes.File); err != nil {
+		return fail(err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return fail(err)
+	}
+
+	E.Emit("runtime_installed", installed)
+	return installed, nil
+}
+
+// Load returns a component installed under root for this platform.
+func Load(root, component string) (*Installed, error) {
+	return LoadPlatform(root, component, "")
+}
+
+// LoadPlatform returns a component installed under root for a platform (empty means Platform()).
+func LoadPlatform(root, component, platform string) (*Installed, error) {
+	if platform == "" {
+		platform = Platform()
+	}
+	dir := componentDir(root, component, platform)
+	data, err := os.ReadFile(filepath.Join(dir, versionFile))
+	if err != nil {
+		return nil, fmt.Errorf("runtime %s is not installed: %w", component, err)
+	}
+	var installed Installed
+	if err := json.Unmarshal(data, &installed); err != nil {
+		return nil, fmt.Errorf("failed to parse runtime %s: %w", component, err)
+	}
+	installed.Dir, installed.Platform = dir, platform
+	return &installed, nil
+}
+
+// List returns every runtime installed under root for this platform.
+func List(root string) ([]*Installed, error) {
+	return list(root, false)
+}
+
+// ListAll returns every runtime installed under root for any platform, e.g. runtimes
+// installed with InstallPlatform for another system.
+func ListAll(root string) ([]*Installed, error) {
+	return list(root, true)
+}
+
+// list returns the runtimes installed under root for this platform, or for every platform
+// when all is set.
+func list(root string, all bool) ([]*Installed, error) {
+	entries, err := os.ReadDir(RuntimesDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []*Installed
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		platforms := []string{Platform()}
+		if all {
+			platforms = nil
+			dirs, err := os.ReadDir(filepath.Join(RuntimesDir(root), entry.Name()))
+			if err != nil {
+				continue
+			}
+			for _, dir := range dirs {
+				if dir.IsDir() {
+					platforms = append(platforms, dir.Name())
+				}
+			}
+		}
+		for _, platform := range platforms {
+			if rt, err := LoadPlatform(root, entry.Name(), platform); err == nil {
+				installed = append(installed, rt)
+			}
+		}
+	}
+	return installed, nil
+}
@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+)
+
+// hostArch returns the Minecraft/LWJGL style name of the architecture this process runs on.
+func hostArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "arm64"
+	case "386":
+		return "x86"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// hasArm64MacNatives reports whether the version ships LWJGL natives built for Apple Silicon.
+// Versions before LWJGL 3.3 only provide x86_64 natives on macOS.
+func hasArm64MacNatives(versionJSON *VersionJSON) bool {
+	for _, lib := range versionJSON.Libraries {
+		name := strings.ToLower(lib.Name)
+		if strings.Contains(name, "natives-macos-arm64") || strings.Contains(name, "natives-osx-arm64") {
+			return true
+		}
+		for classifier := range lib.Downloads.Classifiers {
+			if classifier == "natives-macos-arm64" || classifier == "natives-osx-arm64" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectArch decides which architecture the game should run as. An explicit override wins;
+// otherwise, on Apple Silicon, versions without arm64 natives are run as x86_64 under Rosetta.
+func selectArch(override string, versionJSON *VersionJSON) string {
+	if override != "" {
+		return override
+	}
+	if runtime.GOOS == "darwin" && hostArch() == "arm64" && !hasArm64MacNatives(versionJSON) {
+		return "x86_64"
+	}
+	return hostArch()
+}
+
+// needsRosetta reports whether launching for arch on this host requires Rosetta translation.
+func needsRosetta(arch string) bool {
+	return runtime.GOOS == "darwin" && hostArch() == "arm64" && arch == "x86_64"
+}
+
+// nativesDirFor returns the natives directory of a version. Natives for an OS or architecture
+// other than the host's get their own directory so switching platforms never mixes binaries.
+func nativesDirFor(versionDir string, platform rules.Platform) string {
+	if platform.OS != "" && platform.OS != rules.Host().OS {
+		return filepath.Join(versionDir, "natives-"+platform.OS+"-"+platform.Arch)
+	}
+	if platform.Arch == "" || platform.Arch == hostArch() {
+		return filepath.Join(versionDir, "natives")
+	}
+	return filepath.Join(versionDir, "natives-"+platform.Arch)
+}
+
+// nativeJarMatchesOS reports whether a native JAR (lower-case file name) may be extracted for an
+// OS: JARs tagged for another OS are skipped, untagged ones are kept.
+func nativeJarMatchesOS(name, osName string) bool {
+	for tag, tagOS := range map[string]string{
+		"natives-windows": rules.OSWindows,
+		"natives-osx":     rules.OSMac,
+		"natives-macos":   rules.OSMac,
+		"natives-linux":   rules.OSLinux,
+	} {
+		if strings.Contains(name, tag) {
+			return tagOS == osName
+		}
+	}
+	return true
+}
+
+// nativeJarMatchesArch reports whether a native JAR should be extracted for the target architecture.
+// JARs tagged with an architecture must match it; untagged JARs are skipped for arm64 when an
+// arm64 variant exists next to them, since both contain libraries with the same file names.
+func nativeJarMatchesArch(jarPath, arch string) bool {
+	name := strings.ToLower(filepath.Base(jarPath))
+
+	jarArch := ""
+	switch {
+	case strings.Contains(name, "arm64") || strings.Contains(name, "aarch64"):
+		jarArch = "arm64"
+	case strings.HasSuffix(name, "-x86.jar") || strings.HasSuffix(name, "-32.jar"):
+		jarArch = "x86"
+	}
+
+	if jarArch != "" {
+		return jarArch == arch
+	}
+
+	if arch == "arm64" {
+		arm64Variant := strings.TrimSuffix(jarPath, filepath.Ext(jarPath)) + "-arm64.jar"
+		if _, err := os.Stat(arm64Variant); err == nil {
+			return false
+		}
+	}
+	return true
+}
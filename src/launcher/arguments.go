@@ -0,0 +1,128 @@
+package launcher
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+const (
+	// DefaultLauncherName is substituted for ${launcher_name} when no branding is configured.
+	DefaultLauncherName = utils.DefaultLauncherName
+	// DefaultLauncherVersion is substituted for ${launcher_version} when no branding is configured.
+	DefaultLauncherVersion = utils.DefaultLauncherVersion
+)
+
+// argumentContext holds what argument rules are evaluated against.
+type argumentContext struct {
+	platform rules.Platform
+	features map[string]bool
+}
+
+// allowed evaluates a rule list with rules.EvaluateRules.
+func (c argumentContext) allowed(raw []interface{}) bool {
+	return rules.EvaluateRules(rules.Parse(raw), c.platform, c.features)
+}
+
+// resolveArguments flattens an "arguments.game" or "arguments.jvm" list into command-line
+// arguments. Conditional entries are kept only when their rules allow them, and placeholders
+// are substituted per argument so substituted values are never split again.
+func resolveArguments(entries []interface{}, ctx argumentContext, replacements map[string]string) []string {
+	var args []string
+	appendValue := func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			args = append(args, substituteArgument(v, replacements))
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					args = append(args, substituteArgument(s, replacements))
+				}
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			appendValue(e)
+		case map[string]interface{}:
+			rules, _ := e["rules"].([]interface{})
+			if len(rules) == 0 || ctx.allowed(rules) {
+				appendValue(e["value"])
+			}
+		}
+	}
+	return args
+}
+
+// substituteArgument replaces the ${name} placeholders of a single argument.
+func substituteArgument(arg string, replacements map[string]string) string {
+	return expandPlaceholders(arg, func(name string) (string, bool) {
+		value, ok := replacements[name]
+		return value, ok
+	})
+}
+
+// stripClasspathArgs removes "-cp <classpath>" pairs from JVM arguments, since the
+// LaunchPlan adds the classpath itself from its list of entries.
+func stripClasspathArgs(args []string) []string {
+	stripped := args[:0:0]
+	for i := 0; i < len(args); i++ {
+		if (args[i] == "-cp" || args[i] == "-classpath") && i+1 < len(args) {
+			i++
+			continue
+		}
+		stripped = append(stripped, args[i])
+	}
+	return stripped
+}
+
+// splitArgumentTemplate splits a `minecraftArguments` style template into arguments at
+// unquoted whitespace. Double or single quotes group text containing spaces into one argument
+// and are removed; a backslash makes the next character literal, except inside single quotes.
+// An unterminated quote runs to the end of the template.
+func splitArgumentTemplate(template string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		inToken bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range template {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inToken = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inToken = r, true
+		case unicode.IsSpace(r):
+			if inToken {
+				args = append(args, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inToken {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,117 @@
+package launcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgumentTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{"empty", "", nil},
+		{"blank", "  \t ", nil},
+		{"plain", "--username ${auth_player_name} --version ${version_name}", []string{"--username", "${auth_player_name}", "--version", "${version_name}"}},
+		{"repeated whitespace", "  --demo\t\t--width  854 ", []string{"--demo", "--width", "854"}},
+		{"double quotes", `--gameDir "/home/me/My Minecraft"`, []string{"--gameDir", "/home/me/My Minecraft"}},
+		{"backslashes escape inside double quotes", `"C:\Games\My Minecraft"`, []string{"C:GamesMy Minecraft"}},
+		{"single quotes keep backslashes", `--gameDir 'C:\Games\My Minecraft'`, []string{"--gameDir", `C:\Games\My Minecraft`}},
+		{"quoted part of an argument", `--title="My World" --x`, []string{"--title=My World", "--x"}},
+		{"empty quotes", `--server "" --port 25565`, []string{"--server", "", "--port", "25565"}},
+		{"escaped space", `--gameDir /home/me/My\ Minecraft`, []string{"--gameDir", "/home/me/My Minecraft"}},
+		{"escaped quote", `--motd \"hi\"`, []string{"--motd", `"hi"`}},
+		{"escaped quote inside double quotes", `--motd "say \"hi\""`, []string{"--motd", `say "hi"`}},
+		{"other quote inside quotes", `--motd "it's" 'a "b"'`, []string{"--motd", "it's", `a "b"`}},
+		{"trailing backslash", `--path C:\`, []string{"--path", `C:\`}},
+		{"unterminated quote", `--gameDir "/My Games`, []string{"--gameDir", "/My Games"}},
+		{"cjk text", "--username 玩家 --title 'マイ ワールド'", []string{"--username", "玩家", "--title", "マイ ワールド"}},
+		{"ideographic space", "--a\u3000--b", []string{"--a", "--b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitArgumentTemplate(tt.template); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgumentTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMinecraftArguments(t *testing.T) {
+	const template = "--username ${auth_player_name} --version ${version_name} --gameDir ${game_directory} --assetsDir ${assets_root} --uuid ${auth_uuid}"
+	tests := []struct {
+		name         string
+		template     string
+		replacements map[string]string
+		want         []string
+	}{
+		{
+			name:     "plain values",
+			template: template,
+			replacements: map[string]string{
+				"auth_player_name": "Steve",
+				"version_name":     "1.12.2",
+				"game_directory":   "/home/steve/.minecraft",
+				"assets_root":      "/home/steve/.minecraft/assets",
+				"auth_uuid":        "0123",
+			},
+			want: []string{"--username", "Steve", "--version", "1.12.2", "--gameDir", "/home/steve/.minecraft", "--assetsDir", "/home/steve/.minecraft/assets", "--uuid", "0123"},
+		},
+		{
+			name:     "game directory with spaces",
+			template: template,
+			replacements: map[string]string{
+				"auth_player_name": "Steve",
+				"version_name":     "1.8.9",
+				"game_directory":   `C:\Users\Steve\AppData\Roaming\My Minecraft`,
+				"assets_root":      `C:\Users\Steve\AppData\Roaming\My Minecraft\assets`,
+				"auth_uuid":        "0123",
+			},
+			want: []string{"--username", "Steve", "--version", "1.8.9", "--gameDir", `C:\Users\Steve\AppData\Roaming\My Minecraft`, "--assetsDir", `C:\Users\Steve\AppData\Roaming\My Minecraft\assets`, "--uuid", "0123"},
+		},
+		{
+			name:     "cjk username",
+			template: template,
+			replacements: map[string]string{
+				"auth_player_name": "史蒂夫",
+				"version_name":     "1.7.10",
+				"game_directory":   "/home/用户/.minecraft",
+				"assets_root":      "/home/用户/.minecraft/assets",
+				"auth_uuid":        "0123",
+			},
+			want: []string{"--username", "史蒂夫", "--version", "1.7.10", "--gameDir", "/home/用户/.minecraft", "--assetsDir", "/home/用户/.minecraft/assets", "--uuid", "0123"},
+		},
+		{
+			name:         "values with quotes and backslashes stay literal",
+			template:     "--username ${auth_player_name} --gameDir ${game_directory}",
+			replacements: map[string]string{"auth_player_name": `a"b'c`, "game_directory": `D:\it's "here"\`},
+			want:         []string{"--username", `a"b'c`, "--gameDir", `D:\it's "here"\`},
+		},
+		{
+			name:         "quoted template arguments",
+			template:     `--title "${version_name} Snapshot" --tweakClass net.minecraft.launchwrapper.AlphaVanillaTweaker`,
+			replacements: map[string]string{"version_name": "a1.2.6"},
+			want:         []string{"--title", "a1.2.6 Snapshot", "--tweakClass", "net.minecraft.launchwrapper.AlphaVanillaTweaker"},
+		},
+		{
+			name:         "empty value keeps its argument",
+			template:     "--userProperties ${user_properties} --demo",
+			replacements: map[string]string{"user_properties": ""},
+			want:         []string{"--userProperties", "", "--demo"},
+		},
+		{
+			name:         "unknown placeholder is kept",
+			template:     "--server ${server_address}",
+			replacements: map[string]string{},
+			want:         []string{"--server", "${server_address}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMinecraftArguments(tt.template, tt.replacements); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMinecraftArguments(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
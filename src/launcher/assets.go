@@ -0,0 +1,132 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Asset layouts, reported by the assets_layout event.
+const (
+	// AssetLayoutObjects is the hashed object store read by 1.7.10+ through the index.
+	AssetLayoutObjects = "objects"
+	// AssetLayoutVirtual copies assets by name into assets/virtual/<index> (1.6 "legacy" index).
+	AssetLayoutVirtual = "virtual"
+	// AssetLayoutResources copies assets by name into <game dir>/resources (pre-1.6 "pre-1.6" index).
+	AssetLayoutResources = "resources"
+)
+
+// assetIndexFile is the part of an asset index the layout depends on.
+type assetIndexFile struct {
+	Virtual        bool `json:"virtual"`
+	MapToResources bool `json:"map_to_resources"`
+	Objects        map[string]struct {
+		Hash string `json:"hash"`
+		Size int64  `json:"size"`
+	} `json:"objects"`
+}
+
+// selectAssetIndex returns the ID of the asset index a version uses. Indexes are stored under
+// the assetIndex id; the older "assets" field is only a fallback for JSONs without one.
+func selectAssetIndex(versionJSON *VersionJSON) string {
+	if versionJSON.AssetIndex.ID != "" {
+		return versionJSON.AssetIndex.ID
+	}
+	if versionJSON.Assets != "" {
+		return versionJSON.Assets
+	}
+	return "legacy"
+}
+
+// placeAsset hard-links src to dst, copying when linking is impossible. A dst of the right
+// size is kept.
+func placeAsset(src, dst string, size int64) (bool, error) {
+	if info, err := os.Stat(dst); err == nil && info.Size() == size {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return false, err
+	}
+	_ = os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return true, nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return false, err
+	}
+	return true, out.Close()
+}
+
+// prepareAssetLayout lays the assets of an index out the way the version expects, honoring the
+// index's virtual and map_to_resources flags, and emits assets_layout. It returns the directory
+// to pass as ${game_assets}. Virtual layouts go below virtualRoot. A missing index leaves the
+// object store as is.
+func prepareAssetLayout(gameDir, assetsRoot, virtualRoot, indexID string, E *events.EventEmitter) string {
+	data, err := os.ReadFile(filepath.Join(assetsRoot, "indexes", indexID+".json"))
+	if err != nil {
+		return assetsRoot
+	}
+	var index assetIndexFile
+	if err := json.Unmarshal(data, &index); err != nil {
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to parse asset index %s: %w", indexID, err)))
+		return assetsRoot
+	}
+
+	layout, dir := AssetLayoutObjects, assetsRoot
+	switch {
+	case index.MapToResources:
+		layout, dir = AssetLayoutResources, filepath.Join(gameDir, "resources")
+	case index.Virtual:
+		layout, dir = AssetLayoutVirtual, filepath.Join(virtualRoot, indexID)
+	}
+
+	placed, missing := 0, 0
+	if layout != AssetLayoutObjects {
+		for name, object := range index.Objects {
+			if len(object.Hash) < 2 || strings.Contains(name, "..") {
+				continue
+			}
+			src := filepath.Join(assetsRoot, "objects", object.Hash[:2], object.Hash)
+			if _, err := os.Stat(src); err != nil {
+				missing++
+				continue
+			}
+			changed, err := placeAsset(src, filepath.Join(dir, filepath.FromSlash(name)), object.Size)
+			if err != nil {
+				E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to place asset %s: %w", name, err)))
+				continue
+			}
+			if changed {
+				placed++
+			}
+		}
+	}
+
+	E.Emit("assets_layout", map[string]interface{}{
+		"index":   indexID,
+		"layout":  layout,
+		"dir":     dir,
+		"placed":  placed,
+		"missing": missing,
+	})
+	return dir
+}
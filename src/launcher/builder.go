@@ -0,0 +1,277 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// Rule is a condition of a library or argument, in the form used by version JSONs.
+// The last matching rule decides whether the entry applies.
+type Rule struct {
+	// Action is "allow" or "disallow".
+	Action   string          `json:"action"`
+	OS       *RuleOS         `json:"os,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// RuleOS restricts a rule to an operating system ("windows", "osx", "linux"),
+// an architecture ("x86", "arm64") and/or an OS version regular expression.
+type RuleOS struct {
+	Name    string `json:"name,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Allow returns a rule allowing an entry on the given operating system, or everywhere when os is empty.
+func Allow(osName string) Rule {
+	if osName == "" {
+		return Rule{Action: "allow"}
+	}
+	return Rule{Action: "allow", OS: &RuleOS{Name: osName}}
+}
+
+// Disallow returns a rule excluding an entry on the given operating system.
+func Disallow(osName string) Rule {
+	return Rule{Action: "disallow", OS: &RuleOS{Name: osName}}
+}
+
+// Library describes a library added with VersionBuilder.Library.
+type Library struct {
+	// Name is the Maven coordinate "group:artifact:version[:classifier]".
+	Name string
+	// Repository is the base URL of the Maven repository serving the library, e.g. "https://maven.example.org/".
+	// It is ignored when URL is set.
+	Repository string
+	// URL is the direct download URL of the artifact.
+	URL string
+	// SHA1 and Size describe the artifact for integrity checks; both are optional.
+	SHA1 string
+	Size int64
+	// Rules restrict the library to some platforms.
+	Rules []Rule
+}
+
+// Argument is a conditional argument: Value is only passed when Rules allow it.
+type Argument struct {
+	Rules []Rule   `json:"rules"`
+	Value []string `json:"value"`
+}
+
+// builtArtifact, builtLibrary and builtVersion mirror the version JSON layout written by the builder.
+type builtArtifact struct {
+	Path string `json:"path"`
+	URL  string `json:"url,omitempty"`
+	SHA1 string `json:"sha1,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+type builtLibrary struct {
+	Name      string `json:"name"`
+	Downloads struct {
+		Artifact builtArtifact `json:"artifact"`
+	} `json:"downloads"`
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+type builtArguments struct {
+	Game []any `json:"game,omitempty"`
+	JVM  []any `json:"jvm,omitempty"`
+}
+
+type builtVersion struct {
+	ID                 string          `json:"id"`
+	InheritsFrom       string          `json:"inheritsFrom,omitempty"`
+	Type               string          `json:"type"`
+	MainClass          string          `json:"mainClass,omitempty"`
+	MinecraftArguments string          `json:"minecraftArguments,omitempty"`
+	Arguments          *builtArguments `json:"arguments,omitempty"`
+	Assets             string          `json:"assets,omitempty"`
+	ReleaseTime        string          `json:"releaseTime,omitempty"`
+	Time               string          `json:"time,omitempty"`
+	Libraries          []builtLibrary  `json:"libraries"`
+}
+
+// VersionBuilder constructs version JSONs programmatically, e.g. for custom clients or private
+// loader distributions. Methods can be chained; the first error is reported by Build or Write.
+//
+//	data, err := launcher.NewVersionBuilder("my-client-1.20.1").
+//		InheritsFrom("1.20.1").
+//		MainClass("com.example.client.Main").
+//		Library(launcher.Library{Name: "com.example:client:1.0", Repository: "https://maven.example.com/"}).
+//		GameArgs("--clientMode", "${version_type}").
+//		Build()
+type VersionBuilder struct {
+	version builtVersion
+	err     error
+}
+
+// ------------------ Builder ------------------
+
+// NewVersionBuilder starts a version JSON with the given ID and type "release".
+func NewVersionBuilder(id string) *VersionBuilder {
+	return &VersionBuilder{version: builtVersion{ID: id, Type: "release", Libraries: []builtLibrary{}}}
+}
+
+// InheritsFrom makes the version inherit libraries, arguments and assets from parent.
+func (b *VersionBuilder) InheritsFrom(parent string) *VersionBuilder {
+	b.version.InheritsFrom = parent
+	return b
+}
+
+// Type sets the version type, e.g. "release" or "snapshot", exposed as ${version_type}.
+func (b *VersionBuilder) Type(versionType string) *VersionBuilder {
+	b.version.Type = versionType
+	return b
+}
+
+// MainClass sets the class launched by the JVM.
+func (b *VersionBuilder) MainClass(mainClass string) *VersionBuilder {
+	b.version.MainClass = mainClass
+	return b
+}
+
+// Assets sets the asset index ID.
+func (b *VersionBuilder) Assets(id string) *VersionBuilder {
+	b.version.Assets = id
+	return b
+}
+
+// ReleaseTime sets both the release and the modification time, in RFC 3339 format.
+func (b *VersionBuilder) ReleaseTime(t string) *VersionBuilder {
+	b.version.ReleaseTime = t
+	b.version.Time = t
+	return b
+}
+
+// LegacyArguments sets minecraftArguments, the space separated argument string used before 1.13.
+// It cannot be combined with GameArgs or JVMArgs.
+func (b *VersionBuilder) LegacyArguments(args string) *VersionBuilder {
+	b.version.MinecraftArguments = args
+	return b
+}
+
+// arguments returns the modern arguments object, creating it on first use.
+func (b *VersionBuilder) arguments() *builtArguments {
+	if b.version.Arguments == nil {
+		b.version.Arguments = &builtArguments{}
+	}
+	return b.version.Arguments
+}
+
+// GameArgs appends unconditional game arguments. Placeholders such as ${auth_player_name} are kept as is.
+func (b *VersionBuilder) GameArgs(args ...string) *VersionBuilder {
+	arguments := b.arguments()
+	for _, arg := range args {
+		arguments.Game = append(arguments.Game, arg)
+	}
+	return b
+}
+
+// JVMArgs appends unconditional JVM arguments.
+func (b *VersionBuilder) JVMArgs(args ...string) *VersionBuilder {
+	arguments := b.arguments()
+	for _, arg := range args {
+		arguments.JVM = append(arguments.JVM, arg)
+	}
+	return b
+}
+
+// ConditionalGameArg appends a game argument that only applies when its rules allow it.
+func (b *VersionBuilder) ConditionalGameArg(arg Argument) *VersionBuilder {
+	b.arguments().Game = append(b.arguments().Game, arg)
+	return b
+}
+
+// ConditionalJVMArg appends a JVM argument that only applies when its rules allow it.
+func (b *VersionBuilder) ConditionalJVMArg(arg Argument) *VersionBuilder {
+	b.arguments().JVM = append(b.arguments().JVM, arg)
+	return b
+}
+
+// Library adds a library. Its artifact path is derived from the Maven coordinate, so the
+// launcher finds it under libraries/ and the downloader can fetch it from its repository.
+func (b *VersionBuilder) Library(lib Library) *VersionBuilder {
+	path := downloader.MavenPath(lib.Name)
+	if path == "" {
+		if b.err == nil {
+			b.err = fmt.Errorf("library %q is not a group:artifact:version coordinate", lib.Name)
+		}
+		return b
+	}
+
+	url := lib.URL
+	if url == "" && lib.Repository != "" {
+		url = strings.TrimSuffix(lib.Repository, "/") + "/" + path
+	}
+
+	built := builtLibrary{Name: lib.Name, Rules: lib.Rules}
+	built.Downloads.Artifact = builtArtifact{Path: path, URL: url, SHA1: lib.SHA1, Size: lib.Size}
+	b.version.Libraries = append(b.version.Libraries, built)
+	return b
+}
+
+// Build returns the indented version JSON. The result is checked with ValidateVersionJSON,
+// so anything it returns is accepted by the launcher; blocking issues are returned as a *VersionJSONError.
+func (b *VersionBuilder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := validateVersionID(b.version.ID); err != nil {
+		return nil, err
+	}
+	if b.version.MinecraftArguments != "" && b.version.Arguments != nil {
+		return nil, fmt.Errorf("version %s: legacy arguments cannot be combined with game or JVM arguments", b.version.ID)
+	}
+
+	data, err := json.MarshalIndent(b.version, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var blocking []ValidationIssue
+	for _, issue := range ValidateVersionJSON(data) {
+		if !issue.Warning {
+			blocking = append(blocking, issue)
+		}
+	}
+	if len(blocking) > 0 {
+		return nil, &VersionJSONError{File: b.version.ID + ".json", Issues: blocking}
+	}
+	return data, nil
+}
+
+// Write builds the version JSON and installs it as versions/<id>/<id>.json under gameDir.
+// It returns the path of the written file.
+func (b *VersionBuilder) Write(gameDir string) (string, error) {
+	data, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+
+	versionDir := utils.NewLayout(gameDir).VersionDir(b.version.ID)
+	if err := os.MkdirAll(versionDir, utils.Modes.Dir); err != nil {
+		return "", fmt.Errorf("failed to create version directory: %w", err)
+	}
+
+	path := filepath.Join(versionDir, b.version.ID+".json")
+	if err := os.WriteFile(path, data, utils.Modes.File); err != nil {
+		return "", fmt.Errorf("failed to write version JSON: %w", err)
+	}
+	return path, nil
+}
+
+// validateVersionID rejects version IDs that cannot be used as a directory and file name.
+func validateVersionID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\:`) {
+		return fmt.Errorf("invalid version id %q", id)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package launcher
+
+import (
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Sources a library can be resolved from, reported in LibraryResolution.Source.
+const (
+	// LibrarySourceArtifact is the downloads.artifact.path of the version JSON.
+	LibrarySourceArtifact = "artifact"
+	// LibrarySourceVersionDir is <version dir>/<artifact>-<version>.jar.
+	LibrarySourceVersionDir = "version_dir"
+	// LibrarySourceMaven is the Maven layout <library root>/<group>/<artifact>/<version>/<artifact>-<version>.jar.
+	LibrarySourceMaven = "maven"
+	// LibrarySourceFlat is <library root>/<group>/<artifact>/<artifact>-<version>.jar.
+	LibrarySourceFlat = "flat"
+	// LibrarySourceVersionName is <version dir>/<library name>.jar.
+	LibrarySourceVersionName = "version_name"
+	// LibrarySourceExtra is a library added through LaunchOptions.ExtraLibraries.
+	LibrarySourceExtra = "extra"
+)
+
+// LibraryResolution describes how one library of the version was resolved.
+type LibraryResolution struct {
+	// Name is the Maven coordinate of the library.
+	Name string
+	// Path is the file put on the classpath or, for a missing library, where it is expected.
+	Path string
+	// Source is the LibrarySource* pattern that matched; empty when the library is missing.
+	Source string
+	// ArtifactPath is the repository-relative path of the artifact.
+	ArtifactPath string
+	// URL and SHA1 come from the version JSON and are empty when it declares no download.
+	URL  string
+	SHA1 string
+	// Repository is the Maven repository of a library declared by name only, if given.
+	Repository string
+	// Missing is set when no file was found for the library.
+	Missing bool
+}
+
+// ClasspathReport is the result of resolving the classpath of a version. Callers can
+// download the Missing libraries instead of parsing library_missing events.
+type ClasspathReport struct {
+	// Classpath lists every resolved library and the client JAR in load order.
+	Classpath []string
+	// Libraries lists every library that applies to this platform, in version JSON order.
+	Libraries []LibraryResolution
+	// Missing lists the libraries that could not be found.
+	Missing []LibraryResolution
+	// ClientJar is the client JAR on the classpath; empty when it does not exist.
+	ClientJar string
+}
+
+// add records a library, putting it on the classpath when it was found.
+func (r *ClasspathReport) add(resolution LibraryResolution) {
+	r.Libraries = append(r.Libraries, resolution)
+	if resolution.Missing {
+		r.Missing = append(r.Missing, resolution)
+		return
+	}
+	r.Classpath = append(r.Classpath, resolution.Path)
+}
+
+// downloadURL returns where a missing library can be downloaded from, or "" when only
+// downloader.LibraryMirrors can be tried.
+func (l *LibraryResolution) downloadURL() string {
+	if l.URL != "" {
+		return l.URL
+	}
+	if l.Repository != "" {
+		return strings.TrimSuffix(l.Repository, "/") + "/" + l.ArtifactPath
+	}
+	return ""
+}
+
+// downloadMissingLibraries downloads missing libraries to their expected paths, trying the
+// declared URL or repository first and downloader.LibraryMirrors after it. It returns the
+// number of libraries downloaded. The install lock of gameDir is held meanwhile.
+func downloadMissingLibraries(gameDir string, missing []LibraryResolution, E *events.EventEmitter) int {
+	// Repairs write into the shared libraries like installs do
+	lock, err := downloader.AcquireLock(gameDir, "repair libraries", downloader.InstallLockOptions, E)
+	if err != nil {
+		return 0
+	}
+	defer lock.Release()
+
+	E.Emit("missing_libraries_download_start", len(missing))
+
+	downloaded := 0
+	for _, lib := range missing {
+		E.Emit("library_download_start", lib.Name)
+		if err := downloader.DownloadLibraryFile(lib.Path, lib.downloadURL(), lib.ArtifactPath, lib.SHA1, E); err != nil {
+			E.Emit("library_failed", downloader.FailureDetails(lib.Name, err))
+			continue
+		}
+		E.Emit("library_done", lib.Name)
+		downloaded++
+	}
+
+	E.Emit("missing_libraries_downloaded", map[string]int{"downloaded": downloaded, "missing": len(missing)})
+	return downloaded
+}
@@ -0,0 +1,217 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Severities of a CompatRule.
+const (
+	// CompatWarning only reports the problem through a compat_warning event.
+	CompatWarning = "warning"
+	// CompatError aborts the launch with ErrIncompatibleJava unless another runtime fits.
+	CompatError = "error"
+)
+
+// ErrIncompatibleJava is returned when the selected Java runtime is known not to work with the version.
+var ErrIncompatibleJava error = i18n.New("launcher.incompatible_java", "java runtime is incompatible with this version")
+
+// CompatRule describes a known-broken combination of a Java runtime and a game version.
+type CompatRule struct {
+	ID string `json:"id"`
+	// Loader is matched case-insensitively against the IDs of the version and its parents
+	// and against its main class, e.g. "forge" or "launchwrapper". Empty matches every version.
+	Loader string `json:"loader,omitempty"`
+	// Minecraft is a regular expression matched against the base (vanilla) version ID.
+	// Empty matches every version.
+	Minecraft string `json:"minecraft,omitempty"`
+	// MinJava and MaxJava bound the Java major versions that work; zero means unbounded.
+	MinJava int `json:"minJava,omitempty"`
+	MaxJava int `json:"maxJava,omitempty"`
+	// Severity is CompatWarning or CompatError; empty means CompatWarning.
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// CompatMatrix is a list of known-broken Java and version combinations, either the built-in
+// DefaultCompatMatrix or a maintained copy fetched with FetchCompatMatrix.
+type CompatMatrix struct {
+	Rules []CompatRule `json:"rules"`
+}
+
+// DefaultCompatMatrix holds the combinations known at release time.
+var DefaultCompatMatrix = &CompatMatrix{Rules: []CompatRule{
+	{
+		ID:       "launchwrapper-java8",
+		Loader:   "launchwrapper",
+		MaxJava:  8,
+		Severity: CompatError,
+		Message:  "LaunchWrapper based versions (legacy Forge, OptiFine, LiteLoader) crash on Java 9 and newer",
+	},
+	{
+		ID:        "forge-pre-1.17-java16",
+		Loader:    "forge",
+		Minecraft: `^1\.1[3-6](\.|-|$)`,
+		MaxJava:   15,
+		Severity:  CompatWarning,
+		Message:   "Forge before 1.17 is not supported on Java 16 and newer",
+	},
+	{
+		ID:        "minecraft-1.17-java16",
+		Minecraft: `^1\.17(\.|-|$)`,
+		MinJava:   16,
+		Severity:  CompatError,
+		Message:   "Minecraft 1.17 requires Java 16 or newer",
+	},
+	{
+		ID:        "minecraft-1.18-java17",
+		Minecraft: `^1\.(18|19)(\.|-|$)|^1\.20(\.[0-4])?(-|$)`,
+		MinJava:   17,
+		Severity:  CompatError,
+		Message:   "Minecraft 1.18 to 1.20.4 requires Java 17 or newer",
+	},
+	{
+		ID:        "minecraft-1.20.5-java21",
+		Minecraft: `^1\.20\.([5-9]|\d\d)(-|$)|^1\.2[1-9](\.|-|$)`,
+		MinJava:   21,
+		Severity:  CompatError,
+		Message:   "Minecraft 1.20.5 and newer requires Java 21 or newer",
+	},
+}}
+
+// FetchCompatMatrix downloads a compatibility matrix in the JSON form of CompatMatrix.
+// Callers typically fall back to DefaultCompatMatrix when it fails.
+func FetchCompatMatrix(url string) (*CompatMatrix, error) {
+	resp, err := utils.HTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch compatibility matrix: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch compatibility matrix: %s", resp.Status)
+	}
+
+	var matrix CompatMatrix
+	if err := json.NewDecoder(resp.Body).Decode(&matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse compatibility matrix: %w", err)
+	}
+	return &matrix, nil
+}
+
+// javaMajor extracts the major version from a java.version string such as "1.8.0_392", "17.0.8" or "21".
+func javaMajor(version string) int {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		version = version[:end]
+	}
+	major, _ := strconv.Atoi(version)
+	return major
+}
+
+// appliesTo reports whether the rule concerns the given version.
+func (r CompatRule) appliesTo(vj *VersionJSON) bool {
+	base := vj.ID
+	loaderFound := r.Loader == "" || strings.Contains(strings.ToLower(vj.MainClass), strings.ToLower(r.Loader))
+	for v := vj; v != nil; v = v.parent {
+		base = v.ID
+		if strings.Contains(strings.ToLower(v.ID), strings.ToLower(r.Loader)) {
+			loaderFound = true
+		}
+	}
+	if !loaderFound {
+		return false
+	}
+
+	if r.Minecraft == "" {
+		return true
+	}
+	matched, err := regexp.MatchString(r.Minecraft, base)
+	return err == nil && matched
+}
+
+// allows reports whether the rule accepts the Java major version.
+func (r CompatRule) allows(major int) bool {
+	return (r.MinJava == 0 || major >= r.MinJava) && (r.MaxJava == 0 || major <= r.MaxJava)
+}
+
+// Check returns the rules violated by running the version on the given Java major version.
+func (m *CompatMatrix) Check(vj *VersionJSON, major int) []CompatRule {
+	var violated []CompatRule
+	for _, rule := range m.Rules {
+		if rule.appliesTo(vj) && !rule.allows(major) {
+			violated = append(violated, rule)
+		}
+	}
+	return violated
+}
+
+// firstCompatError returns the first blocking rule, if any.
+func firstCompatError(rules []CompatRule) (CompatRule, bool) {
+	for _, rule := range rules {
+		if rule.Severity == CompatError {
+			return rule, true
+		}
+	}
+	return CompatRule{}, false
+}
+
+// checkJavaCompat checks javaPath against the matrix. When it violates a rule and one of the
+// candidates does not, that candidate is returned instead, emitting java_runtime_selected.
+// Remaining violations are emitted as compat_warning, and blocking ones fail with ErrIncompatibleJava.
+func checkJavaCompat(matrix *CompatMatrix, vj *VersionJSON, javaPath string, candidates []string, E *events.EventEmitter) (string, error) {
+	info, err := ProbeJVM(javaPath)
+	if err != nil {
+		E.Emit("jvm_probe_failed", err.Error())
+		return javaPath, nil
+	}
+
+	violated := matrix.Check(vj, javaMajor(info.Version))
+	if len(violated) == 0 {
+		return javaPath, nil
+	}
+
+	// Prefer a configured runtime that satisfies every rule
+	for _, candidate := range candidates {
+		if candidate == javaPath {
+			continue
+		}
+		candidateInfo, err := ProbeJVM(candidate)
+		if err != nil {
+			continue
+		}
+		if len(matrix.Check(vj, javaMajor(candidateInfo.Version))) == 0 {
+			E.Emit("java_runtime_selected", map[string]string{
+				"javaPath": candidate,
+				"version":  candidateInfo.Version,
+				"replaced": javaPath,
+			})
+			return candidate, nil
+		}
+	}
+
+	for _, rule := range violated {
+		E.Emit("compat_warning", map[string]string{
+			"id":       rule.ID,
+			"message":  rule.Message,
+			"severity": rule.Severity,
+			"java":     info.Version,
+		})
+	}
+
+	if rule, blocking := firstCompatError(violated); blocking {
+		err := fmt.Errorf("%w: %s (Java %s at %s)", ErrIncompatibleJava, rule.Message, info.Version, javaPath)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	return javaPath, nil
+}
@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// DefaultDebugPort is the JDWP port used when DebugOptions.Port is zero.
+const DefaultDebugPort = 5005
+
+// DebugOptions enables the JDWP agent so an IDE debugger can attach to the game.
+type DebugOptions struct {
+	// Port is the port the JVM listens on; zero uses DefaultDebugPort.
+	Port int
+	// Host is the interface to listen on. Empty listens on localhost only; use "*" to
+	// accept remote debuggers.
+	Host string
+	// Suspend makes the JVM wait for a debugger before running the main class.
+	Suspend bool
+}
+
+// JavaAgent is a -javaagent attached at launch. Path may contain launch placeholders.
+type JavaAgent struct {
+	Path    string
+	Options string
+	// Library locates the agent JAR like an ExtraLibrary, downloading it when missing, when
+	// Path is empty. The agent is not put on the classpath.
+	Library *ExtraLibrary
+}
+
+// FlightRecording starts a Java Flight Recorder recording when the game starts (Java 11+).
+type FlightRecording struct {
+	// File is where the recording is written; it may contain launch placeholders. Empty writes
+	// to ${game_dir}/recordings/<version>-<timestamp>.jfr.
+	File string
+	// Settings is the JFR configuration, "default" or "profile"; empty uses "default".
+	Settings string
+	// Duration stops the recording after the given time; zero records until the game exits.
+	Duration time.Duration
+}
+
+// debugJVMArgs renders the JDWP, agent and JFR options of opts into JVM arguments.
+func debugJVMArgs(opts LaunchOptions, vars map[string]string, E *events.EventEmitter) ([]string, error) {
+	var args []string
+
+	if opts.Debug != nil {
+		port := opts.Debug.Port
+		if port == 0 {
+			port = DefaultDebugPort
+		}
+		address := strconv.Itoa(port)
+		if opts.Debug.Host != "" {
+			address = opts.Debug.Host + ":" + address
+		}
+		suspend := "n"
+		if opts.Debug.Suspend {
+			suspend = "y"
+		}
+		args = append(args, "-agentlib:jdwp=transport=dt_socket,server=y,suspend="+suspend+",address="+address)
+		E.Emit("debug_enabled", map[string]interface{}{
+			"address": address,
+			"suspend": opts.Debug.Suspend,
+		})
+	}
+
+	for _, agent := range opts.JavaAgents {
+		path := expandTemplate(agent.Path, vars)
+		if _, err := os.Stat(path); err != nil {
+			err = fmt.Errorf("java agent not found: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		arg := "-javaagent:" + path
+		if agent.Options != "" {
+			arg += "=" + expandTemplate(agent.Options, vars)
+		}
+		args = append(args, arg)
+	}
+
+	if rec := opts.FlightRecording; rec != nil {
+		file := expandTemplate(rec.File, vars)
+		if file == "" {
+			name := opts.Version + "-" + time.Now().Format("20060102-150405") + ".jfr"
+			file = filepath.Join(vars["game_dir"], "recordings", name)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), utils.Modes.Dir); err != nil {
+			err = fmt.Errorf("failed to create recording directory: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+
+		settings := rec.Settings
+		if settings == "" {
+			settings = "default"
+		}
+		options := []string{"filename=" + file, "settings=" + settings}
+		if rec.Duration > 0 {
+			options = append(options, "duration="+strconv.Itoa(int(rec.Duration/time.Second))+"s")
+		}
+		args = append(args, "-XX:StartFlightRecording="+strings.Join(options, ","))
+		E.Emit("flight_recording_enabled", file)
+	}
+
+	return args, nil
+}
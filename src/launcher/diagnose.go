@@ -0,0 +1,170 @@
+package launcher
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/sysinfo"
+)
+
+// Kinds of NativeLoadFailure.
+const (
+	// NativeGlibcTooOld means the natives were built against a newer glibc than the system has.
+	NativeGlibcTooOld = "glibc_too_old"
+	// NativeMSVCMissing means the Microsoft Visual C++ redistributable is not installed.
+	NativeMSVCMissing = "msvc_runtime_missing"
+	// NativeWrongArch means the natives do not match the architecture of the JVM.
+	NativeWrongArch = "wrong_arch"
+	// NativeSystemLibraryMissing means a system library the natives depend on is not installed (e.g. libGL).
+	NativeSystemLibraryMissing = "system_library_missing"
+	// NativeNotFound means the natives were not extracted or the library path is wrong.
+	NativeNotFound = "natives_missing"
+)
+
+// NativeLoadFailure is the payload of the native_load_failure event.
+type NativeLoadFailure struct {
+	Kind string
+	// Library is the native or system library that failed to load, if known.
+	Library string
+	// Detail holds the specifics of the failure, e.g. the required "GLIBC_2.28".
+	Detail string
+	// Hint is an actionable explanation for the user.
+	Hint string
+	// Line is the output line the failure was detected in.
+	Line string
+}
+
+var (
+	glibcPattern       = regexp.MustCompile("version `(GLIBC_[0-9.]+)' not found(?: \\(required by ([^)]+)\\))?")
+	sharedLibPattern   = regexp.MustCompile(`([\w.+-]+\.so[\w.]*): cannot open shared object file`)
+	machoArchPattern   = regexp.MustCompile(`incompatible architecture \(have '(\w+)', need '(\w+)'\)`)
+	libraryPathPattern = regexp.MustCompile(`no (\w+) in java\.library\.path|Failed to locate library: (\S+)`)
+	dllPattern         = regexp.MustCompile(`(?i)([\w.-]+\.dll)`)
+)
+
+// DiagnoseNativeLoadFailure maps a line of game output to a native loading diagnostic.
+// It recognises UnsatisfiedLinkError messages and the linker errors they wrap.
+func DiagnoseNativeLoadFailure(line string) (*NativeLoadFailure, bool) {
+	lower := strings.ToLower(line)
+
+	if m := glibcPattern.FindStringSubmatch(line); m != nil {
+		return &NativeLoadFailure{
+			Kind:    NativeGlibcTooOld,
+			Library: m[2],
+			Detail:  m[1],
+			Hint:    "The system C library is too old for these natives; " + m[1] + " or newer is required. Update the distribution or use natives built for an older glibc.",
+			Line:    line,
+		}, true
+	}
+
+	if strings.Contains(lower, "vcruntime") || strings.Contains(lower, "msvcp") ||
+		(strings.Contains(lower, "can't find dependent libraries") && strings.Contains(lower, ".dll")) {
+		library := ""
+		if m := dllPattern.FindStringSubmatch(line); m != nil {
+			library = m[1]
+		}
+		return &NativeLoadFailure{
+			Kind:    NativeMSVCMissing,
+			Library: library,
+			Hint:    "The Microsoft Visual C++ Redistributable (2015-2022) for the architecture of Java is missing; install it from Microsoft.",
+			Line:    line,
+		}, true
+	}
+
+	if m := machoArchPattern.FindStringSubmatch(line); m != nil {
+		return &NativeLoadFailure{
+			Kind:   NativeWrongArch,
+			Detail: "have " + m[1] + ", need " + m[2],
+			Hint:   "The natives do not match the Java architecture; use a " + m[1] + " Java or install " + m[2] + " natives.",
+			Line:   line,
+		}, true
+	}
+	if strings.Contains(line, "wrong ELF class") || strings.Contains(line, "32-bit .dll on a AMD 64-bit platform") ||
+		strings.Contains(line, "64-bit .dll on a IA 32-bit platform") {
+		return &NativeLoadFailure{
+			Kind: NativeWrongArch,
+			Hint: "The natives were built for a different architecture (32-bit vs 64-bit) than the Java runtime; use a matching Java.",
+			Line: line,
+		}, true
+	}
+
+	if m := sharedLibPattern.FindStringSubmatch(line); m != nil {
+		return &NativeLoadFailure{
+			Kind:    NativeSystemLibraryMissing,
+			Library: m[1],
+			Hint:    m[1] + " is not installed; install it with the system package manager (e.g. the Mesa or graphics driver package for libGL).",
+			Line:    line,
+		}, true
+	}
+
+	if m := libraryPathPattern.FindStringSubmatch(line); m != nil {
+		library := m[1]
+		if library == "" {
+			library = m[2]
+		}
+		return &NativeLoadFailure{
+			Kind:    NativeNotFound,
+			Library: library,
+			Hint:    "The natives were not found; reinstall the version so they are downloaded and extracted again.",
+			Line:    line,
+		}, true
+	}
+
+	return nil, false
+}
+
+// maxMonitoredLine bounds the unterminated output an OutputMonitor buffers.
+const maxMonitoredLine = 64 * 1024
+
+// OutputMonitor is an io.Writer that scans game output line by line and emits a
+// native_load_failure event for every native loading problem it recognises, and
+// gpu_detected (a sysinfo.GPU) when the game reports its renderer. Monitors created by
+// LaunchWithOptions also emit game_oom once when the game runs out of memory.
+// Use it alongside the real output, e.g. io.MultiWriter(os.Stderr, monitor).
+type OutputMonitor struct {
+	mu      sync.Mutex
+	partial []byte
+	emitter *events.EventEmitter
+	oom     *oomWatch
+}
+
+// NewOutputMonitor creates an OutputMonitor reporting to E.
+func NewOutputMonitor(E *events.EventEmitter) *OutputMonitor {
+	return &OutputMonitor{emitter: E}
+}
+
+// Write scans complete lines of p; an unterminated trailing line is kept until the next write.
+func (m *OutputMonitor) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.partial = append(m.partial, p...)
+	if len(m.partial) > maxMonitoredLine && bytes.IndexByte(m.partial, '\n') < 0 {
+		// Give up on absurdly long lines rather than buffering them
+		m.partial = m.partial[:0]
+	}
+	for {
+		newline := bytes.IndexByte(m.partial, '\n')
+		if newline < 0 {
+			break
+		}
+		line := strings.TrimRight(string(m.partial[:newline]), "\r")
+		m.partial = m.partial[newline+1:]
+
+		if failure, ok := DiagnoseNativeLoadFailure(line); ok {
+			m.emitter.Emit("native_load_failure", failure)
+		}
+		if m.oom != nil {
+			if report, ok := DiagnoseOOM(line, m.oom.maxRam); ok {
+				m.oom.report(report, m.emitter)
+			}
+		}
+		if gpu, _, ok := sysinfo.ParseLogLine(line); ok && gpu != nil {
+			m.emitter.Emit("gpu_detected", *gpu)
+		}
+	}
+	return len(p), nil
+}
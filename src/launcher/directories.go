@@ -0,0 +1,112 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// Directories redirects the files a launch writes next to the shared versions and assets, for
+// deployments where those are read-only (Flatpak, shared network installs). Empty fields keep
+// the default locations inside the game directory.
+type Directories struct {
+	// Natives is where native libraries are extracted, in a subdirectory per version.
+	// Default: versions/<version>/natives.
+	Natives string
+	// VirtualAssets is where assets of legacy (1.6) virtual indexes are laid out, in a
+	// subdirectory per index. Default: assets/virtual.
+	VirtualAssets string
+	// LogConfigs is where the logging configurations of versions are downloaded when they are
+	// not installed yet. Default: assets/log_configs.
+	LogConfigs string
+}
+
+// WritableDirectories returns Directories placing every redirectable file below root, e.g. a
+// directory under os.UserCacheDir() when the game directory is read-only.
+func WritableDirectories(root string) Directories {
+	return Directories{
+		Natives:       filepath.Join(root, "natives"),
+		VirtualAssets: filepath.Join(root, "assets", "virtual"),
+		LogConfigs:    filepath.Join(root, "log_configs"),
+	}
+}
+
+// ------------------ Locations ------------------
+
+// nativesDir returns the directory natives of a version are extracted to.
+func (d Directories) nativesDir(version, versionDir string, platform rules.Platform) string {
+	dir := nativesDirFor(versionDir, platform)
+	if d.Natives == "" {
+		return dir
+	}
+	return filepath.Join(d.Natives, version, filepath.Base(dir))
+}
+
+// virtualAssetsDir returns the root of the virtual asset layouts.
+func (d Directories) virtualAssetsDir(assetsRoot string) string {
+	if d.VirtualAssets == "" {
+		return filepath.Join(assetsRoot, "virtual")
+	}
+	return d.VirtualAssets
+}
+
+// readOnlyHint explains how to redirect a write that failed on a read-only location.
+func readOnlyHint(err error, field string) error {
+	if errors.Is(err, os.ErrPermission) || strings.Contains(err.Error(), "read-only file system") {
+		return fmt.Errorf("%w (set LaunchOptions.Directories.%s to a writable directory)", err, field)
+	}
+	return err
+}
+
+// ------------------ Logging ------------------
+
+// logConfigArgument returns the JVM argument pointing the game at its logging configuration,
+// downloading the configuration first if needed. It looks in LogConfigs, then in
+// assets/log_configs. Versions without one, or whose configuration cannot be fetched, get no
+// argument; the latter is reported as log_config_unavailable.
+func logConfigArgument(versionJSON *VersionJSON, assetsRoot string, dirs Directories, E *events.EventEmitter) string {
+	client := versionJSON.Logging.Client
+	if client.Argument == "" || client.File.ID == "" || strings.ContainsAny(client.File.ID, `/\`) {
+		return ""
+	}
+
+	candidates := []string{filepath.Join(assetsRoot, "log_configs", client.File.ID)}
+	if dirs.LogConfigs != "" {
+		candidates = append([]string{filepath.Join(dirs.LogConfigs, client.File.ID)}, candidates...)
+	}
+
+	path := ""
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" && client.File.URL != "" {
+		path = candidates[0]
+		if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+			err = readOnlyHint(err, "LogConfigs")
+			E.Emit("log_config_unavailable", map[string]string{"id": client.File.ID, "error": err.Error()})
+			return ""
+		}
+		if err := downloader.DownloadFile(path, client.File.URL, E); err != nil {
+			E.Emit("log_config_unavailable", map[string]string{"id": client.File.ID, "error": err.Error()})
+			return ""
+		}
+	}
+	if path == "" {
+		return ""
+	}
+
+	abs, _ := filepath.Abs(path)
+	return strings.ReplaceAll(client.Argument, "${path}", abs)
+}
@@ -0,0 +1,52 @@
+package launcher
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// windowsGPUPreferenceHint explains how to select the discrete GPU on Windows, where it cannot be
+// requested from the command line: drivers look for the NvOptimusEnablement and
+// AmdPowerXpressRequestHighPerformance symbols exported by the executable, which java(w).exe lacks.
+const windowsGPUPreferenceHint = `Windows selects the GPU per executable: add a string value named after the full path of ` +
+	`javaw.exe with data "GpuPreference=2;" under HKEY_CURRENT_USER\Software\Microsoft\DirectX\UserGpuPreferences, ` +
+	`or choose "High performance" for it in Settings > System > Display > Graphics`
+
+// displayOptions renders the display related options of opts into JVM arguments and
+// environment entries for the current OS.
+func displayOptions(opts LaunchOptions, javaPath string, E *events.EventEmitter) ([]string, []string) {
+	var jvmArgs, env []string
+
+	// Java 9+ scales AWT/Swing windows on HiDPI screens; this pins the factor
+	if opts.UIScale > 0 {
+		jvmArgs = append(jvmArgs, "-Dsun.java2d.uiScale="+strconv.FormatFloat(opts.UIScale, 'f', -1, 64))
+	}
+
+	// Lets LWJGL 2 start on machines without hardware OpenGL drivers (e.g. VMs) instead of crashing
+	if opts.AllowSoftwareOpenGL && runtime.GOOS == "windows" {
+		jvmArgs = append(jvmArgs, "-Dorg.lwjgl.opengl.Display.allowSoftwareOpenGL=true")
+	}
+
+	if opts.PreferDiscreteGPU {
+		switch runtime.GOOS {
+		case "linux":
+			// Mesa PRIME offload, plus NVIDIA's render offload when its driver is loaded
+			env = append(env, "DRI_PRIME=1")
+			if _, err := os.Stat("/proc/driver/nvidia"); err == nil {
+				env = append(env, "__NV_PRIME_RENDER_OFFLOAD=1", "__GLX_VENDOR_LIBRARY_NAME=nvidia")
+			}
+			E.Emit("gpu_preference_applied", env)
+		case "windows":
+			E.Emit("gpu_preference_unsupported", map[string]string{
+				"javaPath": javaPath,
+				"hint":     windowsGPUPreferenceHint,
+			})
+		}
+		// macOS switches to the discrete GPU automatically for OpenGL applications
+	}
+
+	return jvmArgs, env
+}
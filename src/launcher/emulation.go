@@ -0,0 +1,151 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Emulators a JVM can end up running under.
+const (
+	EmulatorRosetta = "rosetta"
+	EmulatorBox64   = "box64"
+	EmulatorFEX     = "fex"
+	EmulatorQEMU    = "qemu"
+	EmulatorWindows = "windows-arm64-emulation"
+	EmulatorUnknown = "unknown"
+)
+
+// Emulation describes a JVM that does not run natively on the machine.
+type Emulation struct {
+	Emulator string
+	// MachineArch is the architecture of the hardware, JVMArch the one of the runtime.
+	MachineArch string
+	JVMArch     string
+	JavaPath    string
+	// Impact describes the expected performance cost, Suggestion how to avoid it.
+	Impact     string
+	Suggestion string
+}
+
+// ------------------ Detection ------------------
+
+// normalizeArch converts a JVM os.arch value to the names used by hostArch.
+func normalizeArch(arch string) string {
+	switch strings.ToLower(arch) {
+	case "amd64", "x86_64", "x64":
+		return "x86_64"
+	case "aarch64", "arm64":
+		return "arm64"
+	case "x86", "i386", "i486", "i586", "i686":
+		return "x86"
+	}
+	return strings.ToLower(arch)
+}
+
+// machineArch returns the architecture of the hardware. It differs from hostArch when this
+// process itself is translated, e.g. an x86_64 launcher build running under Rosetta.
+func machineArch() string {
+	if runtime.GOOS == "darwin" && hostArch() == "x86_64" {
+		out, err := exec.Command("sysctl", "-n", "sysctl.proc_translated").Output()
+		if err == nil && strings.TrimSpace(string(out)) == "1" {
+			return "arm64"
+		}
+	}
+	if runtime.GOOS == "windows" && hostArch() != "arm64" {
+		// PROCESSOR_ARCHITEW6432 is only set for emulated processes
+		if strings.EqualFold(os.Getenv("PROCESSOR_ARCHITEW6432"), "ARM64") ||
+			strings.EqualFold(os.Getenv("PROCESSOR_ARCHITECTURE"), "ARM64") {
+			return "arm64"
+		}
+	}
+	if runtime.GOOS == "linux" && hostArch() != "arm64" {
+		// box64 and FEX present an x86 CPU but export their own variables
+		if os.Getenv("BOX64_VERSION") != "" || os.Getenv("FEX_APP_CONFIG_LOCATION") != "" {
+			return "arm64"
+		}
+	}
+	return hostArch()
+}
+
+// linuxEmulator names the binfmt_misc handler that runs foreign binaries on Linux.
+func linuxEmulator() string {
+	if os.Getenv("BOX64_VERSION") != "" {
+		return EmulatorBox64
+	}
+	if os.Getenv("FEX_APP_CONFIG_LOCATION") != "" {
+		return EmulatorFEX
+	}
+	entries, _ := os.ReadDir("/proc/sys/fs/binfmt_misc")
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		switch {
+		case strings.Contains(name, "box64"):
+			return EmulatorBox64
+		case strings.Contains(name, "fex"):
+			return EmulatorFEX
+		case strings.Contains(name, "qemu-x86_64") || strings.Contains(name, "qemu-i386"):
+			return EmulatorQEMU
+		}
+	}
+	return EmulatorUnknown
+}
+
+// DetectEmulation reports whether the JVM at javaPath runs under emulation on this machine,
+// returning nil when it runs natively or cannot be probed.
+func DetectEmulation(javaPath string) *Emulation {
+	info, err := ProbeJVM(javaPath)
+	if err != nil || info.Arch == "" {
+		return nil
+	}
+
+	machine, jvm := machineArch(), normalizeArch(info.Arch)
+	// 32-bit x86 runs natively on x86_64 hardware
+	if jvm == machine || (machine == "x86_64" && jvm == "x86") {
+		return nil
+	}
+
+	e := &Emulation{MachineArch: machine, JVMArch: jvm, JavaPath: javaPath}
+	switch runtime.GOOS {
+	case "darwin":
+		e.Emulator = EmulatorRosetta
+		e.Impact = "Rosetta translation typically costs 20-50% of the frame rate and increases startup time"
+	case "windows":
+		e.Emulator = EmulatorWindows
+		e.Impact = "x64 emulation on Windows on ARM typically halves the frame rate"
+	case "linux":
+		e.Emulator = linuxEmulator()
+		e.Impact = "running the JVM under a translation layer often reduces the frame rate severalfold"
+		if e.Emulator == EmulatorQEMU {
+			e.Impact = "QEMU user-mode emulation makes the game run an order of magnitude slower"
+		}
+	default:
+		e.Emulator = EmulatorUnknown
+		e.Impact = "the game will run considerably slower than with a native runtime"
+	}
+	e.Suggestion = fmt.Sprintf("install a native %s Java runtime (e.g. the Mojang runtime for this platform) and use it instead of %s", machine, javaPath)
+	return e
+}
+
+// warnEmulation emits emulation_warning when the selected JVM will run under emulation.
+// Rosetta selected on purpose for versions without arm64 natives is reported too, since the
+// performance cost is the same; the intended flag tells both cases apart.
+func warnEmulation(javaPath string, intended bool, E *events.EventEmitter) {
+	e := DetectEmulation(javaPath)
+	if e == nil {
+		return
+	}
+	E.Emit("emulation_warning", map[string]interface{}{
+		"emulator":    e.Emulator,
+		"machineArch": e.MachineArch,
+		"jvmArch":     e.JVMArch,
+		"javaPath":    e.JavaPath,
+		"impact":      e.Impact,
+		"suggestion":  e.Suggestion,
+		"intended":    intended,
+	})
+}
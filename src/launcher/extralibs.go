@@ -0,0 +1,160 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// ------------------ Structs ------------------
+
+// ExtraLibrary is a JAR added to a launch without editing the version JSON, e.g. a private
+// patch, a custom API or the JAR of a JavaAgent. It is either a local file (Path) or a library
+// named by its Maven coordinate (Name), kept under the library roots and downloaded when missing.
+type ExtraLibrary struct {
+	// Name is the Maven coordinate "group:artifact:version[:classifier][@extension]".
+	Name string
+	// URL downloads the library; Repository is a Maven repository it is fetched from by Name.
+	// Without either, downloader.LibraryMirrors are tried.
+	URL        string
+	Repository string
+	// Path is a local JAR used as is instead of a library named by Name. It may contain launch
+	// placeholders.
+	Path string
+	// Prepend puts the library before the version's libraries on the classpath, so its classes
+	// take precedence over theirs.
+	Prepend bool
+}
+
+// ------------------ Resolution ------------------
+
+// resolveExtraLibrary locates lib like buildClasspath locates the libraries of a version.
+func resolveExtraLibrary(lib ExtraLibrary, libDirs []string, vars map[string]string) (LibraryResolution, error) {
+	if lib.Path != "" {
+		path := expandTemplate(lib.Path, vars)
+		_, err := os.Stat(path)
+		return LibraryResolution{Name: lib.Name, Path: path, Source: LibrarySourceExtra, Missing: err != nil}, nil
+	}
+
+	artifactPath := downloader.MavenPath(lib.Name)
+	if artifactPath == "" {
+		return LibraryResolution{}, fmt.Errorf("invalid extra library coordinate %q", lib.Name)
+	}
+	resolution := LibraryResolution{
+		Name:         lib.Name,
+		ArtifactPath: artifactPath,
+		URL:          lib.URL,
+		Repository:   lib.Repository,
+		Source:       LibrarySourceExtra,
+	}
+	if path, ok := findLibrary(libDirs, artifactPath); ok {
+		resolution.Path = path
+	} else {
+		resolution.Path = filepath.Join(libDirs[0], filepath.FromSlash(artifactPath))
+		resolution.Missing = true
+	}
+	return resolution, nil
+}
+
+// resolveExtraLibraries locates libs, downloading the missing ones named by coordinate. Unlike
+// the libraries of a version, every extra library must be found: a missing one fails.
+func resolveExtraLibraries(libs []ExtraLibrary, gameDir string, libDirs []string, vars map[string]string, E *events.EventEmitter) ([]LibraryResolution, error) {
+	resolutions := make([]LibraryResolution, len(libs))
+	var missing []LibraryResolution
+	for i, lib := range libs {
+		resolution, err := resolveExtraLibrary(lib, libDirs, vars)
+		if err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		resolutions[i] = resolution
+		if resolution.Missing && resolution.ArtifactPath != "" {
+			missing = append(missing, resolution)
+		}
+	}
+	if len(missing) > 0 {
+		downloadMissingLibraries(gameDir, missing, E)
+	}
+
+	for i := range resolutions {
+		if !resolutions[i].Missing {
+			continue
+		}
+		if _, err := os.Stat(resolutions[i].Path); err != nil {
+			err = fmt.Errorf("extra library %s not found: %w", resolutions[i].Name, err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		resolutions[i].Missing = false
+	}
+	return resolutions, nil
+}
+
+// addExtraLibraries resolves opts.ExtraLibraries and adds them to the classpath of report:
+// prepended ones first, the others after the version's libraries and before the client JAR.
+func addExtraLibraries(report *ClasspathReport, opts LaunchOptions, libDirs []string, E *events.EventEmitter) error {
+	if len(opts.ExtraLibraries) == 0 {
+		return nil
+	}
+	resolutions, err := resolveExtraLibraries(opts.ExtraLibraries, opts.layout().Root, libDirs, templateVars(opts), E)
+	if err != nil {
+		return err
+	}
+
+	var first, last []string
+	for i, resolution := range resolutions {
+		report.Libraries = append(report.Libraries, resolution)
+		if opts.ExtraLibraries[i].Prepend {
+			first = append(first, resolution.Path)
+		} else {
+			last = append(last, resolution.Path)
+		}
+		E.Emit("extra_library_added", resolution.Path)
+	}
+
+	libraries := report.Classpath
+	if report.ClientJar != "" {
+		libraries = libraries[:len(libraries)-1]
+	}
+	classpath := append(append(append([]string(nil), first...), libraries...), last...)
+	if report.ClientJar != "" {
+		classpath = append(classpath, report.ClientJar)
+	}
+	report.Classpath = classpath
+	return nil
+}
+
+// resolveAgentLibraries returns opts.JavaAgents with the Path of every agent given as a
+// Library filled in, downloading missing agent JARs.
+func resolveAgentLibraries(opts LaunchOptions, libDirs []string, E *events.EventEmitter) ([]JavaAgent, error) {
+	agents := append([]JavaAgent(nil), opts.JavaAgents...)
+	for i, agent := range agents {
+		if agent.Path != "" || agent.Library == nil {
+			continue
+		}
+		resolutions, err := resolveExtraLibraries([]ExtraLibrary{*agent.Library}, opts.layout().Root, libDirs, templateVars(opts), E)
+		if err != nil {
+			return nil, err
+		}
+		agents[i].Path = resolutions[0].Path
+	}
+	return agents, nil
+}
+
+// ------------------ Public API ------------------
+
+// InstallExtraLibraries downloads the extra libraries and agent JARs of opts that are named by
+// coordinate and missing, so they are in place before the first launch (which would otherwise
+// fetch them). It fails when one of them cannot be found or downloaded.
+func InstallExtraLibraries(opts LaunchOptions, E *events.EventEmitter) error {
+	libDirs := libraryDirs(opts)
+	if _, err := resolveExtraLibraries(opts.ExtraLibraries, opts.layout().Root, libDirs, templateVars(opts), E); err != nil {
+		return err
+	}
+	_, err := resolveAgentLibraries(opts, libDirs, E)
+	return err
+}
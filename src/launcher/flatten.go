@@ -0,0 +1,127 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// maxInheritanceDepth bounds inheritsFrom chains, so cycles fail instead of looping.
+const maxInheritanceDepth = 16
+
+// readRawVersion reads a version JSON without dropping fields the launcher does not use.
+func readRawVersion(gameDir, version string) (map[string]interface{}, error) {
+	path := utils.NewLayout(gameDir).VersionJSON(version)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version JSON: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return raw, nil
+}
+
+// mergeRawVersion merges a parent version into its child the way loadVersionJSON does:
+// libraries and arguments are concatenated parent first, every other field of the child
+// overrides the parent's.
+func mergeRawVersion(parent, child map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		merged[key] = value
+	}
+
+	parentLibs, _ := parent["libraries"].([]interface{})
+	childLibs, _ := child["libraries"].([]interface{})
+	merged["libraries"] = append(append([]interface{}{}, parentLibs...), childLibs...)
+
+	parentArgs, _ := parent["arguments"].(map[string]interface{})
+	childArgs, _ := child["arguments"].(map[string]interface{})
+	if parentArgs != nil || childArgs != nil {
+		args := map[string]interface{}{}
+		for _, kind := range []string{"game", "jvm"} {
+			p, _ := parentArgs[kind].([]interface{})
+			c, _ := childArgs[kind].([]interface{})
+			if p != nil || c != nil {
+				args[kind] = append(append([]interface{}{}, p...), c...)
+			}
+		}
+		merged["arguments"] = args
+	}
+	return merged
+}
+
+// FlattenVersion resolves the inheritsFrom chain of a version into a single version JSON, keeping
+// every field (including ones this launcher ignores) so other tools can consume it. The result
+// has no inheritsFrom; "jar" names the version whose client JAR is used, as in patched profiles.
+func FlattenVersion(gameDir, version string) (map[string]interface{}, error) {
+	chain := []map[string]interface{}{}
+	seen := map[string]bool{}
+	for id := version; id != ""; {
+		if seen[id] || len(chain) >= maxInheritanceDepth {
+			return nil, fmt.Errorf("inheritance cycle or chain too deep at %s", id)
+		}
+		seen[id] = true
+		raw, err := readRawVersion(gameDir, id)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, raw)
+		id, _ = raw["inheritsFrom"].(string)
+	}
+
+	// Merge from the root down, so every child overrides its parent
+	root := chain[len(chain)-1]
+	flattened := root
+	for i := len(chain) - 2; i >= 0; i-- {
+		flattened = mergeRawVersion(flattened, chain[i])
+	}
+	delete(flattened, "inheritsFrom")
+	flattened["id"] = version
+	if _, ok := flattened["jar"]; !ok && len(chain) > 1 {
+		if rootID, ok := root["id"].(string); ok {
+			flattened["jar"] = rootID
+		}
+	}
+	return flattened, nil
+}
+
+// WriteFlattenedVersion writes FlattenVersion's result to path, or to
+// versions/<version>/<version>.flattened.json when path is empty, and returns the path written.
+func WriteFlattenedVersion(gameDir, version, path string, E *events.EventEmitter) (string, error) {
+	flattened, err := FlattenVersion(gameDir, version)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if path == "" {
+		path = filepath.Join(utils.NewLayout(gameDir).VersionDir(version), version+".flattened.json")
+	}
+
+	data, err := json.MarshalIndent(flattened, "", "  ")
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if err := os.WriteFile(path, data, utils.Modes.File); err != nil {
+		err = fmt.Errorf("failed to write flattened version: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	E.Emit("version_flattened", map[string]string{"version": version, "path": path})
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// ErrClientIntegrity is returned when the client JAR does not match the SHA1 from the version metadata.
+var ErrClientIntegrity error = i18n.New("launcher.client_integrity", "client jar integrity check failed")
+
+// sha1File returns the hex encoded SHA1 digest of a file.
+func sha1File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyClientJar compares the client JAR against the expected SHA1, catching corrupted
+// or tampered clients. Versions without a published hash (e.g. custom clients) are skipped.
+func verifyClientJar(jarPath, expected string, E *events.EventEmitter) error {
+	if expected == "" {
+		E.Emit("client_integrity_skipped", jarPath)
+		return nil
+	}
+
+	actual, err := sha1File(jarPath)
+	if err != nil {
+		err = fmt.Errorf("failed to hash client jar: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	if actual != expected {
+		E.Emit("client_integrity_failed", map[string]string{
+			"path":     jarPath,
+			"expected": expected,
+			"actual":   actual,
+		})
+		return fmt.Errorf("%w: %s has SHA1 %s, expected %s", ErrClientIntegrity, jarPath, actual, expected)
+	}
+
+	E.Emit("client_integrity_verified", jarPath)
+	return nil
+}
@@ -0,0 +1,134 @@
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// Windows API constants used for job objects and process creation.
+const (
+	createNoWindow                    = 0x08000000
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x00002000
+	jobObjectLimitSilentBreakawayOK   = 0x00001000
+	processSetQuota                   = 0x0100
+	processTerminate                  = 0x0001
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+// ------------------ Structs ------------------
+
+// ioCounters mirrors IO_COUNTERS.
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+// basicLimitInformation mirrors JOBOBJECT_BASIC_LIMIT_INFORMATION.
+type basicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// extendedLimitInformation mirrors JOBOBJECT_EXTENDED_LIMIT_INFORMATION.
+type extendedLimitInformation struct {
+	BasicLimitInformation basicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// windowsAPI implements windowsProcessAPI with kernel32.
+type windowsAPI struct{}
+
+// init makes StartGame use the Windows API.
+func init() {
+	windowsProcessAPI = windowsAPI{}
+}
+
+// ------------------ Windows API ------------------
+
+// configure hides the console window of the game when asked to.
+func (windowsAPI) configure(cmd *exec.Cmd, opts WindowsOptions) {
+	if !opts.HideConsole {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.HideWindow = true
+	cmd.SysProcAttr.CreationFlags |= createNoWindow
+}
+
+// createJob creates an anonymous job object. Processes the game starts may leave it silently
+// when they ask to, so launchers of other programs are not tied to the game.
+func (windowsAPI) createJob(killOnClose bool) (uintptr, error) {
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return 0, fmt.Errorf("CreateJobObject failed: %w", err)
+	}
+
+	var info extendedLimitInformation
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitSilentBreakawayOK
+	if killOnClose {
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitKillOnJobClose
+	}
+	ok, _, err := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if ok == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		return 0, fmt.Errorf("SetInformationJobObject failed: %w", err)
+	}
+	return job, nil
+}
+
+// assign puts a process into a job object.
+func (windowsAPI) assign(job uintptr, pid int) error {
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("OpenProcess failed: %w", err)
+	}
+	defer syscall.CloseHandle(process)
+
+	ok, _, err := procAssignProcessToJobObject.Call(job, uintptr(process))
+	if ok == 0 {
+		return fmt.Errorf("AssignProcessToJobObject failed: %w", err)
+	}
+	return nil
+}
+
+// terminate ends every process of a job object.
+func (windowsAPI) terminate(job uintptr) error {
+	ok, _, err := procTerminateJobObject.Call(job, 1)
+	if ok == 0 {
+		return fmt.Errorf("TerminateJobObject failed: %w", err)
+	}
+	return nil
+}
+
+// close releases a job object handle.
+func (windowsAPI) close(job uintptr) error {
+	return syscall.CloseHandle(syscall.Handle(job))
+}
@@ -0,0 +1,148 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// max32BitHeapMB is the largest heap a 32-bit JVM can reliably reserve. Requesting more
+// makes the JVM fail at startup with "Could not reserve enough space for object heap".
+const max32BitHeapMB = 1536
+
+// ErrJVM32BitMemory is returned when the requested maximum heap cannot be reserved by a 32-bit JVM.
+var ErrJVM32BitMemory error = i18n.New("launcher.jvm_32bit_memory", "requested memory exceeds what a 32-bit JVM can reserve")
+
+// JVMInfo describes a Java runtime as reported by the runtime itself.
+type JVMInfo struct {
+	Path    string
+	Version string // java.version, e.g. "17.0.8"
+	Arch    string // os.arch, e.g. "amd64", "x86", "aarch64"
+	Bits    int    // sun.arch.data.model, 32 or 64
+}
+
+var jvmInfoCache sync.Map // java path -> *JVMInfo
+
+// ProbeJVM runs the Java executable once to read its version and architecture.
+// Results are cached per path for the lifetime of the process.
+func ProbeJVM(javaPath string) (*JVMInfo, error) {
+	if cached, ok := jvmInfoCache.Load(javaPath); ok {
+		return cached.(*JVMInfo), nil
+	}
+
+	// The properties are printed to stderr together with the version banner
+	var out bytes.Buffer
+	cmd := exec.Command(javaPath, "-XshowSettings:properties", "-version")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", javaPath, err)
+	}
+
+	info := &JVMInfo{Path: javaPath}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "java.version":
+			info.Version = value
+		case "os.arch":
+			info.Arch = value
+		case "sun.arch.data.model":
+			info.Bits, _ = strconv.Atoi(value)
+		}
+	}
+
+	// Older JVMs without sun.arch.data.model still report a 32-bit os.arch
+	if info.Bits == 0 {
+		switch info.Arch {
+		case "x86", "i386", "i686", "arm":
+			info.Bits = 32
+		default:
+			info.Bits = 64
+		}
+	}
+
+	jvmInfoCache.Store(javaPath, info)
+	return info, nil
+}
+
+// parseMemoryMB converts a JVM memory size such as "2G", "1536M", "1048576k" or "2147483648" to megabytes.
+func parseMemoryMB(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	if size == "" {
+		return 0, fmt.Errorf("empty memory size")
+	}
+
+	var shift uint // plain numbers are bytes
+	switch size[len(size)-1] {
+	case 'k', 'K':
+		shift = 10
+	case 'm', 'M':
+		shift = 20
+	case 'g', 'G':
+		shift = 30
+	case 't', 'T':
+		shift = 40
+	}
+	if shift > 0 {
+		size = size[:len(size)-1]
+	}
+
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+	return (value << shift) >> 20, nil
+}
+
+// checkJVMMemory guards against heaps a 32-bit JVM cannot reserve. It returns the (possibly clamped)
+// maximum and minimum heap sizes, or ErrJVM32BitMemory when clamping is disabled.
+func checkJVMMemory(javaPath, maxRam, minRam string, clamp bool, E *events.EventEmitter) (string, string, error) {
+	maxMB, err := parseMemoryMB(maxRam)
+	if err != nil || maxMB <= max32BitHeapMB {
+		return maxRam, minRam, nil
+	}
+
+	bits := 64
+	if hostArch() == "x86" {
+		// A 32-bit host can only run a 32-bit JVM
+		bits = 32
+	} else if info, err := ProbeJVM(javaPath); err == nil {
+		bits = info.Bits
+	} else {
+		E.Emit("jvm_probe_failed", err.Error())
+	}
+
+	if bits != 32 {
+		return maxRam, minRam, nil
+	}
+
+	if !clamp {
+		err := fmt.Errorf("%w: -Xmx%s requested but %s is 32-bit (limit %dM); install a 64-bit Java or lower the memory",
+			ErrJVM32BitMemory, maxRam, javaPath, max32BitHeapMB)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", "", err
+	}
+
+	clamped := strconv.Itoa(max32BitHeapMB) + "M"
+	if minMB, err := parseMemoryMB(minRam); err == nil && minMB > max32BitHeapMB {
+		minRam = clamped
+	}
+	E.Emit("memory_clamped", map[string]string{
+		"requested": maxRam,
+		"clamped":   clamped,
+		"reason":    "32-bit JVM",
+	})
+	return clamped, minRam, nil
+}
@@ -8,10 +8,27 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
 
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
 	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/javaruntime"
+	"github.com/urixen-org/minecraft-launcher-core/src/metrics"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/sysinfo"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Keyed errors of the launch steps users see fail.
+var (
+	ErrVersionNotInstalled error = i18n.New("launcher.version_not_installed", "version {version} is not installed")
+	ErrVersionJarMissing   error = i18n.New("launcher.version_jar_missing", "the game JAR of {version} is missing")
+	ErrUnsupportedPlatform error = i18n.New("launcher.unsupported_platform", "unsupported platform: {os}")
+	ErrNoNatives           error = i18n.New("launcher.no_natives", "no native libraries were extracted - check if native JARs exist in libraries")
+	ErrGameStartFailed     error = i18n.New("launcher.start_failed", "failed to start the game")
 )
 
 // VersionJSON represents the structure of the Minecraft version metadata JSON file.
@@ -33,8 +50,17 @@ type VersionJSON struct {
 		URL       string `json:"url"`
 	} `json:"assetIndex"`
 	Assets    string `json:"assets"`
+	Downloads struct {
+		Client struct {
+			SHA1 string `json:"sha1"`
+			Size int    `json:"size"`
+			URL  string `json:"url"`
+		} `json:"client"`
+	} `json:"downloads"`
 	Libraries []struct {
-		Name      string `json:"name"`
+		Name string `json:"name"`
+		// URL is the Maven repository of libraries declared by name only (Fabric, Forge).
+		URL       string `json:"url"`
 		Downloads struct {
 			Artifact struct {
 				Path string `json:"path"`
@@ -49,18 +75,35 @@ type VersionJSON struct {
 				Size int    `json:"size"`
 			} `json:"classifiers"`
 		} `json:"downloads"`
-		Rules []struct {
-			Action string `json:"action"`
-			OS     struct {
-				Name string `json:"name"`
-			} `json:"os"`
-		} `json:"rules"`
+		Rules   []rules.Rule      `json:"rules"`
 		Natives map[string]string `json:"natives"`
 	} `json:"libraries"`
 	Arguments struct {
 		Game []interface{} `json:"game"`
 		JVM  []interface{} `json:"jvm"`
 	} `json:"arguments"`
+	// JavaVersion names the Mojang runtime component the version runs on. Versions before
+	// 1.17 have none and use jre-legacy.
+	JavaVersion struct {
+		Component    string `json:"component"`
+		MajorVersion int    `json:"majorVersion"`
+	} `json:"javaVersion"`
+	// Logging configures the game's log4j output; Client.Argument contains ${path}.
+	Logging struct {
+		Client struct {
+			Argument string `json:"argument"`
+			Type     string `json:"type"`
+			File     struct {
+				ID   string `json:"id"`
+				SHA1 string `json:"sha1"`
+				Size int    `json:"size"`
+				URL  string `json:"url"`
+			} `json:"file"`
+		} `json:"client"`
+	} `json:"logging"`
+
+	// parent is the merged version this one inherits from, if any.
+	parent *VersionJSON
 }
 
 // extractJar extracts native files (DLL, SO, DYLIB, JNILIB) from a JAR archive
@@ -93,7 +136,7 @@ func extractJar(jarPath, destDir string, E *events.EventEmitter) error {
 		destPath := filepath.Join(destDir, filepath.Base(f.Name))
 
 		// Skip if already exists
-		if _, err := os.Stat(destPath); err == nil {
+		if _, err := os.Stat(utils.LongPath(destPath)); err == nil {
 			continue
 		}
 
@@ -102,7 +145,7 @@ func extractJar(jarPath, destDir string, E *events.EventEmitter) error {
 			continue
 		}
 
-		outFile, err := os.Create(destPath)
+		outFile, err := os.Create(utils.LongPath(destPath))
 		if err != nil {
 			rc.Close()
 			continue
@@ -120,54 +163,10 @@ func extractJar(jarPath, destDir string, E *events.EventEmitter) error {
 	return nil
 }
 
-// shouldIncludeLibrary checks if a library should be included based on its OS rules defined in the version JSON.
-func shouldIncludeLibrary(rules []struct {
-	Action string `json:"action"`
-	OS     struct {
-		Name string `json:"name"`
-	} `json:"os"`
-}) bool {
-	// If no rules are defined, the library is always included.
-	if len(rules) == 0 {
-		return true
-	}
-
-	osName := getOSName()
-	allowed := false
-
-	for _, rule := range rules {
-		if rule.Action == "allow" {
-			if rule.OS.Name == "" || rule.OS.Name == osName {
-				allowed = true
-			}
-		} else if rule.Action == "disallow" {
-			if rule.OS.Name == "" || rule.OS.Name == osName {
-				return false // Disallow rules are absolute
-			}
-		}
-	}
-	// If there were rules, but none disallowed it, return true only if an allow rule matched.
-	return allowed
-}
-
-// getOSName returns the Minecraft-specific operating system name based on runtime.GOOS.
-func getOSName() string {
-	switch runtime.GOOS {
-	case "windows":
-		return "windows"
-	case "darwin":
-		return "osx"
-	case "linux":
-		return "linux"
-	default:
-		return runtime.GOOS
-	}
-}
-
-// extractNativesFromLibraries recursively walks the libraries directory, identifies platform-specific
-// native JARs, and extracts their contents into the version's natives directory.
-func extractNativesFromLibraries(libDir, nativesDir string, E *events.EventEmitter) error {
-	if err := os.MkdirAll(nativesDir, 0o755); err != nil {
+// extractNativesFromLibraries recursively walks the libraries directories, identifies platform-specific
+// native JARs for the target platform, and extracts their contents into the version's natives directory.
+func extractNativesFromLibraries(libDirs []string, nativesDir string, platform rules.Platform, E *events.EventEmitter) error {
+	if err := os.MkdirAll(utils.LongPath(nativesDir), utils.Modes.Dir); err != nil {
 		return err
 	}
 
@@ -184,38 +183,40 @@ func extractNativesFromLibraries(libDir, nativesDir string, E *events.EventEmitt
 		}
 	}
 
-	E.Emit("extracting_natives_start", libDir)
+	E.Emit("extracting_natives_start", libDirs[0])
 
 	// Determine the platform pattern to match native JAR filenames
-	var nativePattern string
-	switch runtime.GOOS {
-	case "windows":
-		nativePattern = "natives-windows"
-	case "darwin":
-		nativePattern = "natives-osx"
-	case "linux":
-		nativePattern = "natives-linux"
+	switch platform.OS {
+	case rules.OSWindows, rules.OSMac, rules.OSLinux:
 	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		return i18n.WithArgs(ErrUnsupportedPlatform, map[string]any{"os": platform.OS})
 	}
+	nativePattern := "natives-" + platform.OS
 
-	// Walk recursively and extract from matching JARs
-	filepath.Walk(libDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".jar") {
-			return nil
-		}
+	// Walk recursively and extract from matching JARs; the roots are walked last to first
+	// so files from earlier roots overwrite those of later ones
+	for i := len(libDirs) - 1; i >= 0; i-- {
+		filepath.Walk(libDirs[i], func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".jar") {
+				return nil
+			}
 
-		lowerName := strings.ToLower(info.Name())
+			lowerName := strings.ToLower(info.Name())
 
-		// A JAR is considered a native JAR if it contains the platform-specific pattern or "natives"
-		if strings.Contains(lowerName, nativePattern) || strings.Contains(lowerName, "natives") {
-			E.Emit("native_jar_processing", info.Name())
-			// Ignore error from extractJar to continue processing other libraries
-			extractJar(path, nativesDir, E)
-		}
+			// A JAR is considered a native JAR if it contains the platform-specific pattern or "natives"
+			if strings.Contains(lowerName, nativePattern) || strings.Contains(lowerName, "natives") {
+				// Skip natives built for another OS or architecture (e.g. x86_64 LWJGL when running arm64)
+				if !nativeJarMatchesOS(lowerName, platform.OS) || !nativeJarMatchesArch(path, platform.Arch) {
+					return nil
+				}
+				E.Emit("native_jar_processing", info.Name())
+				// Ignore error from extractJar to continue processing other libraries
+				extractJar(path, nativesDir, E)
+			}
 
-		return nil
-	})
+			return nil
+		})
+	}
 
 	// Verify that at least one native file was extracted
 	entries, err = os.ReadDir(nativesDir)
@@ -233,8 +234,8 @@ func extractNativesFromLibraries(libDir, nativesDir string, E *events.EventEmitt
 	}
 
 	if nativeCount == 0 {
-		E.Emit("error", "No native libraries were extracted - check if native JARs exist in libraries")
-		return fmt.Errorf("no native libraries were extracted - check if native JARs exist in libraries")
+		E.Emit("error", i18n.ErrorEvent(ErrNoNatives))
+		return ErrNoNatives
 	}
 
 	E.Emit("natives_extracted", nativeCount)
@@ -243,12 +244,28 @@ func extractNativesFromLibraries(libDir, nativesDir string, E *events.EventEmitt
 
 // loadVersionJSON loads, parses, and handles version inheritance for a specific version JSON file.
 // If the version inherits from a parent, their fields are merged (child overrides parent).
-func loadVersionJSON(gameDir, version string, E *events.EventEmitter) (*VersionJSON, error) {
-	versionJSONPath := filepath.Join(gameDir, "versions", version, version+".json")
+func loadVersionJSON(layout utils.Layout, version string, E *events.EventEmitter) (*VersionJSON, error) {
+	versionJSONPath := layout.VersionJSON(version)
 
 	data, err := os.ReadFile(versionJSONPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read version JSON: %w", err)
+		return nil, fmt.Errorf("%w: %w", i18n.WithArgs(ErrVersionNotInstalled, map[string]any{"version": version}), err)
+	}
+
+	// Report malformed custom versions precisely instead of failing on zero values later
+	var blocking []ValidationIssue
+	for _, issue := range ValidateVersionJSON(data) {
+		if !issue.Warning {
+			blocking = append(blocking, issue)
+			continue
+		}
+		E.Emit("version_json_warning", map[string]string{
+			"version": version,
+			"issue":   issue.String(),
+		})
+	}
+	if len(blocking) > 0 {
+		return nil, &VersionJSONError{File: versionJSONPath, Issues: blocking}
 	}
 
 	var versionJSON VersionJSON
@@ -261,7 +278,7 @@ func loadVersionJSON(gameDir, version string, E *events.EventEmitter) (*VersionJ
 		E.Emit("version_inherits_from", versionJSON.InheritsFrom)
 
 		// Load the parent version's JSON
-		parentJSON, err := loadVersionJSON(gameDir, versionJSON.InheritsFrom, E)
+		parentJSON, err := loadVersionJSON(layout, versionJSON.InheritsFrom, E)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load parent version %s: %w", versionJSON.InheritsFrom, err)
 		}
@@ -279,10 +296,17 @@ func loadVersionJSON(gameDir, version string, E *events.EventEmitter) (*VersionJ
 		if versionJSON.Assets == "" {
 			versionJSON.Assets = parentJSON.Assets
 		}
+		if versionJSON.JavaVersion.Component == "" && versionJSON.JavaVersion.MajorVersion == 0 {
+			versionJSON.JavaVersion = parentJSON.JavaVersion
+		}
+		if versionJSON.Logging.Client.Argument == "" {
+			versionJSON.Logging = parentJSON.Logging
+		}
 
 		// Merge libraries: Parent libraries come first, followed by child libraries.
 		mergedLibs := append([]struct {
 			Name      string `json:"name"`
+			URL       string `json:"url"`
 			Downloads struct {
 				Artifact struct {
 					Path string `json:"path"`
@@ -297,16 +321,16 @@ func loadVersionJSON(gameDir, version string, E *events.EventEmitter) (*VersionJ
 					Size int    `json:"size"`
 				} `json:"classifiers"`
 			} `json:"downloads"`
-			Rules []struct {
-				Action string `json:"action"`
-				OS     struct {
-					Name string `json:"name"`
-				} `json:"os"`
-			} `json:"rules"`
+			Rules   []rules.Rule      `json:"rules"`
 			Natives map[string]string `json:"natives"`
 		}{}, parentJSON.Libraries...)
 		mergedLibs = append(mergedLibs, versionJSON.Libraries...)
 		versionJSON.Libraries = mergedLibs
+		versionJSON.parent = parentJSON
+
+		// Merge arguments: parent arguments first, then the child's additions (e.g. Fabric JVM flags)
+		versionJSON.Arguments.Game = append(append([]interface{}{}, parentJSON.Arguments.Game...), versionJSON.Arguments.Game...)
+		versionJSON.Arguments.JVM = append(append([]interface{}{}, parentJSON.Arguments.JVM...), versionJSON.Arguments.JVM...)
 
 		E.Emit("version_merged", map[string]string{
 			"child":  version,
@@ -317,43 +341,64 @@ func loadVersionJSON(gameDir, version string, E *events.EventEmitter) (*VersionJ
 	return &versionJSON, nil
 }
 
-// parseMinecraftArguments replaces placeholders in the `minecraftArguments` template string
-// with actual values and splits the result into a slice of command-line arguments.
+// parseMinecraftArguments splits the legacy `minecraftArguments` template string into
+// command-line arguments with splitArgumentTemplate and replaces the placeholders of each one.
+// The template is split before substitution, so values containing spaces or non-ASCII
+// characters (game directories, profile names) stay single arguments.
 func parseMinecraftArguments(template string, replacements map[string]string) []string {
-	// Replace all placeholders like ${auth_player_name}
-	for key, value := range replacements {
-		template = strings.ReplaceAll(template, "${"+key+"}", value)
+	args := splitArgumentTemplate(template)
+	for i, arg := range args {
+		args[i] = substituteArgument(arg, replacements)
 	}
-
-	// Split the resulting string into arguments based on whitespace
-	args := strings.Fields(template)
 	return args
 }
 
-// buildClasspath constructs the Java classpath string by finding the absolute paths
-// of all required and downloaded libraries, separated by the system's path list separator.
-func buildClasspath(gameDir, version string, versionJSON *VersionJSON, E *events.EventEmitter) string {
-	libDir := filepath.Join(gameDir, "libraries")
-	versionDir := filepath.Join(gameDir, "versions", version)
-	var classpathParts []string
+// findLibrary returns the first existing file at rel (a slash-separated path) under libDirs.
+func findLibrary(libDirs []string, rel string) (string, bool) {
+	for _, dir := range libDirs {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if _, err := os.Stat(utils.LongPath(path)); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// buildClasspath constructs the Java classpath by finding the absolute paths
+// of all required and downloaded libraries, followed by the client JAR.
+// Libraries are searched in libDirs in order, so earlier roots (e.g. an instance's own
+// libraries) take precedence over later ones (e.g. a shared store).
+// Library rules are evaluated for platform. The returned report records how every library was resolved.
+func buildClasspath(layout utils.Layout, version, clientJar string, libDirs []string, versionJSON *VersionJSON, platform rules.Platform, E *events.EventEmitter) *ClasspathReport {
+	versionDir := layout.VersionDir(version)
+	report := &ClasspathReport{}
 
 	// Add all required libraries (checking OS rules)
 	for _, lib := range versionJSON.Libraries {
-		if !shouldIncludeLibrary(lib.Rules) {
+		if !rules.EvaluateRules(lib.Rules, platform, nil) {
 			continue
 		}
 
 		if lib.Downloads.Artifact.Path != "" {
 			// Library with a defined artifact path (vanilla)
-			libPath := filepath.Join(libDir, filepath.FromSlash(lib.Downloads.Artifact.Path))
-			if _, err := os.Stat(libPath); err == nil {
-				classpathParts = append(classpathParts, libPath)
+			resolution := LibraryResolution{
+				Name:         lib.Name,
+				ArtifactPath: lib.Downloads.Artifact.Path,
+				URL:          lib.Downloads.Artifact.URL,
+				SHA1:         lib.Downloads.Artifact.SHA1,
+				Source:       LibrarySourceArtifact,
+			}
+			if libPath, ok := findLibrary(libDirs, lib.Downloads.Artifact.Path); ok {
+				resolution.Path = libPath
 			} else {
+				resolution.Path = filepath.Join(libDirs[0], filepath.FromSlash(lib.Downloads.Artifact.Path))
+				resolution.Missing = true
 				E.Emit("library_missing", map[string]string{
 					"name": lib.Name,
-					"path": libPath,
+					"path": resolution.Path,
 				})
 			}
+			report.add(resolution)
 		} else if lib.Name != "" {
 			// Library without a download path (often used for modded launchers like Forge/Fabric)
 			// It requires checking alternative, non-standard path patterns.
@@ -364,50 +409,61 @@ func buildClasspath(gameDir, version string, versionJSON *VersionJSON, E *events
 				version := parts[2]
 
 				// Check common paths for modded libraries
-				possiblePaths := []string{
+				type candidate struct{ path, source string }
+				possiblePaths := []candidate{
 					// Pattern 1: `versionDir/artifact-version.jar` (e.g., Optifine or main mod loader JAR)
-					filepath.Join(versionDir, artifact+"-"+version+".jar"),
-					// Pattern 2: `libraries/group/artifact/version/artifact-version.jar` (Maven standard)
-					filepath.Join(libDir, filepath.FromSlash(group), artifact, version, artifact+"-"+version+".jar"),
-					// Pattern 3: `libraries/group/artifact/artifact-version.jar` (Less common variation)
-					filepath.Join(libDir, filepath.FromSlash(group), artifact, artifact+"-"+version+".jar"),
-					// Pattern 4: `versionDir/lib.Name.jar`
-					filepath.Join(versionDir, lib.Name+".jar"),
+					{filepath.Join(versionDir, artifact+"-"+version+".jar"), LibrarySourceVersionDir},
 				}
-
-				found := false
-				for _, path := range possiblePaths {
-					if _, err := os.Stat(path); err == nil {
-						classpathParts = append(classpathParts, path)
+				for _, libDir := range libDirs {
+					possiblePaths = append(possiblePaths,
+						// Pattern 2: `libraries/group/artifact/version/artifact-version.jar` (Maven standard)
+						candidate{filepath.Join(libDir, filepath.FromSlash(group), artifact, version, artifact+"-"+version+".jar"), LibrarySourceMaven},
+						// Pattern 3: `libraries/group/artifact/artifact-version.jar` (Less common variation)
+						candidate{filepath.Join(libDir, filepath.FromSlash(group), artifact, artifact+"-"+version+".jar"), LibrarySourceFlat},
+					)
+				}
+				// Pattern 4: `versionDir/lib.Name.jar`
+				possiblePaths = append(possiblePaths, candidate{filepath.Join(versionDir, lib.Name+".jar"), LibrarySourceVersionName})
+
+				resolution := LibraryResolution{
+					Name:         lib.Name,
+					Repository:   lib.URL,
+					ArtifactPath: strings.ReplaceAll(group, ".", "/") + "/" + artifact + "/" + version + "/" + artifact + "-" + version + ".jar",
+					Missing:      true,
+				}
+				for _, c := range possiblePaths {
+					if _, err := os.Stat(c.path); err == nil {
+						resolution.Path, resolution.Source, resolution.Missing = c.path, c.source, false
 						E.Emit("library_found_alternative", map[string]string{
 							"name": lib.Name,
-							"path": path,
+							"path": c.path,
 						})
-						found = true
 						break
 					}
 				}
 
-				if !found {
+				if resolution.Missing {
+					resolution.Path = filepath.Join(libDirs[0], filepath.FromSlash(resolution.ArtifactPath))
 					E.Emit("library_not_found", lib.Name)
 				}
+				report.add(resolution)
 			}
 		}
 	}
 
-	// Add the main version JAR to the classpath last
-	versionJar := filepath.Join(versionDir, version+".jar")
-	if _, err := os.Stat(versionJar); err == nil {
-		classpathParts = append(classpathParts, versionJar)
+	// Add the client JAR (the version's own or the inherited one) to the classpath last
+	if _, err := os.Stat(clientJar); err == nil {
+		report.Classpath = append(report.Classpath, clientJar)
+		report.ClientJar = clientJar
 	}
 
-	E.Emit("classpath_built", len(classpathParts))
-	// Join all parts with the OS-specific path list separator (e.g., ':' on Linux, ';' on Windows)
-	return strings.Join(classpathParts, string(os.PathListSeparator))
+	E.Emit("classpath_built", len(report.Classpath))
+	return report
 }
 
 // PrepareCMD prepares the Java executable path and command-line arguments required to launch Minecraft.
-// It handles argument construction, memory settings, and finding the main class.
+// It handles argument construction, memory settings, and finding the main class. version may be
+// an alias such as downloader.AliasLatestRelease, resolved through the manifest.
 func PrepareCMD(
 	username string,
 	accessToken string,
@@ -420,6 +476,83 @@ func PrepareCMD(
 	E *events.EventEmitter,
 	extraArgs ...string,
 ) (string, []string, error) {
+	return PrepareWithOptions(LaunchOptions{
+		Username:    username,
+		AccessToken: accessToken,
+		UUID:        uuid,
+		GameDir:     gameDir,
+		Version:     version,
+		JavaPath:    javaPath,
+		MaxRam:      maxRam,
+		MinRam:      minRam,
+		ExtraArgs:   extraArgs,
+	}, E)
+}
+
+// PrepareWithOptions prepares the executable path and command-line arguments described by opts.
+// When a wrapper command is configured, the returned executable is the wrapper and java becomes its first argument.
+func PrepareWithOptions(opts LaunchOptions, E *events.EventEmitter) (string, []string, error) {
+	plan, err := PrepareLaunchPlan(opts, E)
+	if err != nil {
+		return "", nil, err
+	}
+	execPath, args := plan.Command()
+	return execPath, args, nil
+}
+
+// PrepareLaunchPlan resolves everything needed to launch the version described by opts
+// and returns it as a LaunchPlan without starting anything.
+func PrepareLaunchPlan(opts LaunchOptions, E *events.EventEmitter) (*LaunchPlan, error) {
+	start := time.Now()
+	var plan *LaunchPlan
+	err := resolveVersion(&opts, E)
+	if err == nil {
+		plan, err = prepareLaunchPlan(opts, E)
+	}
+	metrics.Inc(metrics.LaunchesTotal, metrics.Result(err))
+	metrics.Since(metrics.LaunchPrepareDuration, start, metrics.Result(err))
+	return plan, err
+}
+
+// resolveVersion replaces a version alias such as downloader.AliasLatestRelease in opts with
+// the version id it stands for.
+func resolveVersion(opts *LaunchOptions, E *events.EventEmitter) error {
+	version, err := downloader.ResolveVersionAlias(opts.Version, E)
+	if err != nil {
+		return err
+	}
+	opts.Version = version
+	return nil
+}
+
+// prepareLaunchPlan performs PrepareLaunchPlan.
+func prepareLaunchPlan(opts LaunchOptions, E *events.EventEmitter) (*LaunchPlan, error) {
+	username := opts.Username
+	accessToken := opts.AccessToken
+	uuid := opts.UUID
+	gameDir := opts.GameDir
+	version := opts.Version
+	javaPath := opts.JavaPath
+	maxRam := opts.MaxRam
+	minRam := opts.MinRam
+	userType, xuid, clientID := opts.UserType, opts.XUID, opts.ClientID
+
+	// Resolve the account through the auth provider, if any
+	if opts.Auth != nil {
+		session, err := auth.EnsureWithGrace(opts.Auth, opts.Session, opts.AuthGracePeriod, E)
+		if err != nil {
+			return nil, err
+		}
+		if session != opts.Session {
+			if opts.OnSessionUpdated != nil {
+				opts.OnSessionUpdated(session)
+			}
+			E.Emit("auth_session_updated", sessionUpdatePayload(session, opts.ExposeAccessToken))
+		}
+		username, uuid, accessToken = session.Username, session.UUID, session.AccessToken
+		userType, xuid, clientID = session.UserType, session.XUID, session.ClientID
+	}
+
 	// Apply default values
 	if username == "" {
 		username = "Player"
@@ -433,6 +566,7 @@ func PrepareCMD(
 	if minRam == "" {
 		minRam = "512M"
 	}
+	userType, xuid, clientID = accountDefaults(userType, xuid, clientID)
 	if accessToken == "" {
 		accessToken = "0"
 	}
@@ -440,103 +574,280 @@ func PrepareCMD(
 		uuid = "00000000-0000-0000-0000-000000000000"
 	}
 
+	// Offline usernames are not checked by any auth server, so validate them here
+	if accessToken == "0" {
+		if err := ValidateUsername(username); err != nil {
+			if !opts.SanitizeUsername {
+				E.Emit("error", i18n.ErrorEvent(err))
+				return nil, err
+			}
+			sanitized := SanitizeUsername(username)
+			E.Emit("username_sanitized", map[string]string{
+				"original":  username,
+				"sanitized": sanitized,
+			})
+			username = sanitized
+		}
+	}
+
 	E.Emit("launch_preparation_start", version)
 
+	// Platform tools can take seconds to answer, so never hold up the launch for them
+	if opts.SystemInfo {
+		go func() { E.Emit("system_info", sysinfo.Collect()) }()
+	}
+
 	// Load version JSON
-	versionJSON, err := loadVersionJSON(gameDir, version, E)
+	layout := opts.layout()
+	versionJSON, err := loadVersionJSON(layout, version, E)
 	if err != nil {
-		E.Emit("error", err.Error())
-		return "", nil, err
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
 	}
 	E.Emit("version_json_loaded", versionJSON.ID)
 
-	versionDir := filepath.Join(gameDir, "versions", version)
+	versionDir := layout.VersionDir(version)
 	versionJar := filepath.Join(versionDir, version+".jar")
+	expectedJarSHA1 := versionJSON.Downloads.Client.SHA1
 
-	// Check for jar or fallback
+	// Check for jar or fall back to the nearest ancestor providing one
+	// (e.g. OptiFine -> Forge -> vanilla, where only vanilla ships a jar)
 	if _, err := os.Stat(versionJar); os.IsNotExist(err) {
-		if versionJSON.InheritsFrom != "" {
-			parentJar := filepath.Join(gameDir, "versions", versionJSON.InheritsFrom, versionJSON.InheritsFrom+".jar")
+		if versionJSON.InheritsFrom == "" {
+			err := fmt.Errorf("%w: %s", i18n.WithArgs(ErrVersionJarMissing, map[string]any{"version": version}), versionJar)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+
+		found := false
+		parentID, parent := versionJSON.InheritsFrom, versionJSON.parent
+		for parentID != "" && parent != nil {
+			parentJar := layout.VersionJar(parentID)
 			if _, err := os.Stat(parentJar); err == nil {
-				E.Emit("using_parent_jar", versionJSON.InheritsFrom)
+				E.Emit("using_parent_jar", parentID)
 				versionJar = parentJar
-			} else {
-				err := fmt.Errorf("version jar not found: %s and parent jar not found: %s", versionJar, parentJar)
-				E.Emit("error", err.Error())
-				return "", nil, err
+				expectedJarSHA1 = parent.Downloads.Client.SHA1
+				found = true
+				break
 			}
-		} else {
-			err := fmt.Errorf("version jar not found: %s", versionJar)
-			E.Emit("error", err.Error())
-			return "", nil, err
+			parentID, parent = parent.InheritsFrom, parent.parent
+		}
+
+		if !found {
+			err := fmt.Errorf("%w: %s and no parent version provides one", i18n.WithArgs(ErrVersionJarMissing, map[string]any{"version": version}), versionJar)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
 		}
 	}
 
-	// Extract natives
-	nativesDir := filepath.Join(versionDir, "natives")
-	libDir := filepath.Join(gameDir, "libraries")
-	if err := extractNativesFromLibraries(libDir, nativesDir, E); err != nil {
-		E.Emit("error", "Failed to extract natives: "+err.Error())
-		return "", nil, err
+	// Optionally make sure the client JAR is exactly the one described by the metadata
+	if opts.VerifyClientJar {
+		if err := verifyClientJar(versionJar, expectedJarSHA1, E); err != nil {
+			return nil, err
+		}
+	}
+
+	// Select the platform, architecture and the matching Java runtime
+	platform := targetPlatform(opts)
+	arch := opts.Arch
+	if arch == "" && opts.Platform != nil {
+		arch = opts.Platform.Arch
+	}
+	arch = selectArch(arch, versionJSON)
+	platform.Arch = arch
+	rosetta := platform.OS == rules.Host().OS && needsRosetta(arch)
+	if archJava := opts.JavaPaths[arch]; archJava != "" {
+		javaPath = archJava
+	} else if opts.JavaPath == "" && opts.RuntimeRoot != "" {
+		// Use the runtime the official launcher would install for this version
+		component := javaruntime.ComponentFor(versionJSON.JavaVersion.Component, versionJSON.JavaVersion.MajorVersion)
+		runtimePlatform := ""
+		if rosetta {
+			runtimePlatform = "mac-os"
+		}
+		rt, err := javaruntime.Ensure(opts.RuntimeRoot, component, runtimePlatform, E)
+		if err != nil {
+			return nil, err
+		}
+		javaPath = rt.JavaPath()
+		E.Emit("runtime_selected", map[string]string{
+			"component": component,
+			"version":   rt.Version,
+			"javaPath":  javaPath,
+		})
+	}
+	E.Emit("arch_selected", map[string]interface{}{
+		"arch":     arch,
+		"host":     hostArch(),
+		"rosetta":  rosetta,
+		"javaPath": javaPath,
+	})
+
+	// Avoid runtimes known not to work with this version
+	if opts.Compat != nil {
+		javaPath, err = checkJavaCompat(opts.Compat, versionJSON, javaPath, opts.JavaCandidates, E)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Build classpath
+	// Tell the user when the selected JVM will be slowed down by emulation
+	warnEmulation(javaPath, rosetta, E)
+
+	// Make sure the heap size can actually be reserved by the selected JVM
+	maxRam, minRam, err = checkJVMMemory(javaPath, maxRam, minRam, opts.ClampMemory, E)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreeMemory(maxRam, opts.FailOnLowMemory, E); err != nil {
+		return nil, err
+	}
+
+	// The probes above need java.exe's console output; the game itself needs no console
+	javaPath = preferJavaw(javaPath, opts.Windows, E)
+
+	libDirs := libraryDirs(opts)
+	libDir := libDirs[0]
+
+	// Build classpath, fetching missing libraries first when asked to
 	E.Emit("building_classpath", libDir)
-	classpath := buildClasspath(gameDir, version, versionJSON, E)
+	classpathReport := buildClasspath(layout, version, versionJar, libDirs, versionJSON, platform, E)
+	if opts.DownloadMissingLibraries && len(classpathReport.Missing) > 0 {
+		if downloadMissingLibraries(layout.Root, classpathReport.Missing, E) > 0 {
+			classpathReport = buildClasspath(layout, version, versionJar, libDirs, versionJSON, platform, E)
+		}
+	}
+	// Libraries and agent JARs added by the caller rather than the version JSON
+	if err := addExtraLibraries(classpathReport, opts, libDirs, E); err != nil {
+		return nil, err
+	}
+	if opts.JavaAgents, err = resolveAgentLibraries(opts, libDirs, E); err != nil {
+		return nil, err
+	}
+	classpath := classpathReport.Classpath
+
+	// The separator is the target's, so arguments prepared for another platform stay valid there
+	classpathSeparator := string(os.PathListSeparator)
+	if platform.OS != rules.Host().OS {
+		classpathSeparator = ":"
+		if platform.OS == rules.OSWindows {
+			classpathSeparator = ";"
+		}
+	}
+
+	// Extract natives
+	nativesDir := opts.Directories.nativesDir(version, versionDir, platform)
+	if err := extractNativesFromLibraries(libDirs, nativesDir, platform, E); err != nil {
+		err = readOnlyHint(err, "Natives")
+		E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to extract natives: %w", err)))
+		return nil, err
+	}
 
 	absNativesDir, _ := filepath.Abs(nativesDir)
 
-	// Determine asset index
-	assetIndex := versionJSON.AssetIndex.ID
-	if versionJSON.Assets != "" {
-		assetIndex = versionJSON.Assets
+	// Determine asset index and lay the assets out the way it asks for
+	assetIndex := selectAssetIndex(versionJSON)
+	assetsRoot := layout.AssetsDir()
+	gameAssets := prepareAssetLayout(gameDir, assetsRoot, opts.Directories.virtualAssetsDir(assetsRoot), assetIndex, E)
+
+	launcherName := opts.LauncherName
+	if launcherName == "" {
+		launcherName = utils.LauncherBrand.Name
+	}
+	if launcherName == "" {
+		launcherName = DefaultLauncherName
+	}
+	launcherVersion := opts.LauncherVersion
+	if launcherVersion == "" {
+		launcherVersion = utils.LauncherBrand.Version
+	}
+	if launcherVersion == "" {
+		launcherVersion = DefaultLauncherVersion
+	}
+
+	// Placeholder values shared by legacy and modern argument formats
+	replacements := map[string]string{
+		"auth_player_name":    username,
+		"version_name":        version,
+		"version_type":        versionJSON.Type,
+		"game_directory":      gameDir,
+		"assets_root":         assetsRoot,
+		"game_assets":         gameAssets,
+		"assets_index_name":   assetIndex,
+		"auth_uuid":           uuid,
+		"auth_access_token":   accessToken,
+		"auth_session":        "token:" + accessToken + ":" + uuid,
+		"user_properties":     "{}",
+		"user_type":           userType,
+		"auth_xuid":           xuid,
+		"clientid":            clientID,
+		"natives_directory":   absNativesDir,
+		"library_directory":   libDir,
+		"launcher_name":       launcherName,
+		"launcher_version":    launcherVersion,
+		"classpath":           strings.Join(classpath, classpathSeparator),
+		"classpath_separator": classpathSeparator,
 	}
+	argCtx := argumentContext{platform: platform, features: map[string]bool{}}
 
 	// Base JVM arguments
-	args := []string{
+	jvmArgs := []string{
 		"-Xmx" + maxRam,
 		"-Xms" + minRam,
-		"-Djava.library.path=" + absNativesDir,
-		"-cp", classpath,
+	}
+	if len(versionJSON.Arguments.JVM) > 0 {
+		// 1.13+ versions describe their own JVM arguments, including the natives path
+		jvmArgs = append(jvmArgs, stripClasspathArgs(resolveArguments(versionJSON.Arguments.JVM, argCtx, replacements))...)
+	} else {
+		jvmArgs = append(jvmArgs, "-Djava.library.path="+absNativesDir)
+	}
+	if arg := logConfigArgument(versionJSON, assetsRoot, opts.Directories, E); arg != "" {
+		jvmArgs = append(jvmArgs, arg)
 	}
 
+	// User supplied JVM arguments, with launch-time placeholders expanded
+	vars := templateVars(opts)
+	vars["natives_dir"] = absNativesDir
+	vars["java_path"] = javaPath
+	jvmArgs = append(jvmArgs, expandAll(opts.ExtraJVMArgs, vars)...)
+
+	// HiDPI and GPU selection
+	displayArgs, env := displayOptions(opts, javaPath, E)
+	jvmArgs = append(jvmArgs, displayArgs...)
+
+	// Debugger, agents and profiling, which must precede the main class
+	debugArgs, err := debugJVMArgs(opts, vars, E)
+	if err != nil {
+		return nil, err
+	}
+	jvmArgs = append(jvmArgs, debugArgs...)
+
 	// Main class
 	mainClass := versionJSON.MainClass
 	if mainClass == "" {
 		mainClass = "net.minecraft.client.main.Main"
 	}
-	args = append(args, mainClass)
 
 	// Game arguments
 	var gameArgs []string
 	if versionJSON.MinecraftArguments != "" {
-		replacements := map[string]string{
-			"auth_player_name":  username,
-			"version_name":      version,
-			"game_directory":    gameDir,
-			"assets_root":       filepath.Join(gameDir, "assets"),
-			"assets_index_name": assetIndex,
-			"auth_uuid":         uuid,
-			"auth_access_token": accessToken,
-			"user_properties":   "{}",
-			"user_type":         "legacy",
-		}
 		gameArgs = parseMinecraftArguments(versionJSON.MinecraftArguments, replacements)
+	} else if len(versionJSON.Arguments.Game) > 0 {
+		gameArgs = resolveArguments(versionJSON.Arguments.Game, argCtx, replacements)
 	} else {
 		gameArgs = []string{
 			"--username", username,
 			"--version", version,
 			"--gameDir", gameDir,
-			"--assetsDir", filepath.Join(gameDir, "assets"),
+			"--assetsDir", assetsRoot,
 			"--assetIndex", assetIndex,
 			"--uuid", uuid,
 			"--accessToken", accessToken,
-			"--userType", "legacy",
+			"--userType", userType,
 		}
 	}
 
-	args = append(args, gameArgs...)
-	args = append(args, extraArgs...)
+	gameArgs = append(gameArgs, opts.ExtraArgs...)
 
 	E.Emit("launch_preparation_complete", map[string]interface{}{
 		"username":  username,
@@ -545,25 +856,112 @@ func PrepareCMD(
 		"mainClass": mainClass,
 	})
 
-	return javaPath, args, nil
+	wrapper := expandAll(opts.WrapperCommand, vars)
+	if len(wrapper) > 0 {
+		E.Emit("wrapper_applied", wrapper)
+	}
+	// Force the x86_64 slice of the Java binary so it runs under Rosetta
+	if rosetta {
+		wrapper = append(wrapper, "arch", "-x86_64")
+	}
+
+	plan := &LaunchPlan{
+		JavaPath:           javaPath,
+		JVMArgs:            jvmArgs,
+		Classpath:          classpath,
+		ClasspathSeparator: classpathSeparator,
+		Libraries:          classpathReport,
+		MaxRam:             maxRam,
+		MainClass:          mainClass,
+		GameArgs:           gameArgs,
+		NativesDir:         absNativesDir,
+		Env:                env,
+		Wrapper:            wrapper,
+		secrets:            []string{accessToken},
+	}
+
+	// Let external tooling inspect the resolved plan and add JVM arguments or environment
+	planEvent := &LaunchPlanEvent{
+		Version:    version,
+		JavaPath:   plan.JavaPath,
+		MainClass:  plan.MainClass,
+		Classpath:  append([]string(nil), plan.Classpath...),
+		NativesDir: plan.NativesDir,
+		JVMArgs:    plan.JVMArgs,
+		Env:        plan.Env,
+	}
+	E.Emit("launch_plan", planEvent)
+	plan.JVMArgs = planEvent.JVMArgs
+	plan.Env = planEvent.Env
+
+	// Leave the Flatpak sandbox last, so the wrapper forwards the final environment
+	if opts.RunOnHost {
+		hostWrapper, err := hostSpawnWrapper(plan.Env, gameDir)
+		if err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		plan.Wrapper = append(hostWrapper, plan.Wrapper...)
+	}
+
+	// Never leak the access token through events unless explicitly requested for debugging
+	execPath, loggedArgs := plan.RedactedCommand()
+	if opts.ExposeAccessToken {
+		execPath, loggedArgs = plan.Command()
+	}
+	E.Emit("launch_command", map[string]interface{}{
+		"executable": execPath,
+		"args":       loggedArgs,
+	})
+
+	return plan, nil
 }
 
 // LaunchMinecraft prepares the Java command and returns an *exec.Cmd ready to be started.
 func LaunchMinecraft(username, accessToken, uuid, gameDir, version, javaPath, maxRam, minRam string, E *events.EventEmitter) (*exec.Cmd, error) {
-	// Get the executable path and arguments
-	javaPath, args, err := PrepareCMD(username, accessToken, uuid, gameDir, version, javaPath, maxRam, minRam, E)
+	return LaunchWithOptions(LaunchOptions{
+		Username:    username,
+		AccessToken: accessToken,
+		UUID:        uuid,
+		GameDir:     gameDir,
+		Version:     version,
+		JavaPath:    javaPath,
+		MaxRam:      maxRam,
+		MinRam:      minRam,
+	}, E)
+}
+
+// LaunchWithOptions prepares the command described by opts and returns an *exec.Cmd ready to be started.
+func LaunchWithOptions(opts LaunchOptions, E *events.EventEmitter) (*exec.Cmd, error) {
+	// Resolve a version alias once, so the hooks see the same version as the plan
+	if err := resolveVersion(&opts, E); err != nil {
+		return nil, err
+	}
+
+	// Resolve the launch plan
+	plan, err := PrepareLaunchPlan(opts, E)
 	if err != nil {
 		return nil, err
 	}
 
-	E.Emit("launching_game", version)
+	if err := runHook("pre_launch", opts.PreLaunchCommand, opts, E); err != nil {
+		return nil, err
+	}
 
-	// Create the command object
-	cmd := exec.Command(javaPath, args...)
-	// Direct the child process's I/O to the launcher's I/O
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	E.Emit("launching_game", opts.Version)
 
+	// Create the command object, with the child's I/O directed to the launcher's I/O
+	// and scanned for native loading problems and memory errors
+	cmd := plan.Cmd()
+	oom := &oomWatch{maxRam: plan.MaxRam}
+	stdout, stderr := NewOutputMonitor(E), NewOutputMonitor(E)
+	stdout.oom, stderr.oom = oom, oom
+	cmd.Stdout = io.MultiWriter(os.Stdout, stdout)
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+
+	// Hide the console window on Windows when asked to; job objects need StartGame
+	if windowsProcessAPI != nil {
+		windowsProcessAPI.configure(cmd, opts.Windows)
+	}
 	return cmd, nil
 }
@@ -0,0 +1,237 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/sysinfo"
+)
+
+// jvmOverheadMB approximates the memory a JVM uses beyond its heap (metaspace, code cache,
+// thread stacks, natives), so the heap alone must leave this much room.
+const jvmOverheadMB = 768
+
+// minSuggestedHeapMB is the smallest heap ever suggested; modern versions barely start below it.
+const minSuggestedHeapMB = 1024
+
+// ErrInsufficientMemory is returned when FailOnLowMemory is set and the requested heap does not
+// fit in the available memory.
+var ErrInsufficientMemory error = i18n.New("launcher.insufficient_memory", "not enough free memory for the requested heap")
+
+// Reasons of an OOMReport.
+const (
+	// OOMHeap means the Java heap (-Xmx) was exhausted.
+	OOMHeap = "heap"
+	// OOMMetaspace means class metadata exhausted the metaspace, usually with large modpacks.
+	OOMMetaspace = "metaspace"
+	// OOMDirect means direct buffer memory ran out (rendering, networking).
+	OOMDirect = "direct_memory"
+	// OOMGCOverhead means the heap was so full that garbage collection could not keep up.
+	OOMGCOverhead = "gc_overhead"
+	// OOMNative means the JVM could not get memory or threads from the operating system.
+	OOMNative = "native"
+	// OOMKilled means the operating system killed the game (exit code 137 / SIGKILL).
+	OOMKilled = "killed"
+)
+
+// OOMReport is the payload of the game_oom event.
+type OOMReport struct {
+	Reason string
+	// MaxRam is the -Xmx the game ran with; SuggestedMaxRam what to try instead (may be empty).
+	MaxRam          string
+	SuggestedMaxRam string
+	// ExtraJVMArgs are suggested additional JVM options, e.g. a larger metaspace.
+	ExtraJVMArgs []string
+	Hint         string
+	// Line is the output line the error was detected in, ExitCode the exit status of the game.
+	Line     string
+	ExitCode int
+}
+
+var oomPattern = regexp.MustCompile(`java\.lang\.OutOfMemoryError(?:: (.+))?`)
+
+// ------------------ Helpers ------------------
+
+// formatMB renders megabytes as a JVM memory size, preferring whole gigabytes.
+func formatMB(mb int64) string {
+	if mb%1024 == 0 {
+		return strconv.FormatInt(mb/1024, 10) + "G"
+	}
+	return strconv.FormatInt(mb, 10) + "M"
+}
+
+// roundDownMB rounds a heap size down to a multiple of 512 megabytes.
+func roundDownMB(mb int64) int64 {
+	return mb / 512 * 512
+}
+
+// suggestHeapMB returns a heap that fits in mem, or 0 when nothing sensible fits.
+func suggestHeapMB(mem *sysinfo.Memory) int64 {
+	suggested := roundDownMB(mem.AvailableMB - jvmOverheadMB)
+	// Never suggest more than half of the machine, the rest is needed by the OS and GPU driver
+	if half := roundDownMB(mem.TotalMB / 2); suggested > half {
+		suggested = half
+	}
+	if suggested < minSuggestedHeapMB {
+		return 0
+	}
+	return suggested
+}
+
+// checkFreeMemory compares the requested heap with the memory available now. It emits
+// memory_warning when it does not fit and fails with ErrInsufficientMemory if strict is set.
+// Platforms where memory cannot be measured are not checked.
+func checkFreeMemory(maxRam string, strict bool, E *events.EventEmitter) error {
+	maxMB, err := parseMemoryMB(maxRam)
+	if err != nil {
+		return nil
+	}
+	mem, err := sysinfo.MemoryStats()
+	if err != nil || mem.TotalMB == 0 {
+		return nil
+	}
+	if maxMB+jvmOverheadMB <= mem.AvailableMB {
+		return nil
+	}
+
+	suggested := ""
+	if mb := suggestHeapMB(mem); mb > 0 {
+		suggested = formatMB(mb)
+	}
+	hint := fmt.Sprintf("-Xmx%s needs about %dM but only %dM of %dM is free; close other programs", maxRam, maxMB+jvmOverheadMB, mem.AvailableMB, mem.TotalMB)
+	if suggested != "" {
+		hint += " or lower the memory to " + suggested
+	}
+	if maxMB+jvmOverheadMB > mem.TotalMB {
+		hint = fmt.Sprintf("-Xmx%s is more than this machine's %dM of memory can hold", maxRam, mem.TotalMB)
+		if suggested != "" {
+			hint += "; lower it to " + suggested
+		}
+	}
+
+	E.Emit("memory_warning", map[string]interface{}{
+		"requested":   maxRam,
+		"availableMB": mem.AvailableMB,
+		"totalMB":     mem.TotalMB,
+		"suggested":   suggested,
+		"hint":        hint,
+	})
+	if strict {
+		err := fmt.Errorf("%w: %s", ErrInsufficientMemory, hint)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	return nil
+}
+
+// DiagnoseOOM maps a line of game output to an OOMReport for a game running with maxRam.
+func DiagnoseOOM(line, maxRam string) (*OOMReport, bool) {
+	m := oomPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+
+	report := &OOMReport{MaxRam: maxRam, Line: line}
+	maxMB, _ := parseMemoryMB(maxRam)
+	raise := func() {
+		if maxMB == 0 {
+			return
+		}
+		suggested := roundDownMB(maxMB*3/2 + 511)
+		if mem, err := sysinfo.MemoryStats(); err == nil && mem.TotalMB > 0 {
+			if limit := roundDownMB(mem.TotalMB - 2048); suggested > limit {
+				suggested = limit
+			}
+		}
+		if suggested > maxMB {
+			report.SuggestedMaxRam = formatMB(suggested)
+		}
+	}
+
+	switch detail := m[1]; {
+	case detail == "Metaspace" || detail == "Compressed class space":
+		report.Reason = OOMMetaspace
+		report.ExtraJVMArgs = []string{"-XX:MaxMetaspaceSize=1G"}
+		report.Hint = "Class metadata ran out of space, which happens with very large modpacks; raise -XX:MaxMetaspaceSize or remove it if it was set."
+	case detail == "Direct buffer memory":
+		report.Reason = OOMDirect
+		report.ExtraJVMArgs = []string{"-XX:MaxDirectMemorySize=1G"}
+		report.Hint = "Direct buffer memory ran out; raise -XX:MaxDirectMemorySize or lower render distance and resource pack resolution."
+	case detail == "GC overhead limit exceeded":
+		report.Reason = OOMGCOverhead
+		raise()
+		report.Hint = "The heap was almost full and the garbage collector could not free enough memory; give the game more memory."
+	case detail == "unable to create native thread" || detail == "unable to create new native thread":
+		report.Reason = OOMNative
+		report.Hint = "The operating system refused more threads or memory; close other programs or lower the memory given to the game."
+	default:
+		report.Reason = OOMHeap
+		raise()
+		report.Hint = "The game ran out of heap memory; give it more memory or remove memory-hungry mods and resource packs."
+	}
+	if report.SuggestedMaxRam != "" {
+		report.Hint += " Suggested: -Xmx" + report.SuggestedMaxRam + "."
+	}
+	return report, true
+}
+
+// ------------------ Detection ------------------
+
+// oomWatch emits a single game_oom event per game process, shared by its output monitors.
+type oomWatch struct {
+	once   sync.Once
+	maxRam string
+}
+
+// report emits game_oom once.
+func (w *oomWatch) report(report *OOMReport, E *events.EventEmitter) {
+	w.once.Do(func() { E.Emit("game_oom", report) })
+}
+
+// killedBySystem reports whether the game exited with 137 or was killed by SIGKILL,
+// which is how the Linux OOM killer (and macOS memory pressure) ends processes.
+func killedBySystem(err error) (int, bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, false
+	}
+	code := exitErr.ExitCode()
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGKILL {
+		return 137, true
+	}
+	return code, code == 137
+}
+
+// CheckGameExit inspects the error returned by waiting for a game started with maxRam. When the
+// operating system killed the game it emits game_oom with the reason OOMKilled and returns the
+// report; otherwise it returns nil.
+func CheckGameExit(err error, maxRam string, E *events.EventEmitter) *OOMReport {
+	code, killed := killedBySystem(err)
+	if !killed {
+		return nil
+	}
+
+	report := &OOMReport{
+		Reason:   OOMKilled,
+		MaxRam:   maxRam,
+		ExitCode: code,
+		Hint:     "The game was killed by the operating system, most likely because the machine ran out of memory; close other programs or lower the memory given to the game.",
+	}
+	if maxMB, err := parseMemoryMB(maxRam); err == nil {
+		if mem, err := sysinfo.MemoryStats(); err == nil && mem.TotalMB > 0 {
+			if suggested := suggestHeapMB(&sysinfo.Memory{TotalMB: mem.TotalMB, AvailableMB: mem.TotalMB / 2}); suggested > 0 && suggested < maxMB {
+				report.SuggestedMaxRam = formatMB(suggested)
+				report.Hint += " Suggested: -Xmx" + report.SuggestedMaxRam + "."
+			}
+		}
+	}
+	E.Emit("game_oom", report)
+	return report
+}
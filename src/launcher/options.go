@@ -0,0 +1,363 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/rules"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// LaunchOptions groups every setting used to prepare a Minecraft launch.
+// Empty fields fall back to the same defaults PrepareCMD applies.
+//
+// ExtraJVMArgs, WrapperCommand and the hook commands may contain placeholders such as
+// ${instance_dir}, ${version} or ${natives_dir}; they are expanded at launch time so
+// per-instance configurations stay portable. Unknown placeholders are looked up in the
+// process environment and left untouched if not set.
+//
+// Version may be an alias such as downloader.AliasLatestSnapshot; it is resolved through the
+// manifest when the launch is prepared and reported as version_alias_resolved.
+type LaunchOptions struct {
+	Username    string
+	AccessToken string
+	UUID        string
+	GameDir     string
+	Version     string
+	JavaPath    string
+	MaxRam      string
+	MinRam      string
+
+	// UserType, XUID and ClientID fill ${user_type}, ${auth_xuid} and ${clientid} when Auth is
+	// not set; with Auth they come from the session. UserType defaults to auth.UserTypeMSA when
+	// an XUID is given and to auth.UserTypeLegacy otherwise; XUID and ClientID default to "0".
+	UserType string
+	XUID     string
+	ClientID string
+
+	// Layout locates the versions, libraries and assets to launch with. An empty Root means
+	// GameDir, so an instance directory can run from a shared installation by pointing Root at
+	// it, and single directories can be moved with the Layout's overrides.
+	Layout utils.Layout
+
+	// LibraryDirs are the library roots searched, in order, for the classpath and natives, so
+	// instance-specific libraries can be layered over a shared store. Empty means the
+	// libraries of Layout. The first root is used for ${library_directory}.
+	LibraryDirs []string
+
+	// DownloadMissingLibraries downloads libraries missing from disk while preparing the launch,
+	// from the URL or repository of the version JSON or else from downloader.LibraryMirrors,
+	// instead of launching with an incomplete classpath.
+	DownloadMissingLibraries bool
+
+	// ExtraLibraries are added to the classpath of the version without editing its JSON. Those
+	// named by coordinate are downloaded when missing, see InstallExtraLibraries; a library
+	// that cannot be found fails the launch.
+	ExtraLibraries []ExtraLibrary
+
+	// Auth, when set, provides the account to launch with and takes precedence over Username,
+	// UUID and AccessToken. Session is used as is while valid and refreshed (or replaced by a new
+	// login) when it expired. A changed session is passed to OnSessionUpdated so it can be
+	// stored, and announced as an auth_session_updated event carrying a SessionUpdate without
+	// its tokens (the full session with ExposeAccessToken).
+	Auth             auth.Provider
+	Session          *auth.Session
+	OnSessionUpdated func(*auth.Session)
+
+	// AuthGracePeriod lets an expired Session launch while the auth servers are unreachable,
+	// as long as it was verified online within the period (see auth.EnsureWithGrace).
+	// Zero requires a successful refresh.
+	AuthGracePeriod time.Duration
+
+	// SanitizeUsername rewrites invalid offline usernames with SanitizeUsername instead of
+	// failing with a *UsernameError.
+	SanitizeUsername bool
+
+	// ExposeAccessToken includes the raw access token in event payloads. It is meant for
+	// debugging only; by default every emitted command line is passed through RedactedArgs.
+	ExposeAccessToken bool
+
+	// VerifyClientJar checks the client JAR's SHA1 against the version metadata before
+	// launching and fails with ErrClientIntegrity on a mismatch.
+	VerifyClientJar bool
+
+	// Arch overrides the architecture the game runs as ("arm64", "x86_64", "x86").
+	// When empty it is chosen per version: on Apple Silicon, versions without arm64
+	// LWJGL natives run as x86_64 under Rosetta.
+	Arch string
+
+	// JavaPaths maps an architecture to the Java executable used for it, so a native
+	// and an x86_64 runtime can be configured side by side. It takes precedence over JavaPath.
+	JavaPaths map[string]string
+
+	// Platform prepares the launch for another platform than the host, e.g. a Linux CI machine
+	// assembling a Windows instance: library rules, argument rules and natives are selected for
+	// it. Its Arch is used when Arch is empty. Nil means rules.Host().
+	Platform *rules.Platform
+
+	// Directories redirects natives, virtual assets and logging configurations to writable
+	// locations when the versions or assets directories are read-only.
+	Directories Directories
+
+	// RunOnHost runs the game outside the launcher's Flatpak sandbox through
+	// "flatpak-spawn --host", e.g. with a host JVM found by DiscoverJava. JavaPath and every path
+	// of the launch must then be valid on the host; the app needs the
+	// --talk-name=org.freedesktop.Flatpak permission. It fails outside Flatpak.
+	RunOnHost bool
+
+	// Windows configures the game process on Windows: javaw.exe, console window and job
+	// object. Start the command with StartGame for the console and job object settings.
+	Windows WindowsOptions
+
+	// LogFile, when set, makes StartGame send the game's output to this file instead of
+	// through the launcher, so the game keeps logging after the launcher exits and Reattach
+	// can stream it. The file is still streamed to the command's Stdout while attached.
+	LogFile string
+
+	// RuntimeRoot, when set and neither JavaPath nor JavaPaths selects a runtime, launches with
+	// the Mojang runtime component the version asks for (javaVersion.component), installing it
+	// under RuntimeRoot/runtime first if needed and emitting runtime_selected.
+	RuntimeRoot string
+
+	// Compat, when set, is checked against the selected Java runtime before launching; see
+	// DefaultCompatMatrix and FetchCompatMatrix. Violations emit compat_warning, and blocking
+	// ones fail with ErrIncompatibleJava.
+	Compat *CompatMatrix
+
+	// JavaCandidates are additional Java executables tried, in order, when the selected
+	// runtime violates the Compat matrix. The first one without violations is used.
+	JavaCandidates []string
+
+	// FailOnLowMemory fails with ErrInsufficientMemory when MaxRam does not fit in the memory
+	// available right now, instead of only emitting memory_warning.
+	FailOnLowMemory bool
+
+	// SystemInfo collects OS, CPU, memory and GPU/driver details in the background at launch
+	// and emits them as a system_info event (a *sysinfo.Info) for diagnostics.
+	SystemInfo bool
+
+	// ClampMemory lowers MaxRam to what a 32-bit JVM can reserve, emitting memory_clamped,
+	// instead of failing with ErrJVM32BitMemory.
+	ClampMemory bool
+
+	// LauncherName and LauncherVersion brand the launch through the ${launcher_name} and
+	// ${launcher_version} argument placeholders. They default to utils.LauncherBrand, which
+	// also names the launcher in the User-Agent of its HTTP requests.
+	LauncherName    string
+	LauncherVersion string
+
+	// ExtraArgs are appended after the game arguments.
+	ExtraArgs []string
+
+	// ExtraJVMArgs are added after the memory and classpath arguments, before the main class.
+	ExtraJVMArgs []string
+
+	// UIScale sets the HiDPI scale factor of the game window through -Dsun.java2d.uiScale,
+	// e.g. 1 to disable scaling. Zero keeps the JVM default.
+	UIScale float64
+
+	// AllowSoftwareOpenGL lets LWJGL 2 versions fall back to software OpenGL on Windows
+	// instead of failing when no hardware driver is available.
+	AllowSoftwareOpenGL bool
+
+	// PreferDiscreteGPU requests the discrete GPU on hybrid graphics systems. On Linux this
+	// sets the PRIME offload environment variables; on Windows it cannot be requested per
+	// launch and a gpu_preference_unsupported event explains the per-executable setting.
+	PreferDiscreteGPU bool
+
+	// Debug enables JDWP debugging on the given port, optionally suspending until a debugger attaches.
+	Debug *DebugOptions
+
+	// JavaAgents are attached with -javaagent, in order.
+	JavaAgents []JavaAgent
+
+	// FlightRecording starts a Java Flight Recorder recording at launch.
+	FlightRecording *FlightRecording
+
+	// WrapperCommand prefixes the java invocation, e.g. []string{"gamemoderun"}
+	// or []string{"firejail", "--noprofile"}. The first element becomes the
+	// executable and java is passed to it as an argument.
+	WrapperCommand []string
+
+	// PreLaunchCommand is run by LaunchWithOptions before the game command is created.
+	// A failing pre-launch command aborts the launch.
+	PreLaunchCommand []string
+
+	// PostExitCommand is run by RunPostExitHook once the game process has exited.
+	PostExitCommand []string
+}
+
+// libraryDirs returns the library roots of a launch, defaulting to the libraries of the layout.
+func libraryDirs(opts LaunchOptions) []string {
+	if len(opts.LibraryDirs) > 0 {
+		return opts.LibraryDirs
+	}
+	return []string{opts.layout().LibrariesDir()}
+}
+
+// layout returns the layout of a launch, rooted at GameDir unless Layout.Root is set.
+func (opts LaunchOptions) layout() utils.Layout {
+	layout := opts.Layout
+	if layout.Root == "" {
+		layout.Root = opts.GameDir
+	}
+	return layout
+}
+
+// targetPlatform returns the platform a launch is prepared for.
+func targetPlatform(opts LaunchOptions) rules.Platform {
+	if opts.Platform != nil {
+		platform := *opts.Platform
+		if platform.OS == "" {
+			platform.OS = rules.Host().OS
+		}
+		return platform
+	}
+	return rules.Host()
+}
+
+// accountDefaults fills in the account placeholders an offline or partially described account
+// lacks. Microsoft accounts must be launched as "msa": servers and the game's telemetry and
+// chat reporting look at it.
+func accountDefaults(userType, xuid, clientID string) (string, string, string) {
+	if userType == "" {
+		userType = auth.UserTypeLegacy
+		if xuid != "" {
+			userType = auth.UserTypeMSA
+		}
+	}
+	if xuid == "" {
+		xuid = "0"
+	}
+	if clientID == "" {
+		clientID = "0"
+	}
+	return userType, xuid, clientID
+}
+
+// applyWrapper prefixes the java executable and its arguments with the wrapper command.
+// Without a wrapper the executable and arguments are returned unchanged.
+func applyWrapper(wrapper []string, javaPath string, args []string) (string, []string) {
+	if len(wrapper) == 0 || wrapper[0] == "" {
+		return javaPath, args
+	}
+
+	wrapped := make([]string, 0, len(wrapper)+len(args))
+	wrapped = append(wrapped, wrapper[1:]...)
+	wrapped = append(wrapped, javaPath)
+	wrapped = append(wrapped, args...)
+	return wrapper[0], wrapped
+}
+
+// templateVars returns the placeholder values available to ExtraJVMArgs, WrapperCommand and hook commands.
+func templateVars(opts LaunchOptions) map[string]string {
+	versionDir := opts.layout().VersionDir(opts.Version)
+	javaPath := opts.JavaPath
+	if javaPath == "" {
+		javaPath = "java"
+	}
+	platform := targetPlatform(opts)
+	if opts.Arch != "" {
+		platform.Arch = opts.Arch
+	}
+
+	return map[string]string{
+		"instance_dir":  opts.GameDir,
+		"game_dir":      opts.GameDir,
+		"version":       opts.Version,
+		"version_dir":   versionDir,
+		"natives_dir":   opts.Directories.nativesDir(opts.Version, versionDir, platform),
+		"libraries_dir": libraryDirs(opts)[0],
+		"assets_dir":    opts.layout().AssetsDir(),
+		"java_path":     javaPath,
+	}
+}
+
+// expandTemplate replaces every ${name} placeholder in s. Names missing from vars are
+// resolved from the environment; placeholders that resolve nowhere are kept verbatim.
+func expandTemplate(s string, vars map[string]string) string {
+	return expandPlaceholders(s, func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	})
+}
+
+// expandPlaceholders replaces every ${name} placeholder in s with the value returned by lookup.
+// Placeholders lookup does not know are kept verbatim.
+func expandPlaceholders(s string, lookup func(name string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		name := s[start+2 : end]
+		b.WriteString(s[:start])
+		if value, ok := lookup(name); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// expandAll applies expandTemplate to every element and returns a new slice.
+func expandAll(values []string, vars map[string]string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		expanded[i] = expandTemplate(value, vars)
+	}
+	return expanded
+}
+
+// runHook expands and runs a hook command in the game directory, forwarding its output.
+func runHook(name string, command []string, opts LaunchOptions, E *events.EventEmitter) error {
+	if len(command) == 0 || command[0] == "" {
+		return nil
+	}
+
+	expanded := expandAll(command, templateVars(opts))
+	E.Emit("hook_start", map[string]interface{}{
+		"hook":    name,
+		"command": expanded,
+	})
+
+	cmd := exec.Command(expanded[0], expanded[1:]...)
+	cmd.Dir = opts.GameDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("%s hook failed: %w", name, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	E.Emit("hook_done", name)
+	return nil
+}
+
+// RunPostExitHook runs the PostExitCommand configured in opts, if any.
+// Callers invoke it after the command returned by LaunchWithOptions has exited.
+func RunPostExitHook(opts LaunchOptions, E *events.EventEmitter) error {
+	return runHook("post_exit", opts.PostExitCommand, opts, E)
+}
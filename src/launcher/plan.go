@@ -0,0 +1,104 @@
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// LaunchPlan is the fully resolved description of a game launch. It is returned by
+// PrepareLaunchPlan so callers can inspect or adjust it (e.g. a GUI showing the final
+// command) before turning it into an *exec.Cmd.
+//
+// The classpath is kept as a list and only joined when the arguments are built, so
+// entries can be added or removed freely. JVMArgs are already rendered: changing
+// NativesDir afterwards does not rewrite -Djava.library.path.
+type LaunchPlan struct {
+	// JavaPath is the Java executable that runs the game.
+	JavaPath string
+	// JVMArgs are the JVM options, excluding the classpath.
+	JVMArgs []string
+	// Classpath lists every library and the client JAR in load order.
+	Classpath []string
+	// ClasspathSeparator joins Classpath: the path list separator of the platform the plan
+	// was prepared for. Empty uses the host's.
+	ClasspathSeparator string
+	// Libraries reports how each library was resolved and which ones are missing.
+	Libraries *ClasspathReport
+	// MaxRam is the -Xmx the game runs with, after clamping; pass it to CheckGameExit.
+	MaxRam string
+	// MainClass is the entry point passed to java.
+	MainClass string
+	// GameArgs are the arguments passed to the main class, including ExtraArgs.
+	GameArgs []string
+	// NativesDir is the directory native libraries were extracted to.
+	NativesDir string
+	// Env holds additional "KEY=value" entries appended to the launcher's environment.
+	Env []string
+	// Wrapper prefixes the java invocation (wrapper commands, Rosetta's arch -x86_64).
+	Wrapper []string
+	// WorkDir is the working directory of the game process; empty keeps the launcher's.
+	WorkDir string
+
+	// secrets are redacted by RedactedCommand.
+	secrets []string
+}
+
+// LaunchPlanEvent is the payload of the launch_plan event, emitted once the launch is resolved
+// and before the command is built. It carries no credentials, so profilers, agents and debugging
+// tools can subscribe freely. Handlers run synchronously and may append to JVMArgs and Env
+// (e.g. a -javaagent or profiler variables); the changes are applied to the plan.
+type LaunchPlanEvent struct {
+	Version    string
+	JavaPath   string
+	MainClass  string
+	Classpath  []string
+	NativesDir string
+	JVMArgs    []string
+	Env        []string
+}
+
+// ClasspathString joins the classpath entries with ClasspathSeparator, so a plan prepared
+// for another platform keeps that platform's separator.
+func (p *LaunchPlan) ClasspathString() string {
+	separator := p.ClasspathSeparator
+	if separator == "" {
+		separator = string(os.PathListSeparator)
+	}
+	return strings.Join(p.Classpath, separator)
+}
+
+// Args returns the arguments passed to the Java executable, in launch order.
+func (p *LaunchPlan) Args() []string {
+	args := make([]string, 0, len(p.JVMArgs)+len(p.GameArgs)+3)
+	args = append(args, p.JVMArgs...)
+	args = append(args, "-cp", p.ClasspathString())
+	args = append(args, p.MainClass)
+	args = append(args, p.GameArgs...)
+	return args
+}
+
+// Command returns the executable and arguments to run, with the wrapper applied.
+func (p *LaunchPlan) Command() (string, []string) {
+	return applyWrapper(p.Wrapper, p.JavaPath, p.Args())
+}
+
+// RedactedCommand returns the same as Command with credentials replaced, suitable for logs and UIs.
+func (p *LaunchPlan) RedactedCommand() (string, []string) {
+	execPath, args := p.Command()
+	return execPath, RedactedArgs(args, p.secrets...)
+}
+
+// Cmd builds an *exec.Cmd for the plan with the child's I/O directed to the launcher's I/O.
+func (p *LaunchPlan) Cmd() *exec.Cmd {
+	execPath, args := p.Command()
+	cmd := exec.Command(execPath, args...)
+	cmd.Dir = p.WorkDir
+	if len(p.Env) > 0 {
+		cmd.Env = append(os.Environ(), p.Env...)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
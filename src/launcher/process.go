@@ -0,0 +1,181 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// WindowsOptions configures how the game process is created on Windows. They are ignored on
+// other systems.
+type WindowsOptions struct {
+	// JobObject assigns the game to a job object, so Game.KillTree ends it together with every
+	// process it started.
+	JobObject bool
+	// KillOnLauncherExit ends the game and its children when the launcher exits, even when it
+	// crashes. It implies JobObject.
+	KillOnLauncherExit bool
+	// HideConsole starts the game without a console window.
+	HideConsole bool
+	// ConsolePassthrough keeps java.exe and its console. Otherwise javaw.exe next to it is
+	// preferred, which runs without a console window while output is still captured.
+	ConsolePassthrough bool
+}
+
+// Game is a game process started by StartGame or found again by Reattach.
+type Game struct {
+	// Cmd is the started command; nil for reattached games.
+	Cmd     *exec.Cmd
+	Process *os.Process
+	Session Session
+
+	mu        sync.Mutex
+	job       uintptr
+	done      chan struct{}
+	following sync.WaitGroup
+	emitter   *events.EventEmitter
+}
+
+// windowsProcessAPI creates processes and job objects with the Windows API. It is set by
+// jobobject_windows.go and nil on other systems.
+var windowsProcessAPI interface {
+	configure(cmd *exec.Cmd, opts WindowsOptions)
+	createJob(killOnClose bool) (uintptr, error)
+	assign(job uintptr, pid int) error
+	terminate(job uintptr) error
+	close(job uintptr) error
+}
+
+// newGame creates the handle of a running game.
+func newGame(cmd *exec.Cmd, process *os.Process, s Session, E *events.EventEmitter) *Game {
+	return &Game{Cmd: cmd, Process: process, Session: s, done: make(chan struct{}), emitter: E}
+}
+
+// ------------------ Java Selection ------------------
+
+// preferJavaw returns javaw.exe from the directory of a java.exe path on Windows, unless
+// ConsolePassthrough is set or it does not exist.
+func preferJavaw(javaPath string, opts WindowsOptions, E *events.EventEmitter) string {
+	if runtime.GOOS != "windows" || opts.ConsolePassthrough || !strings.EqualFold(filepath.Base(javaPath), "java.exe") {
+		return javaPath
+	}
+	javaw := filepath.Join(filepath.Dir(javaPath), "javaw.exe")
+	if _, err := os.Stat(javaw); err != nil {
+		return javaPath
+	}
+	E.Emit("javaw_selected", javaw)
+	return javaw
+}
+
+// ------------------ Starting ------------------
+
+// StartGame starts a command from LaunchWithOptions and, when opts.Windows asks for it, puts
+// it in a job object. Failing to create the job object is reported as job_object_failed and
+// does not stop the game.
+//
+// The game is recorded in the SessionFile of its directory, so Reattach can find it after
+// the launcher restarts. With opts.LogFile, the game writes its output straight to that file,
+// so it outlives the launcher, and the file is streamed to the command's Stdout.
+func StartGame(cmd *exec.Cmd, opts LaunchOptions, E *events.EventEmitter) (*Game, error) {
+	var logOutput io.Writer
+	if opts.LogFile != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.LogFile), utils.Modes.Dir); err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to create log directory: %w", err)))
+			return nil, err
+		}
+		f, err := os.Create(opts.LogFile)
+		if err != nil {
+			E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to create log file: %w", err)))
+			return nil, err
+		}
+		// The child keeps its own handle; the launcher only reads the file
+		defer f.Close()
+		logOutput = cmd.Stdout
+		cmd.Stdout, cmd.Stderr = f, f
+	}
+
+	if err := cmd.Start(); err != nil {
+		err = fmt.Errorf("%w: %w", ErrGameStartFailed, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	game := newGame(cmd, cmd.Process, newSession(cmd.Process.Pid, opts), E)
+	if err := saveSession(game.Session); err != nil {
+		E.Emit("session_save_failed", err.Error())
+	}
+	if logOutput != nil {
+		game.follow(game.Session.LogFile, logOutput)
+	}
+
+	win := opts.Windows
+	if windowsProcessAPI == nil || !(win.JobObject || win.KillOnLauncherExit) {
+		return game, nil
+	}
+	job, err := windowsProcessAPI.createJob(win.KillOnLauncherExit)
+	if err == nil {
+		if err = windowsProcessAPI.assign(job, cmd.Process.Pid); err != nil {
+			windowsProcessAPI.close(job)
+		}
+	}
+	if err != nil {
+		E.Emit("job_object_failed", err.Error())
+		return game, nil
+	}
+	game.job = job
+	E.Emit("job_object_assigned", cmd.Process.Pid)
+	return game, nil
+}
+
+// KillTree ends the game and, when it runs in a job object, every process it started.
+func (g *Game) KillTree() error {
+	g.mu.Lock()
+	job := g.job
+	g.mu.Unlock()
+	if job != 0 {
+		return windowsProcessAPI.terminate(job)
+	}
+	return g.Process.Kill()
+}
+
+// Wait waits for the game to exit, finishes streaming its log file and releases its job
+// object and session file, then emits game_exited with the PID and exit code. With
+// KillOnLauncherExit, releasing the job also ends processes the game left behind.
+func (g *Game) Wait() error {
+	var err error
+	code := -1
+	if g.Cmd != nil {
+		err = g.Cmd.Wait()
+		var exitErr *exec.ExitError
+		if err == nil || errors.As(err, &exitErr) {
+			code = g.Cmd.ProcessState.ExitCode()
+		}
+	} else {
+		code = waitDetached(g.Process)
+	}
+	close(g.done)
+	g.following.Wait()
+
+	g.mu.Lock()
+	if g.job != 0 {
+		windowsProcessAPI.close(g.job)
+		g.job = 0
+	}
+	g.mu.Unlock()
+
+	removeSession(g.Session.GameDir, g.Session.PID)
+	g.emitter.Emit("game_exited", map[string]int{"pid": g.Session.PID, "code": code})
+	return err
+}
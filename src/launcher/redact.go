@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"strings"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+)
+
+// Redacted replaces secrets in arguments returned by RedactedArgs.
+const Redacted = "<redacted>"
+
+// SessionUpdate is the payload of auth_session_updated: the account of a refreshed or
+// replaced session without its access and refresh tokens.
+type SessionUpdate struct {
+	Provider  string    `json:"provider"`
+	Username  string    `json:"username"`
+	UUID      string    `json:"uuid"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	// Changed is always set; the session differs from LaunchOptions.Session.
+	Changed bool `json:"changed"`
+}
+
+// sessionUpdatePayload returns what auth_session_updated carries for s: a SessionUpdate, or
+// s itself when the tokens may be exposed.
+func sessionUpdatePayload(s *auth.Session, expose bool) interface{} {
+	if expose {
+		return s
+	}
+	return SessionUpdate{Provider: s.Provider, Username: s.Username, UUID: s.UUID, ExpiresAt: s.ExpiresAt, Changed: true}
+}
+
+// secretFlags are game arguments whose following value is a credential.
+var secretFlags = map[string]bool{
+	"--accessToken": true,
+	"--session":     true,
+	"--xuid":        true,
+}
+
+// RedactedArgs returns a copy of args that is safe to log: values following credential flags
+// such as --accessToken are replaced, as is every occurrence of the given secrets.
+// Placeholder tokens like the offline "0" are not treated as secrets.
+func RedactedArgs(args []string, secrets ...string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && secretFlags[args[i-1]] {
+			redacted[i] = Redacted
+			continue
+		}
+		for _, secret := range secrets {
+			if len(secret) > 1 {
+				arg = strings.ReplaceAll(arg, secret, Redacted)
+			}
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
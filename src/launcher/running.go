@@ -0,0 +1,215 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ------------------ Structs ------------------
+
+// RunningGame is a game process found by FindRunningGames.
+type RunningGame struct {
+	PID int
+	// GameDir is the --gameDir of the game, absolute when it could be resolved.
+	GameDir string
+	// Version is the --version of the game, "" when the command line has none.
+	Version string
+	// CommandLine is the full command line, as reported by the OS. It contains the access token.
+	CommandLine string
+}
+
+// processInfo is a process as listed by the OS. Args is nil where the OS only reports the
+// command line as a single string.
+type processInfo struct {
+	pid  int
+	args []string
+	line string
+	cwd  string
+}
+
+// ------------------ Detection ------------------
+
+// FindRunningGames lists the game processes launched with gameDir as their --gameDir, by
+// inspecting the command lines of running processes, so a restarted launcher can show them
+// as running. An empty gameDir lists every running game. Processes of other users may not be
+// visible.
+func FindRunningGames(gameDir string) ([]RunningGame, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	want := ""
+	if gameDir != "" {
+		want, _ = filepath.Abs(gameDir)
+	}
+
+	var games []RunningGame
+	for _, p := range procs {
+		if p.pid == os.Getpid() {
+			continue
+		}
+		dir, ok := flagValue(p, "--gameDir")
+		if !ok {
+			continue
+		}
+		version, hasVersion := flagValue(p, "--version")
+		if !hasVersion && !strings.Contains(p.line, "--accessToken") {
+			continue
+		}
+
+		if !filepath.IsAbs(dir) {
+			base := p.cwd
+			if base == "" {
+				base, _ = os.Getwd()
+			}
+			dir = filepath.Join(base, dir)
+		}
+		dir = filepath.Clean(dir)
+		if want != "" && !samePath(dir, want) {
+			continue
+		}
+		games = append(games, RunningGame{PID: p.pid, GameDir: dir, Version: version, CommandLine: p.line})
+	}
+	return games, nil
+}
+
+// IsGameRunning reports whether a game launched with gameDir is running.
+func IsGameRunning(gameDir string) bool {
+	games, err := FindRunningGames(gameDir)
+	return err == nil && len(games) > 0
+}
+
+// samePath compares two cleaned absolute paths, ignoring case on Windows and macOS.
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// flagValue returns the value following flag in a process's command line. Without separate
+// arguments, the value runs up to the next " --", which keeps paths with spaces intact.
+func flagValue(p processInfo, flag string) (string, bool) {
+	if p.args != nil {
+		for i, arg := range p.args {
+			if arg == flag && i+1 < len(p.args) {
+				return p.args[i+1], true
+			}
+			if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	i := strings.Index(p.line+" ", flag+" ")
+	if i < 0 {
+		return "", false
+	}
+	rest := p.line[i+len(flag)+1:]
+	if end := strings.Index(rest, " --"); end >= 0 {
+		rest = rest[:end]
+	}
+	return strings.Trim(strings.TrimSpace(rest), `"`), true
+}
+
+// ------------------ Process Listing ------------------
+
+// listProcesses returns the processes of this host with their command lines.
+func listProcesses() ([]processInfo, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return listProcessesLinux()
+	case "windows":
+		return listProcessesWindows()
+	default:
+		return listProcessesPS()
+	}
+}
+
+// listProcessesLinux reads the command lines from /proc.
+func listProcessesLinux() ([]processInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var procs []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		cwd, _ := os.Readlink(filepath.Join("/proc", entry.Name(), "cwd"))
+		procs = append(procs, processInfo{pid: pid, args: args, line: strings.Join(args, " "), cwd: cwd})
+	}
+	return procs, nil
+}
+
+// listProcessesPS asks ps for the command lines, which it reports with arguments joined by spaces.
+func listProcessesPS() ([]processInfo, error) {
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []processInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		command := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		procs = append(procs, processInfo{pid: pid, line: command})
+	}
+	return procs, nil
+}
+
+// listProcessesWindows asks CIM for the command lines of Java processes.
+func listProcessesWindows() ([]processInfo, error) {
+	script := `Get-CimInstance Win32_Process -Filter "Name like 'java%'" | Select-Object ProcessId,CommandLine | ConvertTo-Json -Compress`
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, err
+	}
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	type cimProcess struct {
+		ProcessId   int
+		CommandLine string
+	}
+	var list []cimProcess
+	// A single process is printed as an object rather than an array
+	if out[0] == '{' {
+		var one cimProcess
+		if err := json.Unmarshal(out, &one); err != nil {
+			return nil, err
+		}
+		list = append(list, one)
+	} else if err := json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+
+	procs := make([]processInfo, 0, len(list))
+	for _, p := range list {
+		procs = append(procs, processInfo{pid: p.ProcessId, line: p.CommandLine})
+	}
+	return procs, nil
+}
@@ -0,0 +1,135 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// hostRoot is where Flatpak mounts the host's /usr and /etc with --filesystem=host-os.
+const hostRoot = "/run/host"
+
+// ------------------ Java Discovery ------------------
+
+// JavaInstall is a Java executable found by DiscoverJava.
+type JavaInstall struct {
+	// Path is the executable, as seen by the process that will run it.
+	Path string
+	// Host marks runtimes outside the Flatpak sandbox; launch them with RunOnHost.
+	Host bool
+}
+
+// javaGlobs returns the patterns of Java executables installed system-wide on this OS.
+func javaGlobs() []string {
+	switch runtime.GOOS {
+	case "windows":
+		var globs []string
+		for _, root := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if root == "" {
+				continue
+			}
+			for _, vendor := range []string{"Java", "Eclipse Adoptium", "Microsoft", "Zulu", "BellSoft"} {
+				globs = append(globs, filepath.Join(root, vendor, "*", "bin", "java.exe"))
+			}
+		}
+		return globs
+	case "darwin":
+		return []string{"/Library/Java/JavaVirtualMachines/*/Contents/Home/bin/java"}
+	default:
+		return []string{"/usr/lib/jvm/*/bin/java"}
+	}
+}
+
+// DiscoverJava lists the Java executables available to the game: JAVA_HOME, java on PATH and
+// the system's JVM directories. Inside Flatpak it also lists the runtimes bundled with the app
+// or its SDK extensions (/app/jre, /usr/lib/sdk/openjdk*) and, when the app has
+// --filesystem=host-os, the host's JVMs (marked Host). Inside Snap it lists the JVMs of the snap.
+func DiscoverJava() []JavaInstall {
+	var found []JavaInstall
+	seen := map[string]bool{}
+	add := func(path string, host bool) {
+		if path == "" {
+			return
+		}
+		check := path
+		if host {
+			check = hostRoot + path
+		}
+		info, err := os.Stat(check)
+		if err != nil || info.IsDir() {
+			return
+		}
+		key := check
+		if resolved, err := filepath.EvalSymlinks(check); err == nil {
+			key = resolved
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		found = append(found, JavaInstall{Path: path, Host: host})
+	}
+
+	exe := "java"
+	if runtime.GOOS == "windows" {
+		exe = "java.exe"
+	}
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		add(filepath.Join(home, "bin", exe), false)
+	}
+	if path, err := exec.LookPath(exe); err == nil {
+		add(path, false)
+	}
+
+	globs := javaGlobs()
+	switch utils.DetectSandbox().Kind {
+	case utils.SandboxFlatpak:
+		globs = append([]string{"/app/jre/bin/java", "/app/jdk/*/bin/java", "/usr/lib/sdk/openjdk*/bin/java"}, globs...)
+	case utils.SandboxSnap:
+		globs = append([]string{filepath.Join(os.Getenv("SNAP"), "usr", "lib", "jvm", "*", "bin", "java")}, globs...)
+	}
+	for _, pattern := range globs {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			add(match, false)
+		}
+	}
+
+	if utils.DetectSandbox().Kind == utils.SandboxFlatpak {
+		matches, _ := filepath.Glob(hostRoot + "/usr/lib/jvm/*/bin/java")
+		for _, match := range matches {
+			add(strings.TrimPrefix(match, hostRoot), true)
+		}
+	}
+	return found
+}
+
+// ------------------ Host Spawning ------------------
+
+// hostSpawnWrapper returns the flatpak-spawn invocation running a command on the host in dir
+// with env. flatpak-spawn does not forward the sandbox's environment, so every entry of env
+// is passed explicitly, and --watch-bus ends the game when the launcher's sandbox goes away.
+func hostSpawnWrapper(env []string, dir string) ([]string, error) {
+	if utils.DetectSandbox().Kind != utils.SandboxFlatpak {
+		return nil, fmt.Errorf("RunOnHost requires the launcher to run inside Flatpak")
+	}
+	if _, err := exec.LookPath("flatpak-spawn"); err != nil {
+		return nil, fmt.Errorf("flatpak-spawn is not available: %w", err)
+	}
+
+	wrapper := []string{"flatpak-spawn", "--host", "--watch-bus"}
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			wrapper = append(wrapper, "--directory="+abs)
+		}
+	}
+	for _, entry := range env {
+		wrapper = append(wrapper, "--env="+entry)
+	}
+	return wrapper, nil
+}
@@ -0,0 +1,195 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// SessionFile is written to the game directory by StartGame and names the running game.
+const SessionFile = "launcher-session.json"
+
+// logPollInterval is how often a followed log file and a reattached game are checked.
+const logPollInterval = 250 * time.Millisecond
+
+// ErrNoSession is returned by Reattach when no game started by StartGame runs in the directory.
+var ErrNoSession error = i18n.New("launcher.no_session", "no running game session")
+
+// ------------------ Structs ------------------
+
+// Session records a game started by StartGame, so a restarted launcher can find it again.
+type Session struct {
+	PID     int    `json:"pid"`
+	Version string `json:"version"`
+	GameDir string `json:"gameDir"`
+	// LogFile is the file the game's output can be read from: LaunchOptions.LogFile, or the
+	// game's own logs/latest.log.
+	LogFile   string    `json:"logFile"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// ------------------ Session File ------------------
+
+// newSession describes a game just started with opts.
+func newSession(pid int, opts LaunchOptions) Session {
+	gameDir, _ := filepath.Abs(opts.GameDir)
+	logFile := opts.LogFile
+	if logFile == "" {
+		logFile = filepath.Join(gameDir, "logs", "latest.log")
+	}
+	logFile, _ = filepath.Abs(logFile)
+	return Session{PID: pid, Version: opts.Version, GameDir: gameDir, LogFile: logFile, StartedAt: time.Now()}
+}
+
+// saveSession writes the session file of s.GameDir.
+func saveSession(s Session) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.GameDir, SessionFile)
+	if err := os.WriteFile(path+".tmp", data, utils.Modes.File); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// LoadSession reads the session file of gameDir. It does not check that the game still runs.
+func LoadSession(gameDir string) (*Session, error) {
+	data, err := os.ReadFile(filepath.Join(gameDir, SessionFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SessionFile, err)
+	}
+	return &s, nil
+}
+
+// removeSession deletes the session file of gameDir if it still names pid.
+func removeSession(gameDir string, pid int) {
+	if s, err := LoadSession(gameDir); err == nil && s.PID == pid {
+		os.Remove(filepath.Join(gameDir, SessionFile))
+	}
+}
+
+// ------------------ Reattaching ------------------
+
+// sessionRunning reports whether the game of s still runs. The PID must belong to a game of
+// the same directory, so a PID reused by another process is not mistaken for the game.
+func sessionRunning(s *Session) bool {
+	games, err := FindRunningGames(s.GameDir)
+	if err != nil {
+		return utils.ProcessAlive(s.PID)
+	}
+	for _, game := range games {
+		if game.PID == s.PID {
+			return true
+		}
+	}
+	return false
+}
+
+// Reattach returns the game a previous launcher run started in gameDir with StartGame, after
+// the launcher restarted. Its log file is streamed to output from the start (nil skips it)
+// and scanned like the output of a fresh launch, Wait reports its exit and KillTree stops it.
+// A session whose game is gone is removed and ErrNoSession returned. Reattached games are not
+// in a job object; the exit code is only known on Windows and -1 elsewhere.
+func Reattach(gameDir string, output io.Writer, E *events.EventEmitter) (*Game, error) {
+	s, err := LoadSession(gameDir)
+	if err != nil {
+		return nil, err
+	}
+	if !sessionRunning(s) {
+		removeSession(gameDir, s.PID)
+		return nil, ErrNoSession
+	}
+	process, err := os.FindProcess(s.PID)
+	if err != nil {
+		removeSession(gameDir, s.PID)
+		return nil, ErrNoSession
+	}
+
+	game := newGame(nil, process, *s, E)
+	if output != nil {
+		game.follow(s.LogFile, io.MultiWriter(output, NewOutputMonitor(E)))
+	}
+	E.Emit("game_reattached", *s)
+	return game, nil
+}
+
+// waitDetached waits for a process the launcher did not start and returns its exit code.
+func waitDetached(process *os.Process) int {
+	if runtime.GOOS == "windows" {
+		if state, err := process.Wait(); err == nil {
+			return state.ExitCode()
+		}
+	}
+	for utils.ProcessAlive(process.Pid) {
+		time.Sleep(logPollInterval)
+	}
+	return -1
+}
+
+// ------------------ Log Following ------------------
+
+// follow copies path to w from its start as it grows, until the game exited and the file
+// was read to its end.
+func (g *Game) follow(path string, w io.Writer) {
+	g.following.Add(1)
+	go func() {
+		defer g.following.Done()
+		var f *os.File
+		// The game may not have created its log yet
+		for f == nil {
+			var err error
+			if f, err = os.Open(path); err != nil {
+				select {
+				case <-g.done:
+					return
+				case <-time.After(logPollInterval):
+				}
+			}
+		}
+		defer f.Close()
+
+		buf := make([]byte, 32*1024)
+		var offset int64
+		for {
+			n, err := f.Read(buf)
+			if n > 0 {
+				w.Write(buf[:n])
+				offset += int64(n)
+				continue
+			}
+			if err != nil && err != io.EOF {
+				return
+			}
+			// Start over when the file was truncated or rotated in place
+			if info, err := f.Stat(); err == nil && info.Size() < offset {
+				f.Seek(0, io.SeekStart)
+				offset = 0
+				continue
+			}
+			select {
+			case <-g.done:
+				io.Copy(w, f)
+				return
+			case <-time.After(logPollInterval):
+			}
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package launcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 16
+)
+
+// UsernameError reports why an offline username would be rejected by the game or servers.
+type UsernameError struct {
+	Username string
+	Reason   string
+}
+
+func (e *UsernameError) Error() string {
+	return fmt.Sprintf("invalid username %q: %s", e.Username, e.Reason)
+}
+
+// isUsernameChar reports whether r is allowed in a Minecraft username (A-Z, a-z, 0-9 and underscore).
+func isUsernameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
+
+// ValidateUsername checks an offline username against the rules enforced by Minecraft:
+// 3 to 16 characters made of letters, digits and underscores. It returns a *UsernameError.
+func ValidateUsername(username string) error {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return &UsernameError{
+			Username: username,
+			Reason:   fmt.Sprintf("must be %d to %d characters long", minUsernameLength, maxUsernameLength),
+		}
+	}
+	for _, r := range username {
+		if !isUsernameChar(r) {
+			return &UsernameError{
+				Username: username,
+				Reason:   fmt.Sprintf("character %q is not allowed (use letters, digits and underscores)", r),
+			}
+		}
+	}
+	return nil
+}
+
+// SanitizeUsername turns an arbitrary string into a valid offline username by replacing
+// disallowed characters with underscores, truncating to 16 characters and padding short names.
+func SanitizeUsername(username string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(username) {
+		if isUsernameChar(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+		if b.Len() == maxUsernameLength {
+			break
+		}
+	}
+
+	sanitized := b.String()
+	if strings.Trim(sanitized, "_") == "" {
+		return "Player"
+	}
+	for len(sanitized) < minUsernameLength {
+		sanitized += "_"
+	}
+	return sanitized
+}
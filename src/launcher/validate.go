@@ -0,0 +1,437 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// ErrInvalidVersionJSON is wrapped by *VersionJSONError.
+var ErrInvalidVersionJSON error = i18n.New("launcher.invalid_version_json", "invalid version JSON")
+
+// ValidationIssue is a single problem found in a version JSON.
+type ValidationIssue struct {
+	// Field is the path of the offending value, e.g. "libraries[3].name"; empty for syntax errors.
+	Field string
+	// Line and Column locate the value in the file (1-based); zero when unknown.
+	Line   int
+	Column int
+	// Warning marks issues the launcher can work around; the others prevent launching.
+	Warning bool
+	Message string
+}
+
+// String formats the issue as "line 12, column 5: libraries[3].name: missing library name".
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", i.Line, i.Column)
+	}
+	if i.Field != "" {
+		b.WriteString(i.Field + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// VersionJSONError reports the blocking issues of a version JSON file.
+type VersionJSONError struct {
+	File   string
+	Issues []ValidationIssue
+}
+
+func (e *VersionJSONError) Error() string {
+	msg := fmt.Sprintf("%v %s: %s", ErrInvalidVersionJSON, e.File, e.Issues[0])
+	if len(e.Issues) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Issues)-1)
+	}
+	return msg
+}
+
+func (e *VersionJSONError) Unwrap() error {
+	return ErrInvalidVersionJSON
+}
+
+// ------------------ Validation ------------------
+
+// versionValidator collects issues while walking a decoded version JSON.
+type versionValidator struct {
+	data    []byte
+	offsets map[string]int64
+	issues  []ValidationIssue
+}
+
+// add records an issue for field, locating it in the source. Missing fields are located
+// at the nearest enclosing value.
+func (v *versionValidator) add(field string, warning bool, format string, args ...any) {
+	issue := ValidationIssue{Field: field, Warning: warning, Message: fmt.Sprintf(format, args...)}
+	for path := field; ; path = path[:strings.LastIndexAny(path, ".[")] {
+		if offset, ok := v.offsets[path]; ok {
+			issue.Line, issue.Column = lineColumn(v.data, offset)
+			break
+		}
+		if !strings.ContainsAny(path, ".[") {
+			break
+		}
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// ValidateVersionJSON checks a version JSON for problems that would otherwise surface as generic
+// unmarshal errors or silently ignored values: syntax errors, fields of the wrong type, a missing
+// mainClass, malformed library entries and argument rules of unknown types.
+func ValidateVersionJSON(data []byte) []ValidationIssue {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		issue := ValidationIssue{Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			issue.Line, issue.Column = lineColumn(data, syntaxErr.Offset-1)
+		}
+		return []ValidationIssue{issue}
+	}
+
+	v := &versionValidator{data: data, offsets: map[string]int64{}}
+	indexOffsets(json.NewDecoder(bytes.NewReader(data)), "", v.offsets)
+
+	obj, ok := root.(map[string]any)
+	if !ok {
+		v.add("", false, "version JSON must be an object")
+		return v.issues
+	}
+
+	for _, field := range []string{"id", "type", "mainClass", "minecraftArguments", "inheritsFrom", "assets", "releaseTime", "time"} {
+		if value, present := obj[field]; present {
+			if _, ok := value.(string); !ok {
+				v.add(field, false, "must be a string, got %s", jsonKind(value))
+			}
+		}
+	}
+	if value, present := obj["minimumLauncherVersion"]; present {
+		if _, ok := value.(float64); !ok {
+			v.add("minimumLauncherVersion", false, "must be a number, got %s", jsonKind(value))
+		}
+	}
+
+	inherits, _ := obj["inheritsFrom"].(string)
+	if mainClass, _ := obj["mainClass"].(string); mainClass == "" && inherits == "" {
+		v.add("mainClass", false, "missing mainClass and no inheritsFrom to take it from")
+	}
+	_, hasLegacyArgs := obj["minecraftArguments"]
+	_, hasArgs := obj["arguments"]
+	if !hasLegacyArgs && !hasArgs && inherits == "" {
+		v.add("arguments", true, "neither arguments nor minecraftArguments is set; default game arguments will be used")
+	}
+
+	for _, field := range []string{"assetIndex", "downloads"} {
+		if value, present := obj[field]; present {
+			if _, ok := value.(map[string]any); !ok {
+				v.add(field, false, "must be an object, got %s", jsonKind(value))
+			}
+		}
+	}
+
+	if value, present := obj["libraries"]; present {
+		v.validateLibraries(value)
+	}
+	if value, present := obj["arguments"]; present {
+		v.validateArguments(value)
+	}
+	return v.issues
+}
+
+// validateLibraries checks the libraries array.
+func (v *versionValidator) validateLibraries(value any) {
+	libs, ok := value.([]any)
+	if !ok {
+		v.add("libraries", false, "must be an array, got %s", jsonKind(value))
+		return
+	}
+
+	for i, entry := range libs {
+		field := fmt.Sprintf("libraries[%d]", i)
+		lib, ok := entry.(map[string]any)
+		if !ok {
+			v.add(field, false, "library must be an object, got %s", jsonKind(entry))
+			continue
+		}
+
+		name, isString := lib["name"].(string)
+		switch {
+		case !isString:
+			v.add(field+".name", false, "missing library name")
+		case len(strings.Split(name, ":")) < 3:
+			v.add(field+".name", false, "library name %q is not a group:artifact:version coordinate", name)
+		}
+
+		if downloads, present := lib["downloads"]; present {
+			v.validateLibraryDownloads(field+".downloads", downloads)
+		}
+		if natives, present := lib["natives"]; present {
+			if _, ok := natives.(map[string]any); !ok {
+				v.add(field+".natives", false, "must be an object, got %s", jsonKind(natives))
+			}
+		}
+		if rules, present := lib["rules"]; present {
+			v.validateRules(field+".rules", rules)
+		}
+	}
+}
+
+// validateLibraryDownloads checks the downloads object of a library.
+func (v *versionValidator) validateLibraryDownloads(field string, value any) {
+	downloads, ok := value.(map[string]any)
+	if !ok {
+		v.add(field, false, "must be an object, got %s", jsonKind(value))
+		return
+	}
+
+	if artifact, present := downloads["artifact"]; present {
+		v.validateArtifact(field+".artifact", artifact)
+	}
+	if classifiers, present := downloads["classifiers"]; present {
+		entries, ok := classifiers.(map[string]any)
+		if !ok {
+			v.add(field+".classifiers", false, "must be an object, got %s", jsonKind(classifiers))
+			return
+		}
+		for name, artifact := range entries {
+			v.validateArtifact(field+".classifiers."+name, artifact)
+		}
+	}
+}
+
+// validateArtifact checks a downloadable artifact (path, url, sha1, size).
+func (v *versionValidator) validateArtifact(field string, value any) {
+	artifact, ok := value.(map[string]any)
+	if !ok {
+		v.add(field, false, "must be an object, got %s", jsonKind(value))
+		return
+	}
+	for _, key := range []string{"path", "url", "sha1"} {
+		if entry, present := artifact[key]; present {
+			if _, ok := entry.(string); !ok {
+				v.add(field+"."+key, false, "must be a string, got %s", jsonKind(entry))
+			}
+		}
+	}
+	if path, _ := artifact["path"].(string); path == "" {
+		v.add(field+".path", true, "missing artifact path; the library cannot be located on disk")
+	}
+	if size, present := artifact["size"]; present {
+		if _, ok := size.(float64); !ok {
+			v.add(field+".size", false, "must be a number, got %s", jsonKind(size))
+		}
+	}
+}
+
+// validateArguments checks the modern arguments object.
+func (v *versionValidator) validateArguments(value any) {
+	args, ok := value.(map[string]any)
+	if !ok {
+		v.add("arguments", false, "must be an object, got %s", jsonKind(value))
+		return
+	}
+
+	for _, kind := range []string{"game", "jvm"} {
+		list, present := args[kind]
+		if !present {
+			continue
+		}
+		field := "arguments." + kind
+		entries, ok := list.([]any)
+		if !ok {
+			v.add(field, false, "must be an array, got %s", jsonKind(list))
+			continue
+		}
+
+		for i, entry := range entries {
+			entryField := fmt.Sprintf("%s[%d]", field, i)
+			switch arg := entry.(type) {
+			case string:
+			case map[string]any:
+				v.validateArgumentValue(entryField+".value", arg["value"])
+				if rules, present := arg["rules"]; present {
+					v.validateRules(entryField+".rules", rules)
+				}
+			default:
+				v.add(entryField, false, "argument must be a string or an object with rules and value, got %s", jsonKind(entry))
+			}
+		}
+	}
+}
+
+// validateArgumentValue checks the value of a conditional argument: a string or an array of strings.
+func (v *versionValidator) validateArgumentValue(field string, value any) {
+	switch arg := value.(type) {
+	case string:
+	case []any:
+		for i, part := range arg {
+			if _, ok := part.(string); !ok {
+				v.add(fmt.Sprintf("%s[%d]", field, i), false, "must be a string, got %s", jsonKind(part))
+			}
+		}
+	case nil:
+		v.add(field, false, "missing argument value")
+	default:
+		v.add(field, false, "must be a string or an array of strings, got %s", jsonKind(value))
+	}
+}
+
+// validateRules checks a rules array as used by libraries and arguments.
+func (v *versionValidator) validateRules(field string, value any) {
+	rules, ok := value.([]any)
+	if !ok {
+		v.add(field, false, "must be an array, got %s", jsonKind(value))
+		return
+	}
+
+	for i, entry := range rules {
+		ruleField := fmt.Sprintf("%s[%d]", field, i)
+		rule, ok := entry.(map[string]any)
+		if !ok {
+			v.add(ruleField, false, "rule must be an object, got %s", jsonKind(entry))
+			continue
+		}
+
+		if action, _ := rule["action"].(string); action != "allow" && action != "disallow" {
+			v.add(ruleField+".action", false, "rule action must be \"allow\" or \"disallow\", got %v", rule["action"])
+		}
+
+		for key, condition := range rule {
+			switch key {
+			case "action":
+			case "os":
+				v.validateOSCondition(ruleField+".os", condition)
+			case "features":
+				v.validateFeatures(ruleField+".features", condition)
+			default:
+				v.add(ruleField+"."+key, true, "unknown rule type %q is ignored", key)
+			}
+		}
+	}
+}
+
+// validateOSCondition checks the os condition of a rule.
+func (v *versionValidator) validateOSCondition(field string, value any) {
+	os, ok := value.(map[string]any)
+	if !ok {
+		v.add(field, false, "must be an object, got %s", jsonKind(value))
+		return
+	}
+	for key, entry := range os {
+		switch key {
+		case "name", "arch", "version":
+			if _, ok := entry.(string); !ok {
+				v.add(field+"."+key, false, "must be a string, got %s", jsonKind(entry))
+			}
+		default:
+			v.add(field+"."+key, true, "unknown os condition %q is ignored", key)
+		}
+	}
+}
+
+// validateFeatures checks the features condition of a rule.
+func (v *versionValidator) validateFeatures(field string, value any) {
+	features, ok := value.(map[string]any)
+	if !ok {
+		v.add(field, false, "must be an object, got %s", jsonKind(value))
+		return
+	}
+	for key, entry := range features {
+		if _, ok := entry.(bool); !ok {
+			v.add(field+"."+key, false, "must be a boolean, got %s", jsonKind(entry))
+		}
+	}
+}
+
+// ------------------ Helpers ------------------
+
+// jsonKind names the JSON type of a decoded value for diagnostics.
+func jsonKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// indexOffsets walks the token stream of a JSON document and records the byte offset
+// at which each value starts, keyed by the same paths the validator reports.
+func indexOffsets(dec *json.Decoder, path string, offsets map[string]int64) error {
+	offsets[path] = dec.InputOffset()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := indexOffsets(dec, child, offsets); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := indexOffsets(dec, path+"["+strconv.Itoa(i)+"]", offsets); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// lineColumn converts a byte offset into a 1-based line and column, skipping the separators
+// and whitespace the decoder reports before a value.
+func lineColumn(data []byte, offset int64) (int, int) {
+	// Empty input fails at offset 0, one before the first byte
+	offset = max(offset, 0)
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+		offset++
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	line, column := 1, 1
+	for _, c := range data[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
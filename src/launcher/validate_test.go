@@ -0,0 +1,58 @@
+package launcher
+
+import "testing"
+
+func TestValidateVersionJSONSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		line, col  int
+		wantIssues int
+	}{
+		{"empty", "", 1, 1, 1},
+		{"whitespace only", "  \n", 2, 1, 1},
+		{"truncated object", `{"id": "1.20.1", "mainClass": "net.minecraft.client.main.Main"`, 1, 62, 1},
+		{"truncated on a later line", "{\n  \"id\": \"1.20.1\",\n  \"libraries\": [", 3, 16, 1},
+		{"truncated string", `{"id": "1.2`, 1, 11, 1},
+		{"stray comma", "{\n  \"id\": \"1.20.1\",\n}", 3, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidateVersionJSON([]byte(tt.data))
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("ValidateVersionJSON(%q) = %d issues, want %d: %+v", tt.data, len(issues), tt.wantIssues, issues)
+			}
+			issue := issues[0]
+			if issue.Warning || issue.Message == "" {
+				t.Errorf("ValidateVersionJSON(%q) = %+v, want a blocking syntax error", tt.data, issue)
+			}
+			if issue.Line != tt.line || issue.Column != tt.col {
+				t.Errorf("ValidateVersionJSON(%q) at %d:%d, want %d:%d", tt.data, issue.Line, issue.Column, tt.line, tt.col)
+			}
+		})
+	}
+}
+
+func TestLineColumn(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		offset       int64
+		line, column int
+	}{
+		{"empty before start", "", -1, 1, 1},
+		{"empty at start", "", 0, 1, 1},
+		{"before start", "{}", -1, 1, 1},
+		{"past end", "{}", 10, 1, 3},
+		{"skips separators", "{\"a\": 1}", 4, 1, 7},
+		{"second line", "{\n\"a\"", 2, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, column := lineColumn([]byte(tt.data), tt.offset)
+			if line != tt.line || column != tt.column {
+				t.Errorf("lineColumn(%q, %d) = %d:%d, want %d:%d", tt.data, tt.offset, line, column, tt.line, tt.column)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package lifecycle models the install and launch lifecycle of a version as a state machine
+// driven by the events of the core, so frontends can query one current state instead of
+// reconstructing it from individual events.
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// State is a stage of the lifecycle.
+type State string
+
+// States, in their usual order. Failed is entered from any stage that can fail.
+const (
+	Idle                 State = "idle"
+	FetchingMetadata     State = "fetching_metadata"
+	DownloadingLibraries State = "downloading_libraries"
+	DownloadingAssets    State = "downloading_assets"
+	Ready                State = "ready"
+	Launching            State = "launching"
+	Running              State = "running"
+	Exited               State = "exited"
+	Failed               State = "failed"
+)
+
+// transitions lists the states each state may move to.
+var transitions = map[State][]State{
+	Idle:                 {FetchingMetadata, Launching},
+	FetchingMetadata:     {DownloadingLibraries, Failed},
+	DownloadingLibraries: {DownloadingAssets, Failed},
+	// With background assets the game may launch before the assets are complete
+	DownloadingAssets: {Ready, Launching, Failed},
+	Ready:             {FetchingMetadata, Launching, Idle},
+	Launching:         {Running, Failed},
+	Running:           {Exited},
+	Exited:            {FetchingMetadata, Launching, Idle},
+	Failed:            {FetchingMetadata, Launching, Idle},
+}
+
+// eventStates maps the events of the downloader and launcher to the state they start.
+var eventStates = map[string]State{
+	"version_download_start":   FetchingMetadata,
+	"metadata_saved":           DownloadingLibraries,
+	"playable":                 DownloadingAssets,
+	"version_downloaded":       Ready,
+	"launch_preparation_start": Launching,
+	"transaction_rolled_back":  Failed,
+}
+
+// ------------------ Structs ------------------
+
+// TransitionError is returned for a transition the model does not allow.
+type TransitionError struct {
+	From State
+	To   State
+}
+
+// Error names both states.
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid lifecycle transition from %s to %s", e.From, e.To)
+}
+
+// Change is the payload of state_changed.
+type Change struct {
+	From State `json:"from"`
+	To   State `json:"to"`
+	// Error is set when entering Failed.
+	Error string `json:"error,omitempty"`
+	// ExitCode is set when entering Exited.
+	ExitCode int `json:"exitCode,omitempty"`
+}
+
+// Machine tracks the lifecycle of one version. It follows the install and launch events of
+// its emitter: FetchingMetadata on version_download_start, DownloadingLibraries once the
+// metadata and client JAR are saved, DownloadingAssets once the version is playable, Ready
+// when everything is downloaded and Launching while the launch is prepared. Running and Exited
+// are entered by Run, and Failed when the install is rolled back or by Fail. Every change is
+// emitted as state_changed with a Change; events that do not fit the current state (e.g. a
+// library download while the game runs) are ignored. Use one emitter, and so one Machine, per
+// concurrently installed or running version.
+type Machine struct {
+	E *events.EventEmitter
+
+	mu    sync.Mutex
+	state State
+	since time.Time
+}
+
+// New returns a machine in the Idle state following the events of E.
+func New(E *events.EventEmitter) *Machine {
+	m := &Machine{E: E, state: Idle, since: time.Now()}
+	E.OnAny(func(event string, data any) {
+		if state, ok := eventStates[event]; ok {
+			_ = m.Transition(state)
+		}
+	})
+	return m
+}
+
+// ------------------ State ------------------
+
+// State returns the current state.
+func (m *Machine) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Since returns when the current state was entered.
+func (m *Machine) Since() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.since
+}
+
+// CanTransition reports whether the model allows moving from one state to another.
+func CanTransition(from, to State) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves to a state and emits state_changed. Moving to the current state does
+// nothing.
+func (m *Machine) Transition(to State) error {
+	return m.change(Change{To: to})
+}
+
+// change applies a transition and emits it.
+func (m *Machine) change(change Change) error {
+	m.mu.Lock()
+	change.From = m.state
+	if change.From == change.To {
+		m.mu.Unlock()
+		return nil
+	}
+	if !CanTransition(change.From, change.To) {
+		m.mu.Unlock()
+		return &TransitionError{From: change.From, To: change.To}
+	}
+	m.state, m.since = change.To, time.Now()
+	m.mu.Unlock()
+
+	m.E.Emit("state_changed", change)
+	return nil
+}
+
+// Fail moves to Failed with the error that stopped the install or launch.
+func (m *Machine) Fail(err error) error {
+	change := Change{To: Failed}
+	if err != nil {
+		change.Error = err.Error()
+	}
+	return m.change(change)
+}
+
+// Reset returns to Idle from Ready, Exited or Failed.
+func (m *Machine) Reset() error {
+	return m.Transition(Idle)
+}
+
+// ------------------ Running ------------------
+
+// Run starts a prepared game command, e.g. from launcher.LaunchWithOptions, and waits for it:
+// Launching, Running once started, then Exited with its exit code. A command that cannot be
+// started moves to Failed. It returns the error of cmd.Wait.
+func (m *Machine) Run(cmd *exec.Cmd) error {
+	if err := m.Transition(Launching); err != nil {
+		m.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		err = fmt.Errorf("failed to start game: %w", err)
+		_ = m.Fail(err)
+		m.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	_ = m.Transition(Running)
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		m.E.Emit("error", i18n.ErrorEvent(fmt.Errorf("failed to wait for game: %w", err)))
+	}
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	_ = m.change(Change{To: Exited, ExitCode: code})
+	return err
+}
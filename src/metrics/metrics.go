@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// ------------------ Metric Names ------------------
+
+// Names of the metrics reported by the launcher core.
+const (
+	// DownloadsTotal counts finished downloads, labelled with "result" ("ok" or "error").
+	DownloadsTotal = "downloads_total"
+	// DownloadBytesTotal counts downloaded bytes.
+	DownloadBytesTotal = "download_bytes_total"
+	// DownloadDuration times single file downloads, labelled with "result".
+	DownloadDuration = "download_duration"
+	// InstallDuration times whole version installs, labelled with "result".
+	InstallDuration = "install_duration"
+	// LaunchesTotal counts launch preparations, labelled with "result".
+	LaunchesTotal = "launches_total"
+	// LaunchPrepareDuration times launch preparation, labelled with "result".
+	LaunchPrepareDuration = "launch_prepare_duration"
+)
+
+// ------------------ Interface ------------------
+
+// Labels qualify a metric sample, e.g. {"result": "error"}. Keep label values low-cardinality.
+type Labels map[string]string
+
+// Metrics receives counters and timings from the launcher core. Implementations feed them into
+// Prometheus, OpenTelemetry or any other system; they must be safe for concurrent use.
+type Metrics interface {
+	// AddCounter increases the counter name by delta.
+	AddCounter(name string, delta float64, labels Labels)
+	// ObserveDuration records how long an operation took.
+	ObserveDuration(name string, d time.Duration, labels Labels)
+}
+
+// Noop discards every sample. It is the default Metrics implementation.
+type Noop struct{}
+
+func (Noop) AddCounter(string, float64, Labels)            {}
+func (Noop) ObserveDuration(string, time.Duration, Labels) {}
+
+// ------------------ Global Provider ------------------
+
+var (
+	current Metrics = Noop{}
+	mu      sync.RWMutex
+)
+
+// Set installs the Metrics implementation used by every package. Passing nil restores Noop.
+func Set(m Metrics) {
+	if m == nil {
+		m = Noop{}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = m
+}
+
+// Get returns the installed Metrics implementation.
+func Get() Metrics {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// ------------------ Helpers ------------------
+
+// Inc increases the counter name by one.
+func Inc(name string, labels Labels) {
+	Get().AddCounter(name, 1, labels)
+}
+
+// Add increases the counter name by delta.
+func Add(name string, delta float64, labels Labels) {
+	Get().AddCounter(name, delta, labels)
+}
+
+// Result returns the standard "result" label for an operation outcome.
+func Result(err error) Labels {
+	if err != nil {
+		return Labels{"result": "error"}
+	}
+	return Labels{"result": "ok"}
+}
+
+// Since records the time elapsed since start under name.
+//
+//	start := time.Now()
+//	defer func() { metrics.Since(metrics.InstallDuration, start, metrics.Result(err)) }()
+func Since(name string, start time.Time, labels Labels) {
+	Get().ObserveDuration(name, time.Since(start), labels)
+}
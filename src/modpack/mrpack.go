@@ -0,0 +1,299 @@
+package modpack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/mods"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Sides a pack can be installed for.
+const (
+	SideClient = "client"
+	SideServer = "server"
+)
+
+// Environment support values of a pack file.
+const (
+	EnvRequired    = "required"
+	EnvOptional    = "optional"
+	EnvUnsupported = "unsupported"
+)
+
+// Dependency keys of a Modrinth pack.
+const (
+	DependencyMinecraft = "minecraft"
+	DependencyFabric    = "fabric-loader"
+	DependencyQuilt     = "quilt-loader"
+	DependencyForge     = "forge"
+	DependencyNeoForge  = "neoforge"
+)
+
+// ------------------ Structs ------------------
+
+// File is a file of a pack downloaded into the game directory.
+type File struct {
+	// Path is relative to the game directory, e.g. "mods/sodium.jar".
+	Path   string            `json:"path"`
+	Hashes map[string]string `json:"hashes"`
+	// Env tells on which side the file is needed; a nil Env means both.
+	Env *struct {
+		Client string `json:"client"`
+		Server string `json:"server"`
+	} `json:"env"`
+	// Downloads are mirrors of the same file.
+	Downloads []string `json:"downloads"`
+	FileSize  int64    `json:"fileSize"`
+}
+
+// Pack is a Modrinth modpack (.mrpack): its index and the archive holding its overrides.
+type Pack struct {
+	FormatVersion int    `json:"formatVersion"`
+	Game          string `json:"game"`
+	VersionID     string `json:"versionId"`
+	Name          string `json:"name"`
+	Summary       string `json:"summary"`
+	Files         []File `json:"files"`
+	// Dependencies maps DependencyMinecraft and loader keys to versions.
+	Dependencies map[string]string `json:"dependencies"`
+
+	// archive is the path of the .mrpack file.
+	archive string
+}
+
+// Options configures Install.
+type Options struct {
+	// Side selects which files are installed; empty means SideClient.
+	Side string
+	// SkipOptional leaves out files marked optional for the side.
+	SkipOptional bool
+	// Variables are substituted for "${name}" in override templates (see TemplateSuffix),
+	// e.g. {"server.address": "play.example.org"}.
+	Variables map[string]string
+}
+
+// ------------------ Helpers ------------------
+
+// side returns the selected side, SideClient by default.
+func (o Options) side() string {
+	if o.Side == "" {
+		return SideClient
+	}
+	return o.Side
+}
+
+// wanted reports whether the file is installed for side.
+func (f *File) wanted(side string, skipOptional bool) bool {
+	if f.Env == nil {
+		return true
+	}
+	support := f.Env.Client
+	if side == SideServer {
+		support = f.Env.Server
+	}
+	switch support {
+	case EnvUnsupported:
+		return false
+	case EnvOptional:
+		return !skipOptional
+	}
+	return true
+}
+
+// safePath returns dir joined with a slash-separated relative path, refusing paths that
+// would escape dir.
+func safePath(dir, rel string) (string, error) {
+	clean := path.Clean("/" + rel)
+	if clean == "/" || strings.Contains(rel, "\\") || strings.HasPrefix(rel, "/") || clean != "/"+rel {
+		return "", fmt.Errorf("unsafe path %q in pack", rel)
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+// ------------------ Public API ------------------
+
+// Load reads the index of a .mrpack file.
+func Load(archive string) (*Pack, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open modpack: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != "modrinth.index.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		var pack Pack
+		if err := json.NewDecoder(rc).Decode(&pack); err != nil {
+			return nil, fmt.Errorf("failed to parse modpack index: %w", err)
+		}
+		if pack.Game != "minecraft" {
+			return nil, fmt.Errorf("modpack is for %q, not minecraft", pack.Game)
+		}
+		pack.archive = archive
+		return &pack, nil
+	}
+	return nil, fmt.Errorf("%s has no modrinth.index.json", archive)
+}
+
+// MinecraftVersion returns the Minecraft version the pack is built for.
+func (p *Pack) MinecraftVersion() string {
+	return p.Dependencies[DependencyMinecraft]
+}
+
+// Loader returns the mod loader dependency of the pack and its version, or "" for vanilla.
+func (p *Pack) Loader() (string, string) {
+	for _, key := range []string{DependencyFabric, DependencyQuilt, DependencyForge, DependencyNeoForge} {
+		if version := p.Dependencies[key]; version != "" {
+			return key, version
+		}
+	}
+	return "", ""
+}
+
+// Install downloads the pack files needed on the selected side into dir, verifying their
+// SHA1, and extracts the overrides (overrides/, then client-overrides/ or server-overrides/).
+// Files unsupported on the side are skipped with modpack_file_skipped. Every file passes the
+// screeners registered with mods.RegisterScreener before it is moved into place. Override
+// templates are rendered with opts.Variables (see TemplateSuffix and DefaultSuffix).
+func (p *Pack) Install(dir string, opts Options, E *events.EventEmitter) error {
+	side := opts.side()
+	E.Emit("modpack_install_start", map[string]string{"name": p.Name, "version": p.VersionID, "side": side})
+
+	for _, file := range p.Files {
+		if !file.wanted(side, opts.SkipOptional) {
+			E.Emit("modpack_file_skipped", file.Path)
+			continue
+		}
+		target, err := safePath(dir, file.Path)
+		if err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+		if _, err := os.Stat(target); err == nil {
+			E.Emit("file_exists", target)
+			continue
+		}
+		// Staged under a name the game ignores until the screeners accepted it
+		staged := target + ".download"
+		os.Remove(staged)
+		if err := downloader.DownloadMultiSource(staged, file.Downloads, file.Hashes["sha1"], E); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Path, err)
+		}
+		if err := mods.ScreenAndInstall(staged, target, file.Path, file.Downloads[0], E); err != nil {
+			return err
+		}
+	}
+
+	if err := p.extractOverrides(dir, opts, E); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	E.Emit("modpack_installed", p.Name)
+	return nil
+}
+
+// extractOverrides copies the override folders of the archive into dir, screening every file
+// like a downloaded one. Templates are rendered and defaults skipped when the file already
+// existed before the install (see TemplateSuffix and DefaultSuffix).
+func (p *Pack) extractOverrides(dir string, opts Options, E *events.EventEmitter) error {
+	zr, err := zip.OpenReader(p.archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	vars := p.variables(dir, opts)
+	written := map[string]bool{}
+	for _, prefix := range []string{"overrides/", opts.side() + "-overrides/"} {
+		for _, f := range zr.File {
+			if !strings.HasPrefix(f.Name, prefix) || f.FileInfo().IsDir() {
+				continue
+			}
+			rel, template, onlyIfMissing := overrideTarget(strings.TrimPrefix(f.Name, prefix))
+			target, err := safePath(dir, rel)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(target); err == nil && onlyIfMissing && !written[target] {
+				E.Emit("modpack_override_kept", target)
+				continue
+			}
+
+			staged := target + ".download"
+			if template {
+				err = extractTemplate(f, staged, rel, vars, E)
+			} else {
+				err = extractFile(f, staged)
+			}
+			if err != nil {
+				os.Remove(staged)
+				return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+			}
+			if err := mods.ScreenAndInstall(staged, target, rel, p.archive, E); err != nil {
+				return err
+			}
+			written[target] = true
+			E.Emit("file_extracted", target)
+		}
+	}
+	return nil
+}
+
+// extractTemplate renders one archive entry with vars and writes it to target.
+func extractTemplate(f *zip.File, target, name string, vars map[string]string, E *events.EventEmitter) error {
+	if err := os.MkdirAll(filepath.Dir(target), utils.Modes.Dir); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, render(name, data, vars, E), utils.Modes.File)
+}
+
+// extractFile writes one archive entry to target.
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), utils.Modes.Dir); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,73 @@
+package modpack
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Suffixes of override files processed while installing. They combine, e.g.
+// "config/servers.json.default.tmpl" is rendered and only written when missing.
+const (
+	// TemplateSuffix marks an override rendered with Options.Variables; the suffix is dropped.
+	TemplateSuffix = ".tmpl"
+	// DefaultSuffix marks an override only written when the file does not exist yet, so user
+	// changes survive pack updates; the suffix is dropped.
+	DefaultSuffix = ".default"
+)
+
+// variablePattern matches "${name}" placeholders in templates.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_.-]+)\}`)
+
+// ------------------ Helpers ------------------
+
+// overrideTarget strips the processing suffixes of an override path and reports them.
+func overrideTarget(rel string) (target string, template, onlyIfMissing bool) {
+	target = rel
+	if strings.HasSuffix(target, TemplateSuffix) {
+		target, template = strings.TrimSuffix(target, TemplateSuffix), true
+	}
+	if strings.HasSuffix(target, DefaultSuffix) {
+		target, onlyIfMissing = strings.TrimSuffix(target, DefaultSuffix), true
+	}
+	return target, template, onlyIfMissing
+}
+
+// variables returns the variables available to templates: the built-in pack.name,
+// pack.version, minecraft.version, loader, loader.version, side and gameDir, then
+// Options.Variables, which may override them.
+func (p *Pack) variables(dir string, opts Options) map[string]string {
+	loader, loaderVersion := p.Loader()
+	vars := map[string]string{
+		"pack.name":         p.Name,
+		"pack.version":      p.VersionID,
+		"minecraft.version": p.MinecraftVersion(),
+		"loader":            loader,
+		"loader.version":    loaderVersion,
+		"side":              opts.side(),
+		"gameDir":           dir,
+	}
+	for name, value := range opts.Variables {
+		vars[name] = value
+	}
+	return vars
+}
+
+// render substitutes the "${name}" placeholders of a template. Unknown placeholders are left
+// as they are and reported with modpack_template_unresolved.
+func render(name string, data []byte, vars map[string]string, E *events.EventEmitter) []byte {
+	var unresolved []string
+	out := variablePattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		key := string(match[2 : len(match)-1])
+		if value, ok := vars[key]; ok {
+			return []byte(value)
+		}
+		unresolved = append(unresolved, key)
+		return match
+	})
+	if len(unresolved) > 0 {
+		E.Emit("modpack_template_unresolved", map[string]any{"file": name, "variables": unresolved})
+	}
+	return out
+}
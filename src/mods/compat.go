@@ -0,0 +1,204 @@
+package mods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/instance"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// loaderLibraries maps Maven group:artifact prefixes of loader libraries to their loader.
+var loaderLibraries = []struct {
+	prefix, loader string
+}{
+	{"org.quiltmc:quilt-loader:", LoaderQuilt},
+	{"net.fabricmc:fabric-loader:", LoaderFabric},
+	{"net.neoforged:neoforge:", LoaderNeoForge},
+	{"net.neoforged.fancymodloader:", LoaderNeoForge},
+	{"net.neoforged:forge:", LoaderNeoForge}, // NeoForge 1.20.1
+	{"net.minecraftforge:forge:", LoaderForge},
+	{"net.minecraftforge:fmlloader:", LoaderForge},
+}
+
+// ------------------ Structs ------------------
+
+// Target is the environment an instance loads mods in.
+type Target struct {
+	// Loader is LoaderFabric, LoaderQuilt, LoaderForge, LoaderNeoForge or "" for vanilla.
+	Loader      string `json:"loader"`
+	GameVersion string `json:"gameVersion"`
+}
+
+// IncompatibleError is returned when a mod cannot be loaded by a Target, with what the mod
+// would need instead.
+type IncompatibleError struct {
+	Mod    *ModInfo
+	Target Target
+	// NeedLoaders are the loaders the mod supports when the target loader is not one of them.
+	NeedLoaders []string
+	// NeedGameVersions are the Minecraft version constraints the mod declares when the target
+	// version matches none of them.
+	NeedGameVersions []string
+}
+
+// Error describes the mismatch, e.g. "sodium 0.5.3 needs fabric or quilt, instance uses forge".
+func (e *IncompatibleError) Error() string {
+	var needs []string
+	if len(e.NeedLoaders) > 0 {
+		loader := e.Target.Loader
+		if loader == "" {
+			loader = "no mod loader"
+		}
+		needs = append(needs, fmt.Sprintf("needs %s, instance uses %s", strings.Join(e.NeedLoaders, " or "), loader))
+	}
+	if len(e.NeedGameVersions) > 0 {
+		needs = append(needs, fmt.Sprintf("needs Minecraft %s, instance runs %s", strings.Join(e.NeedGameVersions, " or "), e.Target.GameVersion))
+	}
+	return fmt.Sprintf("%s %s is incompatible: %s", e.Mod.ID, e.Mod.Version, strings.Join(needs, "; "))
+}
+
+// ------------------ Detection ------------------
+
+// DetectTarget reads the loader and Minecraft version of an installed version by walking its
+// inheritance chain: the loader comes from the loader libraries, the game version from the
+// version at the root of the chain.
+func DetectTarget(mcDir, versionID string) (*Target, error) {
+	target := &Target{}
+	id := versionID
+	for depth := 0; depth < 16; depth++ {
+		data, err := os.ReadFile(utils.NewLayout(mcDir).VersionJSON(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version JSON for %s: %w", id, err)
+		}
+		var v struct {
+			ID           string `json:"id"`
+			InheritsFrom string `json:"inheritsFrom"`
+			Libraries    []struct {
+				Name string `json:"name"`
+			} `json:"libraries"`
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("failed to parse version JSON for %s: %w", id, err)
+		}
+
+		if target.Loader == "" {
+			for _, lib := range v.Libraries {
+				for _, l := range loaderLibraries {
+					if strings.HasPrefix(lib.Name, l.prefix) {
+						target.Loader = l.loader
+						break
+					}
+				}
+				if target.Loader != "" {
+					break
+				}
+			}
+		}
+		if v.InheritsFrom == "" {
+			target.GameVersion = id
+			return target, nil
+		}
+		id = v.InheritsFrom
+	}
+	return nil, fmt.Errorf("version %s inherits too deeply", versionID)
+}
+
+// ------------------ Compatibility ------------------
+
+// CheckCompatibility returns an *IncompatibleError when the mod does not support the target
+// loader or declares a Minecraft version range the target version is outside of.
+func CheckCompatibility(info *ModInfo, target Target) error {
+	err := &IncompatibleError{Mod: info, Target: target}
+	if !info.SupportsLoader(target.Loader) {
+		err.NeedLoaders = info.Loaders
+	}
+	if versions := info.GameVersions(); target.GameVersion != "" && !SatisfiesAny(target.GameVersion, versions) {
+		err.NeedGameVersions = versions
+	}
+	if err.NeedLoaders == nil && err.NeedGameVersions == nil {
+		return nil
+	}
+	return err
+}
+
+// ModsDir returns the mods directory of an instance.
+func ModsDir(inst *instance.Instance) string {
+	return filepath.Join(inst.Dir(), "mods")
+}
+
+// AddMod copies the mod JAR at path into the mods directory of an instance after checking it
+// against the instance's loader and Minecraft version (see DetectTarget). A mod that does not
+// fit is not copied and an *IncompatibleError is returned; use errors.As to inspect it. The
+// registered screeners (see RegisterScreener) run before the file is copied.
+func AddMod(mcDir string, inst *instance.Instance, path string, E *events.EventEmitter) (*ModInfo, error) {
+	info, err := ReadModInfo(path)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	target, err := DetectTarget(mcDir, inst.Version)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	if err := CheckCompatibility(info, *target); err != nil {
+		var incompatible *IncompatibleError
+		if errors.As(err, &incompatible) {
+			E.Emit("mod_incompatible", map[string]any{
+				"mod": info.ID, "version": info.Version, "loader": target.Loader, "gameVersion": target.GameVersion,
+				"needLoaders": incompatible.NeedLoaders, "needGameVersions": incompatible.NeedGameVersions,
+			})
+		}
+		return nil, err
+	}
+
+	dest := filepath.Join(ModsDir(inst), filepath.Base(path))
+	if err := ScreenFile(path, filepath.Base(path), path, E); err != nil {
+		return nil, err
+	}
+	if err := copyMod(path, dest); err != nil {
+		err = fmt.Errorf("failed to add mod %s: %w", info.ID, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	info.File = dest
+	E.Emit("mod_added", map[string]string{"instance": inst.ID, "mod": info.ID, "version": info.Version, "path": dest})
+	return info, nil
+}
+
+// copyMod copies a JAR through a temporary file, so the game never sees half a mod.
+func copyMod(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), utils.Modes.Dir); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
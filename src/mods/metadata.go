@@ -0,0 +1,364 @@
+package mods
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Mod loaders, named as Modrinth names them.
+const (
+	LoaderFabric   = "fabric"
+	LoaderQuilt    = "quilt"
+	LoaderForge    = "forge"
+	LoaderNeoForge = "neoforge"
+)
+
+// Dependency kinds, named as Modrinth names them.
+const (
+	DependencyRequired     = "required"
+	DependencyOptional     = "optional"
+	DependencyIncompatible = "incompatible"
+)
+
+// GameID is the dependency ID mods use to constrain the Minecraft version.
+const GameID = "minecraft"
+
+// environmentIDs are dependency IDs provided by the game, the loader or the JVM rather than by
+// mods.
+var environmentIDs = map[string]bool{
+	GameID: true, "java": true, "fabricloader": true, "fabric-loader": true, "quilt_loader": true,
+	"forge": true, "neoforge": true, "fml": true, "javafml": true,
+}
+
+// ErrNoMetadata is returned for JARs without fabric.mod.json, quilt.mod.json or mods.toml.
+var ErrNoMetadata error = i18n.New("mods.no_metadata", "no mod metadata found")
+
+// ------------------ Structs ------------------
+
+// Dependency is a relation of a mod to another mod, the game or the loader.
+type Dependency struct {
+	ID string `json:"id"`
+	// Versions are alternative constraints (see Satisfies); any may match. Empty matches
+	// every version.
+	Versions []string `json:"versions,omitempty"`
+	// Kind is DependencyRequired, DependencyOptional or DependencyIncompatible.
+	Kind string `json:"kind"`
+}
+
+// ModInfo is the metadata a mod JAR declares about itself.
+type ModInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version"`
+	// Provides are further IDs the mod satisfies dependencies on (Fabric and Quilt).
+	Provides []string `json:"provides,omitempty"`
+	// Loaders are the loaders able to load the mod.
+	Loaders      []string     `json:"loaders"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// File is the JAR the metadata was read from.
+	File string `json:"file,omitempty"`
+}
+
+// GameVersions returns the constraints the mod puts on the Minecraft version; nil means any.
+func (m *ModInfo) GameVersions() []string {
+	for _, dep := range m.Dependencies {
+		if dep.ID == GameID && dep.Kind == DependencyRequired {
+			return dep.Versions
+		}
+	}
+	return nil
+}
+
+// SupportsLoader reports whether the mod can be loaded by loader.
+func (m *ModInfo) SupportsLoader(loader string) bool {
+	for _, l := range m.Loaders {
+		if l == loader {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------ Fabric & Quilt ------------------
+
+// constraintList reads a version constraint given as a string or an array of strings.
+func constraintList(raw interface{}) []string {
+	switch v := raw.(type) {
+	case string:
+		if v == "*" {
+			return nil
+		}
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// parseFabric reads fabric.mod.json.
+func parseFabric(data []byte) (*ModInfo, error) {
+	var raw struct {
+		ID         string                 `json:"id"`
+		Name       string                 `json:"name"`
+		Version    string                 `json:"version"`
+		Provides   []string               `json:"provides"`
+		Depends    map[string]interface{} `json:"depends"`
+		Recommends map[string]interface{} `json:"recommends"`
+		Suggests   map[string]interface{} `json:"suggests"`
+		Breaks     map[string]interface{} `json:"breaks"`
+		Conflicts  map[string]interface{} `json:"conflicts"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid fabric.mod.json: %w", err)
+	}
+
+	// Quilt Loader loads Fabric mods as well
+	info := &ModInfo{ID: raw.ID, Name: raw.Name, Version: raw.Version, Provides: raw.Provides, Loaders: []string{LoaderFabric, LoaderQuilt}}
+	for _, group := range []struct {
+		deps map[string]interface{}
+		kind string
+	}{
+		{raw.Depends, DependencyRequired},
+		{raw.Recommends, DependencyOptional},
+		{raw.Suggests, DependencyOptional},
+		{raw.Breaks, DependencyIncompatible},
+	} {
+		for id, versions := range group.deps {
+			info.Dependencies = append(info.Dependencies, Dependency{ID: id, Versions: constraintList(versions), Kind: group.kind})
+		}
+	}
+	return info, nil
+}
+
+// parseQuilt reads quilt.mod.json.
+func parseQuilt(data []byte) (*ModInfo, error) {
+	var raw struct {
+		Loader struct {
+			ID       string `json:"id"`
+			Version  string `json:"version"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Provides []interface{} `json:"provides"`
+			Depends  []interface{} `json:"depends"`
+			Breaks   []interface{} `json:"breaks"`
+		} `json:"quilt_loader"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid quilt.mod.json: %w", err)
+	}
+
+	info := &ModInfo{ID: raw.Loader.ID, Name: raw.Loader.Metadata.Name, Version: raw.Loader.Version, Loaders: []string{LoaderQuilt}}
+	for _, p := range raw.Loader.Provides {
+		switch v := p.(type) {
+		case string:
+			info.Provides = append(info.Provides, v)
+		case map[string]interface{}:
+			if id, ok := v["id"].(string); ok {
+				info.Provides = append(info.Provides, id)
+			}
+		}
+	}
+	addDeps := func(list []interface{}, kind string) {
+		for _, d := range list {
+			switch v := d.(type) {
+			case string:
+				info.Dependencies = append(info.Dependencies, Dependency{ID: v, Kind: kind})
+			case map[string]interface{}:
+				id, _ := v["id"].(string)
+				depKind := kind
+				if optional, _ := v["optional"].(bool); optional && kind == DependencyRequired {
+					depKind = DependencyOptional
+				}
+				info.Dependencies = append(info.Dependencies, Dependency{ID: id, Versions: constraintList(v["versions"]), Kind: depKind})
+			}
+		}
+	}
+	addDeps(raw.Loader.Depends, DependencyRequired)
+	addDeps(raw.Loader.Breaks, DependencyIncompatible)
+	return info, nil
+}
+
+// ------------------ Forge & NeoForge ------------------
+
+// tomlValue reads a TOML string, boolean or bare value.
+func tomlValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') {
+		if end := strings.IndexByte(raw[1:], raw[0]); end >= 0 {
+			return raw[1 : end+1]
+		}
+	}
+	if hash := strings.IndexByte(raw, '#'); hash >= 0 {
+		raw = raw[:hash]
+	}
+	return strings.TrimSpace(raw)
+}
+
+// parseModsToml reads the first mod and its dependencies from (neoforge.)mods.toml. Only the
+// flat keys and [[mods]] / [[dependencies.<id>]] tables used by mod metadata are understood.
+func parseModsToml(r io.Reader, loaders []string) (*ModInfo, error) {
+	info := &ModInfo{Loaders: loaders}
+	var dep *Dependency
+	table := ""
+	mods := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] ")
+			if dep != nil {
+				info.Dependencies = append(info.Dependencies, *dep)
+				dep = nil
+			}
+			if table == "mods" {
+				mods++
+			}
+			if strings.HasPrefix(table, "dependencies.") && strings.TrimPrefix(table, "dependencies.") == info.ID {
+				dep = &Dependency{Kind: DependencyRequired}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), tomlValue(value)
+		switch {
+		case table == "mods" && mods == 1:
+			switch key {
+			case "modId":
+				info.ID = value
+			case "version":
+				info.Version = value
+			case "displayName":
+				info.Name = value
+			}
+		case dep != nil:
+			switch key {
+			case "modId":
+				dep.ID = value
+			case "versionRange":
+				if value != "" && value != "*" {
+					dep.Versions = []string{value}
+				}
+			case "mandatory":
+				if value == "false" {
+					dep.Kind = DependencyOptional
+				}
+			case "type":
+				// NeoForge: required, optional, incompatible or discouraged
+				switch strings.ToLower(value) {
+				case "optional", "discouraged":
+					dep.Kind = DependencyOptional
+				case "incompatible":
+					dep.Kind = DependencyIncompatible
+				}
+			}
+		}
+	}
+	if dep != nil {
+		info.Dependencies = append(info.Dependencies, *dep)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid mods.toml: %w", err)
+	}
+	return info, nil
+}
+
+// manifestVersion returns the Implementation-Version of a JAR manifest, which Forge
+// substitutes for "${file.jarVersion}".
+func manifestVersion(zr *zip.Reader) string {
+	f, err := zr.Open("META-INF/MANIFEST.MF")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Implementation-Version:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// ------------------ Public API ------------------
+
+// readEntry returns the content of a file in the archive.
+func readEntry(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// ParseModInfo reads the metadata of an opened mod JAR: fabric.mod.json, quilt.mod.json,
+// META-INF/neoforge.mods.toml or META-INF/mods.toml, in that order.
+func ParseModInfo(zr *zip.Reader) (*ModInfo, error) {
+	if data, err := readEntry(zr, "fabric.mod.json"); err == nil {
+		return parseFabric(data)
+	}
+	if data, err := readEntry(zr, "quilt.mod.json"); err == nil {
+		return parseQuilt(data)
+	}
+
+	for _, toml := range []struct {
+		name    string
+		loaders []string
+	}{
+		{"META-INF/neoforge.mods.toml", []string{LoaderNeoForge}},
+		// NeoForge kept loading mods.toml until 1.20.4
+		{"META-INF/mods.toml", []string{LoaderForge, LoaderNeoForge}},
+	} {
+		f, err := zr.Open(toml.name)
+		if err != nil {
+			continue
+		}
+		info, err := parseModsToml(f, toml.loaders)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if info.Version == "${file.jarVersion}" {
+			info.Version = manifestVersion(zr)
+		}
+		return info, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+// ReadModInfo reads the metadata of the mod JAR at path.
+func ReadModInfo(path string) (*ModInfo, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mod %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	info, err := ParseModInfo(&zr.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod %s: %w", path, err)
+	}
+	info.File = path
+	return info, nil
+}
@@ -0,0 +1,138 @@
+package mods
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/apicache"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// ModrinthAPI is the base URL of the Modrinth API.
+const ModrinthAPI = "https://api.modrinth.com/v2"
+
+// ------------------ Structs ------------------
+
+// ModrinthFile is a downloadable file of a Modrinth version.
+type ModrinthFile struct {
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Hashes   map[string]string `json:"hashes"`
+	Primary  bool              `json:"primary"`
+	Size     int64             `json:"size"`
+}
+
+// ModrinthDependency is a dependency of a Modrinth version on a project or a specific version.
+type ModrinthDependency struct {
+	ProjectID string `json:"project_id"`
+	VersionID string `json:"version_id"`
+	// Type is DependencyRequired, DependencyOptional, DependencyIncompatible or "embedded".
+	Type string `json:"dependency_type"`
+}
+
+// ModrinthVersion is a published version of a Modrinth project.
+type ModrinthVersion struct {
+	ID            string               `json:"id"`
+	ProjectID     string               `json:"project_id"`
+	Name          string               `json:"name"`
+	VersionNumber string               `json:"version_number"`
+	GameVersions  []string             `json:"game_versions"`
+	Loaders       []string             `json:"loaders"`
+	Files         []ModrinthFile       `json:"files"`
+	Dependencies  []ModrinthDependency `json:"dependencies"`
+}
+
+// PrimaryFile returns the file to install: the one marked primary, or the first.
+func (v *ModrinthVersion) PrimaryFile() *ModrinthFile {
+	for i := range v.Files {
+		if v.Files[i].Primary {
+			return &v.Files[i]
+		}
+	}
+	if len(v.Files) == 0 {
+		return nil
+	}
+	return &v.Files[0]
+}
+
+// Supports reports whether the version declares support for loader and gameVersion; empty
+// arguments match anything.
+func (v *ModrinthVersion) Supports(loader, gameVersion string) bool {
+	return (loader == "" || contains(v.Loaders, loader)) && (gameVersion == "" || contains(v.GameVersions, gameVersion))
+}
+
+// contains reports whether list contains s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Modrinth is a client of the Modrinth API. Responses go through an apicache.Cache, so
+// resolving a pack again only revalidates what it already fetched.
+type Modrinth struct {
+	// BaseURL defaults to ModrinthAPI.
+	BaseURL string
+	Cache   *apicache.Cache
+}
+
+// NewModrinth creates a Modrinth client caching its responses in cache.
+func NewModrinth(cache *apicache.Cache) *Modrinth {
+	return &Modrinth{BaseURL: ModrinthAPI, Cache: cache}
+}
+
+// ------------------ Requests ------------------
+
+// get decodes the response of path (relative to BaseURL) into out.
+func (m *Modrinth) get(path string, out any, E *events.EventEmitter) error {
+	base := m.BaseURL
+	if base == "" {
+		base = ModrinthAPI
+	}
+	return m.Cache.GetJSON(base+path, out, E)
+}
+
+// Version fetches a version by ID.
+func (m *Modrinth) Version(id string, E *events.EventEmitter) (*ModrinthVersion, error) {
+	var version ModrinthVersion
+	if err := m.get("/version/"+url.PathEscape(id), &version, E); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ProjectVersions lists the versions of a project (ID or slug) for a loader and game version,
+// newest first. Empty filters are not applied.
+func (m *Modrinth) ProjectVersions(project, loader, gameVersion string, E *events.EventEmitter) ([]ModrinthVersion, error) {
+	query := url.Values{}
+	if loader != "" {
+		data, _ := json.Marshal([]string{loader})
+		query.Set("loaders", string(data))
+	}
+	if gameVersion != "" {
+		data, _ := json.Marshal([]string{gameVersion})
+		query.Set("game_versions", string(data))
+	}
+	path := "/project/" + url.PathEscape(project) + "/version"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var versions []ModrinthVersion
+	if err := m.get(path, &versions, E); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// VersionByHash finds the version a file belongs to by its SHA1, e.g. to identify installed mods.
+func (m *Modrinth) VersionByHash(sha1 string, E *events.EventEmitter) (*ModrinthVersion, error) {
+	var version ModrinthVersion
+	if err := m.get("/version_file/"+url.PathEscape(sha1)+"?algorithm=sha1", &version, E); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
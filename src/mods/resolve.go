@@ -0,0 +1,350 @@
+package mods
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Kinds of conflicts found while resolving dependencies.
+const (
+	// ConflictMissing is a required dependency nothing provides.
+	ConflictMissing = "missing"
+	// ConflictVersion is a dependency present in a version outside the required range, or two
+	// mods pinning different versions of the same project.
+	ConflictVersion = "version"
+	// ConflictIncompatible is a mod declared incompatible with another one that is present.
+	ConflictIncompatible = "incompatible"
+	// ConflictUnavailable is a project without a version for the loader and game version.
+	ConflictUnavailable = "unavailable"
+)
+
+// ------------------ Structs ------------------
+
+// Conflict is a dependency problem that would keep the game from starting.
+type Conflict struct {
+	Kind string `json:"kind"`
+	// Mod is the mod ID or Modrinth project the problem is about.
+	Mod string `json:"mod"`
+	// RequiredBy is the mod or project declaring the dependency; empty for requested projects.
+	RequiredBy string `json:"requiredBy,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// PlannedMod is a mod version a Plan would install.
+type PlannedMod struct {
+	ProjectID string           `json:"projectId"`
+	Version   *ModrinthVersion `json:"version"`
+	// RequiredBy lists the projects depending on it; empty when it was requested directly.
+	RequiredBy []string `json:"requiredBy,omitempty"`
+}
+
+// Plan is the result of resolving mods and their dependencies, to review before Download.
+type Plan struct {
+	Loader      string `json:"loader"`
+	GameVersion string `json:"gameVersion"`
+	// Mods are the versions to download, requested projects first.
+	Mods []*PlannedMod `json:"mods"`
+	// Satisfied are projects already installed in the mods directory.
+	Satisfied []string `json:"satisfied,omitempty"`
+	// Optional are optional dependencies left out of the plan.
+	Optional  []string   `json:"optional,omitempty"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// OK reports whether the plan can be installed without conflicts.
+func (p *Plan) OK() bool {
+	return len(p.Conflicts) == 0
+}
+
+// Resolver resolves Modrinth projects and their dependencies for one loader and game version.
+type Resolver struct {
+	Modrinth    *Modrinth
+	Loader      string
+	GameVersion string
+}
+
+// ------------------ Local Dependencies ------------------
+
+// provided returns the version of every mod ID (including provided aliases) among mods.
+func provided(mods []*ModInfo) map[string]string {
+	versions := map[string]string{}
+	for _, mod := range mods {
+		versions[mod.ID] = mod.Version
+		for _, alias := range mod.Provides {
+			versions[alias] = mod.Version
+		}
+	}
+	return versions
+}
+
+// CheckDependencies checks the declared dependencies of installed mods (see ReadModInfo)
+// against each other and against gameVersion (skipped when empty): missing required mods,
+// versions outside the declared ranges and mods declared incompatible.
+func CheckDependencies(mods []*ModInfo, gameVersion string) []Conflict {
+	versions := provided(mods)
+	var conflicts []Conflict
+	for _, mod := range mods {
+		for _, dep := range mod.Dependencies {
+			if dep.ID == GameID {
+				if gameVersion != "" && dep.Kind == DependencyRequired && !SatisfiesAny(gameVersion, dep.Versions) {
+					conflicts = append(conflicts, Conflict{Kind: ConflictVersion, Mod: GameID, RequiredBy: mod.ID,
+						Reason: fmt.Sprintf("%s requires Minecraft %s, not %s", mod.ID, strings.Join(dep.Versions, " or "), gameVersion)})
+				}
+				continue
+			}
+			if environmentIDs[dep.ID] {
+				continue
+			}
+
+			version, present := versions[dep.ID]
+			switch dep.Kind {
+			case DependencyRequired:
+				if !present {
+					conflicts = append(conflicts, Conflict{Kind: ConflictMissing, Mod: dep.ID, RequiredBy: mod.ID,
+						Reason: fmt.Sprintf("%s requires %s, which is not installed", mod.ID, dep.ID)})
+				} else if !SatisfiesAny(version, dep.Versions) {
+					conflicts = append(conflicts, Conflict{Kind: ConflictVersion, Mod: dep.ID, RequiredBy: mod.ID,
+						Reason: fmt.Sprintf("%s requires %s %s, found %s", mod.ID, dep.ID, strings.Join(dep.Versions, " or "), version)})
+				}
+			case DependencyIncompatible:
+				if present && (len(dep.Versions) == 0 || SatisfiesAny(version, dep.Versions)) {
+					conflicts = append(conflicts, Conflict{Kind: ConflictIncompatible, Mod: dep.ID, RequiredBy: mod.ID,
+						Reason: fmt.Sprintf("%s is incompatible with %s %s", mod.ID, dep.ID, version)})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// fileSHA1 returns the hex SHA1 of a file.
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// modJars lists the JARs of a mods directory; a missing directory has none.
+func modJars(modsDir string) []string {
+	jars, _ := filepath.Glob(filepath.Join(modsDir, "*.jar"))
+	sort.Strings(jars)
+	return jars
+}
+
+// ------------------ Resolution ------------------
+
+// pending is a project waiting to be resolved.
+type pending struct {
+	project, version, requiredBy string
+}
+
+// selectVersion picks the version of a project to install: the pinned one, or the newest
+// supporting the resolver's loader and game version.
+func (r *Resolver) selectVersion(p pending, E *events.EventEmitter) (*ModrinthVersion, error) {
+	if p.version != "" {
+		return r.Modrinth.Version(p.version, E)
+	}
+	versions, err := r.Modrinth.ProjectVersions(p.project, r.Loader, r.GameVersion, E)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if versions[i].Supports(r.Loader, r.GameVersion) {
+			return &versions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Resolve plans the installation of Modrinth projects (IDs or slugs) into modsDir with every
+// required dependency, transitively. Mods already in modsDir are identified by their hash and
+// count as installed; their own declared dependencies are checked too (see CheckDependencies),
+// except for missing ones the plan may provide. Nothing is downloaded: review the returned
+// plan and call Download.
+func (r *Resolver) Resolve(projects []string, modsDir string, E *events.EventEmitter) (*Plan, error) {
+	plan := &Plan{Loader: r.Loader, GameVersion: r.GameVersion}
+	E.Emit("mod_resolve_start", projects)
+
+	// Identify what is installed
+	installed := map[string]string{} // project ID -> version ID
+	var local []*ModInfo
+	// Unknown hashes are expected (local builds, other sites) and not worth error events
+	quiet := events.New()
+	for _, jar := range modJars(modsDir) {
+		if info, err := ReadModInfo(jar); err == nil {
+			local = append(local, info)
+		}
+		if sum, err := fileSHA1(jar); err == nil {
+			if version, err := r.Modrinth.VersionByHash(sum, quiet); err == nil {
+				installed[version.ProjectID] = version.ID
+			}
+		}
+	}
+	for _, conflict := range CheckDependencies(local, r.GameVersion) {
+		if conflict.Kind != ConflictMissing {
+			plan.Conflicts = append(plan.Conflicts, conflict)
+		}
+	}
+
+	selected := map[string]*PlannedMod{}
+	var incompatible []pending
+	optional := map[string]bool{}
+	queue := make([]pending, 0, len(projects))
+	for _, project := range projects {
+		queue = append(queue, pending{project: project})
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		// Dependencies may name a version only
+		if p.project == "" {
+			version, err := r.Modrinth.Version(p.version, E)
+			if err != nil {
+				return nil, err
+			}
+			p.project = version.ProjectID
+		}
+
+		if versionID, ok := installed[p.project]; ok {
+			if p.version != "" && p.version != versionID {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Kind: ConflictVersion, Mod: p.project, RequiredBy: p.requiredBy,
+					Reason: fmt.Sprintf("%s requires version %s of %s, but %s is installed", p.requiredBy, p.version, p.project, versionID)})
+			}
+			if !contains(plan.Satisfied, p.project) {
+				plan.Satisfied = append(plan.Satisfied, p.project)
+			}
+			continue
+		}
+		if mod, ok := selected[p.project]; ok {
+			if p.version != "" && p.version != mod.Version.ID {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Kind: ConflictVersion, Mod: p.project, RequiredBy: p.requiredBy,
+					Reason: fmt.Sprintf("%s requires version %s of %s, but %s is planned", p.requiredBy, p.version, p.project, mod.Version.VersionNumber)})
+			}
+			if p.requiredBy != "" && !contains(mod.RequiredBy, p.requiredBy) {
+				mod.RequiredBy = append(mod.RequiredBy, p.requiredBy)
+			}
+			continue
+		}
+
+		version, err := r.selectVersion(p, E)
+		if err != nil {
+			return nil, err
+		}
+		if version == nil || !version.Supports(r.Loader, r.GameVersion) || version.PrimaryFile() == nil {
+			plan.Conflicts = append(plan.Conflicts, Conflict{Kind: ConflictUnavailable, Mod: p.project, RequiredBy: p.requiredBy,
+				Reason: fmt.Sprintf("%s has no version for %s on Minecraft %s", p.project, r.Loader, r.GameVersion)})
+			continue
+		}
+
+		// Requests by slug are only matched to their project ID once its version is known
+		if _, ok := installed[version.ProjectID]; ok {
+			if !contains(plan.Satisfied, version.ProjectID) {
+				plan.Satisfied = append(plan.Satisfied, version.ProjectID)
+			}
+			continue
+		}
+		if mod, ok := selected[version.ProjectID]; ok {
+			selected[p.project] = mod
+			continue
+		}
+
+		mod := &PlannedMod{ProjectID: version.ProjectID, Version: version}
+		if p.requiredBy != "" {
+			mod.RequiredBy = []string{p.requiredBy}
+		}
+		selected[p.project] = mod
+		selected[version.ProjectID] = mod
+		plan.Mods = append(plan.Mods, mod)
+		E.Emit("mod_resolved", map[string]string{"project": version.ProjectID, "version": version.VersionNumber, "requiredBy": p.requiredBy})
+
+		for _, dep := range version.Dependencies {
+			next := pending{project: dep.ProjectID, version: dep.VersionID, requiredBy: version.ProjectID}
+			switch dep.Type {
+			case DependencyRequired:
+				queue = append(queue, next)
+			case DependencyIncompatible:
+				incompatible = append(incompatible, next)
+			case DependencyOptional:
+				if dep.ProjectID != "" {
+					optional[dep.ProjectID] = true
+				}
+			}
+		}
+	}
+
+	for _, p := range incompatible {
+		_, planned := selected[p.project]
+		_, present := installed[p.project]
+		if p.project != "" && (planned || present) {
+			plan.Conflicts = append(plan.Conflicts, Conflict{Kind: ConflictIncompatible, Mod: p.project, RequiredBy: p.requiredBy,
+				Reason: fmt.Sprintf("%s is incompatible with %s", p.requiredBy, p.project)})
+		}
+	}
+	for project := range optional {
+		if _, planned := selected[project]; !planned {
+			if _, present := installed[project]; !present {
+				plan.Optional = append(plan.Optional, project)
+			}
+		}
+	}
+	sort.Strings(plan.Optional)
+
+	E.Emit("mod_resolve_done", map[string]int{"mods": len(plan.Mods), "conflicts": len(plan.Conflicts)})
+	return plan, nil
+}
+
+// Download downloads every planned mod into modsDir, verifying the SHA1 published by Modrinth
+// and running the registered screeners (see RegisterScreener) before a JAR lands in modsDir.
+// It refuses plans with conflicts.
+func (p *Plan) Download(modsDir string, E *events.EventEmitter) error {
+	if !p.OK() {
+		err := fmt.Errorf("cannot install mods: %d unresolved conflicts", len(p.Conflicts))
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	for _, mod := range p.Mods {
+		file := mod.Version.PrimaryFile()
+		if filepath.Base(file.Filename) != file.Filename {
+			err := fmt.Errorf("invalid file name %q for %s", file.Filename, mod.ProjectID)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return err
+		}
+		path := filepath.Join(modsDir, file.Filename)
+		if _, err := os.Stat(path); err == nil {
+			E.Emit("file_exists", path)
+			continue
+		}
+		// Download beside the target under a name loaders ignore until it has been screened
+		staged := path + ".download"
+		os.Remove(staged)
+		if err := downloader.DownloadMultiSource(staged, []string{file.URL}, file.Hashes["sha1"], E); err != nil {
+			return err
+		}
+		if err := ScreenAndInstall(staged, path, file.Filename, file.URL, E); err != nil {
+			return err
+		}
+		E.Emit("mod_installed", map[string]string{"project": mod.ProjectID, "version": mod.Version.VersionNumber, "path": path})
+	}
+	return nil
+}
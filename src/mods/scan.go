@@ -0,0 +1,146 @@
+package mods
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Kinds of problems found by Scan.
+const (
+	// FindingCorrupt is a JAR that is not a readable zip, or has entries failing their CRC.
+	FindingCorrupt = "corrupt"
+	// FindingNoMetadata is a JAR without mod metadata, or with metadata that does not parse;
+	// loaders either skip or refuse it.
+	FindingNoMetadata = "no_metadata"
+	// FindingDuplicate is a mod ID declared by several JARs, which every loader refuses to start with.
+	FindingDuplicate = "duplicate"
+)
+
+// ------------------ Structs ------------------
+
+// Finding is a problem with the JARs of a mods directory.
+type Finding struct {
+	Kind string `json:"kind"`
+	// Files are the JARs involved: one, or every duplicate.
+	Files []string `json:"files"`
+	// ModID is the duplicated mod ID.
+	ModID  string `json:"modId,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ScanReport is the result of Scan.
+type ScanReport struct {
+	// Mods are the JARs whose metadata could be read.
+	Mods     []*ModInfo `json:"mods"`
+	Findings []Finding  `json:"findings,omitempty"`
+}
+
+// OK reports whether no problem was found.
+func (r *ScanReport) OK() bool {
+	return len(r.Findings) == 0
+}
+
+// ------------------ Helpers ------------------
+
+// checkArchive reads every entry of a JAR, so truncated files and CRC mismatches surface.
+func checkArchive(zr *zip.Reader) error {
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// scanJar checks one JAR and reads its metadata.
+func scanJar(path string) (*ModInfo, *Finding) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, &Finding{Kind: FindingCorrupt, Files: []string{path}, Reason: fmt.Sprintf("not a readable zip: %v", err)}
+	}
+	defer zr.Close()
+
+	if err := checkArchive(&zr.Reader); err != nil {
+		return nil, &Finding{Kind: FindingCorrupt, Files: []string{path}, Reason: fmt.Sprintf("damaged entry %v", err)}
+	}
+	info, err := ParseModInfo(&zr.Reader)
+	if errors.Is(err, ErrNoMetadata) {
+		return nil, &Finding{Kind: FindingNoMetadata, Files: []string{path}, Reason: "no fabric.mod.json, quilt.mod.json or mods.toml"}
+	}
+	if err != nil || info.ID == "" {
+		reason := "metadata declares no mod ID"
+		if err != nil {
+			reason = err.Error()
+		}
+		return nil, &Finding{Kind: FindingNoMetadata, Files: []string{path}, Reason: reason}
+	}
+	info.File = path
+	return info, nil
+}
+
+// ------------------ Public API ------------------
+
+// Scan checks every JAR of a mods directory: that it is a readable zip whose entries pass
+// their CRC, that it declares mod metadata, and that no mod ID is declared by two JARs (the
+// usual leftover of updating a mod by adding its new JAR). Disabled mods (*.jar.disabled) are
+// not scanned. A missing directory gives an empty report.
+func Scan(modsDir string, E *events.EventEmitter) (*ScanReport, error) {
+	if _, err := os.Stat(modsDir); err != nil && !os.IsNotExist(err) {
+		err = fmt.Errorf("failed to scan mods in %s: %w", modsDir, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	report := &ScanReport{}
+	byID := map[string][]string{}
+	jars := modJars(modsDir)
+	E.Emit("mod_scan_start", map[string]any{"dir": modsDir, "total": len(jars)})
+
+	for i, jar := range jars {
+		info, finding := scanJar(jar)
+		if finding != nil {
+			report.Findings = append(report.Findings, *finding)
+			E.Emit("mod_scan_finding", *finding)
+		} else {
+			report.Mods = append(report.Mods, info)
+			byID[info.ID] = append(byID[info.ID], jar)
+		}
+		E.Emit("mod_scan_progress", map[string]any{"file": filepath.Base(jar), "done": i + 1, "total": len(jars)})
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		files := byID[id]
+		if len(files) < 2 {
+			continue
+		}
+		finding := Finding{Kind: FindingDuplicate, Files: files, ModID: id,
+			Reason: fmt.Sprintf("mod %s is declared by %d files; keep one", id, len(files))}
+		report.Findings = append(report.Findings, finding)
+		E.Emit("mod_scan_finding", finding)
+	}
+
+	E.Emit("mod_scan_done", map[string]int{"mods": len(report.Mods), "findings": len(report.Findings)})
+	return report, nil
+}
@@ -0,0 +1,205 @@
+package mods
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// ------------------ Structs ------------------
+
+// ScreenedFile is a file about to be written into a mods folder (or an instance, for modpack
+// files), as handed to a Screener.
+type ScreenedFile struct {
+	// Name is the file name, or the path relative to the instance for modpack files.
+	Name string `json:"name"`
+	// Path is where the content currently is; it is not loadable by the game yet.
+	Path string `json:"path"`
+	// Source is the URL or local file the content came from.
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+	// Hex digests of the content, lowercase.
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+}
+
+// Screener inspects files before they are installed, e.g. against the hash lists published
+// for known mod malware such as fractureiser. Returning an error refuses the file; return a
+// *BlockedError to say why. Implementations must be safe for concurrent use.
+type Screener interface {
+	Screen(file *ScreenedFile) error
+}
+
+// ScreenerFunc adapts a function to the Screener interface.
+type ScreenerFunc func(file *ScreenedFile) error
+
+// Screen calls f.
+func (f ScreenerFunc) Screen(file *ScreenedFile) error {
+	return f(file)
+}
+
+// BlockedError is returned when a Screener refuses a file.
+type BlockedError struct {
+	File string
+	// Hash is the matched digest, if the file was refused for its hash.
+	Hash   string
+	Reason string
+}
+
+// Error describes the refused file and why.
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("refused to install %s: %s", e.File, e.Reason)
+}
+
+// ------------------ Registry ------------------
+
+var (
+	screeners  []Screener
+	screenerMu sync.RWMutex
+)
+
+// RegisterScreener adds a Screener run on every file AddMod, Plan.Download and modpack installs
+// are about to write. Screeners run in registration order; the first refusal wins.
+func RegisterScreener(s Screener) {
+	screenerMu.Lock()
+	defer screenerMu.Unlock()
+	screeners = append(screeners, s)
+}
+
+// ResetScreeners removes every registered Screener.
+func ResetScreeners() {
+	screenerMu.Lock()
+	defer screenerMu.Unlock()
+	screeners = nil
+}
+
+// registered returns a snapshot of the registered screeners.
+func registered() []Screener {
+	screenerMu.RLock()
+	defer screenerMu.RUnlock()
+	return append([]Screener(nil), screeners...)
+}
+
+// ------------------ Hash Blocklist ------------------
+
+// HashBlocklist is a Screener refusing files by SHA1, SHA256 or SHA512 digest.
+type HashBlocklist struct {
+	mu     sync.RWMutex
+	hashes map[string]string // digest -> reason
+}
+
+// NewHashBlocklist creates an empty blocklist.
+func NewHashBlocklist() *HashBlocklist {
+	return &HashBlocklist{hashes: map[string]string{}}
+}
+
+// Add blocks a hex digest with the reason reported when it matches.
+func (b *HashBlocklist) Add(hash, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hashes[strings.ToLower(strings.TrimSpace(hash))] = reason
+}
+
+// Load reads one hex digest per line, the format IOC lists are usually published in. Text after
+// the digest and lines starting with "#" are ignored. It returns how many digests were added.
+func (b *HashBlocklist) Load(r io.Reader, reason string) (int, error) {
+	added := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil {
+			continue
+		}
+		b.Add(fields[0], reason)
+		added++
+	}
+	return added, scanner.Err()
+}
+
+// Screen refuses the file when one of its digests is blocked.
+func (b *HashBlocklist) Screen(file *ScreenedFile) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, hash := range []string{file.SHA1, file.SHA256, file.SHA512} {
+		if reason, ok := b.hashes[hash]; ok && hash != "" {
+			return &BlockedError{File: file.Name, Hash: hash, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// ------------------ Screening ------------------
+
+// hashFile fills in the size and digests of a ScreenedFile in one pass.
+func hashFile(file *ScreenedFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h1, h256, h512 := sha1.New(), sha256.New(), sha512.New()
+	size, err := io.Copy(io.MultiWriter(h1, h256, h512), f)
+	if err != nil {
+		return err
+	}
+	file.Size = size
+	file.SHA1 = hex.EncodeToString(h1.Sum(nil))
+	file.SHA256 = hex.EncodeToString(h256.Sum(nil))
+	file.SHA512 = hex.EncodeToString(h512.Sum(nil))
+	return nil
+}
+
+// ScreenFile runs the registered screeners on the file at path, which is about to be installed
+// as name. Installers call it before moving the file into place; a refusal is emitted as
+// mod_blocked and returned. Without screeners the file is not even read.
+func ScreenFile(path, name, source string, E *events.EventEmitter) error {
+	list := registered()
+	if len(list) == 0 {
+		return nil
+	}
+
+	file := &ScreenedFile{Name: name, Path: path, Source: source}
+	if err := hashFile(file); err != nil {
+		err = fmt.Errorf("failed to screen %s: %w", name, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	for _, s := range list {
+		if err := s.Screen(file); err != nil {
+			E.Emit("mod_blocked", map[string]string{"file": name, "source": source, "sha1": file.SHA1, "reason": err.Error()})
+			return err
+		}
+	}
+	E.Emit("mod_screened", name)
+	return nil
+}
+
+// ScreenAndInstall screens a staged file and renames it to dest, or removes it when refused.
+func ScreenAndInstall(staged, dest, name, source string, E *events.EventEmitter) error {
+	if err := ScreenFile(staged, name, source, E); err != nil {
+		os.Remove(staged)
+		return err
+	}
+	if err := os.Rename(staged, dest); err != nil {
+		os.Remove(staged)
+		err = fmt.Errorf("failed to install %s: %w", name, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package mods
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ------------------ Version Comparison ------------------
+
+// splitVersion splits a version into its release parts and its pre-release label:
+// "1.20.1-beta.2+build" gives ["1","20","1"] and "beta.2".
+func splitVersion(v string) ([]string, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if plus := strings.IndexByte(v, '+'); plus >= 0 {
+		v = v[:plus]
+	}
+	pre := ""
+	if dash := strings.IndexByte(v, '-'); dash >= 0 {
+		v, pre = v[:dash], v[dash+1:]
+	}
+	return strings.Split(v, "."), pre
+}
+
+// comparePart compares two version parts, numerically when both are numbers.
+func comparePart(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return 1 // numbers sort after labels, so 1.0 > 1.0.rc
+	case errB == nil:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts returns -1, 0 or 1.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareParts compares dotted part lists, missing parts counting as 0.
+func compareParts(a, b []string) int {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		pa, pb := "0", "0"
+		if i < len(a) {
+			pa = a[i]
+		}
+		if i < len(b) {
+			pb = b[i]
+		}
+		if c := comparePart(pa, pb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// CompareVersions compares two mod versions the way Fabric Loader and Maven order them,
+// returning -1, 0 or 1. Numeric parts compare as numbers, missing parts count as 0, a
+// pre-release ("1.0-beta") sorts before its release and build metadata ("+mc1.20") is ignored.
+func CompareVersions(a, b string) int {
+	partsA, preA := splitVersion(a)
+	partsB, preB := splitVersion(b)
+	if c := compareParts(partsA, partsB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareParts(strings.Split(preA, "."), strings.Split(preB, "."))
+}
+
+// ------------------ Constraints ------------------
+
+// matchesWildcard reports whether version matches a pattern with "x" or "*" parts, e.g. "1.20.x".
+func matchesWildcard(version, pattern string) bool {
+	parts, _ := splitVersion(version)
+	want, _ := splitVersion(pattern)
+	for i, w := range want {
+		if w == "x" || w == "X" || w == "*" {
+			return true
+		}
+		if i >= len(parts) || comparePart(parts[i], w) != 0 {
+			return false
+		}
+	}
+	return len(parts) == len(want)
+}
+
+// bump returns the smallest version above every version sharing the first n parts of v.
+func bump(v string, n int) string {
+	parts, _ := splitVersion(v)
+	for len(parts) < n {
+		parts = append(parts, "0")
+	}
+	num, _ := strconv.Atoi(parts[n-1])
+	parts = append(parts[:n-1:n-1], strconv.Itoa(num+1))
+	return strings.Join(parts, ".")
+}
+
+// matchesPredicate reports whether version satisfies one Fabric version predicate.
+func matchesPredicate(version, predicate string) bool {
+	switch {
+	case predicate == "" || predicate == "*":
+		return true
+	case strings.HasPrefix(predicate, ">="):
+		return CompareVersions(version, predicate[2:]) >= 0
+	case strings.HasPrefix(predicate, "<="):
+		return CompareVersions(version, predicate[2:]) <= 0
+	case strings.HasPrefix(predicate, ">"):
+		return CompareVersions(version, predicate[1:]) > 0
+	case strings.HasPrefix(predicate, "<"):
+		return CompareVersions(version, predicate[1:]) < 0
+	case strings.HasPrefix(predicate, "="):
+		return CompareVersions(version, predicate[1:]) == 0
+	case strings.HasPrefix(predicate, "~"):
+		// Same major and minor version
+		return CompareVersions(version, predicate[1:]) >= 0 && CompareVersions(version, bump(predicate[1:], 2)) < 0
+	case strings.HasPrefix(predicate, "^"):
+		// Same major version
+		return CompareVersions(version, predicate[1:]) >= 0 && CompareVersions(version, bump(predicate[1:], 1)) < 0
+	case strings.ContainsAny(predicate, "xX*"):
+		return matchesWildcard(version, predicate)
+	}
+	return CompareVersions(version, predicate) == 0
+}
+
+// matchesMavenRange reports whether version lies in a Maven range such as "[1.0,2.0)",
+// "[47,)" or "[1.0]"; comma-joined ranges ("[1,2),[3,4)") match any of them.
+func matchesMavenRange(version, ranges string) bool {
+	for ranges != "" {
+		end := strings.IndexAny(ranges, ")]")
+		if end < 0 {
+			return false
+		}
+		r := ranges[:end+1]
+		ranges = strings.TrimLeft(ranges[end+1:], ", ")
+
+		lowInclusive, highInclusive := r[0] == '[', r[len(r)-1] == ']'
+		bounds := strings.SplitN(r[1:len(r)-1], ",", 2)
+		low := strings.TrimSpace(bounds[0])
+		if len(bounds) == 1 {
+			// "[1.0]" pins a single version
+			if CompareVersions(version, low) == 0 {
+				return true
+			}
+			continue
+		}
+		high := strings.TrimSpace(bounds[1])
+
+		ok := true
+		if low != "" {
+			c := CompareVersions(version, low)
+			ok = c > 0 || (c == 0 && lowInclusive)
+		}
+		if ok && high != "" {
+			c := CompareVersions(version, high)
+			ok = c < 0 || (c == 0 && highInclusive)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Satisfies reports whether version satisfies a constraint: a Maven range ("[1.0,2.0)") as used
+// by Forge, or space-separated Fabric predicates (">=0.14 <0.16", "~1.2", "^3.0", "1.20.x",
+// "*") that must all match. An empty constraint matches every version.
+func Satisfies(version, constraint string) bool {
+	constraint = strings.TrimSpace(constraint)
+	if strings.HasPrefix(constraint, "[") || strings.HasPrefix(constraint, "(") {
+		return matchesMavenRange(version, constraint)
+	}
+	for _, predicate := range strings.Fields(constraint) {
+		if !matchesPredicate(version, predicate) {
+			return false
+		}
+	}
+	return true
+}
+
+// SatisfiesAny reports whether version satisfies one of the constraints, as a Fabric
+// dependency listing several alternatives does. No constraints match every version.
+func SatisfiesAny(version string, constraints []string) bool {
+	if len(constraints) == 0 {
+		return true
+	}
+	for _, c := range constraints {
+		if Satisfies(version, c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package mojang
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Endpoints ------------------
+
+const (
+	profileByNameURL   = "https://api.mojang.com/users/profiles/minecraft/"
+	profilesByNamesURL = "https://api.minecraftservices.com/minecraft/profile/lookup/bulk/byname"
+	sessionProfileURL  = "https://sessionserver.mojang.com/session/minecraft/profile/"
+
+	// maxNamesPerRequest is the largest batch accepted by the bulk name lookup.
+	maxNamesPerRequest = 10
+)
+
+// ErrNotFound is returned when no profile exists for a name or UUID.
+var ErrNotFound error = i18n.New("mojang.profile_not_found", "profile not found")
+
+// ErrRateLimited is returned when the API kept answering 429 after every retry.
+var ErrRateLimited error = i18n.New("mojang.rate_limited", "rate limited by the Mojang API")
+
+// ------------------ Structs ------------------
+
+// Profile identifies a Minecraft account by UUID (without dashes) and current name.
+type Profile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Textures are the skin and cape of a profile. Empty URLs mean the default skin or no cape.
+type Textures struct {
+	SkinURL string
+	// Slim is set for skins using the slim ("Alex") model.
+	Slim    bool
+	CapeURL string
+}
+
+// SessionProfile is a profile with its textures, as served by the session server.
+type SessionProfile struct {
+	Profile
+	Textures Textures
+}
+
+// cacheEntry is a cached response body.
+type cacheEntry struct {
+	status  int
+	body    []byte
+	expires time.Time
+}
+
+// Client centralizes Mojang API calls so every feature shares one cache and one rate limit.
+// Responses are cached for CacheTTL, requests are spaced by at least MinInterval, and 429
+// responses are retried after the Retry-After delay or an exponential backoff.
+type Client struct {
+	// HTTP performs the requests; nil uses utils.HTTPClient.
+	HTTP *http.Client
+	// CacheTTL is how long successful and not-found responses are reused.
+	CacheTTL time.Duration
+	// MinInterval is the minimum time between two requests.
+	MinInterval time.Duration
+	// MaxRetries bounds how often a rate limited request is retried.
+	MaxRetries int
+	// E receives rate_limited events; it may be nil.
+	E *events.EventEmitter
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	next  time.Time
+}
+
+// Default is the shared client used by the package level functions.
+var Default = NewClient(nil)
+
+// NewClient creates a client with conservative defaults: a 5 minute cache, 200ms between
+// requests and up to 3 retries on 429.
+func NewClient(E *events.EventEmitter) *Client {
+	return &Client{
+		CacheTTL:    5 * time.Minute,
+		MinInterval: 200 * time.Millisecond,
+		MaxRetries:  3,
+		E:           E,
+		cache:       make(map[string]cacheEntry),
+	}
+}
+
+// ------------------ Requests ------------------
+
+// wait blocks until the next request may be sent and reserves the slot after it.
+func (c *Client) wait() {
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next
+	if start.Before(now) {
+		start = now
+	}
+	c.next = start.Add(c.MinInterval)
+	c.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
+// backoff pushes the next allowed request time back by d, for every caller.
+func (c *Client) backoff(d time.Duration) {
+	c.mu.Lock()
+	if until := time.Now().Add(d); until.After(c.next) {
+		c.next = until
+	}
+	c.mu.Unlock()
+}
+
+// do sends a request, honouring the rate limit, retrying on 429 and caching the response.
+// Only 200, 204 and 404 responses are cached.
+func (c *Client) do(method, url string, body []byte) (int, []byte, error) {
+	key := method + " " + url + " " + string(body)
+
+	c.mu.Lock()
+	if entry, ok := c.cache[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.status, entry.body, nil
+	}
+	c.mu.Unlock()
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = utils.HTTPClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.wait()
+
+		// Rate limits are handled below, with the client's shared spacing
+		req, err := http.NewRequestWithContext(utils.WithoutRetry(context.Background()), method, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("mojang request failed: %w", err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read mojang response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if attempt >= c.MaxRetries {
+				return resp.StatusCode, nil, ErrRateLimited
+			}
+			delay := utils.RetryAfter(resp, attempt)
+			c.backoff(delay)
+			if c.E != nil {
+				c.E.Emit("rate_limited", map[string]interface{}{"url": url, "retryIn": delay.String()})
+			}
+			continue
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+			c.mu.Lock()
+			c.cache[key] = cacheEntry{status: resp.StatusCode, body: data, expires: time.Now().Add(c.CacheTTL)}
+			c.mu.Unlock()
+		}
+		return resp.StatusCode, data, nil
+	}
+}
+
+// ClearCache drops every cached response.
+func (c *Client) ClearCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = make(map[string]cacheEntry)
+}
+
+// ------------------ Public API ------------------
+
+// ProfileByName looks up the profile currently using name.
+func (c *Client) ProfileByName(name string) (*Profile, error) {
+	status, data, err := c.do(http.MethodGet, profileByNameURL+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	default:
+		return nil, fmt.Errorf("profile lookup for %s failed with status %d", name, status)
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// ProfilesByNames looks up many names with the bulk endpoint, in batches of 10.
+// Names without a profile are missing from the result, which is keyed by lowercase name.
+func (c *Client) ProfilesByNames(names []string) (map[string]Profile, error) {
+	profiles := make(map[string]Profile, len(names))
+	for start := 0; start < len(names); start += maxNamesPerRequest {
+		end := start + maxNamesPerRequest
+		if end > len(names) {
+			end = len(names)
+		}
+
+		body, _ := json.Marshal(names[start:end])
+		status, data, err := c.do(http.MethodPost, profilesByNamesURL, body)
+		if err != nil {
+			return profiles, err
+		}
+		if status != http.StatusOK {
+			return profiles, fmt.Errorf("bulk profile lookup failed with status %d", status)
+		}
+
+		var batch []Profile
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return profiles, fmt.Errorf("failed to parse profiles: %w", err)
+		}
+		for _, profile := range batch {
+			profiles[strings.ToLower(profile.Name)] = profile
+		}
+	}
+	return profiles, nil
+}
+
+// SessionProfile fetches a profile and its skin and cape by UUID (with or without dashes).
+func (c *Client) SessionProfile(uuid string) (*SessionProfile, error) {
+	uuid = strings.ReplaceAll(uuid, "-", "")
+	status, data, err := c.do(http.MethodGet, sessionProfileURL+uuid, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uuid)
+	default:
+		return nil, fmt.Errorf("session profile lookup for %s failed with status %d", uuid, status)
+	}
+
+	var raw struct {
+		Profile
+		Properties []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse session profile: %w", err)
+	}
+
+	profile := &SessionProfile{Profile: raw.Profile}
+	for _, property := range raw.Properties {
+		if property.Name != "textures" {
+			continue
+		}
+		textures, err := decodeTextures(property.Value)
+		if err != nil {
+			return nil, err
+		}
+		profile.Textures = textures
+	}
+	return profile, nil
+}
+
+// decodeTextures decodes the base64 "textures" property of a session profile.
+func decodeTextures(value string) (Textures, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return Textures{}, fmt.Errorf("failed to decode textures: %w", err)
+	}
+
+	var payload struct {
+		Textures struct {
+			Skin struct {
+				URL      string `json:"url"`
+				Metadata struct {
+					Model string `json:"model"`
+				} `json:"metadata"`
+			} `json:"SKIN"`
+			Cape struct {
+				URL string `json:"url"`
+			} `json:"CAPE"`
+		} `json:"textures"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return Textures{}, fmt.Errorf("failed to parse textures: %w", err)
+	}
+
+	return Textures{
+		SkinURL: payload.Textures.Skin.URL,
+		Slim:    payload.Textures.Skin.Metadata.Model == "slim",
+		CapeURL: payload.Textures.Cape.URL,
+	}, nil
+}
+
+// ProfileByName looks up a profile by name with the Default client.
+func ProfileByName(name string) (*Profile, error) {
+	return Default.ProfileByName(name)
+}
+
+// ProfilesByNames looks up many profiles by name with the Default client.
+func ProfilesByNames(names []string) (map[string]Profile, error) {
+	return Default.ProfilesByNames(names)
+}
+
+// GetSessionProfile fetches a profile with its textures with the Default client.
+func GetSessionProfile(uuid string) (*SessionProfile, error) {
+	return Default.SessionProfile(uuid)
+}
@@ -0,0 +1,405 @@
+package multiplayer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+const (
+	// DefaultPort is the port servers listen on when an address names none.
+	DefaultPort = 25565
+	// DefaultPingTimeout bounds a whole ping when PingOptions.Timeout is zero.
+	DefaultPingTimeout = 5 * time.Second
+	// StatusProtocol is the protocol version sent in the handshake when PingOptions.Protocol
+	// is zero. Servers answer status requests for any version; -1 marks the client version
+	// as unknown, as status-only clients do.
+	StatusProtocol = -1
+
+	// maxStatusPacket bounds a status packet, which carries the MOTD and the favicon.
+	maxStatusPacket = 2 << 20
+)
+
+// ------------------ Structs ------------------
+
+// PingOptions tunes Ping.
+type PingOptions struct {
+	// Timeout bounds the whole exchange; zero uses DefaultPingTimeout.
+	Timeout time.Duration
+	// Protocol is sent in the handshake; zero uses StatusProtocol.
+	Protocol int
+	// NoLegacyFallback disables retrying with PingLegacy when a server does not answer the
+	// Server List Ping of 1.7 and later.
+	NoLegacyFallback bool
+}
+
+// PlayerSample is one of the online players a server lists.
+type PlayerSample struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// ServerStatus is what a server reports in the multiplayer server list.
+type ServerStatus struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Online int            `json:"online"`
+		Max    int            `json:"max"`
+		Sample []PlayerSample `json:"sample"`
+	} `json:"players"`
+	// Description is the MOTD as sent: a string or a chat component.
+	Description json.RawMessage `json:"description"`
+	// Favicon is a data:image/png;base64 URI, or empty.
+	Favicon string `json:"favicon"`
+
+	// MOTD is the plain text of Description without formatting codes.
+	MOTD string `json:"-"`
+	// Latency is the round trip of the ping packet, or of the whole legacy exchange.
+	Latency time.Duration `json:"-"`
+	// Legacy is set when the status came from PingLegacy.
+	Legacy bool `json:"-"`
+}
+
+// ------------------ Addresses ------------------
+
+// resolveAddress returns the host and port to connect to for a server address as typed in
+// the server list: "host", "host:port" or "[ipv6]:port". Addresses without a port are looked
+// up as a _minecraft._tcp SRV record first.
+func resolveAddress(address string) (string, int, error) {
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		// No port given
+		host = strings.Trim(address, "[]")
+		if host == "" {
+			return "", 0, fmt.Errorf("invalid server address %q", address)
+		}
+		if _, records, err := net.LookupSRV("minecraft", "tcp", host); err == nil && len(records) > 0 {
+			return strings.TrimSuffix(records[0].Target, "."), int(records[0].Port), nil
+		}
+		return host, DefaultPort, nil
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in server address %q", address)
+	}
+	return host, port, nil
+}
+
+// dial connects to host:port with the deadline applied to the connection.
+func dial(host string, port int, deadline time.Time) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// ------------------ Packets ------------------
+
+// appendVarInt appends v in the protocol's variable-length encoding.
+func appendVarInt(b []byte, v int32) []byte {
+	u := uint32(v)
+	for u >= 0x80 {
+		b = append(b, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(b, byte(u))
+}
+
+// readVarInt reads a value in the protocol's variable-length encoding.
+func readVarInt(r io.ByteReader) (int32, error) {
+	var u uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		u |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return int32(u), nil
+		}
+	}
+	return 0, errors.New("varint too long")
+}
+
+// appendString appends a length-prefixed UTF-8 string.
+func appendString(b []byte, s string) []byte {
+	return append(appendVarInt(b, int32(len(s))), s...)
+}
+
+// writePacket writes a packet with its length prefix.
+func writePacket(w io.Writer, id int32, payload []byte) error {
+	body := append(appendVarInt(nil, id), payload...)
+	_, err := w.Write(append(appendVarInt(nil, int32(len(body))), body...))
+	return err
+}
+
+// readPacket reads a packet and returns its id and payload.
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxStatusPacket {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	payload := bytes.NewReader(data)
+	id, err := readVarInt(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, data[len(data)-payload.Len():], nil
+}
+
+// ------------------ MOTD ------------------
+
+// stripFormatting removes § formatting codes from legacy text.
+func stripFormatting(s string) string {
+	var b strings.Builder
+	skip := false
+	for _, r := range s {
+		switch {
+		case skip:
+			skip = false
+		case r == '§':
+			skip = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// plainText flattens a chat component (a string, a component object or an array of them)
+// into its text.
+func plainText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		var b strings.Builder
+		for _, item := range list {
+			b.WriteString(plainText(item))
+		}
+		return b.String()
+	}
+	var component struct {
+		Text  string            `json:"text"`
+		Extra []json.RawMessage `json:"extra"`
+	}
+	if json.Unmarshal(raw, &component) != nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(component.Text)
+	for _, item := range component.Extra {
+		b.WriteString(plainText(item))
+	}
+	return b.String()
+}
+
+// ------------------ Ping ------------------
+
+// pingModern performs the Server List Ping of 1.7 and later.
+func pingModern(host string, port int, opts PingOptions, deadline time.Time) (*ServerStatus, error) {
+	conn, err := dial(host, port, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	protocol := opts.Protocol
+	if protocol == 0 {
+		protocol = StatusProtocol
+	}
+	handshake := appendVarInt(nil, int32(protocol))
+	handshake = appendString(handshake, host)
+	handshake = binary.BigEndian.AppendUint16(handshake, uint16(port))
+	handshake = appendVarInt(handshake, 1) // next state: status
+	if err := writePacket(conn, 0x00, handshake); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, 0x00, nil); err != nil {
+		return nil, err
+	}
+
+	id, payload, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x00 {
+		return nil, fmt.Errorf("unexpected status packet 0x%02x", id)
+	}
+	body := bytes.NewReader(payload)
+	length, err := readVarInt(body)
+	if err != nil || int(length) > body.Len() || length < 0 {
+		return nil, errors.New("malformed status response")
+	}
+	var status ServerStatus
+	if err := json.Unmarshal(payload[len(payload)-body.Len():][:length], &status); err != nil {
+		return nil, fmt.Errorf("invalid status JSON: %w", err)
+	}
+	status.MOTD = stripFormatting(plainText(status.Description))
+
+	// Latency is the round trip of the ping packet, which the server echoes
+	start := time.Now()
+	token := start.UnixNano()
+	if err := writePacket(conn, 0x01, binary.BigEndian.AppendUint64(nil, uint64(token))); err != nil {
+		return &status, nil
+	}
+	if id, payload, err := readPacket(r); err == nil && id == 0x01 && len(payload) == 8 {
+		status.Latency = time.Since(start)
+	}
+	return &status, nil
+}
+
+// pingLegacy performs the ping of versions before 1.7.
+func pingLegacy(host string, port int, deadline time.Time) (*ServerStatus, error) {
+	conn, err := dial(host, port, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	utf16be := func(s string) []byte {
+		var b []byte
+		for _, c := range utf16.Encode([]rune(s)) {
+			b = binary.BigEndian.AppendUint16(b, c)
+		}
+		return b
+	}
+	// 1.6 servers read the MC|PingHost plugin message; older ones stop after 0xFE 0x01
+	request := []byte{0xFE, 0x01, 0xFA}
+	request = binary.BigEndian.AppendUint16(request, uint16(len("MC|PingHost")))
+	request = append(request, utf16be("MC|PingHost")...)
+	hostData := utf16be(host)
+	request = binary.BigEndian.AppendUint16(request, uint16(7+len(hostData)))
+	request = append(request, 74) // protocol of 1.6.2
+	request = binary.BigEndian.AppendUint16(request, uint16(len(hostData)/2))
+	request = append(request, hostData...)
+	request = binary.BigEndian.AppendUint32(request, uint32(port))
+
+	start := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0xFF {
+		return nil, fmt.Errorf("unexpected legacy response 0x%02x", header[0])
+	}
+	data := make([]byte, 2*int(binary.BigEndian.Uint16(header[1:])))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	text := string(utf16.Decode(units))
+
+	status := &ServerStatus{Latency: latency, Legacy: true}
+	var motd string
+	if fields := strings.Split(text, "\x00"); len(fields) == 6 && fields[0] == "§1" {
+		// 1.4 to 1.6: §1, protocol, version, MOTD, online, max
+		status.Version.Protocol, _ = strconv.Atoi(fields[1])
+		status.Version.Name = fields[2]
+		motd = fields[3]
+		status.Players.Online, _ = strconv.Atoi(fields[4])
+		status.Players.Max, _ = strconv.Atoi(fields[5])
+	} else {
+		// Beta 1.8 to 1.3: MOTD§online§max
+		fields := strings.Split(text, "§")
+		if len(fields) < 3 {
+			return nil, errors.New("malformed legacy status response")
+		}
+		n := len(fields)
+		motd = strings.Join(fields[:n-2], "§")
+		status.Players.Online, _ = strconv.Atoi(fields[n-2])
+		status.Players.Max, _ = strconv.Atoi(fields[n-1])
+	}
+	status.Description, _ = json.Marshal(motd)
+	status.MOTD = stripFormatting(motd)
+	return status, nil
+}
+
+// ------------------ Public API ------------------
+
+// Ping queries the status a server shows in the multiplayer server list: version, player
+// count, MOTD and favicon, and measures its latency. address is written as in the server list
+// ("host", "host:port" or "[ipv6]:port"); without a port the _minecraft._tcp SRV record is
+// used, like the game does. Servers before 1.7, which do not answer the Server List Ping, are
+// asked again with PingLegacy unless opts.NoLegacyFallback is set. The status is emitted as
+// server_pinged.
+func Ping(address string, opts PingOptions, E *events.EventEmitter) (*ServerStatus, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultPingTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	host, port, err := resolveAddress(address)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	status, err := pingModern(host, port, opts, deadline)
+	if err != nil && !opts.NoLegacyFallback && time.Now().Before(deadline) {
+		status, err = pingLegacy(host, port, deadline)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to ping %s: %w", address, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	E.Emit("server_pinged", map[string]interface{}{"address": address, "status": status})
+	return status, nil
+}
+
+// PingLegacy queries a server with the ping of versions before 1.7 (beta 1.8 to 1.6). Only
+// the MOTD, the player counts and, from 1.4 on, the version are reported.
+func PingLegacy(address string, timeout time.Duration, E *events.EventEmitter) (*ServerStatus, error) {
+	if timeout == 0 {
+		timeout = DefaultPingTimeout
+	}
+	host, port, err := resolveAddress(address)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	status, err := pingLegacy(host, port, time.Now().Add(timeout))
+	if err != nil {
+		err = fmt.Errorf("failed to ping %s: %w", address, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	E.Emit("server_pinged", map[string]interface{}{"address": address, "status": status})
+	return status, nil
+}
@@ -0,0 +1,181 @@
+package multiplayer
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+const (
+	// LegacyPackCacheDir is where versions before 1.20.3 cache server resource packs, as
+	// <game dir>/server-resource-packs/<sha1>.
+	LegacyPackCacheDir = "server-resource-packs"
+	// PackCacheDir is where 1.20.3 and later cache server resource packs, as
+	// <game dir>/downloads/<pack id>/<sha1>.
+	PackCacheDir = "downloads"
+)
+
+// ErrNoPackHash is returned by PredownloadResourcePack for packs without a valid SHA1; the
+// game only reuses cached packs whose hash the server announces.
+var ErrNoPackHash error = i18n.New("multiplayer.no_pack_hash", "server resource pack has no valid SHA1")
+
+// ------------------ Structs ------------------
+
+// ResourcePack is a resource pack a server sends to joining players.
+type ResourcePack struct {
+	URL string
+	// SHA1 is the hex SHA1 of the pack.
+	SHA1 string
+	// ID is the UUID of the pack (1.20.3+). Empty uses the ID a server derives from URL when
+	// server.properties sets no resource-pack-id.
+	ID string
+}
+
+// ResourcePackFromProperties returns the resource pack configured in server.properties, as
+// read by server.ReadProperties, or nil when none is configured.
+func ResourcePackFromProperties(props map[string]string) *ResourcePack {
+	if props["resource-pack"] == "" {
+		return nil
+	}
+	return &ResourcePack{
+		URL:  props["resource-pack"],
+		SHA1: props["resource-pack-sha1"],
+		ID:   props["resource-pack-id"],
+	}
+}
+
+// ------------------ Helpers ------------------
+
+// nameUUID returns the version 3 (MD5) name-based UUID of name, like Java's
+// UUID.nameUUIDFromBytes.
+func nameUUID(name string) string {
+	h := md5.Sum([]byte(name))
+	h[6] = h[6]&0x0f | 0x30
+	h[8] = h[8]&0x3f | 0x80
+	s := hex.EncodeToString(h[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// validSHA1 reports whether s is a hex SHA1.
+func validSHA1(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// hasSHA1 reports whether path exists with the given hex SHA1.
+func hasSHA1(path, sum string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == sum
+}
+
+// placeCopy puts a hard link to src at dst, or a copy where links are not possible.
+func placeCopy(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return err
+	}
+	os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, utils.Modes.File)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ------------------ Public API ------------------
+
+// ResourcePackPaths returns where the game looks for a cached copy of pack in gameDir: the
+// LegacyPackCacheDir file of versions before 1.20.3 and the PackCacheDir file of later ones.
+func ResourcePackPaths(gameDir string, pack ResourcePack) []string {
+	sum := strings.ToLower(pack.SHA1)
+	id := pack.ID
+	if id == "" {
+		id = nameUUID(pack.URL)
+	}
+	return []string{
+		filepath.Join(gameDir, LegacyPackCacheDir, sum),
+		filepath.Join(gameDir, PackCacheDir, id, sum),
+	}
+}
+
+// PredownloadResourcePack downloads the resource pack of a server into the cache of gameDir
+// ahead of joining, so the game finds it by its hash instead of making the player wait. The
+// pack is placed at every path of ResourcePackPaths (hard-linked where possible), which covers
+// old and new game versions. A pack already cached with the right hash is not downloaded
+// again. It returns the cached paths and emits resource_pack_cached.
+func PredownloadResourcePack(gameDir string, pack ResourcePack, E *events.EventEmitter) ([]string, error) {
+	pack.SHA1 = strings.ToLower(pack.SHA1)
+	if !validSHA1(pack.SHA1) {
+		E.Emit("error", i18n.ErrorEvent(ErrNoPackHash))
+		return nil, ErrNoPackHash
+	}
+	paths := ResourcePackPaths(gameDir, pack)
+
+	source := ""
+	for _, path := range paths {
+		if hasSHA1(path, pack.SHA1) {
+			source = path
+			break
+		}
+	}
+	if source == "" {
+		// A stale or partial file would be taken for the pack by DownloadMultiSource
+		for _, path := range paths {
+			os.Remove(path)
+		}
+		source = paths[0]
+		if err := downloader.DownloadMultiSource(source, []string{pack.URL}, pack.SHA1, E); err != nil {
+			return nil, fmt.Errorf("failed to download server resource pack: %w", err)
+		}
+	}
+
+	for _, path := range paths {
+		if path == source || hasSHA1(path, pack.SHA1) {
+			continue
+		}
+		if err := placeCopy(source, path); err != nil {
+			err = fmt.Errorf("failed to cache server resource pack: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+	}
+
+	E.Emit("resource_pack_cached", map[string]interface{}{
+		"url":   pack.URL,
+		"sha1":  pack.SHA1,
+		"paths": paths,
+	})
+	return paths, nil
+}
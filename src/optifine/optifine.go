@@ -0,0 +1,254 @@
+package optifine
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ------------------ Structs ------------------
+
+// Composition describes how OptiFine was added to a Forge/NeoForge version.
+type Composition struct {
+	// Mode is "mod" for 1.13+ loaders (OptiFine dropped into mods/) or
+	// "tweaker" for LaunchWrapper based loaders (OptiFine loaded as a library tweak).
+	Mode string
+	// VersionID is the version to launch: the Forge version itself in "mod" mode,
+	// or the generated child version in "tweaker" mode.
+	VersionID string
+	// Path is the installed OptiFine JAR.
+	Path string
+}
+
+// loaderVersion holds the fields of a loader version JSON needed to compose OptiFine into it.
+type loaderVersion struct {
+	ID                 string          `json:"id"`
+	InheritsFrom       string          `json:"inheritsFrom"`
+	Type               string          `json:"type"`
+	MainClass          string          `json:"mainClass"`
+	MinecraftArguments string          `json:"minecraftArguments"`
+	Arguments          json.RawMessage `json:"arguments"`
+}
+
+// composedVersion is the child version JSON written for legacy (tweaker) compositions.
+type composedVersion struct {
+	ID                 string    `json:"id"`
+	InheritsFrom       string    `json:"inheritsFrom"`
+	Type               string    `json:"type"`
+	MainClass          string    `json:"mainClass"`
+	MinecraftArguments string    `json:"minecraftArguments"`
+	Libraries          []library `json:"libraries"`
+}
+
+type library struct {
+	Name string `json:"name"`
+}
+
+// forgeTweaker is the OptiFine tweak class that cooperates with FML on LaunchWrapper versions.
+const forgeTweaker = "optifine.OptiFineForgeTweaker"
+
+// ------------------ Helpers ------------------
+
+// readLoaderVersion reads versions/<id>/<id>.json without resolving inheritance.
+func readLoaderVersion(mcDir, id string) (*loaderVersion, error) {
+	data, err := os.ReadFile(utils.NewLayout(mcDir).VersionJSON(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version JSON for %s: %w", id, err)
+	}
+
+	var v loaderVersion
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse version JSON for %s: %w", id, err)
+	}
+	return &v, nil
+}
+
+// isModern reports whether a loader version uses the 1.13+ "arguments" format, walking
+// up the inheritance chain when the loader JSON itself defines neither format.
+func isModern(mcDir string, v *loaderVersion) (bool, error) {
+	for {
+		if len(v.Arguments) > 0 && string(v.Arguments) != "null" {
+			return true, nil
+		}
+		if v.MinecraftArguments != "" || v.InheritsFrom == "" {
+			return false, nil
+		}
+		parent, err := readLoaderVersion(mcDir, v.InheritsFrom)
+		if err != nil {
+			return false, err
+		}
+		v = parent
+	}
+}
+
+// optifineVersion derives the OptiFine version from its JAR name,
+// e.g. "OptiFine_1.12.2_HD_U_G5.jar" -> "1.12.2_HD_U_G5".
+func optifineVersion(jarPath string) string {
+	name := strings.TrimSuffix(filepath.Base(jarPath), filepath.Ext(jarPath))
+	name = strings.TrimPrefix(name, "preview_")
+	return strings.TrimPrefix(name, "OptiFine_")
+}
+
+// checkOptiFineJar makes sure the file is a readable OptiFine JAR, which catches the common
+// mistake of passing the OptiFine installer wrapper or an unrelated mod.
+func checkOptiFineJar(jarPath string) error {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return fmt.Errorf("failed to open OptiFine jar: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if strings.HasPrefix(f.Name, "optifine/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s does not look like an OptiFine jar", jarPath)
+}
+
+// copyFile copies src to dst, creating the parent directories of dst.
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), utils.Modes.Dir); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// sameFile reports whether dst exists with the same contents as src.
+func sameFile(src, dst string) bool {
+	a, err := os.ReadFile(src)
+	if err != nil {
+		return false
+	}
+	b, err := os.ReadFile(dst)
+	return err == nil && bytes.Equal(a, b)
+}
+
+// ------------------ Public API ------------------
+
+// ComposeWithForge adds OptiFine to an installed Forge or NeoForge version.
+//
+// For 1.13+ loaders OptiFine is a regular mod, so the JAR is copied into <mcDir>/mods and the
+// loader version is launched unchanged. For legacy LaunchWrapper loaders OptiFine must be on the
+// classpath as a tweaker: the JAR is installed as the library optifine:OptiFine:<version> and a
+// child version "<forgeVersionID>-OptiFine_<version>" adding --tweakClass optifine.OptiFineForgeTweaker
+// is written. Running it again when the composition is already in place changes nothing and
+// emits optifine_already_installed.
+func ComposeWithForge(mcDir, forgeVersionID, optifineJar string, E *events.EventEmitter) (*Composition, error) {
+	E.Emit("optifine_compose_start", forgeVersionID)
+
+	if err := checkOptiFineJar(optifineJar); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	forge, err := readLoaderVersion(mcDir, forgeVersionID)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	modern, err := isModern(mcDir, forge)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	ofVersion := optifineVersion(optifineJar)
+
+	if modern {
+		// 1.13+: OptiFine is loaded by the mod loader like any other mod
+		modPath := filepath.Join(mcDir, "mods", filepath.Base(optifineJar))
+		if sameFile(optifineJar, modPath) {
+			E.Emit("optifine_already_installed", forgeVersionID)
+			return &Composition{Mode: "mod", VersionID: forgeVersionID, Path: modPath}, nil
+		}
+		if err := copyFile(optifineJar, modPath); err != nil {
+			err = fmt.Errorf("failed to copy OptiFine into mods: %w", err)
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		E.Emit("optifine_installed_as_mod", modPath)
+		return &Composition{Mode: "mod", VersionID: forgeVersionID, Path: modPath}, nil
+	}
+
+	// Legacy: install OptiFine as a library using the Maven layout the launcher resolves
+	libPath := filepath.Join(utils.NewLayout(mcDir).LibrariesDir(), "optifine", "OptiFine", ofVersion, "OptiFine-"+ofVersion+".jar")
+	composedID := forgeVersionID + "-OptiFine_" + ofVersion
+	if existing, err := readLoaderVersion(mcDir, composedID); err == nil && existing.InheritsFrom == forgeVersionID && sameFile(optifineJar, libPath) {
+		E.Emit("optifine_already_installed", composedID)
+		return &Composition{Mode: "tweaker", VersionID: composedID, Path: libPath}, nil
+	}
+	if err := copyFile(optifineJar, libPath); err != nil {
+		err = fmt.Errorf("failed to install OptiFine library: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	// The child's minecraftArguments replace the parent's, so start from the loader's arguments
+	args := forge.MinecraftArguments
+	parentID := forge.InheritsFrom
+	for args == "" && parentID != "" {
+		parent, err := readLoaderVersion(mcDir, parentID)
+		if err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		args, parentID = parent.MinecraftArguments, parent.InheritsFrom
+	}
+	if !strings.Contains(args, forgeTweaker) {
+		args = strings.TrimSpace(args + " --tweakClass " + forgeTweaker)
+	}
+
+	versionType := forge.Type
+	if versionType == "" {
+		versionType = "release"
+	}
+	composed := composedVersion{
+		ID:                 composedID,
+		InheritsFrom:       forgeVersionID,
+		Type:               versionType,
+		MainClass:          "net.minecraft.launchwrapper.Launch",
+		MinecraftArguments: args,
+		Libraries:          []library{{Name: "optifine:OptiFine:" + ofVersion}},
+	}
+
+	versionDir := utils.NewLayout(mcDir).VersionDir(composed.ID)
+	if err := os.MkdirAll(versionDir, utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	data, _ := json.MarshalIndent(composed, "", "  ")
+	versionJSONPath := filepath.Join(versionDir, composed.ID+".json")
+	if err := os.WriteFile(versionJSONPath, data, utils.Modes.File); err != nil {
+		err = fmt.Errorf("failed to write composed version JSON: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	E.Emit("optifine_version_written", versionJSONPath)
+	return &Composition{Mode: "tweaker", VersionID: composed.ID, Path: libPath}, nil
+}
@@ -0,0 +1,159 @@
+package rules
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Operating system names used by version JSON rules.
+const (
+	OSWindows = "windows"
+	OSMac     = "osx"
+	OSLinux   = "linux"
+)
+
+// ------------------ Structs ------------------
+
+// Platform is what OS rules are evaluated against. Use Host for the running system or fill it
+// in to evaluate rules for another platform.
+type Platform struct {
+	// OS is OSWindows, OSMac or OSLinux.
+	OS string
+	// Version is the OS version, matched against the os.version regular expression of a rule
+	// (e.g. "10.0" for Windows 10). Empty never matches a version constraint.
+	Version string
+	// Arch is "x86_64", "arm64" or "x86", matched against os.arch.
+	Arch string
+}
+
+// OSRule is the os constraint of a rule.
+type OSRule struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+}
+
+// Rule is one entry of a "rules" list of a library or argument.
+type Rule struct {
+	// Action is "allow" or "disallow".
+	Action   string          `json:"action"`
+	OS       OSRule          `json:"os,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// ------------------ Host Platform ------------------
+
+var (
+	hostVersionOnce sync.Once
+	hostVersion     string
+)
+
+// OSName returns the rule name of a Go GOOS value.
+func OSName(goos string) string {
+	if goos == "darwin" {
+		return OSMac
+	}
+	return goos
+}
+
+// ArchName returns the rule architecture name of a Go GOARCH value.
+func ArchName(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	case "386":
+		return "x86"
+	}
+	return goarch
+}
+
+// detectOSVersion returns the version of the running OS as the official launcher reports it.
+func detectOSVersion() string {
+	switch runtime.GOOS {
+	case "windows":
+		// "Microsoft Windows [Version 10.0.19045.3448]"
+		out, _ := exec.Command("cmd", "/c", "ver").Output()
+		if _, rest, ok := strings.Cut(string(out), "Version "); ok {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "]"))
+		}
+	case "darwin":
+		out, _ := exec.Command("sw_vers", "-productVersion").Output()
+		return strings.TrimSpace(string(out))
+	case "linux":
+		data, _ := os.ReadFile("/proc/sys/kernel/osrelease")
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
+
+// Host returns the platform this process runs on. The OS version is detected once.
+func Host() Platform {
+	hostVersionOnce.Do(func() { hostVersion = detectOSVersion() })
+	return Platform{OS: OSName(runtime.GOOS), Version: hostVersion, Arch: ArchName(runtime.GOARCH)}
+}
+
+// ------------------ Evaluation ------------------
+
+// Matches reports whether the rule applies to the platform and features: every os constraint
+// and every listed feature must match. Features missing from the map count as false.
+func (r Rule) Matches(p Platform, features map[string]bool) bool {
+	if r.OS.Name != "" && r.OS.Name != p.OS {
+		return false
+	}
+	if r.OS.Arch != "" && r.OS.Arch != p.Arch {
+		return false
+	}
+	if r.OS.Version != "" {
+		re, err := regexp.Compile(r.OS.Version)
+		if err != nil || !re.MatchString(p.Version) {
+			return false
+		}
+	}
+	for name, want := range r.Features {
+		if features[name] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// EvaluateRules applies a rule list the way the official launcher does: an empty list allows,
+// otherwise the last matching rule decides and nothing is allowed unless an "allow" rule matched.
+func EvaluateRules(rules []Rule, p Platform, features map[string]bool) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	allowed := false
+	for _, rule := range rules {
+		if rule.Matches(p, features) {
+			allowed = rule.Action == "allow"
+		}
+	}
+	return allowed
+}
+
+// Parse converts a rule list decoded into interface{} values (as found in "arguments") to Rules.
+// Malformed entries are dropped.
+func Parse(raw []interface{}) []Rule {
+	var parsed []Rule
+	// Decode entry by entry so one bad rule does not discard the others
+	for _, entry := range raw {
+		if _, ok := entry.(map[string]interface{}); !ok {
+			continue
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		var rule Rule
+		if json.Unmarshal(data, &rule) == nil {
+			parsed = append(parsed, rule)
+		}
+	}
+	return parsed
+}
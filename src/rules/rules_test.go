@@ -0,0 +1,123 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	windows := Platform{OS: OSWindows, Version: "10.0.19045", Arch: "x86_64"}
+	mac := Platform{OS: OSMac, Version: "10.5.8", Arch: "x86"}
+	linux := Platform{OS: OSLinux, Version: "", Arch: "arm64"}
+
+	allow := Rule{Action: "allow"}
+	tests := []struct {
+		name     string
+		rules    []Rule
+		platform Platform
+		features map[string]bool
+		want     bool
+	}{
+		{"empty list allows", nil, linux, nil, true},
+		{"empty slice allows", []Rule{}, windows, nil, true},
+		{"unconditional allow", []Rule{allow}, linux, nil, true},
+		{"unconditional disallow", []Rule{{Action: "disallow"}}, linux, nil, false},
+		{"no matching rule disallows", []Rule{{Action: "allow", OS: OSRule{Name: OSWindows}}}, linux, nil, false},
+		{"os name matches", []Rule{{Action: "allow", OS: OSRule{Name: OSWindows}}}, windows, nil, true},
+		{"disallow overrides allow", []Rule{allow, {Action: "disallow", OS: OSRule{Name: OSMac}}}, mac, nil, false},
+		{"disallow for another os", []Rule{allow, {Action: "disallow", OS: OSRule{Name: OSMac}}}, windows, nil, true},
+		{"last matching rule wins", []Rule{{Action: "disallow", OS: OSRule{Name: OSLinux}}, allow}, linux, nil, true},
+		{"later non-matching rule does not decide", []Rule{allow, {Action: "disallow", OS: OSRule{Name: OSLinux}}, {Action: "allow", OS: OSRule{Name: OSWindows}}}, linux, nil, false},
+		{"arch matches", []Rule{{Action: "allow", OS: OSRule{Arch: "x86"}}}, mac, nil, true},
+		{"arch differs", []Rule{{Action: "allow", OS: OSRule{Arch: "x86"}}}, windows, nil, false},
+		{"os and arch must both match", []Rule{{Action: "allow", OS: OSRule{Name: OSWindows, Arch: "x86"}}}, windows, nil, false},
+		{"version regex matches", []Rule{allow, {Action: "disallow", OS: OSRule{Name: OSMac, Version: `^10\.5\.\d$`}}}, mac, nil, false},
+		{"version regex differs", []Rule{allow, {Action: "disallow", OS: OSRule{Name: OSWindows, Version: `^6\.1\.`}}}, windows, nil, true},
+		{"version regex matches part of the version", []Rule{{Action: "allow", OS: OSRule{Version: `10\.0`}}}, windows, nil, true},
+		{"invalid version regex never matches", []Rule{allow, {Action: "disallow", OS: OSRule{Version: `^10\.(`}}}, windows, nil, true},
+		{"empty platform version fails a version constraint", []Rule{{Action: "allow", OS: OSRule{Version: `^\d+`}}}, linux, nil, false},
+		{"empty platform version matches no constraint", []Rule{{Action: "allow", OS: OSRule{Name: OSLinux}}}, linux, nil, true},
+		{"feature set", []Rule{{Action: "allow", Features: map[string]bool{"is_demo_user": true}}}, linux, map[string]bool{"is_demo_user": true}, true},
+		{"feature unset", []Rule{{Action: "allow", Features: map[string]bool{"is_demo_user": true}}}, linux, map[string]bool{"is_demo_user": false}, false},
+		{"missing feature counts as false", []Rule{{Action: "allow", Features: map[string]bool{"has_custom_resolution": true}}}, linux, map[string]bool{"is_demo_user": true}, false},
+		{"nil features count as false", []Rule{{Action: "allow", Features: map[string]bool{"has_custom_resolution": true}}}, linux, nil, false},
+		{"feature required false matches a missing feature", []Rule{{Action: "allow", Features: map[string]bool{"is_demo_user": false}}}, linux, nil, true},
+		{"every feature must match", []Rule{{Action: "allow", Features: map[string]bool{"is_demo_user": true, "has_custom_resolution": true}}}, linux, map[string]bool{"is_demo_user": true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EvaluateRules(tt.rules, tt.platform, tt.features); got != tt.want {
+				t.Errorf("EvaluateRules(%+v, %+v, %v) = %t, want %t", tt.rules, tt.platform, tt.features, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	p := Platform{OS: OSWindows, Version: "10.0", Arch: "x86_64"}
+	tests := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"no constraints", Rule{Action: "disallow"}, true},
+		{"action does not affect matching", Rule{Action: "disallow", OS: OSRule{Name: OSWindows}}, true},
+		{"other os", Rule{Action: "allow", OS: OSRule{Name: OSLinux}}, false},
+		{"other arch", Rule{Action: "allow", OS: OSRule{Arch: "arm64"}}, false},
+		{"version regex", Rule{Action: "allow", OS: OSRule{Version: `^10\.`}}, true},
+		{"invalid version regex", Rule{Action: "allow", OS: OSRule{Version: `[`}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(p, nil); got != tt.want {
+				t.Errorf("%+v.Matches(%+v) = %t, want %t", tt.rule, p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []interface{}
+		want []Rule
+	}{
+		{"nil", nil, nil},
+		{
+			name: "well-formed rules",
+			raw: []interface{}{
+				map[string]interface{}{"action": "allow"},
+				map[string]interface{}{"action": "disallow", "os": map[string]interface{}{"name": "osx", "version": `^10\.5`}},
+				map[string]interface{}{"action": "allow", "features": map[string]interface{}{"is_demo_user": true}},
+			},
+			want: []Rule{
+				{Action: "allow"},
+				{Action: "disallow", OS: OSRule{Name: OSMac, Version: `^10\.5`}},
+				{Action: "allow", Features: map[string]bool{"is_demo_user": true}},
+			},
+		},
+		{
+			name: "malformed entries are dropped",
+			raw: []interface{}{
+				map[string]interface{}{"action": "allow"},
+				"allow",
+				map[string]interface{}{"action": 1},
+				map[string]interface{}{"action": "allow", "os": "windows"},
+				map[string]interface{}{"action": "allow", "features": map[string]interface{}{"is_demo_user": "yes"}},
+				map[string]interface{}{"action": "disallow", "os": map[string]interface{}{"arch": "x86"}},
+			},
+			want: []Rule{
+				{Action: "allow"},
+				{Action: "disallow", OS: OSRule{Arch: "x86"}},
+			},
+		},
+		{"only malformed entries", []interface{}{42, nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%v) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
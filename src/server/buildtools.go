@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// BuildToolsURL is the latest successful build of Spigot's BuildTools.
+const BuildToolsURL = "https://hub.spigotmc.org/jenkins/job/BuildTools/lastSuccessfulBuild/artifact/target/BuildTools.jar"
+
+// javaVersionPattern extracts the version from `java -version` output.
+var javaVersionPattern = regexp.MustCompile(`version "(\d+)(?:\.(\d+))?`)
+
+// BuildToolsOptions configures BuildSpigot.
+type BuildToolsOptions struct {
+	// Version is the Minecraft version to build, passed as --rev; empty builds the latest.
+	Version string
+	// WorkDir holds BuildTools and its checkouts, reused between builds to save time.
+	WorkDir string
+	// OutputDir receives the built JAR; empty uses WorkDir.
+	OutputDir string
+	// JavaPath runs BuildTools when JavaPaths has no runtime for the required Java version;
+	// empty uses "java".
+	JavaPath string
+	// JavaPaths maps Java major versions to executables, e.g. {8: ".../jre8/bin/java", 21: ...},
+	// so the runtime matching the version is picked.
+	JavaPaths map[int]string
+}
+
+// ------------------ Helpers ------------------
+
+// requiredJava returns the Java major version BuildTools needs for a Minecraft version.
+func requiredJava(version string) int {
+	parts := strings.Split(version, ".")
+	minor, patch := 0, 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 {
+		patch, _ = strconv.Atoi(parts[2])
+	}
+	switch {
+	case version == "" || minor > 20 || (minor == 20 && patch >= 5):
+		return 21
+	case minor >= 18:
+		return 17
+	case minor == 17:
+		return 16
+	default:
+		return 8
+	}
+}
+
+// javaMajorVersion runs `java -version` and returns the major version.
+func javaMajorVersion(javaPath string) (int, error) {
+	out, err := exec.Command(javaPath, "-version").CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %s: %w", javaPath, err)
+	}
+	m := javaVersionPattern.FindStringSubmatch(string(out))
+	if m == nil {
+		return 0, fmt.Errorf("unrecognized java version output")
+	}
+	major, _ := strconv.Atoi(m[1])
+	if major == 1 && m[2] != "" {
+		major, _ = strconv.Atoi(m[2])
+	}
+	return major, nil
+}
+
+// selectBuildToolsJava picks the runtime for a version, failing when it is too old.
+func selectBuildToolsJava(opts BuildToolsOptions, E *events.EventEmitter) (string, error) {
+	required := requiredJava(opts.Version)
+	if path := opts.JavaPaths[required]; path != "" {
+		return path, nil
+	}
+
+	javaPath := opts.JavaPath
+	if javaPath == "" {
+		javaPath = "java"
+	}
+	major, err := javaMajorVersion(javaPath)
+	if err != nil {
+		return "", err
+	}
+	if major < required {
+		return "", fmt.Errorf("building Spigot %s needs Java %d, but %s is Java %d", opts.Version, required, javaPath, major)
+	}
+	if required == 8 && major > 8 {
+		E.Emit("compat_warning", fmt.Sprintf("Spigot %s is usually built with Java 8; using Java %d", opts.Version, major))
+	}
+	return javaPath, nil
+}
+
+// emitLines forwards every line read from r as a buildtools_output event.
+func emitLines(r io.Reader, E *events.EventEmitter) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		E.Emit("buildtools_output", scanner.Text())
+	}
+}
+
+// ------------------ Public API ------------------
+
+// BuildSpigot downloads BuildTools and builds Spigot with the Java runtime the version needs,
+// forwarding its output as buildtools_output events. It returns the path of the built JAR.
+// Building takes several minutes and requires git, which BuildTools downloads on Windows.
+func BuildSpigot(opts BuildToolsOptions, E *events.EventEmitter) (string, error) {
+	if opts.WorkDir == "" {
+		err := fmt.Errorf("a BuildTools work directory is required")
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = opts.WorkDir
+	}
+	if err := os.MkdirAll(opts.OutputDir, utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	javaPath, err := selectBuildToolsJava(opts, E)
+	if err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	// Always fetch the current BuildTools; old ones cannot build new versions
+	jar := filepath.Join(opts.WorkDir, "BuildTools.jar")
+	_ = os.Remove(jar)
+	if err := downloader.DownloadFile(jar, BuildToolsURL, E); err != nil {
+		return "", err
+	}
+
+	rev := opts.Version
+	if rev == "" {
+		rev = "latest"
+	}
+	outDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return "", err
+	}
+	E.Emit("buildtools_start", map[string]string{"version": rev, "java": javaPath})
+
+	cmd := exec.Command(javaPath, "-jar", "BuildTools.jar", "--rev", rev, "--output-dir", outDir)
+	cmd.Dir = opts.WorkDir
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	done := make(chan struct{})
+	go func() {
+		emitLines(pr, E)
+		close(done)
+	}()
+	err = cmd.Run()
+	pw.Close()
+	<-done
+	if err != nil {
+		err = fmt.Errorf("BuildTools failed for %s: %w", rev, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	// BuildTools names the JAR spigot-<version>.jar
+	pattern := "spigot-*.jar"
+	if opts.Version != "" {
+		pattern = "spigot-" + opts.Version + ".jar"
+	}
+	matches, _ := filepath.Glob(filepath.Join(outDir, pattern))
+	if len(matches) == 0 {
+		err := fmt.Errorf("BuildTools finished but no %s was produced in %s", pattern, outDir)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	built := matches[len(matches)-1]
+	E.Emit("buildtools_done", built)
+	return built, nil
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// Alternative server software downloaded by DownloadFlavor.
+const (
+	FlavorPaper    = "paper"
+	FlavorFolia    = "folia"
+	FlavorPurpur   = "purpur"
+	FlavorVelocity = "velocity"
+)
+
+// API roots of the flavors.
+const (
+	paperAPI  = "https://api.papermc.io/v2/projects/"
+	purpurAPI = "https://api.purpurmc.org/v2/purpur/"
+)
+
+// Build is a published build of a server flavor.
+type Build struct {
+	Number int
+	// Channel is "default" for stable builds and "experimental" otherwise (PaperMC projects).
+	Channel  string
+	FileName string
+	URL      string
+	// SHA256 (PaperMC) or MD5 (Purpur) of the JAR.
+	SHA256 string
+	MD5    string
+}
+
+// ------------------ Build Listing ------------------
+
+// paperBuilds lists the builds of a PaperMC project (Paper, Folia, Velocity) for a version.
+func paperBuilds(project, version string) ([]Build, error) {
+	var resp struct {
+		Builds []struct {
+			Build     int    `json:"build"`
+			Channel   string `json:"channel"`
+			Downloads struct {
+				Application struct {
+					Name   string `json:"name"`
+					SHA256 string `json:"sha256"`
+				} `json:"application"`
+			} `json:"downloads"`
+		} `json:"builds"`
+	}
+	if err := getJSON(paperAPI+project+"/versions/"+version+"/builds", &resp); err != nil {
+		return nil, err
+	}
+
+	builds := make([]Build, 0, len(resp.Builds))
+	for _, b := range resp.Builds {
+		name := b.Downloads.Application.Name
+		builds = append(builds, Build{
+			Number:   b.Build,
+			Channel:  b.Channel,
+			FileName: name,
+			URL:      fmt.Sprintf("%s%s/versions/%s/builds/%d/downloads/%s", paperAPI, project, version, b.Build, name),
+			SHA256:   b.Downloads.Application.SHA256,
+		})
+	}
+	return builds, nil
+}
+
+// purpurBuilds lists the builds of Purpur for a version.
+func purpurBuilds(version string) ([]Build, error) {
+	var resp struct {
+		Builds struct {
+			All []string `json:"all"`
+		} `json:"builds"`
+	}
+	if err := getJSON(purpurAPI+version, &resp); err != nil {
+		return nil, err
+	}
+
+	builds := make([]Build, 0, len(resp.Builds.All))
+	for _, b := range resp.Builds.All {
+		number, err := strconv.Atoi(b)
+		if err != nil {
+			continue
+		}
+		builds = append(builds, Build{
+			Number:   number,
+			Channel:  "default",
+			FileName: fmt.Sprintf("purpur-%s-%d.jar", version, number),
+			URL:      fmt.Sprintf("%s%s/%d/download", purpurAPI, version, number),
+		})
+	}
+	return builds, nil
+}
+
+// FlavorBuilds lists the builds of a flavor for a version, oldest first. For Velocity the
+// version is the proxy version (e.g. "3.3.0-SNAPSHOT"), not a Minecraft version.
+func FlavorBuilds(flavor, version string) ([]Build, error) {
+	var builds []Build
+	var err error
+	switch flavor {
+	case FlavorPaper, FlavorFolia, FlavorVelocity:
+		builds, err = paperBuilds(flavor, version)
+	case FlavorPurpur:
+		builds, err = purpurBuilds(version)
+	default:
+		return nil, fmt.Errorf("unknown server flavor %q", flavor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i].Number < builds[j].Number })
+	return builds, nil
+}
+
+// selectBuild returns the requested build, or the latest stable one when number is 0.
+func selectBuild(builds []Build, number int) (*Build, error) {
+	for i := len(builds) - 1; i >= 0; i-- {
+		b := builds[i]
+		if (number == 0 && b.Channel == "default") || (number != 0 && b.Number == number) {
+			return &b, nil
+		}
+	}
+	if number == 0 {
+		return nil, fmt.Errorf("no stable build available")
+	}
+	return nil, fmt.Errorf("build %d not found", number)
+}
+
+// ------------------ Download ------------------
+
+// purpurMD5 fetches the MD5 Purpur publishes for a build.
+func purpurMD5(version string, number int) (string, error) {
+	var resp struct {
+		MD5 string `json:"md5"`
+	}
+	if err := getJSON(fmt.Sprintf("%s%s/%d", purpurAPI, version, number), &resp); err != nil {
+		return "", err
+	}
+	return resp.MD5, nil
+}
+
+// verifyHash checks a file against a hex digest.
+func verifyHash(path string, h hash.Hash, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filepath.Base(path), expected, actual)
+	}
+	return nil
+}
+
+// DownloadFlavor downloads a build of Paper, Folia, Purpur or Velocity into dir and verifies
+// its published checksum. Build 0 selects the latest stable build. It returns the JAR path.
+func DownloadFlavor(flavor, version string, build int, dir string, E *events.EventEmitter) (string, error) {
+	builds, err := FlavorBuilds(flavor, version)
+	if err != nil {
+		err = fmt.Errorf("failed to list %s builds for %s: %w", flavor, version, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	selected, err := selectBuild(builds, build)
+	if err != nil {
+		err = fmt.Errorf("%s %s: %w", flavor, version, err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if flavor == FlavorPurpur {
+		if selected.MD5, err = purpurMD5(version, selected.Number); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return "", err
+		}
+	}
+	E.Emit("server_build_selected", map[string]interface{}{"flavor": flavor, "version": version, "build": selected.Number})
+
+	jar := filepath.Join(dir, selected.FileName)
+	if err := downloader.DownloadFile(jar, selected.URL, E); err != nil {
+		return "", err
+	}
+
+	switch {
+	case selected.SHA256 != "":
+		err = verifyHash(jar, sha256.New(), selected.SHA256)
+	case selected.MD5 != "":
+		err = verifyHash(jar, md5.New(), selected.MD5)
+	}
+	if err != nil {
+		os.Remove(jar)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	return jar, nil
+}
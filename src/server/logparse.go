@@ -0,0 +1,130 @@
+package server
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// Log line patterns. Messages are matched after the "[time] [thread/LEVEL]: " prefix of
+// vanilla, Forge and Paper logs has been removed.
+var (
+	logPrefix     = regexp.MustCompile(`^\[[^\]]*\](?: \[[^\]]*\])*:? `)
+	colorCodes    = regexp.MustCompile(`§.|\x1b\[[0-9;]*m`)
+	joinedPattern = regexp.MustCompile(`^([A-Za-z0-9_.]{1,16}) joined the game$`)
+	leftPattern   = regexp.MustCompile(`^([A-Za-z0-9_.]{1,16}) left the game$`)
+	lostPattern   = regexp.MustCompile(`^([A-Za-z0-9_.]{1,16}) lost connection: (.*)$`)
+	donePattern   = regexp.MustCompile(`^Done \(([0-9.]+)s\)! For help`)
+	lagPattern    = regexp.MustCompile(`^Can't keep up! Is the server overloaded\? Running (\d+)ms or (\d+) ticks behind`)
+	tpsPattern    = regexp.MustCompile(`TPS from last 1m, 5m, 15m: \*?([0-9.]+), \*?([0-9.]+), \*?([0-9.]+)`)
+)
+
+// stoppingPrefix starts the message logged when the server begins shutting down.
+const stoppingPrefix = "Stopping server"
+
+// ------------------ Structs ------------------
+
+// LogParser turns dedicated server output into structured events: server_player_joined,
+// server_player_left, server_ready, server_lag, server_tps (Paper's /tps output) and
+// server_stopping. It tracks the players online. Watchdog feeds it every output line; feed it
+// yourself when running the server some other way.
+type LogParser struct {
+	mu      sync.Mutex
+	online  map[string]time.Time
+	reasons map[string]string
+}
+
+// PlayerEvent is the payload of server_player_joined and server_player_left.
+type PlayerEvent struct {
+	Name string `json:"name"`
+	// Reason is the disconnect reason, when the server logged one.
+	Reason string `json:"reason,omitempty"`
+	// Session is how long the player was online; zero for joins.
+	Session time.Duration `json:"session,omitempty"`
+}
+
+// NewLogParser returns a parser with no players online.
+func NewLogParser() *LogParser {
+	return &LogParser{online: map[string]time.Time{}, reasons: map[string]string{}}
+}
+
+// ------------------ Parsing ------------------
+
+// message strips the log prefix and color codes of a line.
+func message(line string) string {
+	line = colorCodes.ReplaceAllString(line, "")
+	return strings.TrimSpace(logPrefix.ReplaceAllString(line, ""))
+}
+
+// Parse emits the event a line of server output describes, if any, and reports whether it did.
+func (p *LogParser) Parse(line string, E *events.EventEmitter) bool {
+	msg := message(line)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case joinedPattern.MatchString(msg):
+		name := joinedPattern.FindStringSubmatch(msg)[1]
+		p.online[name] = time.Now()
+		E.Emit("server_player_joined", PlayerEvent{Name: name})
+	case lostPattern.MatchString(msg):
+		// Followed by "<name> left the game", which reports the reason
+		m := lostPattern.FindStringSubmatch(msg)
+		p.reasons[m[1]] = m[2]
+		return false
+	case leftPattern.MatchString(msg):
+		name := leftPattern.FindStringSubmatch(msg)[1]
+		event := PlayerEvent{Name: name, Reason: p.reasons[name]}
+		if joined, ok := p.online[name]; ok {
+			event.Session = time.Since(joined)
+		}
+		delete(p.online, name)
+		delete(p.reasons, name)
+		E.Emit("server_player_left", event)
+	case donePattern.MatchString(msg):
+		secs, _ := strconv.ParseFloat(donePattern.FindStringSubmatch(msg)[1], 64)
+		E.Emit("server_ready", map[string]float64{"startupSeconds": secs})
+	case lagPattern.MatchString(msg):
+		m := lagPattern.FindStringSubmatch(msg)
+		behind, _ := strconv.Atoi(m[1])
+		ticks, _ := strconv.Atoi(m[2])
+		E.Emit("server_lag", map[string]int{"behindMs": behind, "ticks": ticks})
+	case tpsPattern.MatchString(msg):
+		m := tpsPattern.FindStringSubmatch(msg)
+		tps := map[string]float64{}
+		for i, window := range []string{"1m", "5m", "15m"} {
+			tps[window], _ = strconv.ParseFloat(m[i+1], 64)
+		}
+		E.Emit("server_tps", tps)
+	case strings.HasPrefix(msg, stoppingPrefix):
+		E.Emit("server_stopping", nil)
+	default:
+		return false
+	}
+	return true
+}
+
+// Players returns the names of the players online, sorted.
+func (p *LogParser) Players() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, 0, len(p.online))
+	for name := range p.online {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reset forgets the players online, e.g. when the server restarts.
+func (p *LogParser) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.online = map[string]time.Time{}
+	p.reasons = map[string]string{}
+}
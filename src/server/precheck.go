@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// ServerPIDFile is written into the server directory by Watchdog while the server runs.
+const ServerPIDFile = "server.pid"
+
+// DefaultPort is the port of a server.properties without server-port.
+const DefaultPort = 25565
+
+// ErrEULANotAccepted is returned when eula.txt is missing or does not say eula=true.
+var ErrEULANotAccepted error = i18n.New("server.eula_not_accepted", "the Minecraft EULA is not accepted in eula.txt (see WriteEULA)")
+
+// ------------------ Errors ------------------
+
+// PortInUseError is returned when a port the server would listen on is taken.
+type PortInUseError struct {
+	// Property is the server.properties key of the port, e.g. "server-port".
+	Property string
+	Address  string
+	Err      error
+}
+
+// Error names the port and the property to change.
+func (e *PortInUseError) Error() string {
+	return fmt.Sprintf("%s %s is already in use: %v", e.Property, e.Address, e.Err)
+}
+
+// Unwrap returns the listen error.
+func (e *PortInUseError) Unwrap() error {
+	return e.Err
+}
+
+// WorldLockedError is returned when the world directory is in use by another process.
+type WorldLockedError struct {
+	World string
+	// PID is the process holding the world, when known.
+	PID int
+}
+
+// Error names the world and, when known, the process holding it.
+func (e *WorldLockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("world %s is in use by process %d", e.World, e.PID)
+	}
+	return fmt.Sprintf("world %s is in use by another process", e.World)
+}
+
+// ------------------ Properties ------------------
+
+// ReadProperties reads dir/server.properties. A missing file gives an empty map, as the server
+// creates it with defaults on first start.
+func ReadProperties(dir string) (map[string]string, error) {
+	props := map[string]string{}
+	f, err := os.Open(filepath.Join(dir, "server.properties"))
+	if os.IsNotExist(err) {
+		return props, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		props[strings.TrimSpace(key)] = strings.ReplaceAll(strings.TrimSpace(value), `\:`, ":")
+	}
+	return props, scanner.Err()
+}
+
+// property returns a property or its default.
+func property(props map[string]string, key, def string) string {
+	if value := props[key]; value != "" {
+		return value
+	}
+	return def
+}
+
+// ------------------ Checks ------------------
+
+// EULAAccepted reports whether dir/eula.txt says eula=true.
+func EULAAccepted(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "eula.txt"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(key) == "eula" {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+	return false
+}
+
+// checkPort tries to listen on a port the way the server would.
+func checkPort(network, property, host string, port int) error {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var err error
+	if network == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket(network, addr); err == nil {
+			conn.Close()
+		}
+	} else {
+		var l net.Listener
+		if l, err = net.Listen(network, addr); err == nil {
+			l.Close()
+		}
+	}
+	if err != nil {
+		return &PortInUseError{Property: property, Address: addr, Err: err}
+	}
+	return nil
+}
+
+// lockHolder returns the PID of a process with path open, by scanning /proc on Linux.
+func lockHolder(path string) int {
+	procs, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range procs {
+		if target, err := os.Readlink(fd); err == nil && target == path {
+			pid, _ := strconv.Atoi(strings.Split(fd, "/")[2])
+			return pid
+		}
+	}
+	return 0
+}
+
+// checkWorld detects a server already running on the world: the ServerPIDFile of a live
+// process, the session.lock held open (Linux) or locked against reading (Windows).
+func checkWorld(dir, world string) error {
+	if data, err := os.ReadFile(filepath.Join(dir, ServerPIDFile)); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid != os.Getpid() && utils.ProcessAlive(pid) {
+			return &WorldLockedError{World: world, PID: pid}
+		}
+	}
+
+	lock, err := filepath.Abs(filepath.Join(dir, world, "session.lock"))
+	if err != nil {
+		return nil
+	}
+	if _, err := os.Stat(lock); err != nil {
+		return nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		if pid := lockHolder(lock); pid > 0 {
+			return &WorldLockedError{World: world, PID: pid}
+		}
+	case "windows":
+		// The JVM locks session.lock with LockFileEx, which makes reads fail
+		if _, err := os.ReadFile(lock); err != nil && !os.IsNotExist(err) {
+			return &WorldLockedError{World: world}
+		}
+	}
+	return nil
+}
+
+// Precheck verifies that the server in dir can start: eula.txt is accepted
+// (ErrEULANotAccepted), the game port and the enabled query and RCON ports are free
+// (*PortInUseError) and the world is not used by another server (*WorldLockedError). Every
+// failing check is returned, joined; test them with errors.Is and errors.As. Lock detection is
+// best effort: it sees servers started by Watchdog everywhere, and other servers on Linux and
+// Windows.
+func Precheck(dir string, E *events.EventEmitter) error {
+	props, err := ReadProperties(dir)
+	if err != nil {
+		err = fmt.Errorf("failed to read server.properties: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	var errs []error
+	if !EULAAccepted(dir) {
+		errs = append(errs, ErrEULANotAccepted)
+	}
+
+	host := props["server-ip"]
+	port, err := strconv.Atoi(property(props, "server-port", strconv.Itoa(DefaultPort)))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid server-port %q", props["server-port"]))
+	} else if err := checkPort("tcp", "server-port", host, port); err != nil {
+		errs = append(errs, err)
+	}
+	if props["enable-query"] == "true" {
+		if query, err := strconv.Atoi(property(props, "query.port", strconv.Itoa(port))); err == nil {
+			if err := checkPort("udp", "query.port", host, query); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if props["enable-rcon"] == "true" {
+		if rcon, err := strconv.Atoi(property(props, "rcon.port", "25575")); err == nil {
+			if err := checkPort("tcp", "rcon.port", host, rcon); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := checkWorld(dir, property(props, "level-name", "world")); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		E.Emit("server_precheck_failed", err.Error())
+		return err
+	}
+	E.Emit("server_precheck_passed", dir)
+	return nil
+}
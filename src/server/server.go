@@ -0,0 +1,471 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/downloader"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/forge"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/modpack"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Loaders a server can be provisioned with.
+const (
+	LoaderVanilla  = ""
+	LoaderFabric   = "fabric"
+	LoaderForge    = "forge"
+	LoaderNeoForge = "neoforge"
+)
+
+// ------------------ Structs ------------------
+
+// Spec describes the dedicated server to provision: either a modpack or a version and loader.
+type Spec struct {
+	// Modpack is a .mrpack file; its Minecraft version and loader override Version and Loader.
+	Modpack string
+	// Version is the Minecraft version, e.g. "1.20.1", or an alias such as
+	// downloader.AliasLatestRelease, resolved when provisioning.
+	Version string
+	// Loader is LoaderVanilla, LoaderFabric, LoaderForge, LoaderNeoForge or a server flavor
+	// (FlavorPaper, FlavorFolia, FlavorPurpur, FlavorVelocity).
+	Loader string
+	// LoaderVersion is the loader version; for Fabric empty selects the latest stable loader.
+	// Forge versions are given without the Minecraft prefix, e.g. "47.2.0". For flavors it is
+	// the build number, empty selecting the latest stable build.
+	LoaderVersion string
+
+	// JavaPath runs installers and the server; empty uses "java".
+	JavaPath string
+	MaxRam   string
+	MinRam   string
+	// ExtraJVMArgs are added before the server JAR.
+	ExtraJVMArgs []string
+
+	// AcceptEULA writes eula=true. It must reflect that the operator agreed to the
+	// Minecraft EULA (https://aka.ms/MinecraftEULA); without it the server refuses to start.
+	AcceptEULA bool
+}
+
+// StartPlan is the command starting a provisioned server.
+type StartPlan struct {
+	JavaPath string
+	// JVMArgs include memory settings and, for modern Forge, the @argument files.
+	JVMArgs []string
+	// Jar is run with -jar; empty when JVMArgs already select the main class.
+	Jar string
+	// Args are passed to the server, e.g. "nogui".
+	Args []string
+	// Dir is the server directory and working directory of the process.
+	Dir string
+}
+
+// CommandArgs returns the full java argument list.
+func (p *StartPlan) CommandArgs() []string {
+	args := append([]string{}, p.JVMArgs...)
+	if p.Jar != "" {
+		args = append(args, "-jar", p.Jar)
+	}
+	return append(args, p.Args...)
+}
+
+// Command returns a command starting the server in its directory.
+func (p *StartPlan) Command() *exec.Cmd {
+	cmd := exec.Command(p.JavaPath, p.CommandArgs()...)
+	cmd.Dir = p.Dir
+	return cmd
+}
+
+// ------------------ Helpers ------------------
+
+// getJSON decodes the JSON document at url into out.
+func getJSON(url string, out any) error {
+	resp, err := utils.HTTPClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// DownloadVanillaServer downloads the official server JAR of a version to dir/server.jar,
+// verifying its SHA1. version may be an alias such as downloader.AliasLatestRelease.
+func DownloadVanillaServer(version, dir string, E *events.EventEmitter) (string, error) {
+	version, err := downloader.ResolveVersionAlias(version, E)
+	if err != nil {
+		return "", err
+	}
+	var manifest downloader.Manifest
+	if err := getJSON(downloader.VersionManifestURL, &manifest); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	metaURL := ""
+	for _, v := range manifest.Versions {
+		if v.Id == version {
+			metaURL = v.Url
+			break
+		}
+	}
+	if metaURL == "" {
+		E.Emit("version_not_found", version)
+		return "", fmt.Errorf("version %s not found in manifest", version)
+	}
+
+	var meta struct {
+		Downloads struct {
+			Server struct {
+				URL  string `json:"url"`
+				SHA1 string `json:"sha1"`
+			} `json:"server"`
+		} `json:"downloads"`
+	}
+	if err := getJSON(metaURL, &meta); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+	if meta.Downloads.Server.URL == "" {
+		err := fmt.Errorf("version %s has no dedicated server download", version)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return "", err
+	}
+
+	jar := filepath.Join(dir, "server.jar")
+	if err := downloader.DownloadMultiSource(jar, []string{meta.Downloads.Server.URL}, meta.Downloads.Server.SHA1, E); err != nil {
+		return "", err
+	}
+	return jar, nil
+}
+
+// installFabric downloads the Fabric server launcher, which starts the vanilla server.jar next to it.
+func installFabric(spec Spec, dir string, E *events.EventEmitter) (string, error) {
+	loader := spec.LoaderVersion
+	if loader == "" {
+		var loaders []struct {
+			Loader struct {
+				Version string `json:"version"`
+				Stable  bool   `json:"stable"`
+			} `json:"loader"`
+		}
+		if err := getJSON("https://meta.fabricmc.net/v2/versions/loader/"+spec.Version, &loaders); err != nil {
+			return "", err
+		}
+		for _, l := range loaders {
+			if l.Loader.Stable {
+				loader = l.Loader.Version
+				break
+			}
+		}
+		if loader == "" {
+			return "", fmt.Errorf("no stable Fabric loader for %s", spec.Version)
+		}
+	}
+
+	var installers []struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	}
+	if err := getJSON("https://meta.fabricmc.net/v2/versions/installer", &installers); err != nil {
+		return "", err
+	}
+	installer := ""
+	for _, i := range installers {
+		if i.Stable {
+			installer = i.Version
+			break
+		}
+	}
+	if installer == "" {
+		return "", fmt.Errorf("no stable Fabric installer found")
+	}
+
+	jar := filepath.Join(dir, "fabric-server-launch.jar")
+	url := fmt.Sprintf("https://meta.fabricmc.net/v2/versions/loader/%s/%s/%s/server/jar", spec.Version, loader, installer)
+	if err := downloader.DownloadFile(jar, url, E); err != nil {
+		return "", err
+	}
+	return jar, nil
+}
+
+// forgeInstallerURL returns the installer of a Forge or NeoForge server.
+func forgeInstallerURL(spec Spec) string {
+	if spec.Loader == LoaderNeoForge {
+		return fmt.Sprintf("https://maven.neoforged.net/releases/net/neoforged/neoforge/%[1]s/neoforge-%[1]s-installer.jar", spec.LoaderVersion)
+	}
+	version := spec.LoaderVersion
+	if !strings.HasPrefix(version, spec.Version+"-") {
+		version = spec.Version + "-" + version
+	}
+	return fmt.Sprintf("https://maven.minecraftforge.net/net/minecraftforge/forge/%[1]s/forge-%[1]s-installer.jar", version)
+}
+
+// installForge runs the Forge or NeoForge installer in server mode, unless
+// forge.IsProfileInstalled finds its loader version already installed in dir, in which case
+// forge_already_installed is emitted instead.
+func installForge(spec Spec, dir string, E *events.EventEmitter) error {
+	if spec.LoaderVersion == "" {
+		return fmt.Errorf("a %s version is required", spec.Loader)
+	}
+
+	installer := filepath.Join(dir, spec.Loader+"-installer.jar")
+	if err := downloader.DownloadFile(installer, forgeInstallerURL(spec), E); err != nil {
+		return err
+	}
+	defer os.Remove(installer)
+	defer os.Remove(installer + ".log")
+
+	// Legacy installers without an install profile are always run
+	if profile, err := forge.LoadInstallProfile(installer); err == nil &&
+		forge.IsProfileInstalled(profile, forge.ProcessorOptions{MCDir: dir, InstallerJar: installer, Side: "server"}) {
+		E.Emit("forge_already_installed", profile.Version)
+		return nil
+	}
+
+	E.Emit("server_loader_install_start", spec.Loader)
+	cmd := exec.Command(spec.JavaPath, "-jar", installer, "--installServer", dir)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		tail := strings.TrimSpace(string(output))
+		if len(tail) > 2000 {
+			tail = tail[len(tail)-2000:]
+		}
+		return fmt.Errorf("%s installer failed: %w\n%s", spec.Loader, err, tail)
+	}
+	return nil
+}
+
+// forgeStart finds how an installed Forge or NeoForge server starts: an @argument file for
+// 1.17+ or the universal JAR for older versions.
+func forgeStart(dir string) (argsFile, jar string, err error) {
+	name := "unix_args.txt"
+	if runtime.GOOS == "windows" {
+		name = "win_args.txt"
+	}
+	for _, pattern := range []string{
+		filepath.Join(dir, "libraries", "net", "minecraftforge", "forge", "*", name),
+		filepath.Join(dir, "libraries", "net", "neoforged", "neoforge", "*", name),
+		filepath.Join(dir, "libraries", "net", "neoforged", "forge", "*", name),
+	} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			rel, err := filepath.Rel(dir, matches[len(matches)-1])
+			return rel, "", err
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "forge-*.jar"))
+	for _, m := range matches {
+		if !strings.HasSuffix(m, "-installer.jar") {
+			return "", filepath.Base(m), nil
+		}
+	}
+	return "", "", fmt.Errorf("no Forge server launcher found in %s", dir)
+}
+
+// shellQuote quotes an argument for a POSIX shell when needed.
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'$&;|<>()*?`\\") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// batchQuote quotes an argument for a Windows batch file when needed. The argument is quoted
+// the way programs split their command line, with quotes escaped by backslashes; cmd sees
+// those quotes too, so its special characters left outside a quoted run are escaped with ^,
+// and % is doubled everywhere so variables are not expanded.
+func batchQuote(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"&|<>^()") {
+		var b strings.Builder
+		b.WriteByte('"')
+		slashes := 0
+		for i := 0; i < len(arg); i++ {
+			switch arg[i] {
+			case '\\':
+				slashes++
+			case '"':
+				b.WriteString(strings.Repeat(`\`, slashes+1))
+				slashes = 0
+			default:
+				slashes = 0
+			}
+			b.WriteByte(arg[i])
+		}
+		b.WriteString(strings.Repeat(`\`, slashes))
+		b.WriteByte('"')
+		arg = b.String()
+	}
+
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '%':
+			b.WriteByte('%')
+		case !quoted && strings.IndexByte("&|<>^()", c) >= 0:
+			b.WriteByte('^')
+		}
+		b.WriteByte(arg[i])
+	}
+	return b.String()
+}
+
+// writeStartScripts writes start.sh and start.bat running the plan.
+func writeStartScripts(plan *StartPlan) error {
+	command := append([]string{plan.JavaPath}, plan.CommandArgs()...)
+	sh := make([]string, len(command))
+	bat := make([]string, len(command))
+	for i, arg := range command {
+		sh[i], bat[i] = shellQuote(arg), batchQuote(arg)
+	}
+
+	script := "#!/bin/sh\ncd \"$(dirname \"$0\")\"\nexec " + strings.Join(sh, " ") + " \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(plan.Dir, "start.sh"), []byte(script), utils.Modes.Executable); err != nil {
+		return err
+	}
+	script = "@echo off\r\ncd /d \"%~dp0\"\r\n" + strings.Join(bat, " ") + " %*\r\n"
+	return os.WriteFile(filepath.Join(plan.Dir, "start.bat"), []byte(script), utils.Modes.File)
+}
+
+// ------------------ Public API ------------------
+
+// WriteEULA records the operator's acceptance of the Minecraft EULA in dir/eula.txt.
+func WriteEULA(dir string, accepted bool) error {
+	content := fmt.Sprintf("# By changing the setting below to TRUE you are indicating your agreement to our EULA (https://aka.ms/MinecraftEULA).\neula=%t\n", accepted)
+	return os.WriteFile(filepath.Join(dir, "eula.txt"), []byte(content), utils.Modes.File)
+}
+
+// Provision prepares a ready-to-run dedicated server in dir for automation pipelines: the
+// vanilla server JAR, the loader, the modpack's server-side files and overrides (client-only
+// mods are filtered out), eula.txt, and start.sh/start.bat. It returns the plan that starts
+// the server.
+func Provision(spec Spec, dir string, E *events.EventEmitter) (*StartPlan, error) {
+	if spec.JavaPath == "" {
+		spec.JavaPath = "java"
+	}
+
+	var pack *modpack.Pack
+	if spec.Modpack != "" {
+		var err error
+		if pack, err = modpack.Load(spec.Modpack); err != nil {
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		}
+		spec.Version = pack.MinecraftVersion()
+		loader, loaderVersion := pack.Loader()
+		switch loader {
+		case modpack.DependencyFabric:
+			spec.Loader = LoaderFabric
+		case modpack.DependencyForge:
+			spec.Loader = LoaderForge
+		case modpack.DependencyNeoForge:
+			spec.Loader = LoaderNeoForge
+		case modpack.DependencyQuilt:
+			err := fmt.Errorf("quilt servers are not supported")
+			E.Emit("error", i18n.ErrorEvent(err))
+			return nil, err
+		default:
+			spec.Loader = LoaderVanilla
+		}
+		spec.LoaderVersion = loaderVersion
+	}
+	if spec.Version == "" {
+		err := fmt.Errorf("no Minecraft version given")
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	version, err := downloader.ResolveVersionAlias(spec.Version, E)
+	if err != nil {
+		return nil, err
+	}
+	spec.Version = version
+
+	if err := os.MkdirAll(dir, utils.Modes.Dir); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	E.Emit("server_provision_start", map[string]string{"dir": dir, "version": spec.Version, "loader": spec.Loader})
+
+	plan := &StartPlan{JavaPath: spec.JavaPath, Dir: dir, Args: []string{"nogui"}}
+	if spec.MaxRam != "" {
+		plan.JVMArgs = append(plan.JVMArgs, "-Xmx"+spec.MaxRam)
+	}
+	if spec.MinRam != "" {
+		plan.JVMArgs = append(plan.JVMArgs, "-Xms"+spec.MinRam)
+	}
+	plan.JVMArgs = append(plan.JVMArgs, spec.ExtraJVMArgs...)
+
+	switch spec.Loader {
+	case LoaderVanilla:
+		plan.Jar = "server.jar"
+		_, err = DownloadVanillaServer(spec.Version, dir, E)
+	case LoaderFabric:
+		if _, err = DownloadVanillaServer(spec.Version, dir, E); err == nil {
+			var jar string
+			jar, err = installFabric(spec, dir, E)
+			plan.Jar = filepath.Base(jar)
+		}
+	case LoaderForge, LoaderNeoForge:
+		if err = installForge(spec, dir, E); err == nil {
+			var argsFile, jar string
+			argsFile, jar, err = forgeStart(dir)
+			if argsFile != "" {
+				plan.JVMArgs = append(plan.JVMArgs, "@"+filepath.ToSlash(argsFile))
+			}
+			plan.Jar = jar
+		}
+	case FlavorPaper, FlavorFolia, FlavorPurpur, FlavorVelocity:
+		build := 0
+		if spec.LoaderVersion != "" {
+			build, err = strconv.Atoi(spec.LoaderVersion)
+		}
+		if err == nil {
+			var jar string
+			jar, err = DownloadFlavor(spec.Loader, spec.Version, build, dir, E)
+			plan.Jar = filepath.Base(jar)
+		}
+		if spec.Loader == FlavorVelocity {
+			plan.Args = nil
+		}
+	default:
+		err = fmt.Errorf("unknown loader %q", spec.Loader)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to install the server: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	if pack != nil {
+		if err := pack.Install(dir, modpack.Options{Side: modpack.SideServer}, E); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := WriteEULA(dir, spec.AcceptEULA); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+	if err := writeStartScripts(plan); err != nil {
+		E.Emit("error", i18n.ErrorEvent(err))
+		return nil, err
+	}
+
+	E.Emit("server_provisioned", dir)
+	return plan, nil
+}
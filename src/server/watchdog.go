@@ -0,0 +1,478 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/launcher"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Restart modes of a RestartPolicy.
+const (
+	// RestartNever leaves the server down whenever it exits.
+	RestartNever = "never"
+	// RestartOnCrash restarts the server when it exits with an error or is killed, but not
+	// after a clean "stop".
+	RestartOnCrash = "on-crash"
+	// RestartAlways restarts the server whenever it exits unless Watchdog.Stop was called.
+	RestartAlways = "always"
+)
+
+// Reasons a watchdog restarts or stops the server, as reported in events.
+const (
+	ExitCrash     = "crash"
+	ExitOOM       = "oom"
+	ExitScheduled = "scheduled"
+	ExitRequested = "requested"
+	ExitStopped   = "stopped"
+	ExitClean     = "exited"
+)
+
+// Defaults of a RestartPolicy.
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryWindow  = 10 * time.Minute
+	DefaultRestartDelay = 5 * time.Second
+	DefaultStopTimeout  = 30 * time.Second
+	// maxRestartDelay caps the doubling restart delay after repeated crashes.
+	maxRestartDelay = 5 * time.Minute
+	// outputDrainTimeout is how long output is still read after the server exited.
+	outputDrainTimeout = 2 * time.Second
+)
+
+// ------------------ Structs ------------------
+
+// RestartPolicy decides when a Watchdog restarts its server.
+type RestartPolicy struct {
+	// Mode is RestartNever, RestartOnCrash or RestartAlways; empty means RestartOnCrash.
+	Mode string
+	// MaxRetries is how many crashes within RetryWindow are restarted before the watchdog gives
+	// up; 0 means DefaultMaxRetries and a negative value retries forever.
+	MaxRetries int
+	// RetryWindow is how far back crashes count towards MaxRetries; 0 means DefaultRetryWindow.
+	RetryWindow time.Duration
+	// RestartDelay is waited before restarting after a crash, doubling with every further crash
+	// in the window; 0 means DefaultRestartDelay.
+	RestartDelay time.Duration
+
+	// OnOOM restarts the server as soon as it logs a java.lang.OutOfMemoryError, since a JVM
+	// that ran out of memory often keeps running in a broken state.
+	OnOOM bool
+
+	// Interval restarts the server after it has been up this long; zero disables it.
+	Interval time.Duration
+	// DailyAt restarts the server every day at these local times, as "HH:MM".
+	DailyAt []string
+
+	// StopCommand is sent to the console for graceful stops; empty means "stop" ("end" for
+	// Velocity proxies).
+	StopCommand string
+	// StopTimeout is how long a graceful stop may take before the process is killed; 0 means
+	// DefaultStopTimeout.
+	StopTimeout time.Duration
+}
+
+// Watchdog runs a server from its StartPlan and restarts it according to a RestartPolicy,
+// so simple hosts do not need systemd or another external supervisor. Server output is
+// forwarded as server_output events and parsed by Log.
+type Watchdog struct {
+	Plan   *StartPlan
+	Policy RestartPolicy
+	// Log parses the output into player, readiness and lag events; nil disables parsing.
+	Log *LogParser
+	// SkipPrecheck starts the server without running Precheck first.
+	SkipPrecheck bool
+
+	E       *events.EventEmitter
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	reason  string // why the running process is being stopped, "" if it is not
+	crashes []time.Time
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// NewWatchdog returns a watchdog for the server started by plan. Call Start to run it.
+func NewWatchdog(plan *StartPlan, policy RestartPolicy, E *events.EventEmitter) *Watchdog {
+	return &Watchdog{Plan: plan, Policy: policy, Log: NewLogParser(), E: E}
+}
+
+// ------------------ Helpers ------------------
+
+// maxRetries returns the effective MaxRetries.
+func (p RestartPolicy) maxRetries() int {
+	if p.MaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	return p.MaxRetries
+}
+
+// stopTimeout returns the effective StopTimeout.
+func (p RestartPolicy) stopTimeout() time.Duration {
+	if p.StopTimeout <= 0 {
+		return DefaultStopTimeout
+	}
+	return p.StopTimeout
+}
+
+// parseDaily parses DailyAt into minutes after midnight.
+func (p RestartPolicy) parseDaily() ([]int, error) {
+	var minutes []int
+	for _, at := range p.DailyAt {
+		t, err := time.Parse("15:04", strings.TrimSpace(at))
+		if err != nil {
+			return nil, fmt.Errorf("invalid daily restart time %q, expected HH:MM", at)
+		}
+		minutes = append(minutes, t.Hour()*60+t.Minute())
+	}
+	return minutes, nil
+}
+
+// nextScheduled returns how long after now the next scheduled restart is due, or 0 when none is.
+func (p RestartPolicy) nextScheduled(now time.Time, daily []int) time.Duration {
+	next := p.Interval
+	for _, m := range daily {
+		at := time.Date(now.Year(), now.Month(), now.Day(), m/60, m%60, 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		if d := at.Sub(now); next <= 0 || d < next {
+			next = d
+		}
+	}
+	return next
+}
+
+// stopCommand returns the console command stopping the server.
+func (w *Watchdog) stopCommand() string {
+	if w.Policy.StopCommand != "" {
+		return w.Policy.StopCommand
+	}
+	if strings.HasPrefix(w.Plan.Jar, FlavorVelocity) {
+		return "end"
+	}
+	return "stop"
+}
+
+// maxRam returns the -Xmx of the plan, for OOM reports.
+func (w *Watchdog) maxRam() string {
+	for _, arg := range w.Plan.JVMArgs {
+		if ram, ok := strings.CutPrefix(arg, "-Xmx"); ok {
+			return ram
+		}
+	}
+	return ""
+}
+
+// requestExit stops the running process gracefully for reason, killing it after StopTimeout.
+// A pending ExitStopped is never replaced by another reason.
+func (w *Watchdog) requestExit(reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cmd == nil || w.reason == ExitStopped {
+		return
+	}
+	first := w.reason == ""
+	w.reason = reason
+	if !first {
+		return
+	}
+
+	cmd := w.cmd
+	if _, err := io.WriteString(w.stdin, w.stopCommand()+"\n"); err != nil {
+		cmd.Process.Kill()
+		return
+	}
+	time.AfterFunc(w.Policy.stopTimeout(), func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.cmd == cmd {
+			w.E.Emit("server_stop_timeout", cmd.Process.Pid)
+			cmd.Process.Kill()
+		}
+	})
+}
+
+// scan forwards the lines of one output stream and watches them for OutOfMemoryError.
+func (w *Watchdog) scan(r io.Reader, oom *sync.Once) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		w.E.Emit("server_output", line)
+		if w.Log != nil {
+			w.Log.Parse(line, w.E)
+		}
+		if report, ok := launcher.DiagnoseOOM(line, w.maxRam()); ok {
+			oom.Do(func() {
+				w.E.Emit("server_oom", report)
+				if w.Policy.OnOOM {
+					w.requestExit(ExitOOM)
+				}
+			})
+		}
+	}
+}
+
+// runOnce starts the server and waits for it to exit, returning why it exited.
+func (w *Watchdog) runOnce(daily []int, restarts int) string {
+	cmd := w.Plan.Command()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return ExitCrash
+	}
+	// Our own pipe, so output left open by orphaned children cannot block after an exit
+	stdout, output, err := os.Pipe()
+	if err != nil {
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return ExitCrash
+	}
+	defer stdout.Close()
+	cmd.Stdout, cmd.Stderr = output, output
+	err = cmd.Start()
+	output.Close()
+	if err != nil {
+		err = fmt.Errorf("failed to start server: %w", err)
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return ExitCrash
+	}
+
+	w.mu.Lock()
+	w.cmd, w.stdin, w.reason = cmd, stdin, ""
+	w.mu.Unlock()
+	if w.Log != nil {
+		w.Log.Reset()
+	}
+	pidFile := filepath.Join(w.Plan.Dir, ServerPIDFile)
+	os.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), utils.Modes.File)
+	defer os.Remove(pidFile)
+	w.E.Emit("server_started", map[string]int{"pid": cmd.Process.Pid, "restarts": restarts})
+	// Stop may have been called while the process was starting
+	select {
+	case <-w.quit:
+		w.requestExit(ExitStopped)
+	default:
+	}
+
+	var schedule *time.Timer
+	if next := w.Policy.nextScheduled(time.Now(), daily); next > 0 {
+		schedule = time.AfterFunc(next, func() { w.requestExit(ExitScheduled) })
+	}
+
+	var oom sync.Once
+	scanned := make(chan struct{})
+	go func() {
+		w.scan(stdout, &oom)
+		close(scanned)
+	}()
+	err = cmd.Wait()
+	if schedule != nil {
+		schedule.Stop()
+	}
+	select {
+	case <-scanned:
+	case <-time.After(outputDrainTimeout):
+		stdout.Close()
+		<-scanned
+	}
+
+	w.mu.Lock()
+	reason := w.reason
+	w.cmd, w.stdin, w.reason = nil, nil, ""
+	w.mu.Unlock()
+	stdin.Close()
+
+	var exitErr *exec.ExitError
+	code := 0
+	killed := false
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+		// The Linux OOM killer ends processes with SIGKILL
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		killed = code == 137 || (ok && status.Signaled() && status.Signal() == syscall.SIGKILL)
+	}
+	switch {
+	case reason != "":
+	case killed:
+		reason = ExitOOM
+	case err != nil:
+		reason = ExitCrash
+	default:
+		reason = ExitClean
+	}
+	w.E.Emit("server_exited", map[string]any{"code": code, "reason": reason})
+	return reason
+}
+
+// restartDelay records a crash and returns how long to wait before restarting, or false when
+// the policy gives up.
+func (w *Watchdog) restartDelay(now time.Time) (time.Duration, bool) {
+	window := w.Policy.RetryWindow
+	if window <= 0 {
+		window = DefaultRetryWindow
+	}
+	recent := w.crashes[:0]
+	for _, t := range w.crashes {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	w.crashes = append(recent, now)
+
+	if max := w.Policy.maxRetries(); max > 0 && len(w.crashes) > max {
+		return 0, false
+	}
+	delay := w.Policy.RestartDelay
+	if delay <= 0 {
+		delay = DefaultRestartDelay
+	}
+	for i := 1; i < len(w.crashes) && delay < maxRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRestartDelay), true
+}
+
+// supervise runs the server until the policy or Stop ends it.
+func (w *Watchdog) supervise(daily []int) {
+	defer close(w.done)
+	for restarts := 0; ; restarts++ {
+		reason := w.runOnce(daily, restarts)
+
+		var delay time.Duration
+		switch reason {
+		case ExitStopped:
+			w.E.Emit("server_stopped", w.Plan.Dir)
+			return
+		case ExitScheduled, ExitRequested:
+			// Restarts asked for do not count as crashes
+		case ExitClean:
+			if w.Policy.Mode != RestartAlways {
+				w.E.Emit("server_stopped", w.Plan.Dir)
+				return
+			}
+		default: // ExitCrash, ExitOOM
+			if w.Policy.Mode == RestartNever {
+				w.E.Emit("server_stopped", w.Plan.Dir)
+				return
+			}
+			var ok bool
+			if delay, ok = w.restartDelay(time.Now()); !ok {
+				w.E.Emit("server_gave_up", map[string]any{"crashes": len(w.crashes), "reason": reason})
+				return
+			}
+		}
+
+		w.E.Emit("server_restarting", map[string]any{"reason": reason, "delay": delay.String(), "restarts": restarts + 1})
+		select {
+		case <-time.After(delay):
+		case <-w.quit:
+			w.E.Emit("server_stopped", w.Plan.Dir)
+			return
+		}
+	}
+}
+
+// ------------------ Public API ------------------
+
+// Start runs the server and supervises it in the background until Stop is called or the
+// policy gives up (server_gave_up). It fails if the watchdog already runs, DailyAt is invalid
+// or, unless SkipPrecheck is set, Precheck fails.
+func (w *Watchdog) Start() error {
+	daily, err := w.Policy.parseDaily()
+	if err != nil {
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	switch w.Policy.Mode {
+	case "":
+		w.Policy.Mode = RestartOnCrash
+	case RestartNever, RestartOnCrash, RestartAlways:
+	default:
+		err := fmt.Errorf("unknown restart mode %q", w.Policy.Mode)
+		w.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	w.mu.Lock()
+	if w.done != nil {
+		select {
+		case <-w.done:
+		default:
+			w.mu.Unlock()
+			return fmt.Errorf("watchdog for %s is already running", w.Plan.Dir)
+		}
+	}
+	w.mu.Unlock()
+
+	if !w.SkipPrecheck {
+		if err := Precheck(w.Plan.Dir, w.E); err != nil {
+			return err
+		}
+	}
+	w.mu.Lock()
+	w.quit, w.done, w.crashes = make(chan struct{}), make(chan struct{}), nil
+	w.mu.Unlock()
+
+	go w.supervise(daily)
+	return nil
+}
+
+// Restart stops the server gracefully and starts it again, without counting as a crash.
+func (w *Watchdog) Restart() {
+	w.requestExit(ExitRequested)
+}
+
+// SendCommand writes a console command to the running server, e.g. "say Restarting soon".
+func (w *Watchdog) SendCommand(command string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stdin == nil {
+		return fmt.Errorf("server %s is not running", w.Plan.Dir)
+	}
+	_, err := io.WriteString(w.stdin, command+"\n")
+	return err
+}
+
+// Stop stops the server gracefully, killing it after StopTimeout, and waits until the watchdog
+// has ended. It does not restart the server.
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	quit, done := w.quit, w.done
+	w.mu.Unlock()
+	if done == nil {
+		return
+	}
+
+	w.requestExit(ExitStopped)
+	select {
+	case <-quit:
+	default:
+		close(quit)
+	}
+	<-done
+}
+
+// Wait blocks until the watchdog has ended.
+func (w *Watchdog) Wait() {
+	w.mu.Lock()
+	done := w.done
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
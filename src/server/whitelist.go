@@ -0,0 +1,410 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/auth"
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+	"github.com/urixen-org/minecraft-launcher-core/src/mojang"
+	"github.com/urixen-org/minecraft-launcher-core/src/utils"
+)
+
+// Files the server reads its player lists from.
+const (
+	WhitelistFile = "whitelist.json"
+	OpsFile       = "ops.json"
+)
+
+// defaultOpLevel is the level of ops.json entries when neither the entry nor
+// op-permission-level set one.
+const defaultOpLevel = 4
+
+// ------------------ Structs ------------------
+
+// Player is an entry of whitelist.json. UUID may be left empty in a Roster; it is then looked
+// up from Mojang, or derived from the name when the server runs with online-mode=false.
+type Player struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// Operator is an entry of ops.json. A zero Level uses the op-permission-level of
+// server.properties.
+type Operator struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+// Roster is the desired content of whitelist.json and ops.json. A nil list leaves its file
+// alone; an empty one clears it. As JSON: {"whitelist": [...], "ops": [...]}.
+type Roster struct {
+	Whitelist []Player   `json:"whitelist"`
+	Ops       []Operator `json:"ops"`
+}
+
+// RosterSource supplies the roster to sync, e.g. from a community's website or database.
+type RosterSource interface {
+	Roster(ctx context.Context) (*Roster, error)
+}
+
+// RosterFunc adapts a function to a RosterSource.
+type RosterFunc func(ctx context.Context) (*Roster, error)
+
+// Roster calls f.
+func (f RosterFunc) Roster(ctx context.Context) (*Roster, error) {
+	return f(ctx)
+}
+
+// HTTPRoster fetches a Roster as JSON from URL.
+type HTTPRoster struct {
+	URL string
+	// Header is added to the request, e.g. an Authorization header.
+	Header http.Header
+}
+
+// Roster downloads and decodes the roster.
+func (h *HTTPRoster) Roster(ctx context.Context) (*Roster, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range h.Header {
+		req.Header[key] = values
+	}
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", h.URL, resp.StatusCode)
+	}
+
+	var roster Roster
+	if err := json.NewDecoder(resp.Body).Decode(&roster); err != nil {
+		return nil, fmt.Errorf("failed to parse roster: %w", err)
+	}
+	return &roster, nil
+}
+
+// Console runs commands on a running server. *Watchdog implements it.
+type Console interface {
+	SendCommand(command string) error
+}
+
+// WhitelistSync keeps whitelist.json and ops.json of a server directory in line with a
+// RosterSource, once with Sync or periodically with Start.
+type WhitelistSync struct {
+	Dir    string
+	Source RosterSource
+	// Interval is the time between syncs once Start was called; zero syncs only on Start.
+	Interval time.Duration
+	// Console, when set, applies changes to the running server: "whitelist reload" and
+	// op/deop for added and removed operators. Changed op levels apply on the next start.
+	Console Console
+
+	E       *events.EventEmitter
+	mu      sync.Mutex
+	stop    chan struct{}
+	cancel  context.CancelFunc
+	running sync.Mutex
+}
+
+// NewWhitelistSync returns a sync of the server in dir from source. Configure its fields, then
+// call Sync or Start.
+func NewWhitelistSync(dir string, source RosterSource, E *events.EventEmitter) *WhitelistSync {
+	return &WhitelistSync{Dir: dir, Source: source, E: E}
+}
+
+// ------------------ Resolving ------------------
+
+// formatUUID returns uuid in the dashed lowercase form of the server's files.
+func formatUUID(uuid string) string {
+	u := strings.ToLower(strings.ReplaceAll(uuid, "-", ""))
+	if len(u) != 32 {
+		return strings.ToLower(uuid)
+	}
+	return u[0:8] + "-" + u[8:12] + "-" + u[12:16] + "-" + u[16:20] + "-" + u[20:32]
+}
+
+// resolveUUIDs looks up the UUIDs of names: derived from the name in offline mode, from Mojang
+// otherwise. Names without a Mojang profile are missing from the result, which is keyed by
+// lowercase name.
+func resolveUUIDs(names []string, online bool) (map[string]string, error) {
+	uuids := make(map[string]string, len(names))
+	if len(names) == 0 {
+		return uuids, nil
+	}
+	if !online {
+		for _, name := range names {
+			uuids[strings.ToLower(name)] = auth.OfflineUUID(name)
+		}
+		return uuids, nil
+	}
+	profiles, err := mojang.ProfilesByNames(names)
+	for key, profile := range profiles {
+		uuids[key] = formatUUID(profile.ID)
+	}
+	return uuids, err
+}
+
+// normalize fills in missing UUIDs and levels and drops duplicate and unknown players.
+func (s *WhitelistSync) normalize(roster *Roster, props map[string]string) (*Roster, error) {
+	var missing []string
+	for _, p := range roster.Whitelist {
+		if p.UUID == "" {
+			missing = append(missing, p.Name)
+		}
+	}
+	for _, op := range roster.Ops {
+		if op.UUID == "" {
+			missing = append(missing, op.Name)
+		}
+	}
+	uuids, err := resolveUUIDs(missing, property(props, "online-mode", "true") != "false")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up player UUIDs: %w", err)
+	}
+
+	// uuid returns the UUID of a player, or "" when it is unknown or already listed
+	seen := map[string]bool{}
+	uuid := func(id, name string) string {
+		if id == "" {
+			id = uuids[strings.ToLower(name)]
+		}
+		if id == "" {
+			s.E.Emit("whitelist_unknown_player", name)
+			return ""
+		}
+		id = formatUUID(id)
+		if seen[id] {
+			return ""
+		}
+		seen[id] = true
+		return id
+	}
+
+	out := &Roster{}
+	if roster.Whitelist != nil {
+		out.Whitelist = []Player{}
+		for _, p := range roster.Whitelist {
+			if p.UUID = uuid(p.UUID, p.Name); p.UUID != "" {
+				out.Whitelist = append(out.Whitelist, p)
+			}
+		}
+	}
+
+	level, err := strconv.Atoi(property(props, "op-permission-level", strconv.Itoa(defaultOpLevel)))
+	if err != nil {
+		level = defaultOpLevel
+	}
+	seen = map[string]bool{}
+	if roster.Ops != nil {
+		out.Ops = []Operator{}
+		for _, op := range roster.Ops {
+			if op.UUID = uuid(op.UUID, op.Name); op.UUID == "" {
+				continue
+			}
+			if op.Level == 0 {
+				op.Level = level
+			}
+			out.Ops = append(out.Ops, op)
+		}
+	}
+	return out, nil
+}
+
+// ------------------ Writing ------------------
+
+// playerNames returns the names in a player file by UUID, or nil when it is missing or invalid.
+func playerNames(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var players []Player
+	if json.Unmarshal(data, &players) != nil {
+		return nil
+	}
+	names := make(map[string]string, len(players))
+	for _, p := range players {
+		names[formatUUID(p.UUID)] = p.Name
+	}
+	return names
+}
+
+// writeList replaces a player file when its content differs and returns the names added and
+// removed. changed is false when the file already matched.
+func writeList(path string, list any, current map[string]string, next map[string]string) (added, removed []string, changed bool, err error) {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(bytes.TrimSpace(old), data) {
+		return nil, nil, false, nil
+	}
+	if err := os.WriteFile(path+".tmp", data, utils.Modes.File); err != nil {
+		return nil, nil, false, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return nil, nil, false, err
+	}
+
+	for uuid, name := range next {
+		if _, ok := current[uuid]; !ok {
+			added = append(added, name)
+		}
+	}
+	for uuid, name := range current {
+		if _, ok := next[uuid]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, true, nil
+}
+
+// apply writes the lists of roster that are set and notifies the console of the changes.
+func (s *WhitelistSync) apply(roster *Roster) error {
+	if roster.Whitelist != nil {
+		path := filepath.Join(s.Dir, WhitelistFile)
+		next := map[string]string{}
+		for _, p := range roster.Whitelist {
+			next[p.UUID] = p.Name
+		}
+		added, removed, changed, err := writeList(path, roster.Whitelist, playerNames(path), next)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", WhitelistFile, err)
+		}
+		if changed {
+			s.E.Emit("whitelist_synced", map[string]any{"file": WhitelistFile, "added": added, "removed": removed})
+			if s.Console != nil {
+				_ = s.Console.SendCommand("whitelist reload")
+			}
+		}
+	}
+
+	if roster.Ops != nil {
+		path := filepath.Join(s.Dir, OpsFile)
+		next := map[string]string{}
+		for _, op := range roster.Ops {
+			next[op.UUID] = op.Name
+		}
+		added, removed, changed, err := writeList(path, roster.Ops, playerNames(path), next)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", OpsFile, err)
+		}
+		if changed {
+			s.E.Emit("whitelist_synced", map[string]any{"file": OpsFile, "added": added, "removed": removed})
+			if s.Console != nil {
+				// The server keeps operators in memory and saves them after op/deop
+				for _, name := range added {
+					_ = s.Console.SendCommand("op " + name)
+				}
+				for _, name := range removed {
+					_ = s.Console.SendCommand("deop " + name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ------------------ Syncing ------------------
+
+// Sync fetches the roster and writes the files that changed. whitelist_synced is emitted per
+// changed file with the names added and removed, and whitelist_unknown_player for names
+// without a Mojang profile, which are left out.
+func (s *WhitelistSync) Sync() error {
+	return s.sync(context.Background())
+}
+
+// sync is Sync with a context, which Start ends on Stop.
+func (s *WhitelistSync) sync(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	roster, err := s.Source.Roster(ctx)
+	if err == nil && roster == nil {
+		err = fmt.Errorf("source returned no roster")
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to fetch roster: %w", err)
+		s.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	props, err := ReadProperties(s.Dir)
+	if err != nil {
+		err = fmt.Errorf("failed to read server.properties: %w", err)
+		s.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	if roster, err = s.normalize(roster, props); err == nil {
+		err = s.apply(roster)
+	}
+	if err != nil {
+		s.E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+	return nil
+}
+
+// Start syncs now and then every Interval until Stop. Failures are reported as error events
+// and retried at the next interval. It does nothing when the sync already runs.
+func (s *WhitelistSync) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop, s.cancel = stop, cancel
+	go func() {
+		_ = s.sync(ctx)
+		if s.Interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = s.sync(ctx)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends periodic syncs and cancels a fetch they have in progress.
+func (s *WhitelistSync) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
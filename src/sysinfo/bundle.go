@@ -0,0 +1,101 @@
+package sysinfo
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+	"github.com/urixen-org/minecraft-launcher-core/src/i18n"
+)
+
+// maxCrashReports is how many of the newest crash reports a diagnostic bundle includes.
+const maxCrashReports = 3
+
+// addFile copies the file at path into the archive as name, ignoring missing files.
+func addFile(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// newestCrashReports returns the most recent crash reports of a game directory, newest first.
+func newestCrashReports(gameDir string) []string {
+	reports, _ := filepath.Glob(filepath.Join(gameDir, "crash-reports", "crash-*.txt"))
+	// Crash report names embed a sortable timestamp
+	sort.Sort(sort.Reverse(sort.StringSlice(reports)))
+	if len(reports) > maxCrashReports {
+		reports = reports[:maxCrashReports]
+	}
+	return reports
+}
+
+// WriteBundle writes a diagnostic ZIP for a game directory to path. It contains system-info.json
+// (info, completed with the renderer found in the logs), logs/latest.log and the newest crash
+// reports. A nil info is collected first. Share it when reporting rendering or crash issues.
+func WriteBundle(path, gameDir string, info *Info, E *events.EventEmitter) error {
+	if info == nil {
+		info = Collect()
+	}
+	latestLog := filepath.Join(gameDir, "logs", "latest.log")
+	crashReports := newestCrashReports(gameDir)
+	for _, file := range append([]string{latestLog}, crashReports...) {
+		if f, err := os.Open(file); err == nil {
+			info.AddLog(f)
+			f.Close()
+		}
+	}
+
+	fail := func(err error) error {
+		err = fmt.Errorf("failed to write diagnostic bundle: %w", err)
+		E.Emit("error", i18n.ErrorEvent(err))
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fail(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+
+	w, err := zw.Create("system-info.json")
+	if err != nil {
+		return fail(err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(info); err != nil {
+		return fail(err)
+	}
+	if err := addFile(zw, latestLog, "logs/latest.log"); err != nil {
+		return fail(err)
+	}
+	for _, report := range crashReports {
+		if err := addFile(zw, report, "crash-reports/"+filepath.Base(report)); err != nil {
+			return fail(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+
+	E.Emit("diagnostic_bundle_written", path)
+	return nil
+}
@@ -0,0 +1,68 @@
+package sysinfo
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Minecraft logs "Backend library: LWJGL version 3.3.1 build 7" at startup.
+	lwjglPattern = regexp.MustCompile(`LWJGL version ([\w.+-]+)`)
+	// Minecraft logs and crash reports describe the renderer as "<renderer> GL version <version>, <vendor>",
+	// prefixed by "Backend API:" or "OpenGL:".
+	glPattern = regexp.MustCompile(`(?:Backend API|OpenGL): (.+?) GL version ([\d.]+)(?: ([^,]*))?, (.+)$`)
+)
+
+// ParseLogLine extracts GPU or LWJGL details from a line of game output or a crash
+// report. It returns the GPU (if any), the LWJGL version (if any) and whether the line matched.
+func ParseLogLine(line string) (*GPU, string, bool) {
+	if m := glPattern.FindStringSubmatch(line); m != nil {
+		return &GPU{
+			Vendor:    vendorOf(m[4] + " " + m[1]),
+			Model:     strings.TrimSpace(m[1]),
+			Driver:    strings.TrimSpace(m[3]),
+			GLVersion: m[2],
+			Source:    SourceLog,
+		}, "", true
+	}
+	if m := lwjglPattern.FindStringSubmatch(line); m != nil {
+		return nil, m[1], true
+	}
+	return nil, "", false
+}
+
+// AddLog scans a game log or crash report and records the renderer and LWJGL version the
+// game reported. The renderer the game actually used replaces a system entry of the same
+// model, since it carries the OpenGL version.
+func (i *Info) AddLog(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		gpu, lwjgl, ok := ParseLogLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if lwjgl != "" {
+			i.LWJGL = lwjgl
+		}
+		if gpu != nil {
+			i.addGPU(*gpu)
+		}
+	}
+}
+
+// addGPU records a GPU reported by the game.
+func (i *Info) addGPU(gpu GPU) {
+	for n, known := range i.GPUs {
+		if known.Source == SourceLog || strings.Contains(gpu.Model, known.Model) || strings.Contains(known.Model, gpu.Model) {
+			if gpu.Driver == "" {
+				gpu.Driver = known.Driver
+			}
+			i.GPUs[n] = gpu
+			return
+		}
+	}
+	i.GPUs = append(i.GPUs, gpu)
+}
@@ -0,0 +1,79 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Memory is the physical memory of the machine in megabytes.
+type Memory struct {
+	TotalMB int64 `json:"totalMB"`
+	// AvailableMB is what can be allocated without swapping, including reclaimable caches.
+	AvailableMB int64 `json:"availableMB"`
+}
+
+// memoryLinux reads MemTotal and MemAvailable from /proc/meminfo.
+func memoryLinux() (*Memory, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	values := keyValues(string(data), ":")
+	kb := func(key string) int64 {
+		fields := strings.Fields(values[key])
+		if len(fields) == 0 {
+			return 0
+		}
+		n, _ := strconv.ParseInt(fields[0], 10, 64)
+		return n
+	}
+	available := kb("MemAvailable")
+	if available == 0 {
+		// Kernels before 3.14 have no MemAvailable
+		available = kb("MemFree") + kb("Buffers") + kb("Cached")
+	}
+	return &Memory{TotalMB: kb("MemTotal") / 1024, AvailableMB: available / 1024}, nil
+}
+
+// memoryDarwin counts free, inactive and purgeable pages as available, like Activity Monitor.
+func memoryDarwin() (*Memory, error) {
+	total, err := strconv.ParseInt(run("sysctl", "-n", "hw.memsize"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hw.memsize: %w", err)
+	}
+	out := run("vm_stat")
+	pageSize := int64(4096)
+	if _, rest, ok := strings.Cut(out, "page size of "); ok {
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			pageSize, _ = strconv.ParseInt(fields[0], 10, 64)
+		}
+	}
+	values := keyValues(out, ":")
+	var pages int64
+	for _, key := range []string{"Pages free", "Pages inactive", "Pages purgeable", "Pages speculative"} {
+		n, _ := strconv.ParseInt(strings.TrimSuffix(values[key], "."), 10, 64)
+		pages += n
+	}
+	return &Memory{TotalMB: total >> 20, AvailableMB: pages * pageSize >> 20}, nil
+}
+
+// memoryWindows reads the memory of Windows; memory_windows.go sets it.
+var memoryWindows func() (*Memory, error)
+
+// MemoryStats returns the total and currently available physical memory.
+func MemoryStats() (*Memory, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return memoryLinux()
+	case "darwin":
+		return memoryDarwin()
+	case "windows":
+		if memoryWindows != nil {
+			return memoryWindows()
+		}
+	}
+	return nil, fmt.Errorf("memory statistics are not supported on %s", runtime.GOOS)
+}
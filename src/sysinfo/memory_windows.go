@@ -0,0 +1,42 @@
+package sysinfo
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+)
+
+// memoryStatusEx mirrors MEMORYSTATUSEX.
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// init makes MemoryStats use GlobalMemoryStatusEx.
+func init() {
+	memoryWindows = globalMemoryStatus
+}
+
+// globalMemoryStatus reads the physical memory from GlobalMemoryStatusEx. Its available
+// memory includes the standby list, which Windows hands out without swapping, unlike the
+// FreePhysicalMemory of CIM.
+func globalMemoryStatus() (*Memory, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	if r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return nil, fmt.Errorf("GlobalMemoryStatusEx failed: %w", err)
+	}
+	return &Memory{TotalMB: int64(status.TotalPhys >> 20), AvailableMB: int64(status.AvailPhys >> 20)}, nil
+}
@@ -0,0 +1,218 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds every external tool run while collecting, so a hung driver
+// utility never blocks a launch.
+const commandTimeout = 5 * time.Second
+
+// Sources of a GPU entry.
+const (
+	SourceSystem = "system"
+	SourceLog    = "log"
+)
+
+// ------------------ Structs ------------------
+
+// GPU is a graphics adapter and its driver, as far as it could be determined.
+type GPU struct {
+	Vendor string `json:"vendor,omitempty"`
+	Model  string `json:"model"`
+	Driver string `json:"driver,omitempty"`
+	// GLVersion is the OpenGL version reported by the game, e.g. "4.6.0".
+	GLVersion string `json:"glVersion,omitempty"`
+	// Source is SourceSystem for platform tools and SourceLog for game log lines.
+	Source string `json:"source"`
+}
+
+// Info is a best-effort description of the machine. Fields that could not be
+// determined are left empty.
+type Info struct {
+	OS        string `json:"os"`
+	OSVersion string `json:"osVersion,omitempty"`
+	Arch      string `json:"arch"`
+	CPU       string `json:"cpu,omitempty"`
+	CPUCores  int    `json:"cpuCores"`
+	// MemoryMB is the total physical memory.
+	MemoryMB int64 `json:"memoryMB,omitempty"`
+	GPUs     []GPU `json:"gpus,omitempty"`
+	// LWJGL is the LWJGL version reported by the game.
+	LWJGL string `json:"lwjgl,omitempty"`
+}
+
+// ------------------ Helpers ------------------
+
+// run returns the trimmed output of a command, or "" when it fails.
+func run(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// keyValues reads "key: value" (or "key=value" with sep "=") lines of text.
+func keyValues(text, sep string) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), sep)
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, seen := values[key]; !seen {
+			values[key] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return values
+}
+
+// vendorOf guesses the GPU vendor from a model or driver string.
+func vendorOf(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "nvidia") || strings.Contains(lower, "geforce") || strings.Contains(lower, "quadro"):
+		return "NVIDIA"
+	case strings.Contains(lower, "amd") || strings.Contains(lower, "radeon") || strings.Contains(lower, "ati "):
+		return "AMD"
+	case strings.Contains(lower, "intel"):
+		return "Intel"
+	case strings.Contains(lower, "apple"):
+		return "Apple"
+	case strings.Contains(lower, "mesa") || strings.Contains(lower, "llvmpipe"):
+		return "Mesa"
+	}
+	return ""
+}
+
+// ------------------ Platforms ------------------
+
+// collectLinux fills in what /proc, os-release and the GPU tools report.
+func collectLinux(info *Info) {
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		info.OSVersion = keyValues(string(data), "=")["PRETTY_NAME"]
+	}
+	if data, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+		info.CPU = keyValues(string(data), ":")["model name"]
+	}
+	if mem, err := memoryLinux(); err == nil {
+		info.MemoryMB = mem.TotalMB
+	}
+
+	// nvidia-smi knows the exact driver; glxinfo covers Mesa and everything else
+	if out := run("nvidia-smi", "--query-gpu=name,driver_version", "--format=csv,noheader"); out != "" {
+		for _, line := range strings.Split(out, "\n") {
+			name, driver, _ := strings.Cut(line, ",")
+			info.GPUs = append(info.GPUs, GPU{Vendor: "NVIDIA", Model: strings.TrimSpace(name), Driver: strings.TrimSpace(driver), Source: SourceSystem})
+		}
+	}
+	if out := run("glxinfo", "-B"); out != "" {
+		values := keyValues(out, ":")
+		if renderer := values["OpenGL renderer string"]; renderer != "" && len(info.GPUs) == 0 {
+			version := values["OpenGL version string"]
+			glVersion, driver, _ := strings.Cut(version, " ")
+			info.GPUs = append(info.GPUs, GPU{
+				Vendor:    vendorOf(values["OpenGL vendor string"] + " " + renderer),
+				Model:     renderer,
+				Driver:    driver,
+				GLVersion: glVersion,
+				Source:    SourceSystem,
+			})
+		}
+	}
+	if len(info.GPUs) == 0 {
+		for _, line := range strings.Split(run("lspci", "-mm"), "\n") {
+			if !strings.Contains(line, "VGA") && !strings.Contains(line, "3D controller") {
+				continue
+			}
+			// lspci -mm quotes the fields: slot "class" "vendor" "device" ...
+			parts := strings.Split(line, `"`)
+			if len(parts) >= 6 {
+				info.GPUs = append(info.GPUs, GPU{Vendor: vendorOf(parts[3]), Model: parts[3] + " " + parts[5], Source: SourceSystem})
+			}
+		}
+	}
+}
+
+// collectWindows asks CIM for the OS, CPU and video controllers.
+func collectWindows(info *Info) {
+	ps := func(script string) string {
+		return run("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	}
+	info.OSVersion = ps("(Get-CimInstance Win32_OperatingSystem).Caption + ' ' + (Get-CimInstance Win32_OperatingSystem).Version")
+	info.CPU = ps("(Get-CimInstance Win32_Processor | Select-Object -First 1).Name")
+	if bytes, err := strconv.ParseInt(ps("(Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory"), 10, 64); err == nil {
+		info.MemoryMB = bytes >> 20
+	}
+	out := ps("Get-CimInstance Win32_VideoController | ForEach-Object { $_.Name + '|' + $_.DriverVersion + '|' + $_.AdapterCompatibility }")
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) < 3 || parts[0] == "" {
+			continue
+		}
+		vendor := vendorOf(parts[2] + " " + parts[0])
+		info.GPUs = append(info.GPUs, GPU{Vendor: vendor, Model: parts[0], Driver: parts[1], Source: SourceSystem})
+	}
+}
+
+// collectDarwin reads sysctl, sw_vers and system_profiler.
+func collectDarwin(info *Info) {
+	info.OSVersion = strings.TrimSpace("macOS " + run("sw_vers", "-productVersion"))
+	info.CPU = run("sysctl", "-n", "machdep.cpu.brand_string")
+	if bytes, err := strconv.ParseInt(run("sysctl", "-n", "hw.memsize"), 10, 64); err == nil {
+		info.MemoryMB = bytes >> 20
+	}
+
+	// system_profiler lists one "Chipset Model" per adapter, followed by its details
+	var gpu *GPU
+	for _, line := range strings.Split(run("system_profiler", "SPDisplaysDataType"), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Chipset Model":
+			info.GPUs = append(info.GPUs, GPU{Model: value, Vendor: vendorOf(value), Source: SourceSystem})
+			gpu = &info.GPUs[len(info.GPUs)-1]
+		case "Vendor":
+			if gpu != nil {
+				gpu.Vendor = vendorOf(value)
+			}
+		case "Metal Support", "Metal Family":
+			if gpu != nil {
+				gpu.Driver = value
+			}
+		}
+	}
+}
+
+// ------------------ Public API ------------------
+
+// Collect gathers what the platform tools report about the machine. It never fails; every
+// piece that cannot be determined stays empty. It runs external tools, so it takes up to a
+// few seconds and should not be called on a UI thread.
+func Collect() *Info {
+	info := &Info{OS: runtime.GOOS, Arch: runtime.GOARCH, CPUCores: runtime.NumCPU()}
+	switch runtime.GOOS {
+	case "linux":
+		collectLinux(info)
+	case "windows":
+		collectWindows(info)
+	case "darwin":
+		collectDarwin(info)
+	}
+	return info
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// javaLibExecutables are the helper programs a Java home keeps in lib/ rather than bin/.
+var javaLibExecutables = []string{"jspawnhelper", "jexec"}
+
+// -------------------- Archive Post-Processing --------------------
+
+// ArchiveEntry is a file of an archive or a download manifest whose metadata extraction does
+// not restore by itself: the target of a symbolic link, or the executable bit.
+type ArchiveEntry struct {
+	// Path is slash-separated and relative to the extraction root.
+	Path string
+	// Link is the target of a symbolic link, relative to the directory of Path.
+	Link string
+	// Executable marks programs.
+	Executable bool
+}
+
+// RestoreArchiveEntries finishes an extraction into root: links are recreated, replacing
+// whatever is at their path, and executables get Modes.Executable. Links must stay inside
+// root. On Windows, where links need privileges, links to files are replaced by copies of
+// their targets and links to directories are skipped, so entries should be given after the
+// files they point to were extracted.
+func RestoreArchiveEntries(root string, entries []ArchiveEntry) error {
+	for _, entry := range entries {
+		if entry.Path == "" || !filepath.IsLocal(filepath.FromSlash(entry.Path)) {
+			return fmt.Errorf("archive entry %q escapes %s", entry.Path, root)
+		}
+		dst := filepath.Join(root, filepath.FromSlash(entry.Path))
+
+		if entry.Link != "" {
+			target := path.Join(path.Dir(entry.Path), entry.Link)
+			if path.IsAbs(entry.Link) || !filepath.IsLocal(filepath.FromSlash(target)) {
+				return fmt.Errorf("archive link %q points outside %s", entry.Path, root)
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), Modes.Dir); err != nil {
+				return err
+			}
+			if current, err := os.Readlink(dst); err == nil && filepath.ToSlash(current) == entry.Link {
+				continue
+			}
+			os.Remove(dst)
+			if runtime.GOOS == "windows" {
+				if err := copyLinkTarget(filepath.Join(root, filepath.FromSlash(target)), dst); err != nil {
+					return fmt.Errorf("failed to recreate link %s: %w", entry.Path, err)
+				}
+				continue
+			}
+			if err := os.Symlink(filepath.FromSlash(entry.Link), dst); err != nil {
+				return fmt.Errorf("failed to recreate link %s: %w", entry.Path, err)
+			}
+			continue
+		}
+
+		if entry.Executable {
+			if err := MakeExecutable(dst); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyLinkTarget copies the file a link points to in place of the link. Directories are skipped.
+func copyLinkTarget(target, dst string) error {
+	info, err := os.Stat(target)
+	if err != nil || info.IsDir() {
+		return err
+	}
+	in, err := os.Open(target)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// FixJavaExecutables marks the programs of every Java home below root executable: the files
+// in bin/ and the helpers in lib/ (jspawnhelper, which the JVM needs to start processes, and
+// jexec). Runtimes extracted from archives written on Windows or by tools that drop modes
+// otherwise fail with "permission denied". It returns the number of files marked and does
+// nothing on Windows.
+func FixJavaExecutables(root string) (int, error) {
+	if runtime.GOOS == "windows" {
+		return 0, nil
+	}
+	fixed := 0
+	mark := func(file string) error {
+		info, err := os.Lstat(file)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0100 != 0 {
+			return nil
+		}
+		if err := MakeExecutable(file); err != nil {
+			return err
+		}
+		fixed++
+		return nil
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || d.Name() != "bin" {
+			return err
+		}
+		home := filepath.Dir(p)
+		if _, err := os.Stat(filepath.Join(home, "lib")); err != nil {
+			return nil
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if err := mark(filepath.Join(p, entry.Name())); err != nil {
+				return err
+			}
+		}
+		for _, helper := range javaLibExecutables {
+			if err := mark(filepath.Join(home, "lib", helper)); err != nil {
+				return err
+			}
+		}
+		return filepath.SkipDir
+	})
+	return fixed, err
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// -------------------- Parallel Hashing --------------------
+
+// FileHash is the SHA1 and size of a file hashed by HashFiles, or why it could not be hashed.
+type FileHash struct {
+	SHA1 string
+	Size int64
+	Err  error
+}
+
+// hashFile returns the hex SHA1 and size of a file.
+func hashFile(path string) FileHash {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileHash{Err: err}
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return FileHash{Err: err}
+	}
+	return FileHash{SHA1: hex.EncodeToString(h.Sum(nil)), Size: size}
+}
+
+// HashFiles hashes files with one worker per usable CPU (GOMAXPROCS), since SHA1 rather than
+// I/O bounds a verify once the data is in the page cache. Results are in the order of paths.
+// progress, when set, is called from the workers after each file with the number of files
+// hashed so far and the index of the file.
+func HashFiles(paths []string, progress func(done, i int)) []FileHash {
+	hashes := make([]FileHash, len(paths))
+	jobs := make(chan int)
+	var done atomic.Int64
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				hashes[i] = hashFile(paths[i])
+				if progress != nil {
+					progress(int(done.Add(1)), i)
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return hashes
+}
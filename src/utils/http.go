@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/urixen-org/minecraft-launcher-core/src/events"
+)
+
+// -------------------- Launcher Identification --------------------
+
+const (
+	// DefaultLauncherName is the name of the default LauncherBrand.
+	DefaultLauncherName = "minecraft-launcher-core"
+	// DefaultLauncherVersion is the version of the default LauncherBrand.
+	DefaultLauncherVersion = "1.0"
+)
+
+// Brand identifies a launcher built on this core.
+type Brand struct {
+	Name    string
+	Version string
+}
+
+// LauncherBrand identifies the launcher to Mojang and mirrors, which ask clients to do so. It is
+// sent as the User-Agent of every request made through HTTPClient and substituted for the
+// ${launcher_name} and ${launcher_version} launch arguments. Set it once at startup.
+var LauncherBrand = Brand{Name: DefaultLauncherName, Version: DefaultLauncherVersion}
+
+// UserAgent returns the User-Agent header value for LauncherBrand, e.g. "MyLauncher/2.1".
+func UserAgent() string {
+	if LauncherBrand.Version == "" {
+		return LauncherBrand.Name
+	}
+	return LauncherBrand.Name + "/" + LauncherBrand.Version
+}
+
+// -------------------- Rate Limits --------------------
+
+// RateLimitRetries is how often HTTPClient retries a request answered with 429 Too Many
+// Requests (or 503 with a Retry-After header) before returning that response.
+var RateLimitRetries = 3
+
+// MaxRetryDelay caps the delay taken from a Retry-After header.
+var MaxRetryDelay = 2 * time.Minute
+
+// RateLimitEvents receives a rate_limited event, with the URL, host and delay, before HTTPClient
+// waits out a rate limit, so UIs can show "waiting 30s". It may be nil.
+var RateLimitEvents *events.EventEmitter
+
+// hostBackoff holds, per host, the time before which no request is sent after a rate limit,
+// so parallel downloads back off together instead of hammering the server.
+var (
+	hostBackoffMu sync.Mutex
+	hostBackoff   = map[string]time.Time{}
+)
+
+// noRetryKey marks contexts of requests whose caller handles rate limits itself.
+type noRetryKey struct{}
+
+// WithoutRetry returns a context whose requests HTTPClient does not retry on rate limits, for
+// callers with their own backoff.
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// RetryAfter returns the delay asked for by the Retry-After header of resp (seconds or an
+// HTTP date), or an exponential delay from attempt when there is none. It never exceeds
+// MaxRetryDelay.
+func RetryAfter(resp *http.Response, attempt int) time.Duration {
+	delay := time.Second << min(attempt, 6)
+	header := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if when, err := http.ParseTime(header); err == nil {
+		delay = max(time.Until(when), 0)
+	}
+	return min(delay, MaxRetryDelay)
+}
+
+// rateLimited reports whether resp asks the client to slow down.
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "")
+}
+
+// waitForHost blocks until the backoff of host is over or ctx is done.
+func waitForHost(ctx context.Context, host string) error {
+	hostBackoffMu.Lock()
+	until := hostBackoff[host]
+	hostBackoffMu.Unlock()
+
+	delay := time.Until(until)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backOffHost delays every further request to host by delay.
+func backOffHost(host string, delay time.Duration) {
+	hostBackoffMu.Lock()
+	if until := time.Now().Add(delay); until.After(hostBackoff[host]) {
+		hostBackoff[host] = until
+	}
+	hostBackoffMu.Unlock()
+}
+
+// -------------------- HTTP Client --------------------
+
+// brandTransport sets the User-Agent of requests that do not carry one and waits out rate limits.
+type brandTransport struct{}
+
+// RoundTrip implements http.RoundTripper on top of http.DefaultTransport. Requests answered
+// with a rate limit are retried after the Retry-After delay when their body can be replayed.
+func (brandTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" && LauncherBrand.Name != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	retry := req.Context().Value(noRetryKey{}) == nil && (req.Body == nil || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		if err := waitForHost(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil || !rateLimited(resp) || !retry || attempt >= RateLimitRetries {
+			return resp, err
+		}
+
+		delay := RetryAfter(resp, attempt)
+		resp.Body.Close()
+		backOffHost(req.URL.Host, delay)
+		if RateLimitEvents != nil {
+			RateLimitEvents.Emit("rate_limited", map[string]interface{}{
+				"url": req.URL.String(), "host": req.URL.Host, "retryIn": delay.String(), "attempt": attempt + 1,
+			})
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// HTTPClient performs every HTTP request of this core, identifying it with LauncherBrand and
+// backing off when Mojang, Modrinth, CurseForge or a mirror rate limits it. Replace its
+// Transport to add proxies or custom TLS settings; wrap it rather than dropping the User-Agent.
+var HTTPClient = &http.Client{Transport: brandTransport{}}
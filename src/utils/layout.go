@@ -0,0 +1,91 @@
+package utils
+
+import "path/filepath"
+
+// Directory names of the official launcher's layout, relative to the game directory.
+const (
+	VersionsDirName  = "versions"
+	LibrariesDirName = "libraries"
+	AssetsDirName    = "assets"
+	RuntimesDirName  = "runtime"
+	NativesDirName   = "natives"
+)
+
+// -------------------- Layout --------------------
+
+// Layout locates the shared files of an installation: versions, libraries, assets and Java
+// runtimes. Every path derives from Root in the official launcher's layout unless its
+// directory is overridden, so installations can keep e.g. libraries on another drive.
+type Layout struct {
+	Root string
+
+	// Versions, Libraries, Assets and Runtimes replace the default directories below Root
+	// when set.
+	Versions  string
+	Libraries string
+	Assets    string
+	Runtimes  string
+}
+
+// NewLayout returns the default layout of the installation at root.
+func NewLayout(root string) Layout {
+	return Layout{Root: root}
+}
+
+// dir returns override when set and Root/name otherwise.
+func (l Layout) dir(override, name string) string {
+	if override != "" {
+		return override
+	}
+	return filepath.Join(l.Root, name)
+}
+
+// VersionsDir returns the directory holding one directory per installed version.
+func (l Layout) VersionsDir() string {
+	return l.dir(l.Versions, VersionsDirName)
+}
+
+// VersionDir returns the directory of a version.
+func (l Layout) VersionDir(id string) string {
+	return filepath.Join(l.VersionsDir(), id)
+}
+
+// VersionJSON returns the path of a version's JSON.
+func (l Layout) VersionJSON(id string) string {
+	return filepath.Join(l.VersionDir(id), id+".json")
+}
+
+// VersionJar returns the path of a version's client JAR.
+func (l Layout) VersionJar(id string) string {
+	return filepath.Join(l.VersionDir(id), id+".jar")
+}
+
+// NativesDir returns the directory the natives of a version are extracted to for the host.
+func (l Layout) NativesDir(version string) string {
+	return filepath.Join(l.VersionDir(version), NativesDirName)
+}
+
+// LibrariesDir returns the root of the Maven-style library tree.
+func (l Layout) LibrariesDir() string {
+	return l.dir(l.Libraries, LibrariesDirName)
+}
+
+// AssetsDir returns the assets root, holding the indexes and objects.
+func (l Layout) AssetsDir() string {
+	return l.dir(l.Assets, AssetsDirName)
+}
+
+// AssetIndex returns the path of an asset index.
+func (l Layout) AssetIndex(id string) string {
+	return filepath.Join(l.AssetsDir(), "indexes", id+".json")
+}
+
+// AssetObjectsDir returns the directory of the hash-named asset objects.
+func (l Layout) AssetObjectsDir() string {
+	return filepath.Join(l.AssetsDir(), "objects")
+}
+
+// RuntimesDir returns the directory holding the Java runtimes installed by the launcher.
+func (l Layout) RuntimesDir() string {
+	return l.dir(l.Runtimes, RuntimesDirName)
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxShortPath is the longest path Windows APIs accept without the extended-length prefix;
+// directories are limited to MAX_PATH (260) minus room for an 8.3 file name.
+const maxShortPath = 248
+
+// -------------------- Long Paths --------------------
+
+// LongPath returns p in a form Windows file APIs accept beyond MAX_PATH: absolute, with the
+// \\?\ (or \\?\UNC\ for network shares) extended-length prefix. Deep library trees of modded
+// versions easily exceed MAX_PATH, and Go only extends absolute paths itself, so relative game
+// directories fail there. Short paths and every path on other systems are returned unchanged.
+// Use the result for file operations only; paths shown to users or passed to the game keep
+// their original form.
+func LongPath(p string) string {
+	if runtime.GOOS != "windows" || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil || len(abs) < maxShortPath {
+		return p
+	}
+	return extendedLengthPath(abs)
+}
+
+// extendedLengthPath prefixes a clean absolute Windows path with \\?\.
+func extendedLengthPath(abs string) string {
+	if share, ok := strings.CutPrefix(abs, `\\`); ok {
+		return `\\?\UNC\` + share
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// -------------------- File Modes --------------------
+
+// FileModes are the permission bits of the directories and files the core creates. They are
+// requested before the process umask, which still applies, so a umask of 002 together with
+// SharedFileModes gives group-writable installations while the default umask of 022 keeps
+// them private to their owner.
+type FileModes struct {
+	Dir        os.FileMode
+	File       os.FileMode
+	Executable os.FileMode
+}
+
+// DefaultFileModes are the modes of the official launcher.
+var DefaultFileModes = FileModes{Dir: 0755, File: 0644, Executable: 0755}
+
+// SharedFileModes make installations writable by their group, e.g. a shared /opt/minecraft.
+var SharedFileModes = FileModes{Dir: 0775, File: 0664, Executable: 0775}
+
+// Modes are the file modes used by every package. Set them before installing anything.
+// Credentials and sessions are always written readable by their owner only.
+var Modes = DefaultFileModes
+
+var (
+	umaskOnce sync.Once
+	umask     os.FileMode
+)
+
+// Umask returns the permission bits the process umask removes from new files. It is probed
+// once by creating a temporary file, as Go has no portable way to read it.
+func Umask() os.FileMode {
+	umaskOnce.Do(func() {
+		umask = 0022
+		dir, err := os.MkdirTemp("", "umask")
+		if err != nil {
+			return
+		}
+		defer os.RemoveAll(dir)
+		f, err := os.OpenFile(filepath.Join(dir, "probe"), os.O_CREATE|os.O_WRONLY, 0777)
+		if err != nil {
+			return
+		}
+		f.Close()
+		if info, err := os.Stat(f.Name()); err == nil {
+			umask = 0777 &^ info.Mode().Perm()
+		}
+	})
+	return umask
+}
+
+// Chmod sets the mode of path like a file created with it would get: with the umask applied,
+// which os.Chmod does not do.
+func Chmod(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode&^Umask())
+}
+
+// MakeExecutable marks a downloaded or extracted program executable with Modes.Executable.
+// It does nothing on Windows, where the file name decides what can run.
+func MakeExecutable(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return Chmod(path, Modes.Executable)
+}
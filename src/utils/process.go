@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// -------------------- Processes --------------------
+
+// ProcessAlive reports whether a process with the given PID runs on this host.
+func ProcessAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Windows only finds existing processes; elsewhere FindProcess always succeeds
+	if runtime.GOOS == "windows" {
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Sandboxes the launcher can be packaged in.
+const (
+	SandboxNone    = ""
+	SandboxFlatpak = "flatpak"
+	SandboxSnap    = "snap"
+)
+
+// flatpakInfoFile exists inside every Flatpak sandbox.
+const flatpakInfoFile = "/.flatpak-info"
+
+// -------------------- Sandbox Detection --------------------
+
+// Sandbox describes the application sandbox the launcher runs in on Linux.
+type Sandbox struct {
+	// Kind is SandboxFlatpak, SandboxSnap or SandboxNone.
+	Kind string
+	// AppID is the Flatpak application ID or the snap name.
+	AppID string
+}
+
+// DetectSandbox reports whether the process runs inside Flatpak or Snap.
+func DetectSandbox() Sandbox {
+	if runtime.GOOS != "linux" {
+		return Sandbox{}
+	}
+	if id := os.Getenv("FLATPAK_ID"); id != "" {
+		return Sandbox{Kind: SandboxFlatpak, AppID: id}
+	}
+	if f, err := os.Open(flatpakInfoFile); err == nil {
+		defer f.Close()
+		sandbox := Sandbox{Kind: SandboxFlatpak}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if name, ok := strings.CutPrefix(scanner.Text(), "name="); ok {
+				sandbox.AppID = strings.TrimSpace(name)
+				break
+			}
+		}
+		return sandbox
+	}
+	if os.Getenv("SNAP") != "" && os.Getenv("SNAP_NAME") != "" {
+		return Sandbox{Kind: SandboxSnap, AppID: os.Getenv("SNAP_NAME")}
+	}
+	return Sandbox{}
+}
+
+// sandboxMCDir returns the default game directory inside a sandbox: ~/.minecraft when the
+// sandbox can see an existing one, otherwise the app's persistent data directory
+// (~/.var/app/<id>/data/.minecraft for Flatpak, ~/snap/<name>/common/.minecraft for Snap,
+// which survives snap revisions). It returns "" outside sandboxes.
+func sandboxMCDir() string {
+	sandbox := DetectSandbox()
+	if sandbox.Kind == SandboxNone {
+		return ""
+	}
+	if sandbox.Kind == SandboxFlatpak && DirExists(filepath.Join(os.Getenv("HOME"), ".minecraft")) {
+		return filepath.Join(os.Getenv("HOME"), ".minecraft")
+	}
+
+	var data string
+	switch sandbox.Kind {
+	case SandboxFlatpak:
+		data = os.Getenv("XDG_DATA_HOME")
+	case SandboxSnap:
+		data = os.Getenv("SNAP_USER_COMMON")
+	}
+	if data == "" {
+		return ""
+	}
+	return filepath.Join(data, ".minecraft")
+}
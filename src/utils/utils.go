@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -22,12 +21,22 @@ func GetMCDir() string {
 	if mcDir != "" {
 		return mcDir
 	}
+	return DefaultMCDir()
+}
+
+// DefaultMCDir returns the platform's default .minecraft location used by the official launcher,
+// ignoring any directory configured with SetMCDir. Inside Flatpak and Snap it is the app's
+// persistent data directory unless the sandbox can see an existing ~/.minecraft.
+func DefaultMCDir() string {
 	switch runtime.GOOS {
 	case "windows":
 		return filepath.Join(os.Getenv("APPDATA"), ".minecraft")
 	case "darwin":
 		return filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "minecraft")
 	default:
+		if dir := sandboxMCDir(); dir != "" {
+			return dir
+		}
 		return filepath.Join(os.Getenv("HOME"), ".minecraft")
 	}
 }
@@ -50,13 +59,13 @@ func DirExists(path string) bool {
 
 func EnsureDirExists(path string) error {
 	if !DirExists(path) {
-		return os.MkdirAll(path, 0755)
+		return os.MkdirAll(path, Modes.Dir)
 	}
 	return nil
 }
 
 func DownloadFile(url, dest string) error {
-	resp, err := http.Get(url)
+	resp, err := HTTPClient.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download %s: %w", url, err)
 	}
@@ -77,7 +86,7 @@ func DownloadFile(url, dest string) error {
 func GetAllVanillaMCVersions() ([]string, error) {
 	const manifestURL = "https://launchermeta.mojang.com/mc/game/version_manifest.json"
 
-	resp, err := http.Get(manifestURL)
+	resp, err := HTTPClient.Get(manifestURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
 	}
@@ -108,7 +117,7 @@ func GetAllVanillaMCVersions() ([]string, error) {
 func GetLatestMCVersion() (string, error) {
 	const manifestURL = "https://launchermeta.mojang.com/mc/game/version_manifest.json"
 
-	resp, err := http.Get(manifestURL)
+	resp, err := HTTPClient.Get(manifestURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch manifest: %w", err)
 	}